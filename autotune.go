@@ -0,0 +1,162 @@
+package pathwalk
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/dsoprea/go-logging"
+)
+
+const (
+	// defaultAutoTuneDuration is the default total time that `AutoTune()`
+	// spends sampling.
+	defaultAutoTuneDuration = time.Second * 4
+)
+
+var (
+	// autoTuneConcurrencies are the worker-counts that are sampled.
+	autoTuneConcurrencies = []int{16, 64, 200, defaultConcurrency}
+
+	// autoTuneBatchSizes are the batch-sizes that are sampled (with the best
+	// worker-count).
+	autoTuneBatchSizes = []int{25, defaultDirectoryEntryBatchSize, 400}
+)
+
+// SetAutoTuneDuration sets the total time that `AutoTune()` spends sampling.
+// This defaults to `defaultAutoTuneDuration`.
+func (walk *Walk) SetAutoTuneDuration(autoTuneDuration time.Duration) {
+	walk.autoTuneDuration = autoTuneDuration
+}
+
+// AutoTune estimates a good worker-count and batch-size for this tree and
+// sets them (see `Config()`). It's meant to be called once before `Run()`.
+//
+// This does a series of short walks from the root with a callback that does
+// nothing, first at a few worker-counts and then at a few batch-sizes with the
+// best worker-count, and keeps whatever visited the most entries per second.
+// Each walk only gets an equal share of the time given to
+// `SetAutoTuneDuration()`, so, on a large tree, it only samples the part of
+// the tree that it gets to in that time. An additional walk is done first to
+// warm the caches so that the first level isn't penalized.
+//
+// This is a heuristic. It's most useful for large, uniform trees, where the
+// sampled part is representative of the rest. It can't account for the cost
+// of the real callback and the results on small or skewed trees (or on a
+// machine that is otherwise busy) are mostly noise. The user's callbacks are
+// never called while sampling, but the child lister and the filter are used
+// as configured.
+func (walk *Walk) AutoTune() (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if walk.rootPath == "" {
+		return ErrEmptyRootPath
+	}
+
+	if atomic.LoadInt32(&walk.isRunActive) == 1 {
+		return ErrAlreadyRunning
+	}
+
+	sampleDuration := walk.autoTuneDuration / time.Duration(len(autoTuneConcurrencies)+len(autoTuneBatchSizes)+1)
+
+	// Warm the caches.
+
+	_, err = walk.sampleThroughput(autoTuneConcurrencies[0], walk.batchSize, sampleDuration)
+	log.PanicIf(err)
+
+	bestConcurrency := 0
+	bestRate := 0.0
+
+	for _, concurrency := range autoTuneConcurrencies {
+		rate, err := walk.sampleThroughput(concurrency, walk.batchSize, sampleDuration)
+		log.PanicIf(err)
+
+		walk.logDebugf("Auto-tune sample: CONCURRENCY=(%d) BATCH-SIZE=(%d) RATE=(%.1f)/s", concurrency, walk.batchSize, rate)
+
+		if rate > bestRate {
+			bestConcurrency = concurrency
+			bestRate = rate
+		}
+	}
+
+	if bestConcurrency == 0 {
+		// Nothing was visited, so there's nothing to go on.
+
+		walk.logWarningf("auto-tune didn't visit anything; the current settings will be kept")
+		return nil
+	}
+
+	bestBatchSize := 0
+	bestRate = 0.0
+
+	for _, batchSize := range autoTuneBatchSizes {
+		rate, err := walk.sampleThroughput(bestConcurrency, batchSize, sampleDuration)
+		log.PanicIf(err)
+
+		walk.logDebugf("Auto-tune sample: CONCURRENCY=(%d) BATCH-SIZE=(%d) RATE=(%.1f)/s", bestConcurrency, batchSize, rate)
+
+		if rate > bestRate {
+			bestBatchSize = batchSize
+			bestRate = rate
+		}
+	}
+
+	if bestBatchSize == 0 {
+		bestBatchSize = walk.batchSize
+	}
+
+	walk.concurrency = bestConcurrency
+	walk.batchSize = bestBatchSize
+	walk.isAutoTuned = true
+
+	walk.logDebugf("Auto-tune chose: CONCURRENCY=(%d) BATCH-SIZE=(%d)", bestConcurrency, bestBatchSize)
+
+	return nil
+}
+
+// sampleThroughput walks from the root with the given settings until it's
+// done or the duration elapses and returns the number of entries visited per
+// second.
+func (walk *Walk) sampleThroughput(concurrency, batchSize int, duration time.Duration) (rate float64, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		return nil
+	}
+
+	sample := NewWalk(walk.rootPath, walkFunc)
+	sample.SetConcurrency(concurrency)
+	sample.SetBatchSize(batchSize)
+	sample.SetBufferSize(walk.bufferSize)
+	sample.SetGlobalTimeoutDuration(walk.timeoutDuration)
+	sample.SetChildLister(walk.childLister)
+
+	err = sample.SetFilter(walk.userFilter.copy())
+	log.PanicIf(err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), duration)
+	defer cancel()
+
+	startedAt := time.Now()
+
+	err = sample.RunContext(ctx)
+	if err != nil && err != context.DeadlineExceeded {
+		log.Panic(err)
+	}
+
+	elapsed := time.Since(startedAt)
+
+	stats := sample.Stats()
+	visited := stats.FilesVisited + stats.DirectoriesVisited
+
+	return float64(visited) / elapsed.Seconds(), nil
+}
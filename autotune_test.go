@@ -0,0 +1,86 @@
+package pathwalk
+
+import (
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-parallel-walker/internal/testing"
+)
+
+func TestWalk_AutoTune(t *testing.T) {
+	fileCount := 200
+	tempPath, _ := pwtesting.FillHeirarchicalTempPath(fileCount, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	var callCount int32
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		atomic.AddInt32(&callCount, 1)
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetAutoTuneDuration(time.Millisecond * 400)
+
+	err := walk.AutoTune()
+	log.PanicIf(err)
+
+	if atomic.LoadInt32(&callCount) != 0 {
+		t.Fatalf("The callback should not be called while sampling: (%d)", callCount)
+	}
+
+	config := walk.Config()
+
+	if config.IsAutoTuned != true {
+		t.Fatalf("Expected the walk to be marked as auto-tuned.")
+	}
+
+	isFound := false
+	for _, concurrency := range autoTuneConcurrencies {
+		if config.Concurrency == concurrency {
+			isFound = true
+			break
+		}
+	}
+
+	if isFound == false {
+		t.Fatalf("Concurrency not one of the sampled values: (%d)", config.Concurrency)
+	}
+
+	isFound = false
+	for _, batchSize := range autoTuneBatchSizes {
+		if config.BatchSize == batchSize {
+			isFound = true
+			break
+		}
+	}
+
+	if isFound == false {
+		t.Fatalf("BatchSize not one of the sampled values: (%d)", config.BatchSize)
+	}
+
+	// The real run is unaffected by the sampling.
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	if walk.Stats().FilesVisited != fileCount {
+		t.Fatalf("FilesVisited not correct: (%d)", walk.Stats().FilesVisited)
+	}
+}
+
+func TestWalk_AutoTune__emptyRootPath(t *testing.T) {
+	walk := NewWalk("", nil)
+
+	err := walk.AutoTune()
+	if err != ErrEmptyRootPath {
+		t.Fatalf("Expected ErrEmptyRootPath: [%v]", err)
+	}
+}
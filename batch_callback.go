@@ -0,0 +1,55 @@
+package pathwalk
+
+import (
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// BatchWalkFunc is the function type for the batch callback. It receives the
+// files of one batch of a directory's entries.
+type BatchWalkFunc func(parentPath string, infos []os.FileInfo) (err error)
+
+// SetBatchCallback sets a callback that receives the files of each batch of
+// directory entries in one call rather than calling the regular callback once
+// per file. Directories are still delivered to the regular callback
+// individually. Within a batch, files are in the order that they were read
+// from the directory. Batches from different directories (and from the same
+// directory) are processed in parallel, so their calls interleave. Returning
+// an error will terminate the walk.
+func (walk *Walk) SetBatchCallback(batchWalkFunc BatchWalkFunc) {
+	walk.batchWalkFunc = batchWalkFunc
+}
+
+// callBatchWalkFunc delivers one batch of files to the batch callback.
+func (walk *Walk) callBatchWalkFunc(parentNodePath string, infos []os.FileInfo) (err error) {
+	atomic.AddInt64(&walk.hotStats.filesVisited, int64(len(infos)))
+
+	if walk.isPruneEmptyDirectories == true && len(infos) > 0 {
+		walk.markIncludedDescendant(parentNodePath)
+	}
+
+	if walk.isCountingMatches == true {
+		walk.countMatch(false, len(infos))
+		return nil
+	}
+
+	if walk.isReformattingReportedPaths() == true {
+		reportedParentNodePath := parentNodePath
+		for i, info := range infos {
+			reportedParentNodePath, infos[i] = walk.reportPath(parentNodePath, info)
+		}
+
+		parentNodePath = reportedParentNodePath
+	}
+
+	startTime := time.Now()
+
+	defer func() {
+		duration := time.Since(startTime)
+
+		walk.hotStats.addCallbackTime(duration)
+	}()
+
+	return walk.batchWalkFunc(parentNodePath, infos)
+}
@@ -0,0 +1,79 @@
+package pathwalk
+
+import (
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-parallel-walker/internal/testing"
+)
+
+func TestWalk_SetBatchCallback(t *testing.T) {
+	fileCount := defaultDirectoryEntryBatchSize*2 + 50
+	tempPath, tempFilenames := pwtesting.FillFlatTempPath(fileCount, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	m := sync.Mutex{}
+	directories := make([]string, 0)
+	files := make(map[string]struct{})
+	batchCount := 0
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		if info.IsDir() == false {
+			t.Fatalf("File delivered individually: [%s]", info.Name())
+		}
+
+		directories = append(directories, info.Name())
+
+		return nil
+	}
+
+	batchWalkFunc := func(parentPath string, infos []os.FileInfo) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		if parentPath != tempPath {
+			t.Fatalf("Parent path not correct: [%s]", parentPath)
+		}
+
+		batchCount++
+
+		for _, info := range infos {
+			files[info.Name()] = struct{}{}
+		}
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetBatchCallback(batchWalkFunc)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	if len(directories) != 1 {
+		t.Fatalf("Directories not correct: %v", directories)
+	} else if batchCount != 3 {
+		t.Fatalf("Batch count not correct: (%d)", batchCount)
+	} else if len(files) != fileCount {
+		t.Fatalf("File count not correct: (%d)", len(files))
+	}
+
+	for _, filename := range tempFilenames {
+		if _, found := files[filename]; found == false {
+			t.Fatalf("File not delivered: [%s]", filename)
+		}
+	}
+
+	if walk.Stats().FilesVisited != fileCount {
+		t.Fatalf("FilesVisited not correct: (%d)", walk.Stats().FilesVisited)
+	}
+}
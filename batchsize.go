@@ -0,0 +1,56 @@
+package pathwalk
+
+import (
+	"os"
+
+	"github.com/dsoprea/go-logging"
+)
+
+// BatchSizeFunc returns the number of entries to read at a time from the given
+// directory, or zero to use the batch-size set with `SetBatchSize()`.
+type BatchSizeFunc func(directoryPath string, info os.FileInfo) int
+
+// SetBatchSizeFunc sets a callback that chooses the batch-size for each
+// directory before it's read (e.g. large for directories known to be huge and
+// small otherwise). This takes precedence over `SetBatchSize()` for any
+// directory that it returns a nonzero value for, but a hint from
+// `SetDirectoryHint()` takes precedence over both. Sampling (see
+// `SetSampleEntriesPerDirectory()`) still caps the batch-size. A negative
+// value will terminate the walk.
+func (walk *Walk) SetBatchSizeFunc(batchSizeFunc BatchSizeFunc) {
+	walk.batchSizeFunc = batchSizeFunc
+}
+
+// directoryBatchSize returns the batch-size to read the given directory with.
+func (walk *Walk) directoryBatchSize(directoryPath string, info os.FileInfo) (batchSize int, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if hint, found := walk.directoryHint(directoryPath, info); found == true && hint.BatchSize != 0 {
+		if hint.BatchSize < 0 {
+			log.Panicf("hinted batch-size for [%s] is not valid: (%d)", directoryPath, hint.BatchSize)
+		}
+
+		walk.statsLocker.Lock()
+		walk.stats.DirectoryHintsApplied++
+		walk.statsLocker.Unlock()
+
+		return hint.BatchSize, nil
+	}
+
+	if walk.batchSizeFunc == nil {
+		return walk.batchSize, nil
+	}
+
+	batchSize = walk.batchSizeFunc(directoryPath, info)
+	if batchSize == 0 {
+		return walk.batchSize, nil
+	} else if batchSize < 0 {
+		log.Panicf("batch-size for [%s] is not valid: (%d)", directoryPath, batchSize)
+	}
+
+	return batchSize, nil
+}
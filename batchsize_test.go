@@ -0,0 +1,148 @@
+package pathwalk
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+)
+
+func TestWalk_SetBatchSizeFunc(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	for _, directoryName := range []string{"huge", "normal"} {
+		err := os.Mkdir(path.Join(tempPath, directoryName), 0755)
+		log.PanicIf(err)
+
+		for i := 0; i < 10; i++ {
+			filepath := path.Join(tempPath, directoryName, fmt.Sprintf("file%d", i))
+
+			err := ioutil.WriteFile(filepath, []byte{}, 0644)
+			log.PanicIf(err)
+		}
+	}
+
+	m := sync.Mutex{}
+	batchSizes := make(map[string][]int)
+	queried := make(map[string]struct{})
+
+	batchWalkFunc := func(parentPath string, infos []os.FileInfo) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		name := path.Base(parentPath)
+		batchSizes[name] = append(batchSizes[name], len(infos))
+
+		return nil
+	}
+
+	batchSizeFunc := func(directoryPath string, info os.FileInfo) int {
+		m.Lock()
+		defer m.Unlock()
+
+		queried[info.Name()] = struct{}{}
+
+		if info.Name() == "huge" {
+			return 3
+		}
+
+		return 0
+	}
+
+	walk := NewWalk(tempPath, nil)
+	walk.SetBatchCallback(batchWalkFunc)
+	walk.SetBatchSizeFunc(batchSizeFunc)
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	if len(queried) != 3 {
+		t.Fatalf("Callback not consulted for every directory: %v", queried)
+	}
+
+	hugeBatchSizes := batchSizes["huge"]
+	if len(hugeBatchSizes) != 4 {
+		t.Fatalf("Huge directory not batched correctly: %v", hugeBatchSizes)
+	}
+
+	total := 0
+	for _, batchSize := range hugeBatchSizes {
+		if batchSize > 3 {
+			t.Fatalf("Batch exceeds the batch-size: %v", hugeBatchSizes)
+		}
+
+		total += batchSize
+	}
+
+	if total != 10 {
+		t.Fatalf("Huge directory not complete: %v", hugeBatchSizes)
+	}
+
+	// Returning zero uses the default.
+
+	normalBatchSizes := batchSizes["normal"]
+	if len(normalBatchSizes) != 1 || normalBatchSizes[0] != 10 {
+		t.Fatalf("Normal directory not batched correctly: %v", normalBatchSizes)
+	}
+
+	if walk.Config().HasBatchSizeFunc != true {
+		t.Fatalf("Config does not reflect the batch-size callback.")
+	}
+}
+
+func TestWalk_SetBatchSizeFunc__invalid(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	batchSizeFunc := func(directoryPath string, info os.FileInfo) int {
+		return -1
+	}
+
+	walk := NewWalk(tempPath, nil)
+	walk.SetBatchSizeFunc(batchSizeFunc)
+
+	err = walk.Run()
+	if err == nil {
+		t.Fatalf("Expected error for invalid batch-size.")
+	} else if strings.Contains(err.Error(), "batch-size for") == false {
+		t.Fatalf("Error not correct: [%v]", err)
+	}
+}
+
+func TestWalk_directoryBatchSize(t *testing.T) {
+	walk := NewWalk("root", nil)
+	walk.SetBatchSize(7)
+
+	batchSize, err := walk.directoryBatchSize("root", nil)
+	log.PanicIf(err)
+
+	if batchSize != 7 {
+		t.Fatalf("Default batch-size not correct: (%d)", batchSize)
+	}
+
+	walk.SetBatchSizeFunc(func(directoryPath string, info os.FileInfo) int {
+		return 11
+	})
+
+	batchSize, err = walk.directoryBatchSize("root", nil)
+	log.PanicIf(err)
+
+	if batchSize != 11 {
+		t.Fatalf("Overridden batch-size not correct: (%d)", batchSize)
+	}
+}
@@ -0,0 +1,111 @@
+package pathwalk
+
+import (
+	"context"
+	"os"
+	"path"
+	"sync/atomic"
+
+	"github.com/dsoprea/go-logging"
+)
+
+// deferredDirectory is a directory whose callback is waiting for everything
+// below it.
+type deferredDirectory struct {
+	parentNodePath string
+	info           os.FileInfo
+	parentInfo     os.FileInfo
+
+	// hasIncludedDescendants indicates that a file was delivered somewhere
+	// below the directory. This is only tracked when pruning.
+	hasIncludedDescendants bool
+}
+
+// isDeferringDirectories indicates that the directories are delivered once
+// everything below them has been.
+func (walk *Walk) isDeferringDirectories() bool {
+	return walk.bottomUpWalkFunc != nil || walk.isPruneEmptyDirectories == true
+}
+
+// RunBottomUp is the same as `Run()` except that the given callback is used
+// instead of the one given to `NewWalk()` and every directory is only
+// delivered to it (and to any visitors and the entry callback) after
+// everything below it has been, recursively, so the root is delivered last.
+// This is a post-order walk for things like recursive deletion, where the
+// contents have to be handled before their directory. Different directories
+// are still processed in parallel.
+//
+// Since the contents of a directory have already been delivered by the time
+// that the directory is, returning `ErrSkipDirectory` for a directory has no
+// effect. The directories still have to be listed before their contents can
+// be, so filtered directories are skipped as usual. This shouldn't be
+// combined with checkpoints since the directories that are waiting for their
+// contents aren't recorded.
+func (walk *Walk) RunBottomUp(walkFunc WalkFunc) (err error) {
+	if walk.rootPath == "" {
+		return ErrEmptyRootPath
+	}
+
+	if atomic.CompareAndSwapInt32(&walk.isRunActive, 0, 1) == false {
+		return ErrAlreadyRunning
+	}
+
+	return walk.run(context.Background(), walkFunc, nil)
+}
+
+// deferDirectory holds the callback for the given directory until everything
+// below it has been delivered.
+func (walk *Walk) deferDirectory(parentNodePath string, info os.FileInfo, parentInfo os.FileInfo) {
+	walk.deferredDirectoriesLocker.Lock()
+	defer walk.deferredDirectoriesLocker.Unlock()
+
+	if walk.deferredDirectories == nil {
+		walk.deferredDirectories = make(map[string]deferredDirectory)
+	}
+
+	dd := deferredDirectory{
+		parentNodePath: parentNodePath,
+		info:           info,
+		parentInfo:     parentInfo,
+	}
+
+	walk.deferredDirectories[path.Join(parentNodePath, info.Name())] = dd
+}
+
+// deliverDeferredDirectory calls the callback for the given completed
+// directory. Its parent isn't released until this returns, so each directory
+// is delivered before its parent.
+func (walk *Walk) deliverDeferredDirectory(ds DirectorySummary) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	walk.deferredDirectoriesLocker.Lock()
+	dd, found := walk.deferredDirectories[ds.Path]
+	delete(walk.deferredDirectories, ds.Path)
+	walk.deferredDirectoriesLocker.Unlock()
+
+	if found == false {
+		// It was filtered or is being resumed.
+		return nil
+	}
+
+	if walk.isPruneEmptyDirectories == true && dd.hasIncludedDescendants == false {
+		walk.statsLocker.Lock()
+		walk.stats.EmptyBranchesPruned++
+		walk.statsLocker.Unlock()
+
+		return nil
+	}
+
+	err = walk.callWalkFunc(dd.parentNodePath, dd.info, dd.parentInfo)
+	if err != nil && err != ErrSkipDirectory {
+		// There's nothing left to skip.
+		_, err = walk.applyDirectoryErrorPolicy(dd.parentNodePath, dd.info, err)
+		log.PanicIf(err)
+	}
+
+	return nil
+}
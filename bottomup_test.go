@@ -0,0 +1,203 @@
+package pathwalk
+
+import (
+	"errors"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-parallel-walker/internal/testing"
+)
+
+// recordSequences registers an entry callback that records the sequence
+// number of every entry by full-path.
+func recordSequences(walk *Walk) (sequences map[string]int64, m *sync.Mutex) {
+	m = new(sync.Mutex)
+	sequences = make(map[string]int64)
+
+	walkEntryFunc := func(entry WalkEntry) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		sequences[path.Join(entry.ParentPath, entry.Info.Name())] = entry.Sequence
+
+		return nil
+	}
+
+	walk.SetWalkEntryFunc(walkEntryFunc)
+
+	return sequences, m
+}
+
+func TestWalk_RunBottomUp(t *testing.T) {
+	tempPath, tempFiles := pwtesting.FillHeirarchicalTempPath(200, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	primaryWalkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		t.Errorf("The primary callback should not be called.")
+		return nil
+	}
+
+	walk := NewWalk(tempPath, primaryWalkFunc)
+	sequences, _ := recordSequences(walk)
+
+	m := sync.Mutex{}
+	filesSeen := 0
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		if info.IsDir() == false {
+			m.Lock()
+			filesSeen++
+			m.Unlock()
+		}
+
+		return nil
+	}
+
+	err := walk.RunBottomUp(walkFunc)
+	log.PanicIf(err)
+
+	if filesSeen != len(tempFiles) {
+		t.Fatalf("Files seen not correct: (%d) != (%d)", filesSeen, len(tempFiles))
+	}
+
+	// Every entry must have been delivered before its directory.
+
+	for entryPath, sequence := range sequences {
+		if entryPath == tempPath {
+			continue
+		}
+
+		parentSequence, found := sequences[path.Dir(entryPath)]
+		if found == false {
+			t.Fatalf("Parent not delivered: [%s]", entryPath)
+		} else if sequence >= parentSequence {
+			t.Fatalf("Entry delivered after its directory: [%s] (%d) >= (%d)", entryPath, sequence, parentSequence)
+		}
+	}
+
+	if sequences[tempPath] != int64(len(sequences)) {
+		t.Fatalf("Root not delivered last: (%d) != (%d)", sequences[tempPath], len(sequences))
+	}
+}
+
+func TestWalk_RunBottomUp__thenRun(t *testing.T) {
+	tempPath, _ := pwtesting.FillHeirarchicalTempPath(50, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	m := sync.Mutex{}
+	primaryCalls := 0
+
+	primaryWalkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		m.Lock()
+		primaryCalls++
+		m.Unlock()
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, primaryWalkFunc)
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		return nil
+	}
+
+	err := walk.RunBottomUp(walkFunc)
+	log.PanicIf(err)
+
+	if primaryCalls != 0 {
+		t.Fatalf("Primary callback called during bottom-up run: (%d)", primaryCalls)
+	}
+
+	// A regular run afterwards is top-down again.
+
+	sequences, _ := recordSequences(walk)
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	if primaryCalls != len(sequences) {
+		t.Fatalf("Primary callback not called for every entry: (%d) != (%d)", primaryCalls, len(sequences))
+	}
+
+	for entryPath, sequence := range sequences {
+		if entryPath == tempPath {
+			continue
+		}
+
+		if sequence <= sequences[path.Dir(entryPath)] {
+			t.Fatalf("Entry delivered before its directory: [%s]", entryPath)
+		}
+	}
+}
+
+func TestWalk_RunBottomUp__skipDirectoryIgnored(t *testing.T) {
+	tempPath, tempFilenames := pwtesting.FillFlatTempPath(10, []string{"subdirectory"})
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	m := sync.Mutex{}
+	filesSeen := 0
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		if info.IsDir() == true {
+			return ErrSkipDirectory
+		}
+
+		m.Lock()
+		filesSeen++
+		m.Unlock()
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, nil)
+
+	err := walk.RunBottomUp(walkFunc)
+	log.PanicIf(err)
+
+	if filesSeen != len(tempFilenames) {
+		t.Fatalf("Files seen not correct: (%d)", filesSeen)
+	} else if walk.Stats().DirectoriesIgnored != 0 {
+		t.Fatalf("DirectoriesIgnored not correct: (%d)", walk.Stats().DirectoriesIgnored)
+	}
+}
+
+func TestWalk_RunBottomUp__error(t *testing.T) {
+	tempPath, _ := pwtesting.FillFlatTempPath(10, []string{"subdirectory"})
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	errTest := errors.New("test error")
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		if info.IsDir() == true && info.Name() == "subdirectory" {
+			return errTest
+		}
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, nil)
+
+	err := walk.RunBottomUp(walkFunc)
+	if err == nil {
+		t.Fatalf("Expected error.")
+	} else if strings.Contains(err.Error(), errTest.Error()) != true {
+		t.Fatalf("Error not correct: [%v]", err)
+	}
+}
@@ -0,0 +1,32 @@
+//go:build darwin || freebsd || netbsd
+// +build darwin freebsd netbsd
+
+package pathwalk
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+const (
+	// isBirthTimeSupported indicates that creation times are available on
+	// this platform.
+	isBirthTimeSupported = true
+)
+
+// getBirthTime returns the creation time of the given node if the filesystem
+// records it.
+func getBirthTime(fqPath string, info os.FileInfo) (btime time.Time, ok bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if ok == false {
+		return time.Time{}, false
+	}
+
+	sec, nsec := stat.Birthtimespec.Unix()
+	if sec == 0 && nsec == 0 {
+		return time.Time{}, false
+	}
+
+	return time.Unix(sec, nsec), true
+}
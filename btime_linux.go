@@ -0,0 +1,92 @@
+//go:build linux && (amd64 || arm64 || 386 || arm)
+// +build linux
+// +build amd64 arm64 386 arm
+
+package pathwalk
+
+import (
+	"os"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const (
+	// isBirthTimeSupported indicates that creation times are available on
+	// this platform (via `statx()`, if the kernel and filesystem support it).
+	isBirthTimeSupported = true
+
+	// atFdCwd makes `statx()` resolve relative paths against the current
+	// directory.
+	atFdCwd = -100
+
+	// atSymlinkNoFollow makes `statx()` describe symlinks themselves.
+	atSymlinkNoFollow = 0x100
+
+	// statxBtime requests (and, in the result mask, confirms) the creation
+	// time.
+	statxBtime = 0x800
+)
+
+// statxTimestamp is `struct statx_timestamp`.
+type statxTimestamp struct {
+	Sec  int64
+	Nsec uint32
+	_    int32
+}
+
+// statxResult is `struct statx`. We only need the fields up to the
+// timestamps, but the kernel fills the whole structure.
+type statxResult struct {
+	Mask           uint32
+	Blksize        uint32
+	Attributes     uint64
+	Nlink          uint32
+	Uid            uint32
+	Gid            uint32
+	Mode           uint16
+	_              uint16
+	Ino            uint64
+	Size           uint64
+	Blocks         uint64
+	AttributesMask uint64
+	Atime          statxTimestamp
+	Btime          statxTimestamp
+	Ctime          statxTimestamp
+	Mtime          statxTimestamp
+	_              [128]byte
+}
+
+// getBirthTime returns the creation time of the given node if the kernel and
+// the filesystem provide it. A symlink is only described itself if the given
+// info is for the symlink rather than for its target, so that both describe
+// the same file. The "syscall" package doesn't support `statx()`, so we call
+// it directly.
+func getBirthTime(fqPath string, info os.FileInfo) (btime time.Time, ok bool) {
+	p, err := syscall.BytePtrFromString(fqPath)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	var sx statxResult
+
+	dirFd := atFdCwd
+
+	flags := 0
+	if info.Mode()&os.ModeSymlink != 0 {
+		flags = atSymlinkNoFollow
+	}
+
+	_, _, errno := syscall.Syscall6(statxTrap, uintptr(dirFd), uintptr(unsafe.Pointer(p)), uintptr(flags), statxBtime, uintptr(unsafe.Pointer(&sx)), 0)
+	if errno != 0 {
+		return time.Time{}, false
+	}
+
+	// Some filesystems (and sandboxes) acknowledge the request but don't
+	// actually record the time.
+	if sx.Mask&statxBtime == 0 || (sx.Btime.Sec == 0 && sx.Btime.Nsec == 0) {
+		return time.Time{}, false
+	}
+
+	return time.Unix(sx.Btime.Sec, int64(sx.Btime.Nsec)), true
+}
@@ -0,0 +1,70 @@
+//go:build linux && (amd64 || arm64 || 386 || arm)
+// +build linux
+// +build amd64 arm64 386 arm
+
+package pathwalk
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+)
+
+func TestGetBirthTime__symlink(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	targetPath := path.Join(tempPath, "target")
+
+	err = ioutil.WriteFile(targetPath, []byte{}, 0644)
+	log.PanicIf(err)
+
+	// Make sure that the symlink is created measurably later.
+	time.Sleep(time.Millisecond * 20)
+
+	linkPath := path.Join(tempPath, "link")
+
+	err = os.Symlink(targetPath, linkPath)
+	log.PanicIf(err)
+
+	targetInfo, err := os.Stat(targetPath)
+	log.PanicIf(err)
+
+	targetBtime, isAvailable := getBirthTime(targetPath, targetInfo)
+	if isAvailable == false {
+		t.Skipf("Creation times are not available here.")
+	}
+
+	// Followed, like the info.
+
+	followedInfo, err := os.Stat(linkPath)
+	log.PanicIf(err)
+
+	followedBtime, ok := getBirthTime(linkPath, followedInfo)
+	if ok != true {
+		t.Fatalf("Expected a creation time for the target.")
+	} else if followedBtime.Equal(targetBtime) != true {
+		t.Fatalf("Creation time should be the target's: [%s] != [%s]", followedBtime, targetBtime)
+	}
+
+	// Not followed, like the info.
+
+	linkInfo, err := os.Lstat(linkPath)
+	log.PanicIf(err)
+
+	linkBtime, ok := getBirthTime(linkPath, linkInfo)
+	if ok != true {
+		t.Fatalf("Expected a creation time for the symlink.")
+	} else if linkBtime.After(targetBtime) != true {
+		t.Fatalf("Creation time should be the symlink's: [%s] <= [%s]", linkBtime, targetBtime)
+	}
+}
@@ -0,0 +1,24 @@
+//go:build !darwin && !freebsd && !netbsd && !(linux && (amd64 || arm64 || 386 || arm))
+// +build !darwin
+// +build !freebsd
+// +build !netbsd
+// +build !linux !amd64,!arm64,!386,!arm
+
+package pathwalk
+
+import (
+	"os"
+	"time"
+)
+
+const (
+	// isBirthTimeSupported indicates that creation times are not available
+	// on this platform.
+	isBirthTimeSupported = false
+)
+
+// getBirthTime always fails since creation times are not supported on this
+// platform. Creation-time filters will exclude every file.
+func getBirthTime(fqPath string, info os.FileInfo) (btime time.Time, ok bool) {
+	return time.Time{}, false
+}
@@ -0,0 +1,145 @@
+package pathwalk
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-parallel-walker/internal/testing"
+)
+
+func TestInternalFilter_IsCreationTimeIncluded(t *testing.T) {
+	now := time.Now()
+
+	ifilter := newInternalFilter(Filter{})
+
+	if ifilter.HasCreationTimeFilter() != false {
+		t.Fatalf("Expected no creation-time filter.")
+	}
+
+	filter := Filter{
+		CreatedAfter:  now.Add(-time.Hour),
+		CreatedBefore: now,
+	}
+
+	ifilter = newInternalFilter(filter)
+
+	if ifilter.HasCreationTimeFilter() != true {
+		t.Fatalf("Expected a creation-time filter.")
+	} else if ifilter.IsCreationTimeIncluded(now.Add(-time.Minute)) != true {
+		t.Fatalf("Expected a time within the bounds to be included.")
+	} else if ifilter.IsCreationTimeIncluded(now.Add(-time.Hour)) != true {
+		t.Fatalf("Expected the lower bound to be included.")
+	} else if ifilter.IsCreationTimeIncluded(now) != false {
+		t.Fatalf("Expected the upper bound to be excluded.")
+	} else if ifilter.IsCreationTimeIncluded(now.Add(-time.Hour*2)) != false {
+		t.Fatalf("Expected an earlier time to be excluded.")
+	}
+
+	// Just one bound.
+
+	filter = Filter{
+		CreatedAfter: now,
+	}
+
+	ifilter = newInternalFilter(filter)
+
+	if ifilter.IsCreationTimeIncluded(now.Add(time.Hour*24*365)) != true {
+		t.Fatalf("Expected a later time to be included.")
+	}
+}
+
+func TestWalk_Run__creationTimeFilter(t *testing.T) {
+	fileCount := 10
+	tempPath, _ := pwtesting.FillFlatTempPath(fileCount, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+
+	filter := Filter{
+		CreatedAfter: time.Now().Add(-time.Hour),
+	}
+
+	err := walk.SetFilter(filter)
+	log.PanicIf(err)
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	stats := walk.Stats()
+
+	// Find out whether this platform and filesystem actually record the time.
+
+	probePath := path.Join(tempPath, "probe")
+
+	f, err := os.Create(probePath)
+	log.PanicIf(err)
+
+	f.Close()
+
+	info, err := os.Lstat(probePath)
+	log.PanicIf(err)
+
+	_, isAvailable := getBirthTime(probePath, info)
+
+	err = os.Remove(probePath)
+	log.PanicIf(err)
+
+	if isAvailable == false {
+		// The filter can't be satisfied.
+
+		if stats.FilesVisited != 0 || stats.CreationTimeFilterExcludes != fileCount {
+			t.Fatalf("Expected all files to be excluded without creation times: (%d) (%d)", stats.FilesVisited, stats.CreationTimeFilterExcludes)
+		}
+
+		t.Skipf("Creation times are not available here.")
+	}
+
+	if stats.FilesVisited != fileCount || stats.CreationTimeFilterExcludes != 0 {
+		t.Fatalf("Expected all files to be included: (%d) (%d)", stats.FilesVisited, stats.CreationTimeFilterExcludes)
+	}
+
+	// Files created before the bound.
+
+	filter = Filter{
+		CreatedBefore: time.Now().Add(-time.Hour),
+	}
+
+	err = walk.SetFilter(filter)
+	log.PanicIf(err)
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	stats = walk.Stats()
+
+	if stats.FilesVisited != 0 || stats.CreationTimeFilterExcludes != fileCount {
+		t.Fatalf("Expected all files to be excluded: (%d) (%d)", stats.FilesVisited, stats.CreationTimeFilterExcludes)
+	}
+}
+
+func TestWalk_SetNamesOnly__creationTimeConflict(t *testing.T) {
+	walk := NewWalk("/root", nil)
+	walk.SetNamesOnly(true)
+
+	filter := Filter{
+		CreatedAfter: time.Now(),
+	}
+
+	walk.SetFilter(filter)
+
+	err := walk.Run()
+	if err == nil || log.Is(err, ErrNamesOnlyConflict) != true {
+		t.Fatalf("Expected conflict error: %v", err)
+	}
+}
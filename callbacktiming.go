@@ -0,0 +1,35 @@
+package pathwalk
+
+import (
+	"path"
+	"time"
+)
+
+// SetTrackCallbackTiming enables recording the entry whose callback took the
+// longest (see `Stats().SlowestCallbackDuration` and
+// `Stats().SlowestCallbackPath`) in order to find outliers when a walk is
+// slower than expected.
+func (walk *Walk) SetTrackCallbackTiming(isTrackCallbackTiming bool) {
+	walk.isTrackCallbackTiming = isTrackCallbackTiming
+}
+
+// recordCallbackDuration records the time spent in the callback for one entry
+// and, if tracking, remembers the entry if it's the slowest so far. The stats
+// lock is only taken in the latter case.
+func (walk *Walk) recordCallbackDuration(parentNodePath string, name string, duration time.Duration) {
+	walk.hotStats.addCallbackTime(duration)
+
+	if walk.isTrackCallbackTiming == false {
+		return
+	}
+
+	walk.statsLocker.Lock()
+	defer walk.statsLocker.Unlock()
+
+	if duration <= walk.stats.SlowestCallbackDuration {
+		return
+	}
+
+	walk.stats.SlowestCallbackDuration = duration
+	walk.stats.SlowestCallbackPath = path.Join(parentNodePath, name)
+}
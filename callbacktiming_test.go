@@ -0,0 +1,68 @@
+package pathwalk
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/dsoprea/go-logging"
+)
+
+func TestWalk_SetTrackCallbackTiming(t *testing.T) {
+	tempPath := createDepthTestTree()
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	slowDelay := time.Millisecond * 50
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		if info.Name() == "file2" {
+			time.Sleep(slowDelay)
+		}
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetTrackCallbackTiming(true)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	stats := walk.Stats()
+
+	expectedPath := path.Join(tempPath, "dir1", "dir2", "file2")
+	if stats.SlowestCallbackPath != expectedPath {
+		t.Fatalf("Slowest callback path not correct: [%s] != [%s]", stats.SlowestCallbackPath, expectedPath)
+	} else if stats.SlowestCallbackDuration < slowDelay {
+		t.Fatalf("Slowest callback duration not correct: %s", stats.SlowestCallbackDuration)
+	} else if stats.SlowestCallbackDuration > stats.CallbackTime {
+		t.Fatalf("Slowest callback duration exceeds the total: %s > %s", stats.SlowestCallbackDuration, stats.CallbackTime)
+	}
+}
+
+func TestWalk_SetTrackCallbackTiming__disabled(t *testing.T) {
+	tempPath := createDepthTestTree()
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	stats := walk.Stats()
+
+	if stats.SlowestCallbackPath != "" || stats.SlowestCallbackDuration != 0 {
+		t.Fatalf("Expected no slowest callback: [%s] %s", stats.SlowestCallbackPath, stats.SlowestCallbackDuration)
+	}
+}
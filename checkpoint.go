@@ -0,0 +1,195 @@
+package pathwalk
+
+import (
+	"errors"
+	"io"
+	"path"
+
+	"encoding/json"
+
+	"github.com/dsoprea/go-logging"
+)
+
+// Checkpoints
+//
+// A checkpoint records every directory that had outstanding work at the moment
+// that it was taken. The tracked state is updated atomically with the pushing
+// and completion of jobs, so taking a checkpoint only needs to briefly hold
+// the tracker's locker in order to get a consistent snapshot, and it can be
+// taken while the walk is running or after it has been stopped.
+//
+// On resume, the directories with pending work are seeded as the initial jobs
+// (instead of the root) and are read again. The files and subdirectories that
+// had already completed are recorded in the checkpoint and are skipped, as are
+// the subdirectories that are in the checkpoint themselves (they are seeded
+// separately). A directory whose callback was already called won't be
+// delivered again.
+//
+// Work is tracked per job rather than per callback, so a file or directory
+// that had been delivered to the callback but whose job hadn't completed will
+// be delivered again. The callback must be able to tolerate seeing an entry
+// again.
+
+const (
+	checkpointVersion = 1
+)
+
+var (
+	// ErrCheckpointsNotEnabled is returned if a checkpoint is requested but
+	// tracking was not enabled before the walk was started.
+	ErrCheckpointsNotEnabled = errors.New("checkpoints not enabled")
+
+	// ErrCheckpointRootMismatch is returned if a checkpoint is loaded for a
+	// different root.
+	ErrCheckpointRootMismatch = errors.New("checkpoint is for a different root")
+)
+
+// checkpointDirectory describes one incomplete directory in a checkpoint.
+type checkpointDirectory struct {
+	// Path is the full-path of the directory.
+	Path string `json:"path"`
+
+	// IsVisited indicates that the callback was already called for the
+	// directory.
+	IsVisited bool `json:"is_visited"`
+
+	// IsEnumerated indicates that all of the directory's children were
+	// already dispatched.
+	IsEnumerated bool `json:"is_enumerated"`
+
+	// HasPendingWork indicates that the directory has jobs of its own that
+	// didn't complete. If false, the directory is only incomplete because of
+	// its subdirectories.
+	HasPendingWork bool `json:"has_pending_work"`
+
+	// CompletedChildren are the names of the files and subdirectories that
+	// had already completed.
+	CompletedChildren []string `json:"completed_children,omitempty"`
+}
+
+// checkpoint is the serialized form of the walk state.
+type checkpoint struct {
+	Version     int                   `json:"version"`
+	RootPath    string                `json:"root_path"`
+	Directories []checkpointDirectory `json:"directories"`
+}
+
+// SetCheckpointsEnabled enables the directory tracking that is required in
+// order to call `SaveCheckpoint()`. This adds some locking overhead to every
+// job and must be set before calling `Run()`.
+func (walk *Walk) SetCheckpointsEnabled(isEnabled bool) {
+	walk.isCheckpointsEnabled = isEnabled
+}
+
+// SaveCheckpoint writes the set of incomplete directories so that the walk can
+// be resumed later using `ResumeFromCheckpoint()`. This can be called from
+// the callback, from another goroutine while the walk is running, or after
+// `Run()` has returned.
+func (walk *Walk) SaveCheckpoint(w io.Writer) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if walk.tracker == nil {
+		return ErrCheckpointsNotEnabled
+	}
+
+	c := checkpoint{
+		Version:     checkpointVersion,
+		RootPath:    walk.rootPath,
+		Directories: walk.tracker.Snapshot(),
+	}
+
+	je := json.NewEncoder(w)
+
+	err = je.Encode(c)
+	log.PanicIf(err)
+
+	return nil
+}
+
+// ResumeFromCheckpoint loads a checkpoint written by `SaveCheckpoint()`. The
+// next call to `Run()` will only process the work that was outstanding when
+// the checkpoint was taken rather than the whole tree. This only applies to
+// that one run.
+func (walk *Walk) ResumeFromCheckpoint(r io.Reader) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	c := checkpoint{}
+
+	jd := json.NewDecoder(r)
+
+	err = jd.Decode(&c)
+	log.PanicIf(err)
+
+	if c.Version != checkpointVersion {
+		log.Panicf("checkpoint version not supported: (%d)", c.Version)
+	}
+
+	if c.RootPath != walk.rootPath {
+		log.Panic(ErrCheckpointRootMismatch)
+	}
+
+	walk.resumeDirectories = c.Directories
+
+	return nil
+}
+
+// pushResumeJobs seeds the directories from the loaded checkpoint. It returns
+// the number of jobs pushed.
+func (walk *Walk) pushResumeJobs(directories []checkpointDirectory) (count int, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	walk.resumeSkipPaths = make(map[string]struct{}, len(directories))
+	for _, cd := range directories {
+		walk.resumeSkipPaths[cd.Path] = struct{}{}
+
+		for _, name := range cd.CompletedChildren {
+			childPath := path.Join(cd.Path, name)
+			walk.resumeSkipPaths[childPath] = struct{}{}
+		}
+	}
+
+	release := walk.holdSeeding()
+	defer release()
+
+	for _, cd := range directories {
+		if cd.HasPendingWork == false {
+			continue
+		}
+
+		info, err := walk.statNode(cd.Path)
+		if err != nil {
+			walk.logWarningf("can not stat checkpointed directory [%s]; it will be skipped: [%s]", cd.Path, err.Error())
+
+			walk.recordFailedDirectory(cd.Path)
+
+			err := walk.recordSkippedEntry(cd.Path, err)
+			log.PanicIf(err)
+
+			continue
+		}
+
+		jdn := newJobDirectoryNode(path.Dir(cd.Path), info)
+		jdn.skipCallback = cd.IsVisited
+		jdn.skipSubdirectories = cd.IsEnumerated
+		jdn.depth = walk.pathDepth(cd.Path)
+
+		err = walk.pushJob(jdn)
+		log.PanicIf(err)
+
+		count++
+	}
+
+	return count, nil
+}
@@ -0,0 +1,196 @@
+package pathwalk
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-parallel-walker/internal/testing"
+)
+
+func TestWalk_SaveCheckpoint__notEnabled(t *testing.T) {
+	walk := NewWalk("root/path", nil)
+	walk.InitSync()
+
+	b := new(bytes.Buffer)
+
+	err := walk.SaveCheckpoint(b)
+	if err != ErrCheckpointsNotEnabled {
+		t.Fatalf("Expected error for disabled checkpoints: %v", err)
+	}
+}
+
+func TestWalk_ResumeFromCheckpoint__rootMismatch(t *testing.T) {
+	b := bytes.NewBufferString(`{"version": 1, "root_path": "other/path", "directories": []}`)
+
+	walk := NewWalk("root/path", nil)
+
+	err := walk.ResumeFromCheckpoint(b)
+	if err == nil || log.Is(err, ErrCheckpointRootMismatch) != true {
+		t.Fatalf("Expected mismatch error: %v", err)
+	}
+}
+
+func TestWalk_ResumeFromCheckpoint(t *testing.T) {
+	fileCount := 300
+	tempPath, tempFiles := pwtesting.FillHeirarchicalTempPath(fileCount, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	m := sync.Mutex{}
+	visited := make(map[string]int)
+
+	var walk *Walk
+
+	stopAfter := fileCount / 3
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		if info.IsDir() == true {
+			return nil
+		}
+
+		m.Lock()
+		defer m.Unlock()
+
+		relFilepath := path.Join(parentPath, info.Name())[len(tempPath)+1:]
+		visited[relFilepath]++
+
+		if len(visited) == stopAfter {
+			walk.Stop()
+		}
+
+		return nil
+	}
+
+	// Walk part of the tree.
+
+	walk = NewWalk(tempPath, walkFunc)
+	walk.SetCheckpointsEnabled(true)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	if walk.Outcome() != OutcomeStopped {
+		t.Fatalf("Expected the first run to be stopped: [%s]", walk.Outcome())
+	}
+
+	b := new(bytes.Buffer)
+
+	err = walk.SaveCheckpoint(b)
+	log.PanicIf(err)
+
+	// Walk the rest of the tree.
+
+	stopAfter = -1
+
+	resumeWalk := NewWalk(tempPath, walkFunc)
+
+	err = resumeWalk.ResumeFromCheckpoint(b)
+	log.PanicIf(err)
+
+	err = resumeWalk.Run()
+	log.PanicIf(err)
+
+	if resumeWalk.Outcome() != OutcomeCompleted {
+		t.Fatalf("Expected the resumed run to complete: [%s]", resumeWalk.Outcome())
+	}
+
+	for _, relFilepath := range tempFiles {
+		if _, found := visited[relFilepath]; found == false {
+			t.Fatalf("File not visited by either run: [%s]", relFilepath)
+		}
+	}
+
+	if len(visited) != len(tempFiles) {
+		t.Fatalf("Visited files not correct: (%d) != (%d)", len(visited), len(tempFiles))
+	}
+
+	// The resumed run should not have reprocessed the whole tree.
+
+	if resumeWalk.Stats().FilesVisited >= fileCount {
+		t.Fatalf("Resumed run reprocessed everything: (%d)", resumeWalk.Stats().FilesVisited)
+	}
+}
+
+// testSlowStatChildLister takes a while to stat anything but lists
+// immediately.
+type testSlowStatChildLister struct {
+	*testMapChildLister
+}
+
+func (tsscl *testSlowStatChildLister) StatChild(nodePath string) (info os.FileInfo, err error) {
+	time.Sleep(time.Millisecond * 20)
+
+	return tsscl.testMapChildLister.StatChild(nodePath)
+}
+
+func TestWalk_ResumeFromCheckpoint__slowSeeding(t *testing.T) {
+	tsscl := &testSlowStatChildLister{
+		testMapChildLister: &testMapChildLister{
+			children: map[string][]string{
+				"/root":      {"dir1", "dir2", "dir3", "dir4"},
+				"/root/dir1": {},
+				"/root/dir2": {},
+				"/root/dir3": {},
+				"/root/dir4": {},
+			},
+			offsets: make(map[string]int),
+		},
+	}
+
+	// Every directory is still pending. Each one is stat'd before it's pushed,
+	// which takes longer than the previous ones take to finish.
+
+	checkpointDirectories := make([]checkpointDirectory, 0)
+	for _, directoryName := range []string{"dir1", "dir2", "dir3", "dir4"} {
+		cd := checkpointDirectory{
+			Path:           path.Join("/root", directoryName),
+			HasPendingWork: true,
+		}
+
+		checkpointDirectories = append(checkpointDirectories, cd)
+	}
+
+	m := sync.Mutex{}
+	visited := make([]string, 0)
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		visited = append(visited, path.Join(parentPath, info.Name()))
+
+		return nil
+	}
+
+	walk := NewWalk("/root", walkFunc)
+	walk.SetChildLister(tsscl)
+
+	walk.resumeDirectories = checkpointDirectories
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	sort.Strings(visited)
+
+	expected := []string{
+		"/root/dir1",
+		"/root/dir2",
+		"/root/dir3",
+		"/root/dir4",
+	}
+
+	if reflect.DeepEqual(visited, expected) != true {
+		t.Fatalf("Visited not correct: %v", visited)
+	} else if walk.Outcome() != OutcomeCompleted {
+		t.Fatalf("Outcome not correct: [%s]", walk.Outcome())
+	}
+}
@@ -0,0 +1,66 @@
+package pathwalk
+
+import (
+	"path"
+	"strings"
+)
+
+// childPathBuilder builds the full-paths of the children of one directory by
+// appending each name to a reused buffer rather than calling `path.Join()` for
+// every child, which has to clean the result every time. The results are
+// identical to `path.Join()`; anything that would be changed by cleaning falls
+// back to it. This is not safe for concurrent use.
+type childPathBuilder struct {
+	parentNodePath string
+
+	// isFastPath indicates that the parent is clean and can be prefixed as-is.
+	isFastPath bool
+
+	buffer    []byte
+	prefixLen int
+}
+
+func newChildPathBuilder(parentNodePath string) *childPathBuilder {
+	cpb := &childPathBuilder{
+		parentNodePath: parentNodePath,
+	}
+
+	var prefix string
+	if parentNodePath == "" || parentNodePath == "." {
+		// `path.Join()` drops these.
+		cpb.isFastPath = true
+	} else if path.Clean(parentNodePath) == parentNodePath {
+		cpb.isFastPath = true
+
+		prefix = parentNodePath
+		if prefix != "/" {
+			prefix += "/"
+		}
+	}
+
+	if cpb.isFastPath == true {
+		cpb.buffer = make([]byte, len(prefix), len(prefix)+64)
+		copy(cpb.buffer, prefix)
+
+		cpb.prefixLen = len(prefix)
+	}
+
+	return cpb
+}
+
+// Join returns the full-path of the given child.
+func (cpb *childPathBuilder) Join(childFilename string) string {
+	if cpb.isFastPath == false || isCleanName(childFilename) == false {
+		return path.Join(cpb.parentNodePath, childFilename)
+	}
+
+	cpb.buffer = append(cpb.buffer[:cpb.prefixLen], childFilename...)
+
+	return string(cpb.buffer)
+}
+
+// isCleanName indicates that the given name is a single path component that
+// wouldn't be changed by cleaning.
+func isCleanName(name string) bool {
+	return name != "" && name != "." && name != ".." && strings.IndexByte(name, '/') == -1
+}
@@ -0,0 +1,101 @@
+package pathwalk
+
+import (
+	"fmt"
+	"path"
+	"testing"
+)
+
+func TestChildPathBuilder_Join(t *testing.T) {
+	parents := []string{
+		"",
+		".",
+		"/",
+		"/a",
+		"/a/",
+		"/a/b",
+		"a",
+		"a/../b",
+		"./a",
+		"//a",
+		"..",
+		"../a",
+	}
+
+	names := []string{
+		"x",
+		".hidden",
+		"..x",
+		"",
+		".",
+		"..",
+		"a/b",
+		"x/",
+		"/x",
+	}
+
+	for _, parent := range parents {
+		cpb := newChildPathBuilder(parent)
+
+		// Do it twice to make sure that the buffer is reused correctly.
+		for i := 0; i < 2; i++ {
+			for _, name := range names {
+				expected := path.Join(parent, name)
+				actual := cpb.Join(name)
+
+				if actual != expected {
+					t.Fatalf("Path not correct for [%s] [%s]: [%s] != [%s]", parent, name, actual, expected)
+				}
+			}
+		}
+	}
+}
+
+func TestChildPathBuilder_Join__resultsNotShared(t *testing.T) {
+	cpb := newChildPathBuilder("/parent")
+
+	first := cpb.Join("first-child-with-a-long-name")
+	second := cpb.Join("second")
+
+	if first != "/parent/first-child-with-a-long-name" {
+		t.Fatalf("First path was modified: [%s]", first)
+	} else if second != "/parent/second" {
+		t.Fatalf("Second path not correct: [%s]", second)
+	}
+}
+
+// benchmarkChildNames are the names used by the child-path benchmarks.
+var benchmarkChildNames = func() []string {
+	names := make([]string, 100)
+	for i := range names {
+		names[i] = fmt.Sprintf("child-file-%d.txt", i)
+	}
+
+	return names
+}()
+
+func BenchmarkChildPaths__pathJoin(b *testing.B) {
+	b.ReportAllocs()
+
+	parentNodePath := "/some/reasonably/deep/parent/directory"
+
+	for i := 0; i < b.N; i++ {
+		for _, name := range benchmarkChildNames {
+			_ = path.Join(parentNodePath, name)
+		}
+	}
+}
+
+func BenchmarkChildPaths__childPathBuilder(b *testing.B) {
+	b.ReportAllocs()
+
+	parentNodePath := "/some/reasonably/deep/parent/directory"
+
+	for i := 0; i < b.N; i++ {
+		cpb := newChildPathBuilder(parentNodePath)
+
+		for _, name := range benchmarkChildNames {
+			_ = cpb.Join(name)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package pathwalk
+
+import (
+	"time"
+)
+
+// clock is the source of time for the idle and deadlock tracking. This allows
+// the tests to control the passage of time.
+type clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// NewTicker returns a ticker that fires every `d`.
+	NewTicker(d time.Duration) ticker
+}
+
+// ticker is the subset of `time.Ticker` that we use.
+type ticker interface {
+	// C returns the channel that the ticks are delivered on.
+	C() <-chan time.Time
+
+	// Stop stops the ticker.
+	Stop()
+}
+
+// realClock is a clock backed by the `time` package.
+type realClock struct{}
+
+// Now returns the current time.
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// NewTicker returns a ticker that fires every `d`.
+func (realClock) NewTicker(d time.Duration) ticker {
+	return realTicker{
+		t: time.NewTicker(d),
+	}
+}
+
+// realTicker wraps a `time.Ticker`.
+type realTicker struct {
+	t *time.Ticker
+}
+
+// C returns the channel that the ticks are delivered on.
+func (rt realTicker) C() <-chan time.Time {
+	return rt.t.C
+}
+
+// Stop stops the ticker.
+func (rt realTicker) Stop() {
+	rt.t.Stop()
+}
+
+// getClock returns the configured clock or the real clock if one was never
+// set.
+func (walk *Walk) getClock() clock {
+	if walk.clock == nil {
+		return realClock{}
+	}
+
+	return walk.clock
+}
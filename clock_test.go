@@ -0,0 +1,270 @@
+package pathwalk
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-parallel-walker/internal/testing"
+)
+
+// fakeClock is a clock whose time only moves when Advance() is called.
+type fakeClock struct {
+	now     time.Time
+	tickers []*fakeTicker
+	locker  sync.Mutex
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{
+		now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+// Now returns the current (fake) time.
+func (fc *fakeClock) Now() time.Time {
+	fc.locker.Lock()
+	defer fc.locker.Unlock()
+
+	return fc.now
+}
+
+// NewTicker returns a ticker that fires as the clock is advanced.
+func (fc *fakeClock) NewTicker(d time.Duration) ticker {
+	fc.locker.Lock()
+	defer fc.locker.Unlock()
+
+	ft := &fakeTicker{
+		interval: d,
+		next:     fc.now.Add(d),
+		c:        make(chan time.Time, 1),
+	}
+
+	fc.tickers = append(fc.tickers, ft)
+
+	return ft
+}
+
+// Advance moves the time forward and fires any tickers that are due. Like
+// real tickers, ticks are dropped if the previous one hasn't been read yet.
+func (fc *fakeClock) Advance(d time.Duration) {
+	fc.locker.Lock()
+	defer fc.locker.Unlock()
+
+	fc.now = fc.now.Add(d)
+
+	for _, ft := range fc.tickers {
+		ft.fire(fc.now)
+	}
+}
+
+type fakeTicker struct {
+	interval  time.Duration
+	next      time.Time
+	isStopped bool
+	locker    sync.Mutex
+
+	c chan time.Time
+}
+
+// C returns the channel that the ticks are delivered on.
+func (ft *fakeTicker) C() <-chan time.Time {
+	return ft.c
+}
+
+// Stop stops the ticker.
+func (ft *fakeTicker) Stop() {
+	ft.locker.Lock()
+	defer ft.locker.Unlock()
+
+	ft.isStopped = true
+}
+
+func (ft *fakeTicker) fire(now time.Time) {
+	ft.locker.Lock()
+	defer ft.locker.Unlock()
+
+	if ft.isStopped == true || now.Before(ft.next) == true {
+		return
+	}
+
+	for now.Before(ft.next) == false {
+		ft.next = ft.next.Add(ft.interval)
+	}
+
+	select {
+	case ft.c <- now:
+	default:
+	}
+}
+
+// advanceUntil advances the clock by `d` until `isDone` returns true. It gives
+// up after a (real) second.
+func advanceUntil(fc *fakeClock, d time.Duration, isDone func() bool) bool {
+	giveUpAt := time.Now().Add(time.Second)
+
+	for time.Now().Before(giveUpAt) == true {
+		fc.Advance(d)
+		time.Sleep(time.Millisecond)
+
+		if isDone() == true {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestRealClock(t *testing.T) {
+	c := realClock{}
+
+	before := time.Now()
+	now := c.Now()
+
+	if now.Before(before) == true {
+		t.Fatalf("Time is not current: %v < %v", now, before)
+	}
+
+	tick := c.NewTicker(time.Millisecond)
+	defer tick.Stop()
+
+	select {
+	case <-tick.C():
+	case <-time.After(time.Second):
+		t.Fatalf("Ticker did not fire.")
+	}
+}
+
+func TestFakeClock_Advance(t *testing.T) {
+	fc := newFakeClock()
+
+	initial := fc.Now()
+
+	tick := fc.NewTicker(time.Second * 2)
+
+	fc.Advance(time.Second)
+
+	select {
+	case <-tick.C():
+		t.Fatalf("Ticker fired early.")
+	default:
+	}
+
+	fc.Advance(time.Second)
+
+	select {
+	case <-tick.C():
+	default:
+		t.Fatalf("Ticker did not fire.")
+	}
+
+	if fc.Now().Sub(initial) != time.Second*2 {
+		t.Fatalf("Time not advanced correctly: %v", fc.Now().Sub(initial))
+	}
+
+	tick.Stop()
+	fc.Advance(time.Second * 10)
+
+	select {
+	case <-tick.C():
+		t.Fatalf("Stopped ticker fired.")
+	default:
+	}
+}
+
+func TestWalk_getClock__default(t *testing.T) {
+	walk := &Walk{}
+
+	if _, ok := walk.getClock().(realClock); ok != true {
+		t.Fatalf("Expected the real clock.")
+	}
+}
+
+func TestWalk_nodeWorker__closeWhenIdle__fakeClock(t *testing.T) {
+	wg := new(sync.WaitGroup)
+	wg.Add(1)
+
+	fc := newFakeClock()
+
+	walk := &Walk{
+		workerCount: 1,
+		wg:          wg,
+		jobsC:       make(chan job, 1),
+		clock:       fc,
+	}
+
+	doneC := make(chan struct{})
+
+	go func() {
+		walk.nodeWorker()
+		close(doneC)
+	}()
+
+	isDone := func() bool {
+		select {
+		case <-doneC:
+			return true
+		default:
+			return false
+		}
+	}
+
+	if advanceUntil(fc, workerIdleCheckInterval, isDone) != true {
+		t.Fatalf("Worker did not exit when idle.")
+	}
+
+	stats := walk.Stats()
+
+	if stats.IdleWorkerTime <= maxWorkerIdleDuration {
+		t.Fatalf("Idle time not measured with the clock: %v", stats.IdleWorkerTime)
+	}
+}
+
+func TestWalk_Run__deadlocked__fakeClock(t *testing.T) {
+	tempPath, _ := pwtesting.FillFlatTempPath(1, nil)
+
+	defer func() {
+		err := os.RemoveAll(tempPath)
+		log.PanicIf(err)
+	}()
+
+	releaseC := make(chan struct{})
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		// Never make progress while the clock is being advanced.
+		<-releaseC
+
+		return nil
+	}
+
+	fc := newFakeClock()
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.clock = fc
+	walk.SetGlobalTimeoutDuration(time.Hour)
+
+	errC := make(chan error, 1)
+
+	go func() {
+		errC <- walk.Run()
+	}()
+
+	isDeadlocked := advanceUntil(fc, time.Minute*10, walk.isStopped)
+
+	// Let the blocked callback return so that the run can finish.
+	close(releaseC)
+
+	err := <-errC
+
+	if isDeadlocked != true {
+		t.Fatalf("Deadlock was not detected.")
+	} else if err == nil {
+		t.Fatalf("Expected error.")
+	} else if strings.Contains(err.Error(), ErrDeadlocked.Error()) == false {
+		t.Fatalf("Expected deadlock error: %v", err)
+	}
+}
@@ -0,0 +1,171 @@
+package pathwalk
+
+import (
+	"fmt"
+
+	"github.com/dsoprea/go-logging"
+)
+
+// jobCoalescedBatches carries the complete listings of several small
+// directories so that they can be processed as one job. Each listing keeps its
+// own parent, context, and filter state; only the dispatch is shared.
+type jobCoalescedBatches struct {
+	batches []jobDirectoryContentsBatch
+}
+
+// ParentNodePath returns an empty string since the batches may each have a
+// different parent. The individual batches are tracked instead.
+func (jcb jobCoalescedBatches) ParentNodePath() string {
+	return ""
+}
+
+// String returns a descriptive string.
+func (jcb jobCoalescedBatches) String() string {
+	return fmt.Sprintf("JobCoalescedBatches<BATCH-COUNT=(%d)>", len(jcb.batches))
+}
+
+// SetAutoTuneBatching enables coalescing the listings of small directories
+// (those that fit in a single batch that isn't full) into shared jobs rather
+// than queueing one job per directory. This reduces the job overhead for
+// trees that have many directories with few entries each (see
+// `Stats.BatchesCoalesced`).
+//
+// There are tradeoffs. A small directory's entries, including its
+// subdirectories, aren't dispatched until enough entries have accumulated to
+// fill a batch (as configured by `SetBatchSize()`) or the walk would otherwise
+// run out of work. This delays the discovery of deeper directories, so a tree
+// that is narrow all of the way down gains nothing. The batch size from
+// `SetBatchSizeFunc()` still decides whether a directory is small, but the
+// shared jobs are always filled to the global batch size. Every entry is still
+// reported with its own parent path, but the entries of unrelated directories
+// are processed by the same worker, one directory after another.
+//
+// This must be set before calling `Run()`.
+func (walk *Walk) SetAutoTuneBatching(isAutoTuneBatching bool) {
+	walk.isAutoTuneBatching = isAutoTuneBatching
+}
+
+// isCoalescable returns whether a batch might be the complete listing of a
+// small directory, which can be shared with others. Only a first batch that
+// didn't fill the batch size qualifies, and only once the directory turns out
+// to have nothing more.
+func (walk *Walk) isCoalescable(batchNumber int, entryCount int, batchSize int) bool {
+	if walk.isAutoTuneBatching == false {
+		return false
+	}
+
+	return batchNumber == 0 && entryCount < batchSize
+}
+
+// coalesceBatch holds the listing of a small directory. Once enough entries
+// have accumulated, they are pushed as one job.
+func (walk *Walk) coalesceBatch(jdcb jobDirectoryContentsBatch) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	// The directory has to remain outstanding until the listing has actually
+	// been processed.
+	if walk.tracker != nil {
+		walk.tracker.JobQueued(jdcb)
+	}
+
+	walk.coalescedBatchesLocker.Lock()
+
+	walk.coalescedBatches = append(walk.coalescedBatches, jdcb)
+	walk.coalescedEntryCount += len(jdcb.childBatch)
+
+	var batches []jobDirectoryContentsBatch
+	if walk.coalescedEntryCount >= walk.batchSize {
+		batches = walk.takeCoalescedBatches()
+	}
+
+	walk.coalescedBatchesLocker.Unlock()
+
+	walk.statsLocker.Lock()
+	walk.stats.BatchesCoalesced++
+	walk.statsLocker.Unlock()
+
+	err = walk.pushCoalescedBatches(batches)
+	log.PanicIf(err)
+
+	return nil
+}
+
+// takeCoalescedBatches returns and clears the held listings. The locker must
+// be held.
+func (walk *Walk) takeCoalescedBatches() []jobDirectoryContentsBatch {
+	batches := walk.coalescedBatches
+
+	walk.coalescedBatches = nil
+	walk.coalescedEntryCount = 0
+
+	return batches
+}
+
+// pushCoalescedBatches pushes the given listings as one job.
+func (walk *Walk) pushCoalescedBatches(batches []jobDirectoryContentsBatch) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if len(batches) == 0 {
+		return nil
+	}
+
+	jcb := jobCoalescedBatches{
+		batches: batches,
+	}
+
+	err = walk.pushJob(jcb)
+	log.PanicIf(err)
+
+	return nil
+}
+
+// handleJobCoalescedBatches processes each of the held listings in turn.
+func (walk *Walk) handleJobCoalescedBatches(jcb jobCoalescedBatches) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	for _, jdcb := range jcb.batches {
+		err := walk.handleJobDirectoryContentsBatch(jdcb)
+		log.PanicIf(err)
+
+		err = walk.trackJobCompleted(jdcb)
+		log.PanicIf(err)
+	}
+
+	return nil
+}
+
+// jobTickDownOrTakeCoalescedBatches accounts for a finished job unless it's
+// the last one in flight and there are still held listings. In that case, the
+// held listings are returned and the caller must push them before calling
+// `jobTickDown()`. This is done under the job-counter locker so that two jobs
+// finishing at the same time can't both assume that the other will flush.
+func (walk *Walk) jobTickDownOrTakeCoalescedBatches() []jobDirectoryContentsBatch {
+	walk.counterLocker.Lock()
+	defer walk.counterLocker.Unlock()
+
+	if walk.jobsInFlight == 1 && walk.hasStopped == false {
+		walk.coalescedBatchesLocker.Lock()
+		batches := walk.takeCoalescedBatches()
+		walk.coalescedBatchesLocker.Unlock()
+
+		if len(batches) > 0 {
+			return batches
+		}
+	}
+
+	walk.jobTickDownLocked()
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package pathwalk
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"testing"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+)
+
+// createCoalesceTestTree creates a root with many small subdirectories and
+// returns the root and the relative paths of the files.
+func createCoalesceTestTree(directoryCount, filesPerDirectory int) (tempPath string, relFilepaths []string) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	for i := 0; i < directoryCount; i++ {
+		directoryName := fmt.Sprintf("dir%d", i)
+
+		err := os.Mkdir(path.Join(tempPath, directoryName), 0755)
+		log.PanicIf(err)
+
+		for j := 0; j < filesPerDirectory; j++ {
+			relFilepath := path.Join(directoryName, fmt.Sprintf("file%d", j))
+
+			err := ioutil.WriteFile(path.Join(tempPath, relFilepath), []byte("data"), 0644)
+			log.PanicIf(err)
+
+			relFilepaths = append(relFilepaths, relFilepath)
+		}
+	}
+
+	sort.Strings(relFilepaths)
+
+	return tempPath, relFilepaths
+}
+
+// runCoalesceTestWalk walks the tree and returns the relative paths of the
+// visited files, the paths of the left directories, and the stats.
+func runCoalesceTestWalk(tempPath string, isAutoTuneBatching bool) (relFilepaths []string, leftDirectoryCount int, stats Stats) {
+	m := sync.Mutex{}
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		if info.IsDir() == true {
+			return nil
+		}
+
+		m.Lock()
+		defer m.Unlock()
+
+		relFilepaths = append(relFilepaths, path.Join(parentPath, info.Name())[len(tempPath)+1:])
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetAutoTuneBatching(isAutoTuneBatching)
+
+	walk.SetDirectoryLeaveFunc(func(summary DirectorySummary) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		leftDirectoryCount++
+
+		return nil
+	})
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	sort.Strings(relFilepaths)
+
+	return relFilepaths, leftDirectoryCount, walk.Stats()
+}
+
+func TestWalk_SetAutoTuneBatching(t *testing.T) {
+	tempPath, expectedRelFilepaths := createCoalesceTestTree(250, 2)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	plainRelFilepaths, plainLeftDirectoryCount, plainStats := runCoalesceTestWalk(tempPath, false)
+	tunedRelFilepaths, tunedLeftDirectoryCount, tunedStats := runCoalesceTestWalk(tempPath, true)
+
+	if len(plainRelFilepaths) != len(expectedRelFilepaths) {
+		t.Fatalf("Plain walk didn't visit all files: (%d)", len(plainRelFilepaths))
+	} else if len(tunedRelFilepaths) != len(expectedRelFilepaths) {
+		t.Fatalf("Tuned walk didn't visit all files: (%d)", len(tunedRelFilepaths))
+	}
+
+	for i, relFilepath := range expectedRelFilepaths {
+		if tunedRelFilepaths[i] != relFilepath {
+			t.Fatalf("Tuned walk reported the wrong path: [%s] != [%s]", tunedRelFilepaths[i], relFilepath)
+		}
+	}
+
+	// Every directory, including the root, has to still be left exactly once.
+	if plainLeftDirectoryCount != 251 {
+		t.Fatalf("Plain walk left-directory count not correct: (%d)", plainLeftDirectoryCount)
+	} else if tunedLeftDirectoryCount != 251 {
+		t.Fatalf("Tuned walk left-directory count not correct: (%d)", tunedLeftDirectoryCount)
+	}
+
+	if plainStats.BatchesCoalesced != 0 {
+		t.Fatalf("Plain walk shouldn't have coalesced: (%d)", plainStats.BatchesCoalesced)
+	} else if tunedStats.BatchesCoalesced != 250 {
+		t.Fatalf("Tuned walk coalesced-batch count not correct: (%d)", tunedStats.BatchesCoalesced)
+	}
+
+	plainJobCount := plainStats.JobsDispatchedToNewWorker + plainStats.JobsDispatchedToIdleWorker
+	tunedJobCount := tunedStats.JobsDispatchedToNewWorker + tunedStats.JobsDispatchedToIdleWorker
+
+	// The root listing is three batches (it has 250 entries) and isn't
+	// coalesced. The 250 two-file listings fill at most one shared job per 50,
+	// plus one flush at the end.
+	if plainJobCount != 1+3+250+250+500 {
+		t.Fatalf("Plain job count not correct: (%d)", plainJobCount)
+	} else if tunedJobCount > 1+3+250+6+500 {
+		t.Fatalf("Tuned job count not reduced: (%d) >= (%d)", tunedJobCount, plainJobCount)
+	}
+}
+
+func TestWalk_isCoalescable(t *testing.T) {
+	walk := NewWalk("", nil)
+
+	if walk.isCoalescable(0, 5, 100) != false {
+		t.Fatalf("Expected nothing to be coalescable when disabled.")
+	}
+
+	walk.SetAutoTuneBatching(true)
+
+	if walk.isCoalescable(0, 5, 100) != true {
+		t.Fatalf("Expected a small first batch to be coalescable.")
+	} else if walk.isCoalescable(0, 100, 100) != false {
+		t.Fatalf("Expected a full batch not to be coalescable.")
+	} else if walk.isCoalescable(1, 5, 100) != false {
+		t.Fatalf("Expected a second batch not to be coalescable.")
+	}
+}
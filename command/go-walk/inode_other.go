@@ -0,0 +1,14 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly && !solaris
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly,!solaris
+
+package main
+
+import (
+	"os"
+)
+
+// getInode always fails since inodes are not supported on this platform. The
+// fields are just omitted from the output.
+func getInode(info os.FileInfo) (device uint64, inode uint64, ok bool) {
+	return 0, 0, false
+}
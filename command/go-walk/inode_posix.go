@@ -0,0 +1,20 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly || solaris
+// +build linux darwin freebsd netbsd openbsd dragonfly solaris
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// getInode returns the device and inode of the given entry if they're
+// available.
+func getInode(info os.FileInfo) (device uint64, inode uint64, ok bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if ok == false {
+		return 0, 0, false
+	}
+
+	return uint64(stat.Dev), uint64(stat.Ino), true
+}
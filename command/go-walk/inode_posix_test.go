@@ -0,0 +1,138 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly || solaris
+// +build linux darwin freebsd netbsd openbsd dragonfly solaris
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"testing"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+	"github.com/dsoprea/go-utility/testing"
+)
+
+func TestGetInode(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	filepath1 := path.Join(tempPath, "file1")
+	filepath2 := path.Join(tempPath, "file2")
+
+	err = ioutil.WriteFile(filepath1, []byte{}, 0644)
+	log.PanicIf(err)
+
+	err = os.Link(filepath1, filepath2)
+	log.PanicIf(err)
+
+	info1, err := os.Lstat(filepath1)
+	log.PanicIf(err)
+
+	info2, err := os.Lstat(filepath2)
+	log.PanicIf(err)
+
+	device1, inode1, ok := getInode(info1)
+	if ok != true {
+		t.Fatalf("Expected the inode to be available.")
+	}
+
+	device2, inode2, ok := getInode(info2)
+	if ok != true {
+		t.Fatalf("Expected the inode to be available for the link.")
+	}
+
+	if device1 != device2 || inode1 != inode2 {
+		t.Fatalf("Hardlinks not identified: (%d, %d) != (%d, %d)", device1, inode1, device2, inode2)
+	}
+}
+
+func TestMain__inode(t *testing.T) {
+	ritesting.RedirectTty()
+
+	defer func() {
+		if errRaw := recover(); errRaw != nil {
+			ritesting.RestoreAndDumpTty()
+
+			err := errRaw.(error)
+			log.PrintError(err)
+
+			log.Panic(err)
+		}
+
+		ritesting.RestoreTty()
+	}()
+
+	originalArgs := os.Args
+	originalArguments := arguments
+
+	defer func() {
+		os.Args = originalArgs
+		arguments = originalArguments
+	}()
+
+	arguments = new(parameters)
+
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	err = ioutil.WriteFile(path.Join(tempPath, "file1"), []byte("abc"), 0644)
+	log.PanicIf(err)
+
+	err = os.Link(path.Join(tempPath, "file1"), path.Join(tempPath, "file2"))
+	log.PanicIf(err)
+
+	err = ioutil.WriteFile(path.Join(tempPath, "file3"), []byte("abc"), 0644)
+	log.PanicIf(err)
+
+	os.Args = []string{
+		os.Args[0],
+		tempPath,
+		"--json",
+		"--inode",
+	}
+
+	main()
+
+	os.Stdout.Close()
+
+	raw, err := ioutil.ReadAll(ritesting.StdoutReader())
+	log.PanicIf(err)
+
+	collected := make([]map[string]interface{}, 0)
+
+	err = json.Unmarshal(raw, &collected)
+	log.PanicIf(err)
+
+	inodes := make(map[string]float64)
+	for _, flat := range collected {
+		if _, found := flat["device"]; found == false {
+			t.Fatalf("Device not included: %v", flat)
+		}
+
+		inode, found := flat["inode"]
+		if found == false {
+			t.Fatalf("Inode not included: %v", flat)
+		}
+
+		inodes[flat["path"].(string)] = inode.(float64)
+	}
+
+	if len(inodes) != 3 {
+		t.Fatalf("Entries not correct: %v", inodes)
+	} else if inodes["file1"] != inodes["file2"] {
+		t.Fatalf("Hardlinks don't have the same inode: %v", inodes)
+	} else if inodes["file1"] == inodes["file3"] {
+		t.Fatalf("Distinct files have the same inode: %v", inodes)
+	}
+}
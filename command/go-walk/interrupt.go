@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"syscall"
+
+	"os/signal"
+)
+
+const (
+	// interruptedExitCode is the conventional exit code for a command that
+	// was ended by SIGINT (128 plus the signal number).
+	interruptedExitCode = 130
+)
+
+var (
+	// interruptSignals are the signals that end the walk early.
+	interruptSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+)
+
+// watchInterrupts calls `cancel` if we receive SIGINT or SIGTERM, until the
+// returned function is called. That function returns whether we were
+// interrupted. Once it has been called, the signals are handled by default
+// again.
+func watchInterrupts(cancel func()) (stop func() bool) {
+	signalC := make(chan os.Signal, 1)
+	signal.Notify(signalC, interruptSignals...)
+
+	stopC := make(chan struct{})
+	doneC := make(chan struct{})
+
+	isInterrupted := false
+
+	go func() {
+		defer close(doneC)
+
+		select {
+		case <-signalC:
+			isInterrupted = true
+			cancel()
+		case <-stopC:
+		}
+	}()
+
+	return func() bool {
+		signal.Stop(signalC)
+
+		close(stopC)
+		<-doneC
+
+		return isInterrupted
+	}
+}
@@ -0,0 +1,51 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly || solaris
+// +build linux darwin freebsd netbsd openbsd dragonfly solaris
+
+package main
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/dsoprea/go-logging"
+)
+
+func TestWatchInterrupts(t *testing.T) {
+	cancelledC := make(chan struct{})
+
+	cancel := func() {
+		close(cancelledC)
+	}
+
+	stop := watchInterrupts(cancel)
+
+	err := syscall.Kill(syscall.Getpid(), syscall.SIGINT)
+	log.PanicIf(err)
+
+	select {
+	case <-cancelledC:
+	case <-time.After(time.Second * 5):
+		t.Fatalf("Expected the walk to be cancelled.")
+	}
+
+	if stop() != true {
+		t.Fatalf("Expected to be interrupted.")
+	}
+}
+
+func TestWatchInterrupts__notInterrupted(t *testing.T) {
+	isCancelled := false
+
+	cancel := func() {
+		isCancelled = true
+	}
+
+	stop := watchInterrupts(cancel)
+
+	if stop() != false {
+		t.Fatalf("Expected to not be interrupted.")
+	} else if isCancelled != false {
+		t.Fatalf("Expected the walk to not be cancelled.")
+	}
+}
@@ -1,14 +1,27 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"fmt"
+	"hash"
+	"io"
 	"os"
 	"path"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"path/filepath"
 
 	"github.com/dsoprea/go-logging"
 	"github.com/dsoprea/go-utility/data"
@@ -19,7 +32,7 @@ import (
 
 type parameters struct {
 	Positional struct {
-		RootPath string `positional-arg-name:"root_path" description:"Path to walk. This path will be included in the results."`
+		RootPath string `positional-arg-name:"root_path" description:"Path to walk. This path will be included in the results. This may be a glob (e.g. '/data/2023-*/logs'), in which case every directory that it matches is walked at the same time and the paths are printed relative to the leading part of the glob that doesn't have any wildcards. Escape literal '*', '?', '[', and '\\' characters with a backslash."`
 	} `positional-args:"yes" required:"yes"`
 
 	ConcurrencyLevel int `short:"j" long:"concurrency" description:"Non-default maximum number of workers"`
@@ -31,16 +44,33 @@ type parameters struct {
 	IncludeFilenames  []string `short:"i" long:"include-filename" description:"Zero or more filename-patterns to include"`
 	ExcludeFilenames  []string `short:"e" long:"exclude-filename" description:"Zero or more filename-patterns to exclude"`
 	IsCaseInsensitive bool     `short:"c" long:"case-insensitive" description:"Use case-insensitive matching"`
+	Extensions        []string `long:"ext" description:"Zero or more comma-separated extensions to include, with or without the leading dot (e.g. 'go,md'). These are added to the --include-filename patterns, so a file that matches either is included. As with those, the --exclude-filename patterns are not applied when there are any includes."`
 
 	DoJustPrintFiles       bool `short:"f" long:"just-files" description:"Just print files"`
 	DoJustPrintDirectories bool `short:"d" long:"just-directories" description:"Just print directories"`
 	DoPrintAsJson          bool `short:"J" long:"json" description:"Print as JSON"`
 	DoPrintTypes           bool `short:"t" long:"type" description:"Prefix lines with entry types. Ignored if printing JSON."`
+	DoIncludeInode         bool `long:"inode" description:"Include the 'device' and 'inode' of every entry in the JSON output (e.g. to detect hardlinks). These are only available on POSIX platforms and are omitted elsewhere. Ignored if not printing JSON."`
 
-	DoPrintStats     bool `short:"s" long:"stats" description:"Print statistics. Ignored if printing JSON."`
-	DoPrintVerbosity bool `short:"v" long:"verbose" description:"Print logging verbosity"`
+	SortBy string `long:"sort" choice:"path" choice:"size" choice:"mtime" description:"Sort the output by path, by size (smallest first), or by modification time (oldest first). Ties are sorted by path. The plain output is held back until the walk finishes rather than being printed as entries are found."`
 
-	DoIncludeMimeType bool `short:"m" long:"mime-type" description:"Include MIME-types in the output. Prints hyphen for directories or for files that could not be processed."`
+	PathSeparator string `long:"path-separator" description:"Replace the '/' separators in printed paths with this string. Ignored if printing JSON."`
+
+	RelativeTo        string `long:"relative-to" description:"Print paths relative to this directory (e.g. '.' for the current directory) rather than to the root. This also applies to the JSON output."`
+	RelativeToOutside string `long:"relative-to-outside" choice:"absolute" choice:"error" default:"absolute" description:"What to do if the root isn't under the --relative-to directory: print absolute paths or fail"`
+
+	OutputBufferSize int `long:"output-buffer-size" description:"Non-default size of the output buffer in bytes. The output is also flushed periodically."`
+
+	DoPrintStats          bool `short:"s" long:"stats" description:"Print statistics. Ignored if printing JSON."`
+	DoPrintDirectorySizes bool `long:"dir-sizes" description:"Print the immediate and recursive entry counts of each directory, largest first"`
+	TopDirectoryCount     int  `long:"top" description:"Just print this many directories with --dir-sizes"`
+	DoPrintVerbosity      bool `short:"v" long:"verbose" description:"Print logging verbosity"`
+	DoPrintProgress       bool `long:"progress" description:"Print a running count of the visited files and directories to STDERR every second. This is only printed if STDERR is a terminal and is cleared when the walk finishes. This also applies with --json."`
+
+	DoIncludeMimeType bool `short:"m" long:"mime-type" description:"Include MIME-types in the output. Prints hyphen for anything that is not a regular file or that could not be processed."`
+
+	HashAlgorithm string `long:"hash" choice:"md5" choice:"sha1" choice:"sha256" choice:"sha512" description:"Print '<digest>  <path>' for every file (like sha256sum) instead of the other prefixes. Only regular files are printed. Files that could not be read are printed with 'ERROR' in place of the digest. With --json, the digest is included as 'hash'."`
+	HashEncoding  string `long:"hash-encoding" choice:"hex" choice:"base64" default:"hex" description:"Encoding of the digests printed with --hash"`
 }
 
 var (
@@ -50,9 +80,300 @@ var (
 var (
 	rootPath    string
 	rootPathLen int
+
+	// outputPathPrefix is prepended to the root-relative paths that are
+	// printed (see --relative-to), or empty.
+	outputPathPrefix string
+)
+
+const (
+	// defaultOutputBufferSize is the size of the buffer that the plain output
+	// is written through.
+	defaultOutputBufferSize = 64 * 1024
+
+	// outputFlushInterval is how often the output is flushed so that it's not
+	// held back for too long during a long walk.
+	outputFlushInterval = time.Second
+
+	// progressInterval is how often the progress is printed with --progress.
+	progressInterval = time.Second
+
+	// hashErrorMarker is printed in place of the digest of a file that
+	// couldn't be hashed.
+	hashErrorMarker = "ERROR"
+
+	// usageErrorExitCode is the exit code if the arguments aren't valid.
+	usageErrorExitCode = 2
 )
 
-func visitorFunction(outputLocker *sync.Mutex, rootPath string, parentNodePath string, info os.FileInfo, collected *[]map[string]interface{}) (err error) {
+var (
+	// filterFieldOptions are the options that populate each filter field.
+	filterFieldOptions = map[string]string{
+		"IncludePaths":     "-I/--include-path",
+		"ExcludePaths":     "-E/--exclude-path",
+		"IncludeFilenames": "-i/--include-filename (or --ext)",
+		"ExcludeFilenames": "-e/--exclude-filename",
+	}
+)
+
+var (
+	// hashConstructors are the supported hash algorithms.
+	hashConstructors = map[string]func() hash.Hash{
+		"md5":    md5.New,
+		"sha1":   sha1.New,
+		"sha256": sha256.New,
+		"sha512": sha512.New,
+	}
+)
+
+// hashFile returns the encoded digest of the given file.
+func hashFile(filepath string, algorithm string, encoding string) (digest string, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	hashConstructor, found := hashConstructors[algorithm]
+	if found == false {
+		log.Panicf("hash algorithm not valid: [%s]", algorithm)
+	}
+
+	f, err := os.Open(filepath)
+	log.PanicIf(err)
+
+	defer f.Close()
+
+	h := hashConstructor()
+
+	_, err = io.Copy(h, f)
+	log.PanicIf(err)
+
+	sum := h.Sum(nil)
+
+	switch encoding {
+	case "", "hex":
+		return hex.EncodeToString(sum), nil
+	case "base64":
+		return base64.StdEncoding.EncodeToString(sum), nil
+	}
+
+	log.Panicf("hash encoding not valid: [%s]", encoding)
+	return "", nil
+}
+
+// startOutputFlusher flushes the given writer periodically until the returned
+// function is called.
+func startOutputFlusher(outputLocker *sync.Mutex, bw *bufio.Writer, interval time.Duration) (stop func()) {
+	stopC := make(chan struct{})
+	doneC := make(chan struct{})
+
+	go func() {
+		defer close(doneC)
+
+		tick := time.NewTicker(interval)
+		defer tick.Stop()
+
+		for {
+			select {
+			case <-tick.C:
+				outputLocker.Lock()
+				bw.Flush()
+				outputLocker.Unlock()
+			case <-stopC:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(stopC)
+		<-doneC
+	}
+}
+
+// isTerminal returns whether the given file is a terminal.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// progressLine prints the progress of the walk over itself on one line.
+type progressLine struct {
+	w io.Writer
+
+	// lastLen is the width of what's currently on the line.
+	lastLen int
+}
+
+// Update replaces the line with the given progress.
+func (pl *progressLine) Update(stats pathwalk.Stats) {
+	line := fmt.Sprintf("Visited: %d files, %d dirs", stats.FilesVisited, stats.DirectoriesVisited)
+
+	// Overwrite whatever is left of a longer line.
+	padding := ""
+	if len(line) < pl.lastLen {
+		padding = strings.Repeat(" ", pl.lastLen-len(line))
+	}
+
+	fmt.Fprintf(pl.w, "\r%s%s", line, padding)
+
+	pl.lastLen = len(line) + len(padding)
+}
+
+// Clear blanks the line, if anything was printed.
+func (pl *progressLine) Clear() {
+	if pl.lastLen == 0 {
+		return
+	}
+
+	fmt.Fprintf(pl.w, "\r%s\r", strings.Repeat(" ", pl.lastLen))
+
+	pl.lastLen = 0
+}
+
+// sortedLine is one entry of the plain output, held back so that the output
+// can be sorted.
+type sortedLine struct {
+	relName string
+	size    int64
+	modTime time.Time
+	line    string
+}
+
+// isSortedBefore returns whether the first entry sorts before the second for
+// the given `--sort` choice.
+func isSortedBefore(sortBy string, relName1 string, size1 int64, modTime1 time.Time, relName2 string, size2 int64, modTime2 time.Time) bool {
+	switch sortBy {
+	case "size":
+		if size1 != size2 {
+			return size1 < size2
+		}
+	case "mtime":
+		if modTime1.Equal(modTime2) == false {
+			return modTime1.Before(modTime2)
+		}
+	}
+
+	return relName1 < relName2
+}
+
+// sortLines sorts the held-back plain output.
+func sortLines(lines []sortedLine, sortBy string) {
+	sort.Slice(lines, func(i, j int) bool {
+		return isSortedBefore(
+			sortBy,
+			lines[i].relName, lines[i].size, lines[i].modTime,
+			lines[j].relName, lines[j].size, lines[j].modTime)
+	})
+}
+
+// sortCollected sorts the collected JSON output.
+func sortCollected(collected []map[string]interface{}, sortBy string) {
+	keys := func(flat map[string]interface{}) (relName string, size int64, modTime time.Time) {
+		relName = flat["path"].(string)
+		size = flat["size"].(int64)
+
+		// This only has a resolution of seconds, but ties are sorted by path.
+		modTime, _ = time.Parse(time.RFC3339, flat["modified_time"].(string))
+
+		return relName, size, modTime
+	}
+
+	sort.Slice(collected, func(i, j int) bool {
+		relName1, size1, modTime1 := keys(collected[i])
+		relName2, size2, modTime2 := keys(collected[j])
+
+		return isSortedBefore(sortBy, relName1, size1, modTime1, relName2, size2, modTime2)
+	})
+}
+
+// filterErrorMessage returns a message for the user that names the pattern
+// that made the filter invalid and the option that it was given with.
+func filterErrorMessage(err error) string {
+	fpe, ok := err.(*pathwalk.FilterPatternError)
+	if ok == false {
+		return fmt.Sprintf("The filters are not valid: %s", err.Error())
+	}
+
+	option, found := filterFieldOptions[fpe.Field]
+	if found == false {
+		option = fpe.Field
+	}
+
+	return fmt.Sprintf("The pattern [%s] given with %s is not valid: %s", fpe.Pattern, option, fpe.Err.Error())
+}
+
+// relativePathPrefix returns what the root-relative paths need to be prefixed
+// with in order to be relative to the given base directory instead. If the
+// root isn't under the base, `isUnder` is false and the prefix is the
+// absolute root.
+func relativePathPrefix(rootPath string, basePath string) (prefix string, isUnder bool, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	absRootPath, err := filepath.Abs(rootPath)
+	log.PanicIf(err)
+
+	absBasePath, err := filepath.Abs(basePath)
+	log.PanicIf(err)
+
+	relRootPath, err := filepath.Rel(absBasePath, absRootPath)
+	log.PanicIf(err)
+
+	relRootPath = filepath.ToSlash(relRootPath)
+
+	if relRootPath == ".." || strings.HasPrefix(relRootPath, "../") == true {
+		return filepath.ToSlash(absRootPath), false, nil
+	} else if relRootPath == "." {
+		return "", true, nil
+	}
+
+	return relRootPath, true, nil
+}
+
+// displayPath returns the given root-relative path as it should be printed.
+func displayPath(relName string) string {
+	if outputPathPrefix == "" {
+		return relName
+	}
+
+	return path.Join(outputPathPrefix, relName)
+}
+
+// extensionPatterns converts the given extensions (which may be comma-
+// separated and may or may not have leading dots) to filename patterns.
+func extensionPatterns(extensions []string, isCaseInsensitive bool) []string {
+	patterns := make([]string, 0)
+
+	for _, phrase := range extensions {
+		for _, extension := range strings.Split(phrase, ",") {
+			extension = strings.TrimLeft(strings.TrimSpace(extension), ".")
+			if extension == "" {
+				continue
+			}
+
+			// The filenames are lowercased before they're matched when case-
+			// insensitive, so the patterns have to be too.
+			if isCaseInsensitive == true {
+				extension = strings.ToLower(extension)
+			}
+
+			patterns = append(patterns, "*."+extension)
+		}
+	}
+
+	return patterns
+}
+
+func visitorFunction(outputLocker *sync.Mutex, w io.Writer, rootPath string, parentNodePath string, info os.FileInfo, collected *[]map[string]interface{}) (err error) {
 	if arguments.DoJustPrintDirectories == true && info.IsDir() == false ||
 		arguments.DoJustPrintFiles == true && info.IsDir() == true {
 		return nil
@@ -64,10 +385,18 @@ func visitorFunction(outputLocker *sync.Mutex, rootPath string, parentNodePath s
 		return nil
 	}
 
-	relName := fqName[rootPathLen:]
+	relName := displayPath(fqName[rootPathLen:])
+
+	// Only regular files have content. Anything else (e.g. a FIFO) could block
+	// forever when opened.
+	isRegular := info.Mode().IsRegular()
+
+	if arguments.HashAlgorithm != "" && isRegular == false && arguments.DoPrintAsJson == false {
+		return nil
+	}
 
 	var mimeType string
-	if info.IsDir() == false && arguments.DoIncludeMimeType == true {
+	if isRegular == true && arguments.DoIncludeMimeType == true {
 		f, err := os.Open(fqName)
 		if err == nil {
 			mimeType, _ = ridata.DetectMimetype(f)
@@ -75,6 +404,19 @@ func visitorFunction(outputLocker *sync.Mutex, rootPath string, parentNodePath s
 		}
 	}
 
+	// Hash outside of the lock so that the files are hashed in parallel.
+
+	var digest string
+	if isRegular == true && arguments.HashAlgorithm != "" {
+		var err error
+
+		digest, err = hashFile(fqName, arguments.HashAlgorithm, arguments.HashEncoding)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Could not hash [%s]: %s\n", fqName, err.Error())
+			digest = hashErrorMarker
+		}
+	}
+
 	outputLocker.Lock()
 	defer outputLocker.Unlock()
 
@@ -91,12 +433,34 @@ func visitorFunction(outputLocker *sync.Mutex, rootPath string, parentNodePath s
 			flat["mime_type"] = mimeType
 		}
 
+		if digest != "" {
+			flat["hash"] = digest
+		}
+
+		if arguments.DoIncludeInode == true {
+			if device, inode, ok := getInode(info); ok == true {
+				flat["device"] = device
+				flat["inode"] = inode
+			}
+		}
+
 		collectedUpdated := append(*collected, flat)
 		*collected = collectedUpdated
 
 		return nil
 	}
 
+	if arguments.PathSeparator != "" {
+		relName = strings.Replace(relName, "/", arguments.PathSeparator, -1)
+	}
+
+	if arguments.HashAlgorithm != "" {
+		// Write the whole line at once.
+		fmt.Fprintf(w, "%s  %s\n", digest, relName)
+
+		return nil
+	}
+
 	if arguments.DoPrintTypes == true {
 		var typeInitial string
 		if info.IsDir() == true {
@@ -105,18 +469,18 @@ func visitorFunction(outputLocker *sync.Mutex, rootPath string, parentNodePath s
 			typeInitial = "f"
 		}
 
-		fmt.Printf("%s ", typeInitial)
+		fmt.Fprintf(w, "%s ", typeInitial)
 	}
 
 	if arguments.DoIncludeMimeType == true {
 		if mimeType != "" {
-			fmt.Printf("%s ", mimeType)
+			fmt.Fprintf(w, "%s ", mimeType)
 		} else {
-			fmt.Printf("- ")
+			fmt.Fprintf(w, "- ")
 		}
 	}
 
-	fmt.Printf("%s\n", relName)
+	fmt.Fprintf(w, "%s\n", relName)
 
 	return nil
 }
@@ -147,19 +511,117 @@ func main() {
 		log.LoadConfiguration(scp)
 	}
 
-	rootPath = strings.TrimRight(arguments.Positional.RootPath, "/")
+	rootArgument := arguments.Positional.RootPath
+
+	rootPath = strings.TrimRight(rootArgument, "/")
+	roots := []string{rootPath}
+	basePath := rootPath
+
+	if isRootPattern(rootArgument) == true {
+		roots, basePath, err = expandRootPattern(rootArgument)
+		if err == filepath.ErrBadPattern {
+			fmt.Fprintf(os.Stderr, "The root pattern [%s] is not valid.\n", rootArgument)
+			os.Exit(usageErrorExitCode)
+		}
+
+		log.PanicIf(err)
+
+		if len(roots) == 0 {
+			fmt.Fprintf(os.Stderr, "The root pattern [%s] doesn't match any directories.\n", rootArgument)
+			os.Exit(1)
+		}
+
+		rootPath = strings.TrimRight(basePath, "/")
+	}
+
 	rootPathLen = len(rootPath) + 1
 
+	// The paths under a relative glob (e.g. "2023-*") are printed as walked.
+	if isRootPattern(rootArgument) == true && basePath == "." {
+		rootPath = ""
+		rootPathLen = 0
+	}
+
+	outputPathPrefix = ""
+	if arguments.RelativeTo != "" {
+		prefix, isUnder, err := relativePathPrefix(basePath, arguments.RelativeTo)
+		log.PanicIf(err)
+
+		if isUnder == false && arguments.RelativeToOutside == "error" {
+			fmt.Fprintf(os.Stderr, "The root [%s] is not under the --relative-to directory [%s].\n", basePath, arguments.RelativeTo)
+			os.Exit(usageErrorExitCode)
+		}
+
+		outputPathPrefix = prefix
+	}
+
+	outputBufferSize := defaultOutputBufferSize
+	if arguments.OutputBufferSize > 0 {
+		outputBufferSize = arguments.OutputBufferSize
+	}
+
 	collected := make([]map[string]interface{}, 0)
 	outputLocker := sync.Mutex{}
+	bw := bufio.NewWriterSize(os.Stdout, outputBufferSize)
+
+	// Make sure that whatever was printed makes it out, even if we fail.
+	defer func() {
+		outputLocker.Lock()
+		bw.Flush()
+		outputLocker.Unlock()
+	}()
+
+	// The plain output is held back if it's to be sorted.
+	isSortingLines := arguments.SortBy != "" && arguments.DoPrintAsJson == false
+	lines := make([]sortedLine, 0)
+
 	visitorFunctionWrapper := func(parentNodePath string, info os.FileInfo) (err error) {
-		err = visitorFunction(&outputLocker, rootPath, parentNodePath, info, &collected)
+		if isSortingLines == false {
+			err = visitorFunction(&outputLocker, bw, rootPath, parentNodePath, info, &collected)
+			log.PanicIf(err)
+
+			return nil
+		}
+
+		b := new(bytes.Buffer)
+
+		err = visitorFunction(&outputLocker, b, rootPath, parentNodePath, info, &collected)
 		log.PanicIf(err)
 
+		if b.Len() == 0 {
+			return nil
+		}
+
+		sl := sortedLine{
+			relName: path.Join(parentNodePath, info.Name())[rootPathLen:],
+			size:    info.Size(),
+			modTime: info.ModTime(),
+			line:    b.String(),
+		}
+
+		outputLocker.Lock()
+		lines = append(lines, sl)
+		outputLocker.Unlock()
+
 		return nil
 	}
 
-	walk := pathwalk.NewWalk(rootPath, visitorFunctionWrapper)
+	walk := pathwalk.NewWalk(roots[0], visitorFunctionWrapper)
+
+	directorySummaries := make([]pathwalk.DirectorySummary, 0)
+	if arguments.DoPrintDirectorySizes == true {
+		summariesLocker := sync.Mutex{}
+		directoryLeaveFunc := func(summary pathwalk.DirectorySummary) (err error) {
+			summariesLocker.Lock()
+			defer summariesLocker.Unlock()
+
+			directorySummaries = append(directorySummaries, summary)
+
+			return nil
+		}
+
+		walk.SetDirectoryLeaveFunc(directoryLeaveFunc)
+	}
 
 	if arguments.ConcurrencyLevel != 0 {
 		walk.SetConcurrency(arguments.ConcurrencyLevel)
@@ -173,21 +635,91 @@ func main() {
 		walk.SetBatchSize(arguments.BatchSize)
 	}
 
+	includeFilenames := make([]string, 0)
+	includeFilenames = append(includeFilenames, arguments.IncludeFilenames...)
+	includeFilenames = append(includeFilenames, extensionPatterns(arguments.Extensions, arguments.IsCaseInsensitive)...)
+
 	filter := pathwalk.Filter{
 		IncludePaths:     arguments.IncludePaths,
 		ExcludePaths:     arguments.ExcludePaths,
-		IncludeFilenames: arguments.IncludeFilenames,
+		IncludeFilenames: includeFilenames,
 		ExcludeFilenames: arguments.ExcludeFilenames,
 
 		IsCaseInsensitive: arguments.IsCaseInsensitive,
 	}
 
-	walk.SetFilter(filter)
+	// A bad pattern is a usage error rather than a failure.
+	err = pathwalk.ValidateFilter(filter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", filterErrorMessage(err))
+		os.Exit(usageErrorExitCode)
+	}
+
+	err = walk.SetFilter(filter)
+	log.PanicIf(err)
+
+	// The progress goes to STDERR, so it doesn't get mixed in with the
+	// output.
+	var pl *progressLine
+	if arguments.DoPrintProgress == true && isTerminal(os.Stderr) == true {
+		pl = &progressLine{
+			w: os.Stderr,
+		}
+
+		walk.SetProgressFunc(progressInterval, pl.Update)
+	}
+
+	stopFlusher := startOutputFlusher(&outputLocker, bw, outputFlushInterval)
+
+	// On Ctrl-C, the walk is stopped and whatever was found so far is still
+	// printed in full.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stopWatchingInterrupts := watchInterrupts(cancel)
+
+	if len(roots) == 1 {
+		err = walk.RunContext(ctx)
+	} else {
+		err = walk.RunNContext(ctx, roots, visitorFunctionWrapper)
+	}
+
+	isInterrupted := stopWatchingInterrupts()
+	if isInterrupted == true && log.Is(err, context.Canceled) == true {
+		err = nil
+	}
+
+	stopFlusher()
+
+	// The progress callback is no longer called once `Run()` returns.
+	if pl != nil {
+		pl.Clear()
+	}
+
+	outputLocker.Lock()
+	flushErr := bw.Flush()
+	outputLocker.Unlock()
 
-	err = walk.Run()
 	log.PanicIf(err)
+	log.PanicIf(flushErr)
+
+	if isSortingLines == true {
+		sortLines(lines, arguments.SortBy)
+
+		for _, sl := range lines {
+			_, err := bw.WriteString(sl.line)
+			log.PanicIf(err)
+		}
+
+		err := bw.Flush()
+		log.PanicIf(err)
+	}
 
 	if arguments.DoPrintAsJson == true {
+		if arguments.SortBy != "" {
+			sortCollected(collected, arguments.SortBy)
+		}
+
 		je := json.NewEncoder(os.Stdout)
 		je.SetIndent("", "    ")
 
@@ -206,4 +738,45 @@ func main() {
 
 		fmt.Printf("\n")
 	}
+
+	if arguments.DoPrintDirectorySizes == true {
+		printDirectorySizes(directorySummaries)
+	}
+
+	if isInterrupted == true {
+		fmt.Fprintf(os.Stderr, "Interrupted. The output is incomplete.\n")
+		os.Exit(interruptedExitCode)
+	}
+}
+
+// printDirectorySizes prints the directories with the most descendants to
+// STDERR.
+func printDirectorySizes(directorySummaries []pathwalk.DirectorySummary) {
+	sort.Slice(directorySummaries, func(i, j int) bool {
+		if directorySummaries[i].DescendantCount != directorySummaries[j].DescendantCount {
+			return directorySummaries[i].DescendantCount > directorySummaries[j].DescendantCount
+		}
+
+		return directorySummaries[i].Path < directorySummaries[j].Path
+	})
+
+	if arguments.TopDirectoryCount > 0 && arguments.TopDirectoryCount < len(directorySummaries) {
+		directorySummaries = directorySummaries[:arguments.TopDirectoryCount]
+	}
+
+	fmt.Fprintf(os.Stderr, "Directory Sizes (children, descendants)\n")
+	fmt.Fprintf(os.Stderr, "=======================================\n")
+
+	for _, ds := range directorySummaries {
+		relName := "."
+		if ds.Path != rootPath {
+			relName = displayPath(ds.Path[rootPathLen:])
+		} else if outputPathPrefix != "" {
+			relName = outputPathPrefix
+		}
+
+		fmt.Fprintf(os.Stderr, "(%d) (%d) %s\n", ds.ChildCount, ds.DescendantCount, relName)
+	}
+
+	fmt.Fprintf(os.Stderr, "\n")
 }
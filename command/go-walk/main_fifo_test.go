@@ -0,0 +1,97 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+// `syscall.Mkfifo()` isn't available on Solaris.
+
+package main
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+	"github.com/dsoprea/go-utility/testing"
+)
+
+func TestMain__hash__fifo(t *testing.T) {
+	ritesting.RedirectTty()
+
+	defer func() {
+		if errRaw := recover(); errRaw != nil {
+			ritesting.RestoreAndDumpTty()
+
+			err := errRaw.(error)
+			log.PrintError(err)
+
+			log.Panic(err)
+		}
+
+		ritesting.RestoreTty()
+	}()
+
+	originalArgs := os.Args
+	originalArguments := arguments
+
+	defer func() {
+		os.Args = originalArgs
+		arguments = originalArguments
+	}()
+
+	arguments = new(parameters)
+
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	err = ioutil.WriteFile(path.Join(tempPath, "file1"), []byte("abc"), 0644)
+	log.PanicIf(err)
+
+	// Nothing will ever write to this, so opening it for reading would block
+	// forever.
+	err = syscall.Mkfifo(path.Join(tempPath, "pipe"), 0644)
+	log.PanicIf(err)
+
+	os.Args = []string{
+		os.Args[0],
+		tempPath,
+		"--hash", "sha256",
+	}
+
+	doneC := make(chan struct{})
+
+	go func() {
+		main()
+		close(doneC)
+	}()
+
+	select {
+	case <-doneC:
+	case <-time.After(time.Second * 10):
+		log.Panicf("hashing blocked on the FIFO")
+	}
+
+	os.Stdout.Close()
+
+	raw, err := ioutil.ReadAll(ritesting.StdoutReader())
+	log.PanicIf(err)
+
+	actual := strings.Split(strings.TrimSpace(string(raw)), "\n")
+
+	expected := []string{
+		"ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad  file1",
+	}
+
+	if reflect.DeepEqual(actual, expected) != true {
+		t.Fatalf("Output not correct: %v", actual)
+	}
+}
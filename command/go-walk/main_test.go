@@ -1,18 +1,27 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
 	"os"
+	"os/exec"
+	"path"
 	"reflect"
 	"sort"
 	"strings"
+	"sync"
+	"syscall"
 	"testing"
+	"time"
 
+	"encoding/json"
 	"io/ioutil"
 
 	"github.com/dsoprea/go-logging"
 	"github.com/dsoprea/go-utility/testing"
 
+	"github.com/dsoprea/go-parallel-walker"
 	"github.com/dsoprea/go-parallel-walker/internal/testing"
 )
 
@@ -87,3 +96,745 @@ func TestMain(t *testing.T) {
 		t.Fatalf("Filenames not correct/complete.")
 	}
 }
+
+func TestPrintDirectorySizes(t *testing.T) {
+	ritesting.RedirectTty()
+
+	defer func() {
+		if errRaw := recover(); errRaw != nil {
+			ritesting.RestoreAndDumpTty()
+
+			err := errRaw.(error)
+			log.PrintError(err)
+
+			log.Panic(err)
+		}
+
+		ritesting.RestoreTty()
+	}()
+
+	originalRootPath := rootPath
+	originalRootPathLen := rootPathLen
+	originalTopDirectoryCount := arguments.TopDirectoryCount
+
+	defer func() {
+		rootPath = originalRootPath
+		rootPathLen = originalRootPathLen
+		arguments.TopDirectoryCount = originalTopDirectoryCount
+	}()
+
+	rootPath = "/root"
+	rootPathLen = len(rootPath) + 1
+	arguments.TopDirectoryCount = 2
+
+	directorySummaries := []pathwalk.DirectorySummary{
+		{Path: "/root/small", ChildCount: 1, DescendantCount: 1},
+		{Path: "/root", ChildCount: 3, DescendantCount: 10},
+		{Path: "/root/large", ChildCount: 2, DescendantCount: 7},
+	}
+
+	printDirectorySizes(directorySummaries)
+
+	os.Stderr.Close()
+
+	raw, err := ioutil.ReadAll(ritesting.StderrReader())
+	log.PanicIf(err)
+
+	expected := `Directory Sizes (children, descendants)
+=======================================
+(3) (10) .
+(2) (7) large
+
+`
+
+	if string(raw) != expected {
+		t.Fatalf("Output not correct:\n%s", string(raw))
+	}
+}
+
+func TestMain__pathSeparator(t *testing.T) {
+	ritesting.RedirectTty()
+
+	defer func() {
+		if errRaw := recover(); errRaw != nil {
+			ritesting.RestoreAndDumpTty()
+
+			err := errRaw.(error)
+			log.PrintError(err)
+
+			log.Panic(err)
+		}
+
+		ritesting.RestoreTty()
+	}()
+
+	originalArgs := os.Args
+	originalArguments := arguments
+
+	defer func() {
+		os.Args = originalArgs
+		arguments = originalArguments
+	}()
+
+	arguments = new(parameters)
+
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	err = os.MkdirAll(path.Join(tempPath, "dir1", "dir2"), 0755)
+	log.PanicIf(err)
+
+	err = ioutil.WriteFile(path.Join(tempPath, "dir1", "dir2", "file"), []byte{}, 0644)
+	log.PanicIf(err)
+
+	os.Args = []string{
+		os.Args[0],
+		tempPath,
+		"--path-separator", "\\",
+	}
+
+	main()
+
+	os.Stdout.Close()
+
+	raw, err := ioutil.ReadAll(ritesting.StdoutReader())
+	log.PanicIf(err)
+
+	actual := strings.Split(strings.TrimSpace(string(raw)), "\n")
+	sort.Strings(actual)
+
+	expected := []string{
+		"dir1",
+		"dir1\\dir2",
+		"dir1\\dir2\\file",
+	}
+
+	if reflect.DeepEqual(actual, expected) != true {
+		t.Fatalf("Output not correct: %v", actual)
+	}
+}
+
+func TestRelativePathPrefix(t *testing.T) {
+	prefix, isUnder, err := relativePathPrefix("/a/b/c", "/a")
+	log.PanicIf(err)
+
+	if prefix != "b/c" || isUnder != true {
+		t.Fatalf("Prefix under the base not correct: [%s] [%v]", prefix, isUnder)
+	}
+
+	prefix, isUnder, err = relativePathPrefix("/a/b", "/a/b")
+	log.PanicIf(err)
+
+	if prefix != "" || isUnder != true {
+		t.Fatalf("Prefix at the base not correct: [%s] [%v]", prefix, isUnder)
+	}
+
+	prefix, isUnder, err = relativePathPrefix("/a/b", "/a/bb")
+	log.PanicIf(err)
+
+	if prefix != "/a/b" || isUnder != false {
+		t.Fatalf("Prefix outside of the base not correct: [%s] [%v]", prefix, isUnder)
+	}
+}
+
+func TestMain__relativeTo(t *testing.T) {
+	ritesting.RedirectTty()
+
+	defer func() {
+		if errRaw := recover(); errRaw != nil {
+			ritesting.RestoreAndDumpTty()
+
+			err := errRaw.(error)
+			log.PrintError(err)
+
+			log.Panic(err)
+		}
+
+		ritesting.RestoreTty()
+	}()
+
+	originalArgs := os.Args
+	originalArguments := arguments
+
+	defer func() {
+		os.Args = originalArgs
+		arguments = originalArguments
+	}()
+
+	arguments = new(parameters)
+
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	rootPath := path.Join(tempPath, "root")
+
+	err = os.MkdirAll(path.Join(rootPath, "dir1"), 0755)
+	log.PanicIf(err)
+
+	err = ioutil.WriteFile(path.Join(rootPath, "dir1", "file"), []byte{}, 0644)
+	log.PanicIf(err)
+
+	os.Args = []string{
+		os.Args[0],
+		rootPath,
+		"--relative-to", tempPath,
+		"--json",
+	}
+
+	main()
+
+	os.Stdout.Close()
+
+	raw, err := ioutil.ReadAll(ritesting.StdoutReader())
+	log.PanicIf(err)
+
+	entries := make([]map[string]interface{}, 0)
+
+	err = json.Unmarshal(raw, &entries)
+	log.PanicIf(err)
+
+	actual := make([]string, 0)
+	for _, entry := range entries {
+		actual = append(actual, entry["path"].(string))
+	}
+
+	sort.Strings(actual)
+
+	expected := []string{
+		"root/dir1",
+		"root/dir1/file",
+	}
+
+	if reflect.DeepEqual(actual, expected) != true {
+		t.Fatalf("Output not correct: %v", actual)
+	}
+}
+
+func TestMain__relativeToOutside(t *testing.T) {
+	// The walk exits the process, so it's run in a child process.
+	if os.Getenv("GO_WALK_TEST_RELATIVE_TO_OUTSIDE") == "1" {
+		tempPath := os.TempDir()
+
+		os.Args = []string{
+			os.Args[0],
+			tempPath,
+			"--relative-to", path.Join(tempPath, "other"),
+			"--relative-to-outside", "error",
+		}
+
+		main()
+
+		return
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run", "^TestMain__relativeToOutside$")
+	cmd.Env = append(os.Environ(), "GO_WALK_TEST_RELATIVE_TO_OUTSIDE=1")
+
+	stderr := new(bytes.Buffer)
+	cmd.Stderr = stderr
+
+	err := cmd.Run()
+
+	exitErr, ok := err.(*exec.ExitError)
+	if ok == false {
+		t.Fatalf("Expected the process to fail: [%v]", err)
+	}
+
+	exitCode := exitErr.Sys().(syscall.WaitStatus).ExitStatus()
+	if exitCode != usageErrorExitCode {
+		t.Fatalf("Exit code not correct: (%d)", exitCode)
+	}
+
+	if strings.Contains(stderr.String(), "is not under the --relative-to directory") != true {
+		t.Fatalf("Message not correct: [%s]", stderr.String())
+	}
+}
+
+func TestExtensionPatterns(t *testing.T) {
+	patterns := extensionPatterns([]string{"go,.md", " txt ,,", "TAR.GZ"}, false)
+
+	expected := []string{
+		"*.go",
+		"*.md",
+		"*.txt",
+		"*.TAR.GZ",
+	}
+
+	if reflect.DeepEqual(patterns, expected) != true {
+		t.Fatalf("Patterns not correct: %v", patterns)
+	}
+
+	patterns = extensionPatterns([]string{"TAR.GZ"}, true)
+
+	if reflect.DeepEqual(patterns, []string{"*.tar.gz"}) != true {
+		t.Fatalf("Case-insensitive patterns not correct: %v", patterns)
+	}
+}
+
+func TestFilterErrorMessage(t *testing.T) {
+	filter := pathwalk.Filter{
+		ExcludePaths: []string{"ok/**", "bad/[a"},
+	}
+
+	err := pathwalk.ValidateFilter(filter)
+	if err == nil {
+		t.Fatalf("Expected the filter to be invalid.")
+	}
+
+	message := filterErrorMessage(err)
+
+	if strings.HasPrefix(message, "The pattern [bad/[a] given with -E/--exclude-path is not valid: ") != true {
+		t.Fatalf("Message not correct: [%s]", message)
+	}
+
+	message = filterErrorMessage(fmt.Errorf("some error"))
+	if message != "The filters are not valid: some error" {
+		t.Fatalf("Message for another error not correct: [%s]", message)
+	}
+}
+
+func TestMain__invalidFilter(t *testing.T) {
+	// The walk exits the process, so it's run in a child process.
+	if os.Getenv("GO_WALK_TEST_INVALID_FILTER") == "1" {
+		os.Args = []string{
+			os.Args[0],
+			"-I",
+			"[a",
+			os.TempDir(),
+		}
+
+		main()
+
+		return
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run", "^TestMain__invalidFilter$")
+	cmd.Env = append(os.Environ(), "GO_WALK_TEST_INVALID_FILTER=1")
+
+	stderr := new(bytes.Buffer)
+	cmd.Stderr = stderr
+
+	err := cmd.Run()
+
+	exitErr, ok := err.(*exec.ExitError)
+	if ok == false {
+		t.Fatalf("Expected the process to fail: [%v]", err)
+	}
+
+	exitCode := exitErr.Sys().(syscall.WaitStatus).ExitStatus()
+	if exitCode != usageErrorExitCode {
+		t.Fatalf("Exit code not correct: (%d)", exitCode)
+	}
+
+	if strings.HasPrefix(stderr.String(), "The pattern [[a] given with -I/--include-path is not valid: ") != true {
+		t.Fatalf("Message not correct: [%s]", stderr.String())
+	}
+}
+
+func TestMain__ext(t *testing.T) {
+	ritesting.RedirectTty()
+
+	defer func() {
+		if errRaw := recover(); errRaw != nil {
+			ritesting.RestoreAndDumpTty()
+
+			err := errRaw.(error)
+			log.PrintError(err)
+
+			log.Panic(err)
+		}
+
+		ritesting.RestoreTty()
+	}()
+
+	originalArgs := os.Args
+	originalArguments := arguments
+
+	defer func() {
+		os.Args = originalArgs
+		arguments = originalArguments
+	}()
+
+	arguments = new(parameters)
+
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	filenames := []string{"a.go", "b.MD", "c.txt", "d.go.bak", "e.json"}
+	for _, filename := range filenames {
+		err = ioutil.WriteFile(path.Join(tempPath, filename), []byte{}, 0644)
+		log.PanicIf(err)
+	}
+
+	os.Args = []string{
+		os.Args[0],
+		tempPath,
+		"--just-files",
+		"--case-insensitive",
+		"--ext", "go,.md",
+		"--include-filename", "*.json",
+	}
+
+	main()
+
+	os.Stdout.Close()
+
+	raw, err := ioutil.ReadAll(ritesting.StdoutReader())
+	log.PanicIf(err)
+
+	actual := strings.Split(strings.TrimSpace(string(raw)), "\n")
+	sort.Strings(actual)
+
+	expected := []string{
+		"a.go",
+		"b.MD",
+		"e.json",
+	}
+
+	if reflect.DeepEqual(actual, expected) != true {
+		t.Fatalf("Output not correct: %v", actual)
+	}
+}
+
+func TestStartOutputFlusher(t *testing.T) {
+	b := new(bytes.Buffer)
+	bw := bufio.NewWriter(b)
+
+	outputLocker := sync.Mutex{}
+
+	stopFlusher := startOutputFlusher(&outputLocker, bw, time.Millisecond*10)
+
+	outputLocker.Lock()
+	fmt.Fprintf(bw, "line\n")
+	outputLocker.Unlock()
+
+	// Wait for the flush.
+
+	isFlushed := false
+	for i := 0; i < 100; i++ {
+		outputLocker.Lock()
+		isFlushed = b.Len() > 0
+		outputLocker.Unlock()
+
+		if isFlushed == true {
+			break
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	stopFlusher()
+
+	if isFlushed != true {
+		t.Fatalf("Output was not flushed.")
+	} else if b.String() != "line\n" {
+		t.Fatalf("Output not correct: [%s]", b.String())
+	}
+}
+
+func TestHashFile(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	filepath := path.Join(tempPath, "file")
+
+	err = ioutil.WriteFile(filepath, []byte("abc"), 0644)
+	log.PanicIf(err)
+
+	testCases := []struct {
+		algorithm string
+		encoding  string
+		expected  string
+	}{
+		{"md5", "hex", "900150983cd24fb0d6963f7d28e17f72"},
+		{"sha1", "hex", "a9993e364706816aba3e25717850c26c9cd0d89d"},
+		{"sha256", "hex", "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{"sha256", "base64", "ungWv48Bz+pBQUDeXa4iI7ADYaOWF3qctBD/YfIAFa0="},
+		{"sha512", "", "ddaf35a193617abacc417349ae20413112e6fa4e89a97ea20a9eeee64b55d39a2192992a274fc1a836ba3c23a3feebbd454d4423643ce80e2a9ac94fa54ca49f"},
+	}
+
+	for _, testCase := range testCases {
+		digest, err := hashFile(filepath, testCase.algorithm, testCase.encoding)
+		log.PanicIf(err)
+
+		if digest != testCase.expected {
+			t.Fatalf("Digest not correct for (%s) (%s): [%s]", testCase.algorithm, testCase.encoding, digest)
+		}
+	}
+
+	_, err = hashFile(path.Join(tempPath, "missing"), "sha256", "hex")
+	if err == nil {
+		t.Fatalf("Expected error for missing file.")
+	}
+
+	_, err = hashFile(filepath, "crc", "hex")
+	if err == nil {
+		t.Fatalf("Expected error for invalid algorithm.")
+	}
+
+	_, err = hashFile(filepath, "sha256", "base32")
+	if err == nil {
+		t.Fatalf("Expected error for invalid encoding.")
+	}
+}
+
+func TestMain__hash(t *testing.T) {
+	ritesting.RedirectTty()
+
+	defer func() {
+		if errRaw := recover(); errRaw != nil {
+			ritesting.RestoreAndDumpTty()
+
+			err := errRaw.(error)
+			log.PrintError(err)
+
+			log.Panic(err)
+		}
+
+		ritesting.RestoreTty()
+	}()
+
+	originalArgs := os.Args
+	originalArguments := arguments
+
+	defer func() {
+		os.Args = originalArgs
+		arguments = originalArguments
+	}()
+
+	arguments = new(parameters)
+
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	err = os.Mkdir(path.Join(tempPath, "dir1"), 0755)
+	log.PanicIf(err)
+
+	err = ioutil.WriteFile(path.Join(tempPath, "dir1", "file1"), []byte("abc"), 0644)
+	log.PanicIf(err)
+
+	err = ioutil.WriteFile(path.Join(tempPath, "file2"), []byte{}, 0644)
+	log.PanicIf(err)
+
+	os.Args = []string{
+		os.Args[0],
+		tempPath,
+		"--hash", "sha256",
+	}
+
+	main()
+
+	os.Stdout.Close()
+
+	raw, err := ioutil.ReadAll(ritesting.StdoutReader())
+	log.PanicIf(err)
+
+	actual := strings.Split(strings.TrimSpace(string(raw)), "\n")
+	sort.Strings(actual)
+
+	expected := []string{
+		"ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad  dir1/file1",
+		"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855  file2",
+	}
+
+	if reflect.DeepEqual(actual, expected) != true {
+		t.Fatalf("Output not correct: %v", actual)
+	}
+}
+
+func TestProgressLine(t *testing.T) {
+	b := new(bytes.Buffer)
+
+	pl := &progressLine{
+		w: b,
+	}
+
+	// Nothing was printed, so there's nothing to clear.
+	pl.Clear()
+
+	if b.Len() != 0 {
+		t.Fatalf("Clear should not print anything before an update: [%s]", b.String())
+	}
+
+	pl.Update(pathwalk.Stats{FilesVisited: 100, DirectoriesVisited: 20})
+	pl.Update(pathwalk.Stats{FilesVisited: 5, DirectoriesVisited: 2})
+	pl.Clear()
+
+	expected := "\rVisited: 100 files, 20 dirs" +
+		"\rVisited: 5 files, 2 dirs   " +
+		"\r" + strings.Repeat(" ", 27) + "\r"
+
+	if b.String() != expected {
+		t.Fatalf("Output not correct: %q", b.String())
+	}
+}
+
+func TestIsTerminal(t *testing.T) {
+	f, err := ioutil.TempFile("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		f.Close()
+		os.Remove(f.Name())
+	}()
+
+	if isTerminal(f) != false {
+		t.Fatalf("A regular file is not a terminal.")
+	}
+}
+
+func TestSortLines(t *testing.T) {
+	now := time.Now()
+
+	lines := []sortedLine{
+		{relName: "c", size: 1, modTime: now.Add(time.Second)},
+		{relName: "a", size: 3, modTime: now},
+		{relName: "b", size: 1, modTime: now.Add(-time.Second)},
+	}
+
+	testCases := []struct {
+		sortBy   string
+		expected []string
+	}{
+		{"path", []string{"a", "b", "c"}},
+		{"size", []string{"b", "c", "a"}},
+		{"mtime", []string{"b", "a", "c"}},
+	}
+
+	for _, testCase := range testCases {
+		sortLines(lines, testCase.sortBy)
+
+		actual := make([]string, len(lines))
+		for i, sl := range lines {
+			actual[i] = sl.relName
+		}
+
+		if reflect.DeepEqual(actual, testCase.expected) != true {
+			t.Fatalf("Order not correct for (%s): %v", testCase.sortBy, actual)
+		}
+	}
+}
+
+func TestSortCollected(t *testing.T) {
+	collected := []map[string]interface{}{
+		{"path": "b", "size": int64(2), "modified_time": "2020-01-01T00:00:00Z"},
+		{"path": "c", "size": int64(1), "modified_time": "2020-01-02T00:00:00Z"},
+		{"path": "a", "size": int64(2), "modified_time": "2019-12-31T00:00:00Z"},
+	}
+
+	testCases := []struct {
+		sortBy   string
+		expected []string
+	}{
+		{"path", []string{"a", "b", "c"}},
+		{"size", []string{"c", "a", "b"}},
+		{"mtime", []string{"a", "b", "c"}},
+	}
+
+	for _, testCase := range testCases {
+		sortCollected(collected, testCase.sortBy)
+
+		actual := make([]string, len(collected))
+		for i, flat := range collected {
+			actual[i] = flat["path"].(string)
+		}
+
+		if reflect.DeepEqual(actual, testCase.expected) != true {
+			t.Fatalf("Order not correct for (%s): %v", testCase.sortBy, actual)
+		}
+	}
+}
+
+func TestMain__sort(t *testing.T) {
+	ritesting.RedirectTty()
+
+	defer func() {
+		if errRaw := recover(); errRaw != nil {
+			ritesting.RestoreAndDumpTty()
+
+			err := errRaw.(error)
+			log.PrintError(err)
+
+			log.Panic(err)
+		}
+
+		ritesting.RestoreTty()
+	}()
+
+	originalArgs := os.Args
+	originalArguments := arguments
+
+	defer func() {
+		os.Args = originalArgs
+		arguments = originalArguments
+	}()
+
+	arguments = new(parameters)
+
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	err = os.Mkdir(path.Join(tempPath, "dir1"), 0755)
+	log.PanicIf(err)
+
+	err = ioutil.WriteFile(path.Join(tempPath, "dir1", "large"), []byte("abcdef"), 0644)
+	log.PanicIf(err)
+
+	err = ioutil.WriteFile(path.Join(tempPath, "medium"), []byte("abc"), 0644)
+	log.PanicIf(err)
+
+	err = ioutil.WriteFile(path.Join(tempPath, "small"), []byte("a"), 0644)
+	log.PanicIf(err)
+
+	os.Args = []string{
+		os.Args[0],
+		tempPath,
+		"--just-files",
+		"--type",
+		"--sort", "size",
+	}
+
+	main()
+
+	os.Stdout.Close()
+
+	raw, err := ioutil.ReadAll(ritesting.StdoutReader())
+	log.PanicIf(err)
+
+	// This is deliberately not sorted by the test.
+	actual := strings.Split(strings.TrimSpace(string(raw)), "\n")
+
+	expected := []string{
+		"f small",
+		"f medium",
+		"f dir1/large",
+	}
+
+	if reflect.DeepEqual(actual, expected) != true {
+		t.Fatalf("Output not correct: %v", actual)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"path/filepath"
+
+	"github.com/dsoprea/go-logging"
+)
+
+const (
+	// globMetaCharacters are the characters that make the root argument a
+	// glob. A backslash escapes the character after it.
+	globMetaCharacters = `*?[\`
+)
+
+// isRootPattern returns whether the root argument is a glob rather than a
+// single path.
+func isRootPattern(rootArgument string) bool {
+	return strings.ContainsAny(rootArgument, globMetaCharacters)
+}
+
+// rootPatternBase returns the leading directories of the given glob that
+// don't have any wildcards (with any escapes removed). All of the matches are
+// under it. If nothing is a wildcard, this is the one path that it matches.
+func rootPatternBase(pattern string) string {
+	parts := strings.Split(pattern, "/")
+
+	literalParts := make([]string, 0)
+	for _, part := range parts {
+		if isLiteralPatternPart(part) == false {
+			break
+		}
+
+		literalParts = append(literalParts, unescapePatternPart(part))
+	}
+
+	basePath := strings.Join(literalParts, "/")
+	if basePath == "" && strings.HasPrefix(pattern, "/") == true {
+		return "/"
+	}
+
+	return path.Clean(basePath)
+}
+
+// isLiteralPatternPart returns whether the given path component has no
+// unescaped wildcards.
+func isLiteralPatternPart(part string) bool {
+	for i := 0; i < len(part); i++ {
+		switch part[i] {
+		case '\\':
+			i++
+		case '*', '?', '[':
+			return false
+		}
+	}
+
+	return true
+}
+
+// unescapePatternPart removes the escapes from the given literal path
+// component.
+func unescapePatternPart(part string) string {
+	unescaped := make([]byte, 0, len(part))
+
+	for i := 0; i < len(part); i++ {
+		if part[i] == '\\' && i+1 < len(part) {
+			i++
+		}
+
+		unescaped = append(unescaped, part[i])
+	}
+
+	return string(unescaped)
+}
+
+// expandRootPattern returns the directories that the given glob matches,
+// sorted and without duplicates, and the directory that they're all under
+// (which the printed paths are relative to). Matches that aren't directories
+// are ignored, so `roots` may be empty. `err` is `filepath.ErrBadPattern` if
+// the glob isn't valid.
+func expandRootPattern(pattern string) (roots []string, basePath string, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	matches, err := filepath.Glob(pattern)
+	if err == filepath.ErrBadPattern {
+		return nil, "", err
+	}
+
+	log.PanicIf(err)
+
+	found := make(map[string]struct{})
+	roots = make([]string, 0)
+
+	for _, match := range matches {
+		match = path.Clean(filepath.ToSlash(match))
+
+		if _, isDuplicate := found[match]; isDuplicate == true {
+			continue
+		}
+
+		found[match] = struct{}{}
+
+		fi, err := os.Stat(match)
+		if err != nil || fi.IsDir() == false {
+			continue
+		}
+
+		roots = append(roots, match)
+	}
+
+	sort.Strings(roots)
+
+	return roots, rootPatternBase(pattern), nil
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/dsoprea/go-logging"
+	"github.com/dsoprea/go-utility/testing"
+)
+
+func TestIsRootPattern(t *testing.T) {
+	if isRootPattern("/data/2023-01/logs") != false {
+		t.Fatalf("Expected a plain path to not be a pattern.")
+	} else if isRootPattern("/data/2023-*/logs") != true {
+		t.Fatalf("Expected a wildcard to be a pattern.")
+	} else if isRootPattern("/data/file\\[1\\]") != true {
+		t.Fatalf("Expected an escape to be a pattern.")
+	}
+}
+
+func TestRootPatternBase(t *testing.T) {
+	cases := map[string]string{
+		"/data/2023-*/logs":       "/data",
+		"/data//2023-0?":          "/data",
+		"/*":                      "/",
+		"2023-*/logs":             ".",
+		"./archive/[0-9]*":        "archive",
+		"/data/\\[old\\]/2023-*":  "/data/[old]",
+		"/data/literal\\*/":       "/data/literal*",
+		"../archive/2023-*/logs/": "../archive",
+	}
+
+	for pattern, expected := range cases {
+		basePath := rootPatternBase(pattern)
+		if basePath != expected {
+			t.Fatalf("Base of [%s] not correct: [%s] != [%s]", pattern, basePath, expected)
+		}
+	}
+}
+
+func TestExpandRootPattern(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	for _, relPath := range []string{"2023-02/logs", "2023-01/logs", "2024-01/logs", "2023-03"} {
+		err := os.MkdirAll(path.Join(tempPath, relPath), 0755)
+		log.PanicIf(err)
+	}
+
+	// Files that match are ignored.
+	err = ioutil.WriteFile(path.Join(tempPath, "2023-04"), []byte{}, 0644)
+	log.PanicIf(err)
+
+	roots, basePath, err := expandRootPattern(path.Join(tempPath, "2023-*", "logs"))
+	log.PanicIf(err)
+
+	expected := []string{
+		path.Join(tempPath, "2023-01", "logs"),
+		path.Join(tempPath, "2023-02", "logs"),
+	}
+
+	if reflect.DeepEqual(roots, expected) != true {
+		t.Fatalf("Roots not correct: %v", roots)
+	} else if basePath != tempPath {
+		t.Fatalf("Base not correct: [%s]", basePath)
+	}
+
+	roots, _, err = expandRootPattern(path.Join(tempPath, "2023-0[34]"))
+	log.PanicIf(err)
+
+	if reflect.DeepEqual(roots, []string{path.Join(tempPath, "2023-03")}) != true {
+		t.Fatalf("Roots not correct for directories and files: %v", roots)
+	}
+
+	roots, _, err = expandRootPattern(path.Join(tempPath, "2025-*"))
+	log.PanicIf(err)
+
+	if len(roots) != 0 {
+		t.Fatalf("Expected no roots: %v", roots)
+	}
+
+	_, _, err = expandRootPattern(path.Join(tempPath, "[a"))
+	if err != filepath.ErrBadPattern {
+		t.Fatalf("Expected bad-pattern error: [%v]", err)
+	}
+}
+
+func TestMain__rootPattern(t *testing.T) {
+	ritesting.RedirectTty()
+
+	defer func() {
+		if errRaw := recover(); errRaw != nil {
+			ritesting.RestoreAndDumpTty()
+
+			err := errRaw.(error)
+			log.PrintError(err)
+
+			log.Panic(err)
+		}
+
+		ritesting.RestoreTty()
+	}()
+
+	originalArgs := os.Args
+	originalArguments := arguments
+
+	defer func() {
+		os.Args = originalArgs
+		arguments = originalArguments
+	}()
+
+	arguments = new(parameters)
+
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	relFilepaths := []string{
+		"2023-01/logs/file1",
+		"2023-02/logs/file2",
+		"2024-01/logs/file3",
+	}
+
+	for _, relFilepath := range relFilepaths {
+		fqFilepath := path.Join(tempPath, relFilepath)
+
+		err := os.MkdirAll(path.Dir(fqFilepath), 0755)
+		log.PanicIf(err)
+
+		err = ioutil.WriteFile(fqFilepath, []byte{}, 0644)
+		log.PanicIf(err)
+	}
+
+	os.Args = []string{
+		os.Args[0],
+		path.Join(tempPath, "2023-*", "logs"),
+		"--just-files",
+	}
+
+	main()
+
+	os.Stdout.Close()
+
+	raw, err := ioutil.ReadAll(ritesting.StdoutReader())
+	log.PanicIf(err)
+
+	actual := strings.Split(strings.TrimSpace(string(raw)), "\n")
+	sort.Strings(actual)
+
+	expected := []string{
+		"2023-01/logs/file1",
+		"2023-02/logs/file2",
+	}
+
+	if reflect.DeepEqual(actual, expected) != true {
+		t.Fatalf("Output not correct: %v", actual)
+	}
+}
@@ -1,6 +1,7 @@
 package pathwalk
 
 import (
+	"fmt"
 	"time"
 )
 
@@ -42,7 +43,288 @@ const (
 	// and how long it has been.
 	workerIdleCheckInterval = time.Second * 2
 
+	// defaultMaxOpenFiles is the maximum number of files that will be open at
+	// once in order to check their content against the content filter.
+	defaultMaxOpenFiles = 100
+
+	// defaultMaxFailedDirectories is the default number of directories that
+	// are kept for `FailedDirectories()`.
+	defaultMaxFailedDirectories = 1000
+
+	// entriesByDepthBarWidth is the width of the longest bar in the
+	// entries-by-depth histogram.
+	entriesByDepthBarWidth = 40
+
 	// frontendIdleCheckInterval is how often the frontend checks for the find
 	// to be done.
 	frontendIdleCheckInterval = time.Millisecond * 500
 )
+
+// Config is a snapshot of the effective settings of a walk.
+type Config struct {
+	RootPath string
+
+	// RunID is the ID of the current (or last) run, or empty if the walk has
+	// never been run.
+	RunID string
+
+	Concurrency     int
+	BufferSize      int
+	BatchSize       int
+	TimeoutDuration time.Duration
+
+	// LoadAwareMaxConcurrency is the maximum number of workers if load-aware
+	// concurrency is enabled (and `Concurrency` doesn't apply), or zero.
+	LoadAwareMaxConcurrency int
+
+	// WarmUpWorkerCount is the number of workers that are started before the
+	// first job of each run.
+	WarmUpWorkerCount int
+
+	// ProgressInterval is how often the progress callback is called, or zero
+	// if there is no progress callback.
+	ProgressInterval time.Duration
+
+	// MaxOpenFiles is the number of files that can be open at once for
+	// content filtering.
+	MaxOpenFiles int
+
+	// MaxGoroutines is the limit on the goroutines created by the walk, or
+	// zero if there is no limit.
+	MaxGoroutines int
+
+	// MaxDepth is the depth of the deepest entries that are visited (one for
+	// `NewWalkParent()`), or zero if there is no limit.
+	MaxDepth int
+
+	// SpillThreshold is the number of pending batches of directory entries
+	// above which further batches are spilled to disk, or zero if spilling is
+	// disabled. SpillDirectory is where the spill file is created (empty for
+	// the system's temporary directory).
+	SpillThreshold int
+	SpillDirectory string
+
+	// Filter is a copy of the filter that was last set.
+	Filter Filter
+
+	// IsFiltered indicates that at least one filter rule is active (the
+	// filter stats are only logged in this case).
+	IsFiltered bool
+
+	SchedulingBias        SchedulingBias
+	IntraDirectoryOrder   IntraDirectoryOrder
+	PathStyle             PathStyle
+	SymlinkDirMode        SymlinkDirMode
+	PathCaseNormalization PathCaseNormalization
+	ReportPrefixStrip     string
+	MaxSkippedEntries     int
+
+	// ToleratePostEnumerationChanges indicates that entries that vanish after
+	// they're listed are silently skipped.
+	ToleratePostEnumerationChanges bool
+
+	// WalkIgnoreFilename is the name of the per-directory ignore files, or
+	// empty if they're not read.
+	WalkIgnoreFilename string
+
+	SampleEntriesPerDirectory int
+	MaxFailedDirectories      int
+	PerDirectoryTimeout       time.Duration
+
+	IsApplyFilterToRoot     bool
+	IsCheckpointsEnabled    bool
+	IsStayOnFilesystem      bool
+	IsDetectDirectoryCycles bool
+	IsPruneEmptyDirectories bool
+	IsAutoTuneBatching      bool
+
+	// IsAutoTuned indicates that `Concurrency` and `BatchSize` were chosen by
+	// `AutoTune()`.
+	IsAutoTuned      bool
+	AutoTuneDuration time.Duration
+
+	// IsResuming indicates that a checkpoint was loaded for the next run.
+	IsResuming bool
+
+	CallbackErrorPolicy     ErrorPolicy
+	IsRecoverCallbackPanics bool
+	IsSerialCallback        bool
+
+	IsApplyErrorPolicyToDirectories bool
+
+	HasBatchCallback      bool
+	HasBatchSizeFunc      bool
+	HasSkipProcessedFunc  bool
+	HasDirectoryLeaveFunc bool
+	HasSpecialFileFunc    bool
+
+	// HasDirectoryResultFunc indicates that entries are also delivered
+	// grouped by directory.
+	HasDirectoryResultFunc bool
+
+	// HasWorkerLifecycle indicates that worker lifecycle or worker callbacks
+	// were set.
+	HasWorkerLifecycle bool
+
+	// VisitorCount is the number of callbacks that receive each entry,
+	// including the one given to `NewWalk()`, the entry callback, and the
+	// counting callback.
+	VisitorCount int
+}
+
+// Dump prints the configuration.
+func (config Config) Dump() {
+	fmt.Printf("Configuration\n")
+	fmt.Printf("=============\n")
+
+	fmt.Printf("RootPath: [%s]\n", config.RootPath)
+	fmt.Printf("RunID: [%s]\n", config.RunID)
+	fmt.Printf("Concurrency: (%d)\n", config.Concurrency)
+	fmt.Printf("BufferSize: (%d)\n", config.BufferSize)
+	fmt.Printf("BatchSize: (%d)\n", config.BatchSize)
+	fmt.Printf("TimeoutDuration: [%s]\n", config.TimeoutDuration)
+	fmt.Printf("LoadAwareMaxConcurrency: (%d)\n", config.LoadAwareMaxConcurrency)
+	fmt.Printf("WarmUpWorkerCount: (%d)\n", config.WarmUpWorkerCount)
+	fmt.Printf("ProgressInterval: [%s]\n", config.ProgressInterval)
+	fmt.Printf("MaxOpenFiles: (%d)\n", config.MaxOpenFiles)
+	fmt.Printf("MaxGoroutines: (%d)\n", config.MaxGoroutines)
+	fmt.Printf("MaxDepth: (%d)\n", config.MaxDepth)
+	fmt.Printf("SpillThreshold: (%d)\n", config.SpillThreshold)
+	fmt.Printf("SpillDirectory: [%s]\n", config.SpillDirectory)
+	fmt.Printf("Filter: %+v\n", config.Filter)
+	fmt.Printf("IsFiltered: [%v]\n", config.IsFiltered)
+	fmt.Printf("SchedulingBias: (%d)\n", config.SchedulingBias)
+	fmt.Printf("IntraDirectoryOrder: (%d)\n", config.IntraDirectoryOrder)
+	fmt.Printf("PathStyle: (%d)\n", config.PathStyle)
+	fmt.Printf("SymlinkDirMode: (%d)\n", config.SymlinkDirMode)
+	fmt.Printf("PathCaseNormalization: (%d)\n", config.PathCaseNormalization)
+	fmt.Printf("ReportPrefixStrip: [%s]\n", config.ReportPrefixStrip)
+	fmt.Printf("MaxSkippedEntries: (%d)\n", config.MaxSkippedEntries)
+	fmt.Printf("ToleratePostEnumerationChanges: [%v]\n", config.ToleratePostEnumerationChanges)
+	fmt.Printf("WalkIgnoreFilename: [%s]\n", config.WalkIgnoreFilename)
+	fmt.Printf("SampleEntriesPerDirectory: (%d)\n", config.SampleEntriesPerDirectory)
+	fmt.Printf("MaxFailedDirectories: (%d)\n", config.MaxFailedDirectories)
+	fmt.Printf("PerDirectoryTimeout: [%s]\n", config.PerDirectoryTimeout)
+	fmt.Printf("IsApplyFilterToRoot: [%v]\n", config.IsApplyFilterToRoot)
+	fmt.Printf("IsCheckpointsEnabled: [%v]\n", config.IsCheckpointsEnabled)
+	fmt.Printf("IsStayOnFilesystem: [%v]\n", config.IsStayOnFilesystem)
+	fmt.Printf("IsDetectDirectoryCycles: [%v]\n", config.IsDetectDirectoryCycles)
+	fmt.Printf("IsPruneEmptyDirectories: [%v]\n", config.IsPruneEmptyDirectories)
+	fmt.Printf("IsAutoTuneBatching: [%v]\n", config.IsAutoTuneBatching)
+	fmt.Printf("IsAutoTuned: [%v]\n", config.IsAutoTuned)
+	fmt.Printf("AutoTuneDuration: [%s]\n", config.AutoTuneDuration)
+	fmt.Printf("IsResuming: [%v]\n", config.IsResuming)
+	fmt.Printf("CallbackErrorPolicy: (%d)\n", config.CallbackErrorPolicy)
+	fmt.Printf("IsRecoverCallbackPanics: [%v]\n", config.IsRecoverCallbackPanics)
+	fmt.Printf("IsSerialCallback: [%v]\n", config.IsSerialCallback)
+	fmt.Printf("IsApplyErrorPolicyToDirectories: [%v]\n", config.IsApplyErrorPolicyToDirectories)
+	fmt.Printf("HasBatchCallback: [%v]\n", config.HasBatchCallback)
+	fmt.Printf("HasBatchSizeFunc: [%v]\n", config.HasBatchSizeFunc)
+	fmt.Printf("HasSkipProcessedFunc: [%v]\n", config.HasSkipProcessedFunc)
+	fmt.Printf("HasDirectoryLeaveFunc: [%v]\n", config.HasDirectoryLeaveFunc)
+	fmt.Printf("HasSpecialFileFunc: [%v]\n", config.HasSpecialFileFunc)
+	fmt.Printf("HasDirectoryResultFunc: [%v]\n", config.HasDirectoryResultFunc)
+	fmt.Printf("HasWorkerLifecycle: [%v]\n", config.HasWorkerLifecycle)
+	fmt.Printf("VisitorCount: (%d)\n", config.VisitorCount)
+
+	fmt.Printf("\n")
+}
+
+// Config returns a snapshot of the effective settings. The filter slices are
+// copies, so modifying them won't affect the walk. This is intended to be
+// called between runs.
+func (walk *Walk) Config() Config {
+	loadAwareMaxConcurrency := 0
+
+	walk.loadLocker.Lock()
+	if walk.loadSampler != nil {
+		loadAwareMaxConcurrency = walk.loadAwareMaxConcurrency
+	}
+	walk.loadLocker.Unlock()
+
+	progressInterval := time.Duration(0)
+	if walk.progressFunc != nil && walk.progressInterval > 0 {
+		progressInterval = walk.progressInterval
+	}
+
+	visitorCount := len(walk.visitors)
+	if walk.walkFunc != nil {
+		visitorCount++
+	}
+
+	if walk.walkEntryFunc != nil {
+		visitorCount++
+	}
+
+	if walk.countingWalkFunc != nil {
+		visitorCount++
+	}
+
+	return Config{
+		RootPath: walk.rootPath,
+		RunID:    walk.RunID(),
+
+		Concurrency:     walk.concurrency,
+		BufferSize:      walk.bufferSize,
+		BatchSize:       walk.batchSize,
+		TimeoutDuration: walk.timeoutDuration,
+		MaxOpenFiles:    cap(walk.openFilesC),
+		MaxGoroutines:   walk.maxGoroutines,
+		MaxDepth:        walk.maxDepth,
+
+		LoadAwareMaxConcurrency: loadAwareMaxConcurrency,
+		WarmUpWorkerCount:       walk.warmUpWorkerCount,
+		ProgressInterval:        progressInterval,
+
+		SpillThreshold: walk.spillThreshold,
+		SpillDirectory: walk.spillDirectory,
+
+		Filter:     walk.userFilter.copy(),
+		IsFiltered: walk.doLogFilterStats,
+
+		SchedulingBias:        walk.schedulingBias,
+		IntraDirectoryOrder:   walk.intraDirectoryOrder,
+		PathStyle:             walk.pathStyle,
+		SymlinkDirMode:        walk.symlinkDirMode,
+		PathCaseNormalization: walk.pathCaseNormalization,
+		ReportPrefixStrip:     walk.reportPrefixStrip,
+		MaxSkippedEntries:     walk.maxSkippedEntries,
+
+		ToleratePostEnumerationChanges: walk.isToleratePostEnumerationChanges,
+
+		WalkIgnoreFilename: walk.walkIgnoreFilename,
+
+		SampleEntriesPerDirectory: walk.sampleEntriesPerDirectory,
+		MaxFailedDirectories:      walk.maxFailedDirectories,
+		PerDirectoryTimeout:       walk.perDirectoryTimeout,
+
+		IsApplyFilterToRoot:     walk.isApplyFilterToRoot,
+		IsCheckpointsEnabled:    walk.isCheckpointsEnabled,
+		IsStayOnFilesystem:      walk.isStayOnFilesystem,
+		IsDetectDirectoryCycles: walk.isDetectDirectoryCycles,
+		IsPruneEmptyDirectories: walk.isPruneEmptyDirectories,
+		IsAutoTuneBatching:      walk.isAutoTuneBatching,
+		IsResuming:              walk.resumeDirectories != nil,
+
+		IsAutoTuned:      walk.isAutoTuned,
+		AutoTuneDuration: walk.autoTuneDuration,
+
+		CallbackErrorPolicy:     walk.callbackErrorPolicy,
+		IsRecoverCallbackPanics: walk.isRecoverCallbackPanics,
+		IsSerialCallback:        walk.isSerialCallback,
+
+		IsApplyErrorPolicyToDirectories: walk.isApplyErrorPolicyToDirectories,
+
+		HasBatchCallback:      walk.batchWalkFunc != nil,
+		HasBatchSizeFunc:      walk.batchSizeFunc != nil,
+		HasSkipProcessedFunc:  walk.skipProcessedFunc != nil,
+		HasDirectoryLeaveFunc: walk.directoryLeaveFunc != nil,
+		HasSpecialFileFunc:    walk.specialFileFunc != nil,
+
+		HasDirectoryResultFunc: walk.directoryResultFunc != nil,
+
+		HasWorkerLifecycle: walk.hasWorkerLifecycle(),
+
+		VisitorCount: visitorCount,
+	}
+}
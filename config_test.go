@@ -0,0 +1,134 @@
+package pathwalk
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestConfig_Dump(t *testing.T) {
+	walk := NewWalk("root/path", nil)
+
+	config := walk.Config()
+	config.Dump()
+}
+
+func TestWalk_Config__defaults(t *testing.T) {
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		return nil
+	}
+
+	walk := NewWalk("root/path", walkFunc)
+
+	config := walk.Config()
+
+	if config.RootPath != "root/path" {
+		t.Fatalf("RootPath not correct: [%s]", config.RootPath)
+	} else if config.Concurrency != defaultConcurrency {
+		t.Fatalf("Concurrency not correct: (%d)", config.Concurrency)
+	} else if config.BufferSize != defaultBufferSize {
+		t.Fatalf("BufferSize not correct: (%d)", config.BufferSize)
+	} else if config.BatchSize != defaultDirectoryEntryBatchSize {
+		t.Fatalf("BatchSize not correct: (%d)", config.BatchSize)
+	} else if config.TimeoutDuration != defaultTimeoutDuration {
+		t.Fatalf("TimeoutDuration not correct: [%s]", config.TimeoutDuration)
+	} else if config.MaxOpenFiles != defaultMaxOpenFiles {
+		t.Fatalf("MaxOpenFiles not correct: (%d)", config.MaxOpenFiles)
+	} else if config.IsFiltered != false {
+		t.Fatalf("Expected no filtering.")
+	} else if config.VisitorCount != 1 {
+		t.Fatalf("VisitorCount not correct: (%d)", config.VisitorCount)
+	} else if config.RunID != "" {
+		t.Fatalf("Expected no run ID: [%s]", config.RunID)
+	}
+}
+
+func TestWalk_Config__settings(t *testing.T) {
+	walk := NewWalk("root/path", nil)
+
+	walk.SetConcurrency(5)
+	walk.SetBufferSize(6)
+	walk.SetBatchSize(7)
+	walk.SetGlobalTimeoutDuration(time.Second * 8)
+	walk.SetSchedulingBias(DepthFirst)
+	walk.SetIntraDirectoryOrder(FilesFirst)
+	walk.SetAutoTuneBatching(true)
+	walk.SetPathStyle(PathStyleRelative)
+	walk.SetReportPrefixStrip("a/b/")
+	walk.SetCheckpointsEnabled(true)
+	walk.SetStayOnFilesystem(true)
+	walk.SetMaxGoroutines(9)
+	walk.SetSpillThreshold(10)
+	walk.SetSpillDirectory("spill/path")
+	walk.SetToleratePostEnumerationChanges(true)
+	walk.SetAutoTuneDuration(time.Second * 11)
+	walk.SetSymlinkDirMode(SymlinkDirAsFile)
+	walk.SetSerialCallback(true)
+	walk.SetDetectDirectoryCycles(true)
+
+	walk.SetDirectoryResultFunc(func(directoryPath string, entries []WalkEntry) (err error) {
+		return nil
+	})
+
+	walk.AddVisitor(func(parentPath string, info os.FileInfo) (err error) {
+		return nil
+	})
+
+	filter := Filter{
+		IncludeFilenames: []string{"*.jpg"},
+	}
+
+	walk.SetFilter(filter)
+
+	// The snapshot shouldn't be affected by changes to the original filter.
+	filter.IncludeFilenames[0] = "*.png"
+
+	config := walk.Config()
+
+	if config.Concurrency != 5 || config.BufferSize != 6 || config.BatchSize != 7 || config.TimeoutDuration != time.Second*8 {
+		t.Fatalf("Sizes not correct: %+v", config)
+	} else if config.MaxGoroutines != 9 {
+		t.Fatalf("MaxGoroutines not correct: (%d)", config.MaxGoroutines)
+	} else if config.SpillThreshold != 10 || config.SpillDirectory != "spill/path" {
+		t.Fatalf("Spilling not correct: (%d) [%s]", config.SpillThreshold, config.SpillDirectory)
+	} else if config.ToleratePostEnumerationChanges != true {
+		t.Fatalf("ToleratePostEnumerationChanges not correct.")
+	} else if config.SchedulingBias != DepthFirst {
+		t.Fatalf("SchedulingBias not correct: (%d)", config.SchedulingBias)
+	} else if config.IntraDirectoryOrder != FilesFirst {
+		t.Fatalf("IntraDirectoryOrder not correct: (%d)", config.IntraDirectoryOrder)
+	} else if config.IsAutoTuneBatching != true {
+		t.Fatalf("IsAutoTuneBatching not correct.")
+	} else if config.IsAutoTuned != false || config.AutoTuneDuration != time.Second*11 {
+		t.Fatalf("Auto-tuning not correct: [%v] [%s]", config.IsAutoTuned, config.AutoTuneDuration)
+	} else if config.SymlinkDirMode != SymlinkDirAsFile {
+		t.Fatalf("SymlinkDirMode not correct: (%d)", config.SymlinkDirMode)
+	} else if config.IsSerialCallback != true {
+		t.Fatalf("IsSerialCallback not correct.")
+	} else if config.IsDetectDirectoryCycles != true {
+		t.Fatalf("IsDetectDirectoryCycles not correct.")
+	} else if config.HasDirectoryResultFunc != true {
+		t.Fatalf("HasDirectoryResultFunc not correct.")
+	} else if config.PathStyle != PathStyleRelative {
+		t.Fatalf("PathStyle not correct: (%d)", config.PathStyle)
+	} else if config.ReportPrefixStrip != "a/b" {
+		t.Fatalf("ReportPrefixStrip not correct: [%s]", config.ReportPrefixStrip)
+	} else if config.IsCheckpointsEnabled != true || config.IsStayOnFilesystem != true {
+		t.Fatalf("Modes not correct: %+v", config)
+	} else if config.IsFiltered != true {
+		t.Fatalf("Expected filtering.")
+	} else if reflect.DeepEqual(config.Filter.IncludeFilenames, []string{"*.jpg"}) != true {
+		t.Fatalf("Filter not correct: %v", config.Filter.IncludeFilenames)
+	} else if config.VisitorCount != 1 {
+		t.Fatalf("VisitorCount not correct: (%d)", config.VisitorCount)
+	}
+
+	// Modifying the snapshot shouldn't affect the walk.
+
+	config.Filter.IncludeFilenames[0] = "*.gif"
+
+	if walk.Config().Filter.IncludeFilenames[0] != "*.jpg" {
+		t.Fatalf("Snapshot shares state with the walk.")
+	}
+}
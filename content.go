@@ -0,0 +1,62 @@
+package pathwalk
+
+import (
+	"io"
+	"os"
+
+	"github.com/dsoprea/go-logging"
+)
+
+// readFileHead reads up to `size` bytes from the beginning of the given file.
+// The number of files that we have open at any one time for this purpose is
+// bounded so that a high concurrency doesn't exhaust the descriptor limit.
+func (walk *Walk) readFileHead(filepath string, size int) (head []byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	walk.openFilesC <- struct{}{}
+
+	defer func() {
+		<-walk.openFilesC
+	}()
+
+	f, err := walk.openChild(filepath)
+	log.PanicIf(err)
+
+	defer f.Close()
+
+	head = make([]byte, size)
+
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		log.Panic(err)
+	}
+
+	return head[:n], nil
+}
+
+// isContentIncluded determines whether the head of the given file matches the
+// content filter. Only regular files are read, since opening a FIFO or a
+// device could block indefinitely, and anything else never matches. Files
+// that can not be read are excluded (and `isReadable` will be false). This
+// will panic if too many entries have been skipped.
+func (walk *Walk) isContentIncluded(filepath string, info os.FileInfo) (isIncluded bool, isReadable bool) {
+	if info.Mode().IsRegular() == false {
+		return false, true
+	}
+
+	head, err := walk.readFileHead(filepath, walk.filter.ContentMagicMaxLen())
+	if err != nil {
+		walk.logWarningf("can not read [%s] for content filtering; it will be excluded: [%s]", filepath, err.Error())
+
+		err := walk.recordSkippedEntry(filepath, err)
+		log.PanicIf(err)
+
+		return false, false
+	}
+
+	return walk.filter.IsContentIncluded(head), true
+}
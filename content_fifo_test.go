@@ -0,0 +1,88 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+// `syscall.Mkfifo()` isn't available on Solaris.
+
+package pathwalk
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+)
+
+func TestWalk_Run__contentMagic__fifo(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	err = ioutil.WriteFile(path.Join(tempPath, "image.png"), []byte("\x89PNG\r\n\x1a\n..."), 0644)
+	log.PanicIf(err)
+
+	// Nothing will ever write to this, so opening it for reading would block
+	// forever.
+	err = syscall.Mkfifo(path.Join(tempPath, "pipe.png"), 0644)
+	log.PanicIf(err)
+
+	m := sync.Mutex{}
+	visited := make([]string, 0)
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		if info.IsDir() == true {
+			return nil
+		}
+
+		m.Lock()
+		defer m.Unlock()
+
+		visited = append(visited, info.Name())
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+
+	filter := Filter{
+		ContentMagic: [][]byte{
+			[]byte("\x89PNG"),
+		},
+	}
+
+	err = walk.SetFilter(filter)
+	log.PanicIf(err)
+
+	doneC := make(chan error, 1)
+
+	go func() {
+		doneC <- walk.Run()
+	}()
+
+	select {
+	case err := <-doneC:
+		log.PanicIf(err)
+	case <-time.After(time.Second * 10):
+		t.Fatalf("Walk blocked on the FIFO.")
+	}
+
+	if reflect.DeepEqual(visited, []string{"image.png"}) != true {
+		t.Fatalf("Visited files not correct: %v", visited)
+	}
+
+	stats := walk.Stats()
+	if stats.ContentFilterMatches != 1 {
+		t.Fatalf("ContentFilterMatches not correct: (%d)", stats.ContentFilterMatches)
+	} else if stats.SkippedEntries != 0 {
+		t.Fatalf("SkippedEntries not correct: (%d)", stats.SkippedEntries)
+	}
+}
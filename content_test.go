@@ -0,0 +1,121 @@
+package pathwalk
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+)
+
+func TestWalk_readFileHead(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	filepath := path.Join(tempPath, "file")
+
+	err = ioutil.WriteFile(filepath, []byte("abcdef"), 0644)
+	log.PanicIf(err)
+
+	walk := NewWalk(tempPath, nil)
+
+	head, err := walk.readFileHead(filepath, 3)
+	log.PanicIf(err)
+
+	if bytes.Equal(head, []byte("abc")) != true {
+		t.Fatalf("Head not correct: %v", head)
+	}
+
+	// Ask for more than is there.
+
+	head, err = walk.readFileHead(filepath, 10)
+	log.PanicIf(err)
+
+	if bytes.Equal(head, []byte("abcdef")) != true {
+		t.Fatalf("Short head not correct: %v", head)
+	}
+}
+
+func TestWalk_Run__contentMagic(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	files := map[string][]byte{
+		"image.png":      []byte("\x89PNG\r\n\x1a\n..."),
+		"image.dat":      []byte("\x89PNG\r\n\x1a\n..."),
+		"document.pdf":   []byte("%PDF-1.4..."),
+		"notes.txt":      []byte("just some text"),
+		"empty.png":      []byte{},
+		"short.png":      []byte("\x89P"),
+		"renamed_pdf.db": []byte("%PDF-1.7..."),
+	}
+
+	for filename, content := range files {
+		err := ioutil.WriteFile(path.Join(tempPath, filename), content, 0644)
+		log.PanicIf(err)
+	}
+
+	m := sync.Mutex{}
+	visited := make([]string, 0)
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		if info.IsDir() == true {
+			return nil
+		}
+
+		m.Lock()
+		defer m.Unlock()
+
+		visited = append(visited, info.Name())
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+
+	filter := Filter{
+		ContentMagic: [][]byte{
+			[]byte("\x89PNG"),
+			[]byte("%PDF-"),
+		},
+	}
+
+	walk.SetFilter(filter)
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	sort.Strings(visited)
+
+	expected := []string{
+		"document.pdf",
+		"image.dat",
+		"image.png",
+		"renamed_pdf.db",
+	}
+
+	if reflect.DeepEqual(visited, expected) != true {
+		t.Fatalf("Visited files not correct: %v", visited)
+	}
+
+	stats := walk.Stats()
+	if stats.ContentFilterMatches != 4 {
+		t.Fatalf("ContentFilterMatches not correct: (%d)", stats.ContentFilterMatches)
+	} else if stats.FileFilterExcludes != 3 {
+		t.Fatalf("FileFilterExcludes not correct: (%d)", stats.FileFilterExcludes)
+	}
+}
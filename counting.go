@@ -0,0 +1,54 @@
+package pathwalk
+
+import (
+	"os"
+	"sync/atomic"
+)
+
+// CountingWalkFunc is a callback that can decide whether the entry that it
+// was given counts as having been processed.
+type CountingWalkFunc func(parentPath string, info os.FileInfo) (isCounted bool, err error)
+
+// SetCountingWalkFunc sets a callback that receives each entry and returns
+// whether it should be counted. This is for callbacks that do their own
+// filtering beyond what the library does: once set, `Stats().FilesVisited` is
+// only the number of files for which it returned true and the rest are
+// counted in `Stats().FilesNotCounted`. Files for which it returns an error
+// are not counted. What it returns for directories is ignored.
+//
+// It is called before the callback given to `NewWalk()` and any visitors and
+// it is subject to the same error handling. It is not called in names-only
+// mode and files are only delivered to the batch callback if one was set.
+// This must be called before `Run()`.
+func (walk *Walk) SetCountingWalkFunc(countingWalkFunc CountingWalkFunc) {
+	walk.countingWalkFunc = countingWalkFunc
+}
+
+// isCountingFiles indicates whether the files are counted according to the
+// counting callback rather than as they're visited.
+func (walk *Walk) isCountingFiles() bool {
+	return walk.countingWalkFunc != nil && walk.batchWalkFunc == nil && walk.isNamesOnly == false && walk.isCountingMatches == false
+}
+
+// callCountingWalkFunc delivers one entry to the counting callback and counts
+// it if it's a file.
+func (walk *Walk) callCountingWalkFunc(parentNodePath string, info os.FileInfo) (err error) {
+	isCounted := false
+
+	walkFunc := func(parentNodePath string, info os.FileInfo) (err error) {
+		isCounted, err = walk.countingWalkFunc(parentNodePath, info)
+		return err
+	}
+
+	err = walk.callVisitorSafely(walkFunc, parentNodePath, info)
+
+	if info.IsDir() == false {
+		if isCounted == true && err == nil {
+			atomic.AddInt64(&walk.hotStats.filesVisited, 1)
+		} else {
+			atomic.AddInt64(&walk.hotStats.filesNotCounted, 1)
+		}
+	}
+
+	return err
+}
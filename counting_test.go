@@ -0,0 +1,180 @@
+package pathwalk
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-parallel-walker/internal/testing"
+)
+
+func TestWalk_SetCountingWalkFunc(t *testing.T) {
+	tempPath, tempFiles := pwtesting.FillHeirarchicalTempPath(100, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	m := sync.Mutex{}
+	counted := 0
+	directories := 0
+
+	countingWalkFunc := func(parentPath string, info os.FileInfo) (isCounted bool, err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		if info.IsDir() == true {
+			directories++
+
+			// This should be ignored.
+			return false, nil
+		}
+
+		// Handle every other file.
+		if len(info.Name())%2 == 0 {
+			counted++
+			return true, nil
+		}
+
+		return false, nil
+	}
+
+	walk := NewWalk(tempPath, nil)
+	walk.SetCountingWalkFunc(countingWalkFunc)
+
+	if walk.Config().VisitorCount != 1 {
+		t.Fatalf("VisitorCount not correct: (%d)", walk.Config().VisitorCount)
+	}
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	stats := walk.Stats()
+
+	if counted == 0 || counted == len(tempFiles) {
+		t.Fatalf("Test files don't exercise both cases: (%d)", counted)
+	} else if stats.FilesVisited != counted {
+		t.Fatalf("FilesVisited not correct: (%d) != (%d)", stats.FilesVisited, counted)
+	} else if stats.FilesNotCounted != len(tempFiles)-counted {
+		t.Fatalf("FilesNotCounted not correct: (%d) != (%d)", stats.FilesNotCounted, len(tempFiles)-counted)
+	} else if stats.DirectoriesVisited != directories {
+		t.Fatalf("DirectoriesVisited not correct: (%d) != (%d)", stats.DirectoriesVisited, directories)
+	}
+}
+
+func TestWalk_SetCountingWalkFunc__beforeOtherCallbacks(t *testing.T) {
+	tempPath, _ := pwtesting.FillFlatTempPath(1, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	m := sync.Mutex{}
+	calls := make([]string, 0)
+
+	record := func(name string) {
+		m.Lock()
+		defer m.Unlock()
+
+		calls = append(calls, name)
+	}
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		if info.IsDir() == false {
+			record("walk")
+		}
+
+		return nil
+	}
+
+	countingWalkFunc := func(parentPath string, info os.FileInfo) (isCounted bool, err error) {
+		if info.IsDir() == false {
+			record("counting")
+		}
+
+		return true, nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetCountingWalkFunc(countingWalkFunc)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	if strings.Join(calls, ",") != "counting,walk" {
+		t.Fatalf("Callbacks not called in the right order: %v", calls)
+	}
+}
+
+func TestWalk_SetCountingWalkFunc__toleratedError(t *testing.T) {
+	tempPath, tempFiles := pwtesting.FillFlatTempPath(10, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	errTest := errors.New("test error")
+
+	countingWalkFunc := func(parentPath string, info os.FileInfo) (isCounted bool, err error) {
+		if info.IsDir() == false && info.Name() == tempFiles[0] {
+			return true, errTest
+		}
+
+		return true, nil
+	}
+
+	walk := NewWalk(tempPath, nil)
+	walk.SetCountingWalkFunc(countingWalkFunc)
+	walk.SetCallbackErrorPolicy(ErrorPolicyContinue)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	stats := walk.Stats()
+
+	if stats.FilesVisited != len(tempFiles)-1 {
+		t.Fatalf("FilesVisited not correct: (%d)", stats.FilesVisited)
+	} else if stats.FilesNotCounted != 1 {
+		t.Fatalf("FilesNotCounted not correct: (%d)", stats.FilesNotCounted)
+	} else if stats.CallbackErrors != 1 {
+		t.Fatalf("CallbackErrors not correct: (%d)", stats.CallbackErrors)
+	}
+}
+
+func TestWalk_SetCountingWalkFunc__batchCallback(t *testing.T) {
+	tempPath, tempFiles := pwtesting.FillFlatTempPath(10, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	countingWalkFunc := func(parentPath string, info os.FileInfo) (isCounted bool, err error) {
+		return false, nil
+	}
+
+	batchWalkFunc := func(parentPath string, infos []os.FileInfo) (err error) {
+		return nil
+	}
+
+	walk := NewWalk(tempPath, nil)
+	walk.SetCountingWalkFunc(countingWalkFunc)
+	walk.SetBatchCallback(batchWalkFunc)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	// The files never reach the counting callback, so they're counted as
+	// usual.
+
+	stats := walk.Stats()
+
+	if stats.FilesVisited != len(tempFiles) {
+		t.Fatalf("FilesVisited not correct: (%d)", stats.FilesVisited)
+	} else if stats.FilesNotCounted != 0 {
+		t.Fatalf("FilesNotCounted not correct: (%d)", stats.FilesNotCounted)
+	}
+}
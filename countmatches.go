@@ -0,0 +1,57 @@
+package pathwalk
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/dsoprea/go-logging"
+)
+
+// CountMatches runs the walk without calling the callbacks that receive the
+// entries and returns how many files and directories would have been
+// delivered to them. This is quicker feedback than a full walk when tuning
+// filters. All of the filters and modes apply as they would for `Run()`. The
+// callback given to `NewWalk()`, the visitors, and the counting, batch,
+// names-only, per-file, filtered-visit, and directory-leave callbacks aren't
+// called, but the callbacks that decide what is traversed (e.g. the
+// contextual directory callback and the already-processed check) still are.
+// The stats are updated as usual.
+func (walk *Walk) CountMatches() (files int, directories int, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if walk.rootPath == "" {
+		return 0, 0, ErrEmptyRootPath
+	}
+
+	if atomic.CompareAndSwapInt32(&walk.isRunActive, 0, 1) == false {
+		return 0, 0, ErrAlreadyRunning
+	}
+
+	walk.isCountingMatches = true
+
+	defer func() {
+		walk.isCountingMatches = false
+	}()
+
+	err = walk.run(context.Background(), nil, nil)
+	log.PanicIf(err)
+
+	files = int(atomic.LoadInt64(&walk.hotStats.matchedFiles))
+	directories = int(atomic.LoadInt64(&walk.hotStats.matchedDirectories))
+
+	return files, directories, nil
+}
+
+// countMatch counts entries that would have been delivered during
+// `CountMatches()`.
+func (walk *Walk) countMatch(isDir bool, count int) {
+	if isDir == true {
+		atomic.AddInt64(&walk.hotStats.matchedDirectories, int64(count))
+	} else {
+		atomic.AddInt64(&walk.hotStats.matchedFiles, int64(count))
+	}
+}
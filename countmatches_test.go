@@ -0,0 +1,137 @@
+package pathwalk
+
+import (
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-parallel-walker/internal/testing"
+)
+
+func TestWalk_CountMatches(t *testing.T) {
+	tempPath, _ := pwtesting.FillHeirarchicalTempPath(200, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	filter := Filter{
+		ExcludeFilenames: []string{"*3*"},
+	}
+
+	visitedFiles := int64(0)
+	visitedDirectories := int64(0)
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		if info.IsDir() == true {
+			atomic.AddInt64(&visitedDirectories, 1)
+		} else {
+			atomic.AddInt64(&visitedFiles, 1)
+		}
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetFilter(filter)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	expectedFiles := int(atomic.LoadInt64(&visitedFiles))
+	expectedDirectories := int(atomic.LoadInt64(&visitedDirectories))
+
+	if expectedFiles == 0 || expectedFiles == 200 {
+		t.Fatalf("Expected the filter to exclude some files: (%d)", expectedFiles)
+	}
+
+	files, directories, err := walk.CountMatches()
+	log.PanicIf(err)
+
+	if files != expectedFiles {
+		t.Fatalf("Files not correct: (%d) != (%d)", files, expectedFiles)
+	} else if directories != expectedDirectories {
+		t.Fatalf("Directories not correct: (%d) != (%d)", directories, expectedDirectories)
+	} else if int(atomic.LoadInt64(&visitedFiles)) != expectedFiles || int(atomic.LoadInt64(&visitedDirectories)) != expectedDirectories {
+		t.Fatalf("The callback was called while counting.")
+	}
+
+	// A normal run should still call the callback afterward.
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	if int(atomic.LoadInt64(&visitedFiles)) != expectedFiles*2 {
+		t.Fatalf("The callback was not called after counting.")
+	}
+}
+
+func TestWalk_CountMatches__namesOnly(t *testing.T) {
+	tempPath := createDepthTestTree()
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	called := false
+
+	nameFunc := func(fqPath string, isDir bool) (err error) {
+		called = true
+		return nil
+	}
+
+	walk := NewWalk(tempPath, nil)
+	walk.SetNamesOnly(true)
+	walk.SetNameFunc(nameFunc)
+
+	files, directories, err := walk.CountMatches()
+	log.PanicIf(err)
+
+	if files != 4 {
+		t.Fatalf("Files not correct: (%d)", files)
+	} else if directories != 3 {
+		t.Fatalf("Directories not correct: (%d)", directories)
+	} else if called == true {
+		t.Fatalf("The callback was called while counting.")
+	}
+}
+
+func TestWalk_CountMatches__batchCallback(t *testing.T) {
+	tempPath := createDepthTestTree()
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	called := int32(0)
+
+	batchWalkFunc := func(parentPath string, infos []os.FileInfo) (err error) {
+		atomic.StoreInt32(&called, 1)
+		return nil
+	}
+
+	walk := NewWalk(tempPath, nil)
+	walk.SetBatchCallback(batchWalkFunc)
+
+	files, directories, err := walk.CountMatches()
+	log.PanicIf(err)
+
+	if files != 4 {
+		t.Fatalf("Files not correct: (%d)", files)
+	} else if directories != 3 {
+		t.Fatalf("Directories not correct: (%d)", directories)
+	} else if atomic.LoadInt32(&called) != 0 {
+		t.Fatalf("The batch callback was called while counting.")
+	}
+}
+
+func TestWalk_CountMatches__emptyRootPath(t *testing.T) {
+	walk := NewWalk("", nil)
+
+	_, _, err := walk.CountMatches()
+	if err != ErrEmptyRootPath {
+		t.Fatalf("Expected empty-root error: [%v]", err)
+	}
+}
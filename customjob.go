@@ -0,0 +1,95 @@
+package pathwalk
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/dsoprea/go-logging"
+)
+
+var (
+	// ErrJobTypeNotRegistered is returned by `Enqueue()` if no handler was
+	// registered for the type of the job.
+	ErrJobTypeNotRegistered = errors.New("job type not registered")
+
+	// ErrWalkNotRunning is returned by operations that can only be performed
+	// during a run.
+	ErrWalkNotRunning = errors.New("walk is not running")
+)
+
+// Job is the interface that a custom job has to satisfy in order to be queued
+// with `Enqueue()` and processed by the workers alongside the built-in jobs.
+// Jobs are routed to their handlers by their concrete type, so each kind of
+// job should have its own type.
+type Job interface {
+	// ParentNodePath returns the full-path of the directory that the job
+	// belongs to. If directories are being tracked (e.g. for checkpoints or
+	// `SetDirectoryLeaveFunc()`), that directory isn't considered complete
+	// until the job is.
+	ParentNodePath() string
+
+	// String returns a description of the job for logging.
+	String() string
+}
+
+// JobHandler processes one custom job. It is called from the workers, so it
+// will be called concurrently. Returning an error fails the walk.
+type JobHandler func(job Job) (err error)
+
+// RegisterJobHandler registers the handler for all jobs that have the same
+// concrete type as `prototype`. Registering a type again replaces its handler.
+// This has to be done between runs. The built-in jobs are always handled
+// directly.
+func (walk *Walk) RegisterJobHandler(prototype Job, handler JobHandler) {
+	if walk.jobHandlers == nil {
+		walk.jobHandlers = make(map[reflect.Type]JobHandler)
+	}
+
+	walk.jobHandlers[reflect.TypeOf(prototype)] = handler
+}
+
+// Enqueue queues a custom job to be processed by the workers. This can only be
+// done during a run (e.g. from a callback) and a handler has to have been
+// registered for the type of the job with `RegisterJobHandler()`. Like the
+// jobs queued by the walk itself, this will block if the queue is full, and
+// the job is quietly discarded if the walk has been stopped.
+func (walk *Walk) Enqueue(job Job) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if _, found := walk.jobHandlers[reflect.TypeOf(job)]; found == false {
+		return ErrJobTypeNotRegistered
+	}
+
+	if walk.IsRunning() == false {
+		return ErrWalkNotRunning
+	}
+
+	err = walk.pushJob(job)
+	log.PanicIf(err)
+
+	return nil
+}
+
+// handleCustomJob passes a job that isn't one of the built-in types to its
+// registered handler.
+func (walk *Walk) handleCustomJob(j job) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	handler, found := walk.jobHandlers[reflect.TypeOf(j)]
+	if found == false {
+		log.Panicf("job not valid: [%v]", reflect.TypeOf(j))
+	}
+
+	err = handler(j)
+	log.PanicIf(err)
+
+	return nil
+}
@@ -0,0 +1,156 @@
+package pathwalk
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+)
+
+type testCustomJob struct {
+	parentPath string
+	name       string
+}
+
+func (tcj testCustomJob) ParentNodePath() string {
+	return tcj.parentPath
+}
+
+func (tcj testCustomJob) String() string {
+	return fmt.Sprintf("TestCustomJob<PARENT=[%s] NAME=[%s]>", tcj.parentPath, tcj.name)
+}
+
+func TestWalk_Enqueue(t *testing.T) {
+	tempPath := createDepthTestTree()
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	var walk *Walk
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		if info.IsDir() == true {
+			return nil
+		}
+
+		tcj := testCustomJob{
+			parentPath: parentPath,
+			name:       info.Name(),
+		}
+
+		err = walk.Enqueue(tcj)
+		log.PanicIf(err)
+
+		return nil
+	}
+
+	walk = NewWalk(tempPath, walkFunc)
+
+	m := sync.Mutex{}
+	handled := make([]string, 0)
+
+	walk.RegisterJobHandler(testCustomJob{}, func(job Job) (err error) {
+		tcj := job.(testCustomJob)
+
+		m.Lock()
+		defer m.Unlock()
+
+		relPath := path.Join(tcj.parentPath, tcj.name)[len(tempPath)+1:]
+		handled = append(handled, relPath)
+
+		return nil
+	})
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	sort.Strings(handled)
+
+	expected := []string{
+		"dir1/dir2/file2",
+		"dir1/dir2/file3",
+		"dir1/file1",
+		"file0",
+	}
+
+	if reflect.DeepEqual(handled, expected) != true {
+		t.Fatalf("Custom jobs not handled correctly: %v", handled)
+	}
+}
+
+func TestWalk_Enqueue__handlerError(t *testing.T) {
+	tempPath := createDepthTestTree()
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	var walk *Walk
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		if info.Name() != "file0" {
+			return nil
+		}
+
+		err = walk.Enqueue(testCustomJob{parentPath: parentPath, name: info.Name()})
+		log.PanicIf(err)
+
+		return nil
+	}
+
+	walk = NewWalk(tempPath, walkFunc)
+
+	errTest := errors.New("test error")
+
+	walk.RegisterJobHandler(testCustomJob{}, func(job Job) (err error) {
+		return errTest
+	})
+
+	err := walk.Run()
+	if err == nil {
+		t.Fatalf("Expected error.")
+	} else if strings.Contains(err.Error(), errTest.Error()) == false {
+		t.Fatalf("Error not correct: [%s]", err)
+	}
+}
+
+func TestWalk_Enqueue__notRegistered(t *testing.T) {
+	walk := NewWalk("root/path", nil)
+
+	err := walk.Enqueue(testCustomJob{})
+	if err != ErrJobTypeNotRegistered {
+		t.Fatalf("Expected not-registered error: [%v]", err)
+	}
+}
+
+func TestWalk_Enqueue__notRunning(t *testing.T) {
+	walk := NewWalk("root/path", nil)
+
+	walk.RegisterJobHandler(testCustomJob{}, func(job Job) (err error) {
+		return nil
+	})
+
+	err := walk.Enqueue(testCustomJob{})
+	if err != ErrWalkNotRunning {
+		t.Fatalf("Expected not-running error: [%v]", err)
+	}
+}
+
+func TestWalk_handleCustomJob__notRegistered(t *testing.T) {
+	walk := NewWalk("root/path", nil)
+
+	err := walk.handleCustomJob(testCustomJob{})
+	if err == nil {
+		t.Fatalf("Expected error.")
+	} else if strings.HasPrefix(err.Error(), "job not valid: ") == false {
+		t.Fatalf("Error not correct: [%s]", err)
+	}
+}
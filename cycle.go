@@ -0,0 +1,93 @@
+package pathwalk
+
+import (
+	"os"
+	"path"
+)
+
+// directoryId uniquely identifies a directory on the system.
+type directoryId struct {
+	deviceId uint64
+	inode    uint64
+}
+
+// getDirectoryId returns the device and inode of the given node if they're
+// available.
+func getDirectoryId(info os.FileInfo) (id directoryId, ok bool) {
+	deviceId, ok := getDeviceId(info)
+	if ok == false {
+		return directoryId{}, false
+	}
+
+	inode, ok := getInode(info)
+	if ok == false {
+		return directoryId{}, false
+	}
+
+	id = directoryId{
+		deviceId: deviceId,
+		inode:    inode,
+	}
+
+	return id, true
+}
+
+// SetDetectDirectoryCycles skips any directory that is the same directory
+// (the same device and inode) as one that was already visited during this
+// run. This protects against infinite recursion from cycles that aren't
+// symlinks (e.g. a bind mount of a directory beneath itself) and also keeps
+// a directory that is reachable by more than one path from being walked more
+// than once. The directory that's reached first is the one that's walked,
+// which, since the walk is concurrent, is not necessarily the one with the
+// shortest path. Skipped directories are counted in
+// `Stats.DirectoryCyclesSkipped`.
+//
+// One entry is kept for every directory for the length of the run. This is
+// only supported on platforms that provide inode numbers (and with
+// `ChildStatter` implementations whose infos provide them) and has no effect
+// elsewhere.
+func (walk *Walk) SetDetectDirectoryCycles(isDetectDirectoryCycles bool) {
+	walk.isDetectDirectoryCycles = isDetectDirectoryCycles
+}
+
+// isDirectoryCycle returns true if we're detecting cycles and the given
+// directory has already been visited. Otherwise, it's recorded as visited.
+func (walk *Walk) isDirectoryCycle(parentNodePath string, info os.FileInfo) bool {
+	if walk.isDetectDirectoryCycles == false {
+		return false
+	}
+
+	id, ok := getDirectoryId(info)
+	if ok == false {
+		return false
+	}
+
+	walk.visitedDirectoriesLocker.Lock()
+
+	_, found := walk.visitedDirectories[id]
+	if found == false {
+		if walk.visitedDirectories == nil {
+			walk.visitedDirectories = make(map[directoryId]struct{})
+		}
+
+		walk.visitedDirectories[id] = struct{}{}
+	}
+
+	walk.visitedDirectoriesLocker.Unlock()
+
+	if found == false {
+		return false
+	}
+
+	fqPath := path.Join(parentNodePath, info.Name())
+
+	walk.logDebugf("Skipping directory that was already visited: [%s]", fqPath)
+
+	walk.notifySkip(fqPath, SkipDirectoryCycle)
+
+	walk.statsLocker.Lock()
+	walk.stats.DirectoryCyclesSkipped++
+	walk.statsLocker.Unlock()
+
+	return true
+}
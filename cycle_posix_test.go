@@ -0,0 +1,210 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly || solaris
+// +build linux darwin freebsd netbsd openbsd dragonfly solaris
+
+package pathwalk
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/dsoprea/go-logging"
+	"github.com/dsoprea/go-utility/filesystem"
+)
+
+// testLoopChildLister simulates a bind mount of the root at "/root/loop", so
+// "/root/loop", "/root/loop/loop", etc.. are all the same directory as the
+// root and have the same inode.
+type testLoopChildLister struct {
+	*testMapChildLister
+
+	inodes map[string]uint64
+}
+
+// canonicalPath returns the path with the loop removed.
+func (tlcl *testLoopChildLister) canonicalPath(nodePath string) string {
+	for strings.HasPrefix(nodePath, "/root/loop") == true {
+		nodePath = "/root" + strings.TrimPrefix(nodePath, "/root/loop")
+	}
+
+	return nodePath
+}
+
+func (tlcl *testLoopChildLister) ListChildren(nodePath string, batchSize int) (names []string, hasMore bool, err error) {
+	tlcl.locker.Lock()
+	defer tlcl.locker.Unlock()
+
+	children := tlcl.children[tlcl.canonicalPath(nodePath)]
+	offset := tlcl.offsets[nodePath]
+
+	end := offset + batchSize
+	if end > len(children) {
+		end = len(children)
+	}
+
+	tlcl.offsets[nodePath] = end
+
+	return children[offset:end], end < len(children), nil
+}
+
+func (tlcl *testLoopChildLister) StatChild(nodePath string) (info os.FileInfo, err error) {
+	canonicalPath := tlcl.canonicalPath(nodePath)
+
+	info, err = tlcl.testMapChildLister.StatChild(canonicalPath)
+	log.PanicIf(err)
+
+	stat := &syscall.Stat_t{}
+	stat.Dev = 1
+	stat.Ino = tlcl.inodes[canonicalPath]
+
+	info = testDeviceFileInfo{
+		FileInfo: testNamedFileInfo{FileInfo: info, name: path.Base(nodePath)},
+		stat:     stat,
+	}
+
+	return info, nil
+}
+
+// testNamedFileInfo reports the given name.
+type testNamedFileInfo struct {
+	os.FileInfo
+
+	name string
+}
+
+func (tnfi testNamedFileInfo) Name() string {
+	return tnfi.name
+}
+
+func newTestLoopChildLister() *testLoopChildLister {
+	return &testLoopChildLister{
+		testMapChildLister: &testMapChildLister{
+			children: map[string][]string{
+				"/root":     {"a", "sub", "loop"},
+				"/root/sub": {"b"},
+			},
+			offsets: make(map[string]int),
+		},
+		inodes: map[string]uint64{
+			"/root":       1,
+			"/root/a":     2,
+			"/root/sub":   3,
+			"/root/sub/b": 4,
+		},
+	}
+}
+
+func TestWalk_SetDetectDirectoryCycles__bindMountLoop(t *testing.T) {
+	m := sync.Mutex{}
+	visited := make([]string, 0)
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		visited = append(visited, path.Join(parentPath, info.Name()))
+
+		return nil
+	}
+
+	skipped := make([]string, 0)
+
+	skipFunc := func(fqPath string, reason SkipReason) {
+		m.Lock()
+		defer m.Unlock()
+
+		if reason == SkipDirectoryCycle {
+			skipped = append(skipped, fqPath)
+		}
+	}
+
+	walk := NewWalk("/root", walkFunc)
+	walk.SetChildLister(newTestLoopChildLister())
+	walk.SetSkipNotifyFunc(skipFunc)
+	walk.SetDetectDirectoryCycles(true)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	sort.Strings(visited)
+
+	expectedVisited := []string{
+		"/root",
+		"/root/a",
+		"/root/sub",
+		"/root/sub/b",
+	}
+
+	if reflect.DeepEqual(visited, expectedVisited) != true {
+		t.Fatalf("Visited not correct: %v", visited)
+	}
+
+	expectedSkipped := []string{
+		"/root/loop",
+	}
+
+	if reflect.DeepEqual(skipped, expectedSkipped) != true {
+		t.Fatalf("Skipped not correct: %v", skipped)
+	}
+
+	stats := walk.Stats()
+
+	if stats.DirectoryCyclesSkipped != 1 {
+		t.Fatalf("DirectoryCyclesSkipped not correct: (%d)", stats.DirectoryCyclesSkipped)
+	}
+}
+
+func TestWalk_isDirectoryCycle(t *testing.T) {
+	walk := NewWalk("/root", nil)
+	walk.SetDetectDirectoryCycles(true)
+
+	stat := &syscall.Stat_t{}
+	stat.Dev = 1
+	stat.Ino = 10
+
+	info := testDeviceFileInfo{
+		FileInfo: rifs.NewSimpleFileInfoWithDirectory("dir", time.Time{}),
+		stat:     stat,
+	}
+
+	if walk.isDirectoryCycle("/root", info) != false {
+		t.Fatalf("Expected the first visit to not be a cycle.")
+	} else if walk.isDirectoryCycle("/root/other", info) != true {
+		t.Fatalf("Expected the second visit to be a cycle.")
+	}
+
+	// The same inode on another device is a different directory.
+
+	otherStat := &syscall.Stat_t{}
+	otherStat.Dev = 2
+	otherStat.Ino = 10
+
+	otherInfo := testDeviceFileInfo{
+		FileInfo: rifs.NewSimpleFileInfoWithDirectory("dir", time.Time{}),
+		stat:     otherStat,
+	}
+
+	if walk.isDirectoryCycle("/root", otherInfo) != false {
+		t.Fatalf("Expected a different device to not be a cycle.")
+	}
+
+	// Infos without an inode are never considered cycles.
+
+	noInodeInfo := rifs.NewSimpleFileInfoWithDirectory("unknown", time.Time{})
+
+	if walk.isDirectoryCycle("/root", noInodeInfo) != false || walk.isDirectoryCycle("/root", noInodeInfo) != false {
+		t.Fatalf("Expected an info without an inode to not be a cycle.")
+	}
+
+	walk.SetDetectDirectoryCycles(false)
+
+	if walk.isDirectoryCycle("/root", info) != false {
+		t.Fatalf("Expected no check when disabled.")
+	}
+}
@@ -0,0 +1,44 @@
+package pathwalk
+
+import (
+	"path"
+	"strings"
+)
+
+// SetTrackDepthStats enables counting the visited entries at each depth (see
+// `Stats().EntriesByDepth`) in order to understand the shape of the tree. The
+// root is at depth zero and its children are at depth one.
+func (walk *Walk) SetTrackDepthStats(isTrackDepthStats bool) {
+	walk.isTrackDepthStats = isTrackDepthStats
+}
+
+// recordDepth counts visited entries at the given depth if we're tracking
+// depths.
+func (walk *Walk) recordDepth(depth int, count int) {
+	if walk.isTrackDepthStats == false {
+		return
+	}
+
+	walk.statsLocker.Lock()
+	defer walk.statsLocker.Unlock()
+
+	if walk.stats.EntriesByDepth == nil {
+		walk.stats.EntriesByDepth = make(map[int]int)
+	}
+
+	walk.stats.EntriesByDepth[depth] += count
+}
+
+// pathDepth returns the depth of the given full-path below the root. This is
+// only needed for jobs that aren't produced by their parents (seeded from a
+// checkpoint or a list).
+func (walk *Walk) pathDepth(fqPath string) int {
+	rootPath := path.Clean(walk.rootPath)
+	fqPath = path.Clean(fqPath)
+
+	if fqPath == rootPath || strings.HasPrefix(fqPath, rootPath+"/") == false {
+		return 0
+	}
+
+	return strings.Count(fqPath[len(rootPath)+1:], "/") + 1
+}
@@ -0,0 +1,138 @@
+package pathwalk
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+)
+
+func createDepthTestTree() (tempPath string) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	err = os.MkdirAll(path.Join(tempPath, "dir1", "dir2"), 0755)
+	log.PanicIf(err)
+
+	relFilepaths := []string{
+		"file0",
+		"dir1/file1",
+		"dir1/dir2/file2",
+		"dir1/dir2/file3",
+	}
+
+	for _, relFilepath := range relFilepaths {
+		err := ioutil.WriteFile(path.Join(tempPath, relFilepath), []byte{}, 0644)
+		log.PanicIf(err)
+	}
+
+	return tempPath
+}
+
+func TestWalk_SetTrackDepthStats(t *testing.T) {
+	tempPath := createDepthTestTree()
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetTrackDepthStats(true)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	expected := map[int]int{
+		0: 1,
+		1: 2,
+		2: 2,
+		3: 2,
+	}
+
+	entriesByDepth := walk.Stats().EntriesByDepth
+
+	if reflect.DeepEqual(entriesByDepth, expected) != true {
+		t.Fatalf("EntriesByDepth not correct: %v", entriesByDepth)
+	}
+
+	// The returned stats shouldn't share the map.
+
+	entriesByDepth[0] = 99
+
+	if walk.Stats().EntriesByDepth[0] != 1 {
+		t.Fatalf("Stats() shares the depth map.")
+	}
+}
+
+func TestWalk_SetTrackDepthStats__batchCallback(t *testing.T) {
+	tempPath := createDepthTestTree()
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		return nil
+	}
+
+	batchWalkFunc := func(parentPath string, infos []os.FileInfo) (err error) {
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetBatchCallback(batchWalkFunc)
+	walk.SetTrackDepthStats(true)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	expected := map[int]int{
+		0: 1,
+		1: 2,
+		2: 2,
+		3: 2,
+	}
+
+	if reflect.DeepEqual(walk.Stats().EntriesByDepth, expected) != true {
+		t.Fatalf("EntriesByDepth not correct: %v", walk.Stats().EntriesByDepth)
+	}
+}
+
+func TestWalk_SetTrackDepthStats__disabled(t *testing.T) {
+	tempPath := createDepthTestTree()
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walk := NewWalk(tempPath, nil)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	if walk.Stats().EntriesByDepth != nil {
+		t.Fatalf("EntriesByDepth should not be populated: %v", walk.Stats().EntriesByDepth)
+	}
+}
+
+func TestWalk_pathDepth(t *testing.T) {
+	walk := NewWalk("/root/path/", nil)
+
+	if walk.pathDepth("/root/path") != 0 {
+		t.Fatalf("Root depth not correct.")
+	} else if walk.pathDepth("/root/path/aa") != 1 {
+		t.Fatalf("Child depth not correct.")
+	} else if walk.pathDepth("/root/path/aa/bb/cc") != 3 {
+		t.Fatalf("Deep depth not correct.")
+	} else if walk.pathDepth("/other/path") != 0 {
+		t.Fatalf("Unrelated depth not correct.")
+	}
+}
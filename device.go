@@ -0,0 +1,34 @@
+package pathwalk
+
+import (
+	"os"
+)
+
+// SetStayOnFilesystem prevents the walk from descending into directories that
+// are on a different device (filesystem) than the root, like `find -xdev`.
+// The mount points themselves are still visited. This is only supported on
+// platforms that provide device IDs and has no effect elsewhere.
+func (walk *Walk) SetStayOnFilesystem(isStayOnFilesystem bool) {
+	walk.isStayOnFilesystem = isStayOnFilesystem
+}
+
+// recordRootDevice records the device of the root so that we can check for
+// mount points.
+func (walk *Walk) recordRootDevice(rootInfo os.FileInfo) {
+	walk.rootDeviceId, walk.hasRootDeviceId = getDeviceId(rootInfo)
+}
+
+// isOtherFilesystem returns true if we're staying on one filesystem and the
+// given directory is on a different one.
+func (walk *Walk) isOtherFilesystem(info os.FileInfo) bool {
+	if walk.isStayOnFilesystem == false || walk.hasRootDeviceId == false {
+		return false
+	}
+
+	deviceId, ok := getDeviceId(info)
+	if ok == false {
+		return false
+	}
+
+	return deviceId != walk.rootDeviceId
+}
@@ -0,0 +1,20 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly && !solaris
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly,!solaris
+
+package pathwalk
+
+import (
+	"os"
+)
+
+// getDeviceId always fails since device IDs are not supported on this
+// platform. Staying on one filesystem will have no effect.
+func getDeviceId(info os.FileInfo) (deviceId uint64, ok bool) {
+	return 0, false
+}
+
+// getInode always fails since inodes are not supported on this platform.
+// Cycle detection will have no effect.
+func getInode(info os.FileInfo) (inode uint64, ok bool) {
+	return 0, false
+}
@@ -0,0 +1,30 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly || solaris
+// +build linux darwin freebsd netbsd openbsd dragonfly solaris
+
+package pathwalk
+
+import (
+	"os"
+	"syscall"
+)
+
+// getDeviceId returns the ID of the device that the given node is on if it's
+// available.
+func getDeviceId(info os.FileInfo) (deviceId uint64, ok bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if ok == false {
+		return 0, false
+	}
+
+	return uint64(stat.Dev), true
+}
+
+// getInode returns the inode of the given node if it's available.
+func getInode(info os.FileInfo) (inode uint64, ok bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if ok == false {
+		return 0, false
+	}
+
+	return uint64(stat.Ino), true
+}
@@ -0,0 +1,94 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly || solaris
+// +build linux darwin freebsd netbsd openbsd dragonfly solaris
+
+package pathwalk
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/dsoprea/go-utility/filesystem"
+)
+
+// testDeviceFileInfo reports the given device.
+type testDeviceFileInfo struct {
+	os.FileInfo
+
+	stat *syscall.Stat_t
+}
+
+func (tdfi testDeviceFileInfo) Sys() interface{} {
+	return tdfi.stat
+}
+
+func TestWalk_isOtherFilesystem(t *testing.T) {
+	walk := NewWalk("/root", nil)
+	walk.SetStayOnFilesystem(true)
+
+	rootStat := &syscall.Stat_t{}
+	rootStat.Dev = 1
+
+	rootInfo := testDeviceFileInfo{
+		FileInfo: rifs.NewSimpleFileInfoWithDirectory("root", time.Time{}),
+		stat:     rootStat,
+	}
+
+	walk.recordRootDevice(rootInfo)
+
+	if walk.isOtherFilesystem(rootInfo) != false {
+		t.Fatalf("Expected the root to be on the same filesystem.")
+	}
+
+	otherStat := &syscall.Stat_t{}
+	otherStat.Dev = 2
+
+	otherInfo := testDeviceFileInfo{
+		FileInfo: rifs.NewSimpleFileInfoWithDirectory("mount", time.Time{}),
+		stat:     otherStat,
+	}
+
+	if walk.isOtherFilesystem(otherInfo) != true {
+		t.Fatalf("Expected a different device to be a different filesystem.")
+	}
+
+	// Infos without a device are never considered to be elsewhere.
+
+	noDeviceInfo := rifs.NewSimpleFileInfoWithDirectory("unknown", time.Time{})
+
+	if walk.isOtherFilesystem(noDeviceInfo) != false {
+		t.Fatalf("Expected an info without a device to be on the same filesystem.")
+	}
+
+	walk.SetStayOnFilesystem(false)
+
+	if walk.isOtherFilesystem(otherInfo) != false {
+		t.Fatalf("Expected no check when disabled.")
+	}
+}
+
+func TestGetInode(t *testing.T) {
+	stat := &syscall.Stat_t{}
+	stat.Ino = 123
+
+	info := testDeviceFileInfo{
+		FileInfo: rifs.NewSimpleFileInfoWithDirectory("dir", time.Time{}),
+		stat:     stat,
+	}
+
+	inode, ok := getInode(info)
+	if ok != true {
+		t.Fatalf("Expected an inode.")
+	} else if inode != 123 {
+		t.Fatalf("Inode not correct: (%d)", inode)
+	}
+
+	// Infos without a stat have no inode.
+
+	noInodeInfo := rifs.NewSimpleFileInfoWithDirectory("unknown", time.Time{})
+
+	if _, ok := getInode(noInodeInfo); ok != false {
+		t.Fatalf("Expected no inode.")
+	}
+}
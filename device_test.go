@@ -0,0 +1,37 @@
+package pathwalk
+
+import (
+	"os"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-parallel-walker/internal/testing"
+)
+
+func TestWalk_SetStayOnFilesystem(t *testing.T) {
+	fileCount := 20
+	tempPath, _ := pwtesting.FillHeirarchicalTempPath(fileCount, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetStayOnFilesystem(true)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	stats := walk.Stats()
+
+	if stats.FilesVisited != fileCount {
+		t.Fatalf("FilesVisited not correct: (%d)", stats.FilesVisited)
+	} else if stats.MountPointsSkipped != 0 {
+		t.Fatalf("MountPointsSkipped not correct: (%d)", stats.MountPointsSkipped)
+	}
+}
@@ -0,0 +1,166 @@
+package pathwalk
+
+import (
+	"errors"
+	"os"
+	"sync/atomic"
+)
+
+var (
+	// ErrPathOutsideRoot is returned if a descriptor-relative walk is asked to
+	// resolve a path that is not below its root or if a listed path is not
+	// below the root.
+	ErrPathOutsideRoot = errors.New("path is not under the root")
+)
+
+// childOpener can optionally be implemented by a `ChildLister` in order to
+// control how files are opened for reading (e.g. for content filtering).
+type childOpener interface {
+	openChild(path string) (f *os.File, err error)
+}
+
+// directoryHolder can optionally be implemented by a `ChildLister` in order to
+// keep a directory open while its children are being resolved so that they
+// can be opened relative to it rather than from the root.
+type directoryHolder interface {
+	// holdDirectory opens the given directory relative to the given parent (or
+	// however it otherwise would if that's nil).
+	holdDirectory(parent *dirHandle, path string) (dh *dirHandle, err error)
+}
+
+// dirHandle is an open directory that is shared by the jobs that resolve its
+// children. Every job that carries it holds a reference, and it's closed once
+// the last one is released.
+type dirHandle struct {
+	// refs is accessed atomically.
+	refs int32
+
+	path string
+	f    *os.File
+
+	// onClose is called once the last reference is released, before the
+	// directory is closed.
+	onClose func(dh *dirHandle)
+}
+
+func newDirHandle(path string, f *os.File, onClose func(dh *dirHandle)) *dirHandle {
+	return &dirHandle{
+		refs:    1,
+		path:    path,
+		f:       f,
+		onClose: onClose,
+	}
+}
+
+// acquire adds a reference for a new holder and returns the handle. The
+// caller must already hold one. Nil handles are allowed.
+func (dh *dirHandle) acquire() *dirHandle {
+	if dh == nil {
+		return nil
+	}
+
+	atomic.AddInt32(&dh.refs, 1)
+
+	return dh
+}
+
+// tryAcquire adds a reference unless the last one has already been released.
+func (dh *dirHandle) tryAcquire() bool {
+	for {
+		refs := atomic.LoadInt32(&dh.refs)
+		if refs <= 0 {
+			return false
+		}
+
+		if atomic.CompareAndSwapInt32(&dh.refs, refs, refs+1) == true {
+			return true
+		}
+	}
+}
+
+// release drops a reference and closes the directory if it was the last one.
+// Nil handles are allowed.
+func (dh *dirHandle) release() {
+	if dh == nil {
+		return
+	}
+
+	if atomic.AddInt32(&dh.refs, -1) != 0 {
+		return
+	}
+
+	if dh.onClose != nil {
+		dh.onClose(dh)
+	}
+
+	dh.f.Close()
+}
+
+// releaseJobDirectories releases the directory handles carried by the given
+// job. This has to happen once the job has been handled or discarded.
+func releaseJobDirectories(j job) {
+	switch t := j.(type) {
+	case jobDirectoryNode:
+		t.parentDir.release()
+	case jobDirectoryContentsBatch:
+		t.dir.release()
+	case jobCoalescedBatches:
+		for _, jdcb := range t.batches {
+			jdcb.dir.release()
+		}
+	}
+}
+
+// NewWalkFromDir returns a walk that is anchored to an already-opened
+// directory rather than to a path. On platforms that support it (Linux), every
+// directory and file is opened relative to the given handle one component at
+// a time without following symlinks, so the walk is immune to the parent (or
+// any directory within the tree) being swapped out for a symlink, and it is
+// not subject to path-length limits. A directory is kept open while any of its
+// children are still waiting to be resolved so that they can be opened
+// relative to it. Symlinks are reported as themselves and are never descended
+// into. Reported paths are still prefixed with the name
+// that the directory was opened with.
+//
+// On other platforms, this falls back to walking the path that the directory
+// was opened with.
+//
+// The directory must stay open for as long as the walk is being used.
+func NewWalkFromDir(dir *os.File, walkFunc WalkFunc) (walk *Walk) {
+	walk = NewWalk(dir.Name(), walkFunc)
+
+	if childLister := newDirChildLister(dir); childLister != nil {
+		walk.SetChildLister(childLister)
+	}
+
+	return walk
+}
+
+// openChild opens the given file for reading, using the child-lister if it
+// knows how.
+func (walk *Walk) openChild(path string) (f *os.File, err error) {
+	walk.injectLatency()
+
+	if co, ok := walk.childLister.(childOpener); ok == true {
+		return co.openChild(path)
+	}
+
+	return os.Open(path)
+}
+
+// holdDirectory returns a handle for the given directory if the child-lister
+// can resolve nodes relative to one. Otherwise, or if the directory can't be
+// opened (listing it will then fail the same way), it returns nil.
+func (walk *Walk) holdDirectory(parent *dirHandle, path string) (dh *dirHandle) {
+	holder, ok := walk.childLister.(directoryHolder)
+	if ok == false {
+		return nil
+	}
+
+	dh, err := holder.holdDirectory(parent, path)
+	if err != nil {
+		return nil
+	}
+
+	return dh
+}
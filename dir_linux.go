@@ -0,0 +1,197 @@
+package pathwalk
+
+import (
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/dsoprea/go-logging"
+)
+
+const (
+	// openPathFlag is O_PATH, which the "syscall" package doesn't define on
+	// every architecture. It has the same value on all of the architectures
+	// that Go supports.
+	openPathFlag = 0x200000
+)
+
+// dirChildLister lists and stats nodes relative to an open root directory.
+// While a directory is held by the walk, its children are opened relative to
+// it.
+type dirChildLister struct {
+	*filesystemChildLister
+
+	root     *os.File
+	rootPath string
+
+	// held are the directories that are currently held, by path.
+	held       map[string]*dirHandle
+	heldLocker sync.Mutex
+}
+
+func newDirChildLister(dir *os.File) ChildLister {
+	dcl := &dirChildLister{
+		filesystemChildLister: newFilesystemChildLister(),
+		root:                  dir,
+		rootPath:              dir.Name(),
+		held:                  make(map[string]*dirHandle),
+	}
+
+	dcl.openFunc = dcl.openDirectory
+
+	return dcl
+}
+
+// openAt opens the given name relative to the given directory without
+// following it if it's a symlink.
+func openAt(dir *os.File, name string, flags int, nodePath string) (f *os.File, err error) {
+	fd, err := syscall.Openat(int(dir.Fd()), name, flags|syscall.O_NOFOLLOW|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, &os.PathError{Op: "openat", Path: nodePath, Err: err}
+	}
+
+	return os.NewFile(uintptr(fd), nodePath), nil
+}
+
+// holdDirectory opens the given directory relative to the parent's handle, or
+// however `openRelative()` would if there isn't one, and keeps it open until
+// the last reference to it is released.
+func (dcl *dirChildLister) holdDirectory(parent *dirHandle, nodePath string) (dh *dirHandle, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	var f *os.File
+	if parent != nil && parent.path == path.Dir(nodePath) {
+		f, err = openAt(parent.f, path.Base(nodePath), syscall.O_RDONLY|syscall.O_DIRECTORY, nodePath)
+	} else {
+		f, err = dcl.openRelative(nodePath, syscall.O_RDONLY|syscall.O_DIRECTORY)
+	}
+
+	log.PanicIf(err)
+
+	dh = newDirHandle(nodePath, f, dcl.forgetHeld)
+
+	dcl.heldLocker.Lock()
+	dcl.held[nodePath] = dh
+	dcl.heldLocker.Unlock()
+
+	return dh, nil
+}
+
+// forgetHeld stops resolving relative to the given directory once it has been
+// released.
+func (dcl *dirChildLister) forgetHeld(dh *dirHandle) {
+	dcl.heldLocker.Lock()
+	defer dcl.heldLocker.Unlock()
+
+	// It might have been held again since.
+	if dcl.held[dh.path] == dh {
+		delete(dcl.held, dh.path)
+	}
+}
+
+// findHeld returns the given directory with a new reference if it's held.
+func (dcl *dirChildLister) findHeld(directoryPath string) (dh *dirHandle, found bool) {
+	dcl.heldLocker.Lock()
+	dh, found = dcl.held[directoryPath]
+	dcl.heldLocker.Unlock()
+
+	if found == false || dh.tryAcquire() == false {
+		return nil, false
+	}
+
+	return dh, true
+}
+
+// openRelative opens the given path relative to its parent if the parent is
+// held. Otherwise, it descends from the root one component at a time.
+// Intermediate components must be directories and are never followed if they
+// are symlinks. `flags` applies to the last component.
+func (dcl *dirChildLister) openRelative(nodePath string, flags int) (f *os.File, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	var parts []string
+	if nodePath != dcl.rootPath {
+		if strings.HasPrefix(nodePath, dcl.rootPath+"/") == false {
+			log.Panic(ErrPathOutsideRoot)
+		}
+
+		if parent, found := dcl.findHeld(path.Dir(nodePath)); found == true {
+			defer parent.release()
+
+			return openAt(parent.f, path.Base(nodePath), flags, nodePath)
+		}
+
+		parts = strings.Split(nodePath[len(dcl.rootPath)+1:], "/")
+	}
+
+	rootFd := int(dcl.root.Fd())
+
+	if len(parts) == 0 {
+		fd, err := syscall.Dup(rootFd)
+		log.PanicIf(err)
+
+		return os.NewFile(uintptr(fd), nodePath), nil
+	}
+
+	currentFd := rootFd
+	for i, part := range parts {
+		currentFlags := syscall.O_RDONLY | syscall.O_DIRECTORY | syscall.O_NOFOLLOW | syscall.O_CLOEXEC
+		if i == len(parts)-1 {
+			currentFlags = flags | syscall.O_NOFOLLOW | syscall.O_CLOEXEC
+		}
+
+		fd, err := syscall.Openat(currentFd, part, currentFlags, 0)
+
+		if currentFd != rootFd {
+			syscall.Close(currentFd)
+		}
+
+		if err != nil {
+			log.Panic(&os.PathError{Op: "openat", Path: path.Join(dcl.rootPath, path.Join(parts[:i+1]...)), Err: err})
+		}
+
+		currentFd = fd
+	}
+
+	return os.NewFile(uintptr(currentFd), nodePath), nil
+}
+
+// openDirectory opens the given directory for listing.
+func (dcl *dirChildLister) openDirectory(nodePath string) (f *os.File, err error) {
+	return dcl.openRelative(nodePath, syscall.O_RDONLY|syscall.O_DIRECTORY)
+}
+
+// openChild opens the given file for reading.
+func (dcl *dirChildLister) openChild(nodePath string) (f *os.File, err error) {
+	return dcl.openRelative(nodePath, syscall.O_RDONLY)
+}
+
+// StatChild returns the info for the given node without following it if it's
+// a symlink.
+func (dcl *dirChildLister) StatChild(nodePath string) (info os.FileInfo, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	f, err := dcl.openRelative(nodePath, openPathFlag)
+	log.PanicIf(err)
+
+	defer f.Close()
+
+	info, err = f.Stat()
+	log.PanicIf(err)
+
+	return info, nil
+}
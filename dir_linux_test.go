@@ -0,0 +1,199 @@
+package pathwalk
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-parallel-walker/internal/testing"
+)
+
+func TestNewWalkFromDir__anchored(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	rootPath := path.Join(tempPath, "root")
+
+	err = os.MkdirAll(path.Join(rootPath, "subdir"), 0755)
+	log.PanicIf(err)
+
+	err = ioutil.WriteFile(path.Join(rootPath, "subdir", "file"), []byte{}, 0644)
+	log.PanicIf(err)
+
+	// A symlink out of the tree should be reported but not descended into.
+
+	outsidePath := path.Join(tempPath, "outside")
+
+	err = os.Mkdir(outsidePath, 0755)
+	log.PanicIf(err)
+
+	err = ioutil.WriteFile(path.Join(outsidePath, "secret"), []byte{}, 0644)
+	log.PanicIf(err)
+
+	err = os.Symlink(outsidePath, path.Join(rootPath, "link"))
+	log.PanicIf(err)
+
+	dir, err := os.Open(rootPath)
+	log.PanicIf(err)
+
+	defer dir.Close()
+
+	// Move the root out from under the walk. The walk should still find
+	// everything since it's anchored to the handle.
+
+	err = os.Rename(rootPath, path.Join(tempPath, "moved"))
+	log.PanicIf(err)
+
+	m := sync.Mutex{}
+	visited := make([]string, 0)
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		visited = append(visited, path.Join(parentPath, info.Name()))
+
+		return nil
+	}
+
+	walk := NewWalkFromDir(dir, walkFunc)
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	sort.Strings(visited)
+
+	expected := []string{
+		rootPath,
+		path.Join(rootPath, "link"),
+		path.Join(rootPath, "subdir"),
+		path.Join(rootPath, "subdir", "file"),
+	}
+
+	if reflect.DeepEqual(visited, expected) != true {
+		t.Fatalf("Visited not correct: %v", visited)
+	}
+}
+
+func TestDirChildLister_openRelative__outsideRoot(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	dir, err := os.Open(tempPath)
+	log.PanicIf(err)
+
+	defer dir.Close()
+
+	dcl := newDirChildLister(dir).(*dirChildLister)
+
+	_, err = dcl.openRelative("/some/other/path", openPathFlag)
+	if err == nil || log.Is(err, ErrPathOutsideRoot) != true {
+		t.Fatalf("Expected outside-root error: %v", err)
+	}
+}
+
+func TestDirChildLister_holdDirectory(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	err = os.MkdirAll(path.Join(tempPath, "a", "b"), 0755)
+	log.PanicIf(err)
+
+	err = ioutil.WriteFile(path.Join(tempPath, "a", "b", "file"), []byte{}, 0644)
+	log.PanicIf(err)
+
+	dir, err := os.Open(tempPath)
+	log.PanicIf(err)
+
+	defer dir.Close()
+
+	dcl := newDirChildLister(dir).(*dirChildLister)
+
+	rootHandle, err := dcl.holdDirectory(nil, tempPath)
+	log.PanicIf(err)
+
+	aHandle, err := dcl.holdDirectory(rootHandle, path.Join(tempPath, "a"))
+	log.PanicIf(err)
+
+	bHandle, err := dcl.holdDirectory(aHandle, path.Join(tempPath, "a", "b"))
+	log.PanicIf(err)
+
+	rootHandle.release()
+	aHandle.release()
+
+	if len(dcl.held) != 1 {
+		t.Fatalf("Only the last directory should still be held: (%d)", len(dcl.held))
+	}
+
+	// Move an intermediate directory. The child of the held directory is
+	// still found since it's opened relative to it rather than from the root.
+
+	err = os.Rename(path.Join(tempPath, "a"), path.Join(tempPath, "moved"))
+	log.PanicIf(err)
+
+	filepath := path.Join(tempPath, "a", "b", "file")
+
+	f, err := dcl.openChild(filepath)
+	log.PanicIf(err)
+
+	f.Close()
+
+	// Once released, it's resolved from the root again.
+
+	bHandle.release()
+
+	if len(dcl.held) != 0 {
+		t.Fatalf("Directories are still held: (%d)", len(dcl.held))
+	}
+
+	_, err = dcl.openChild(filepath)
+	if err == nil {
+		t.Fatalf("Expected the moved directory to not be found.")
+	}
+}
+
+func TestNewWalkFromDir__releasesDirectories(t *testing.T) {
+	tempPath, tempFiles := pwtesting.FillHeirarchicalTempPath(50, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	dir, err := os.Open(tempPath)
+	log.PanicIf(err)
+
+	defer dir.Close()
+
+	walk := NewWalkFromDir(dir, nil)
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	if walk.Stats().FilesVisited != len(tempFiles) {
+		t.Fatalf("FilesVisited not correct: (%d) != (%d)", walk.Stats().FilesVisited, len(tempFiles))
+	}
+
+	dcl := walk.childLister.(*dirChildLister)
+	if len(dcl.held) != 0 {
+		t.Fatalf("Directories are still held: (%d)", len(dcl.held))
+	}
+}
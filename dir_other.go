@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+package pathwalk
+
+import (
+	"os"
+)
+
+// newDirChildLister returns nil since descriptor-relative traversal is not
+// supported on this platform. The walk will fall back to the path.
+func newDirChildLister(dir *os.File) ChildLister {
+	return nil
+}
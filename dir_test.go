@@ -0,0 +1,61 @@
+package pathwalk
+
+import (
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-parallel-walker/internal/testing"
+)
+
+func TestNewWalkFromDir(t *testing.T) {
+	fileCount := 50
+	tempPath, tempFiles := pwtesting.FillHeirarchicalTempPath(fileCount, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	dir, err := os.Open(tempPath)
+	log.PanicIf(err)
+
+	defer dir.Close()
+
+	m := sync.Mutex{}
+	visited := make(sort.StringSlice, 0)
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		if info.IsDir() == true {
+			return nil
+		}
+
+		m.Lock()
+		defer m.Unlock()
+
+		relFilepath := path.Join(parentPath, info.Name())[len(tempPath)+1:]
+		visited = append(visited, relFilepath)
+
+		return nil
+	}
+
+	walk := NewWalkFromDir(dir, walkFunc)
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	visited.Sort()
+
+	if len(visited) != len(tempFiles) {
+		t.Fatalf("Visited files not correct: (%d) != (%d)", len(visited), len(tempFiles))
+	}
+
+	for i, relFilepath := range tempFiles {
+		if visited[i] != relFilepath {
+			t.Fatalf("Visited file (%d) not correct: [%s] != [%s]", i, visited[i], relFilepath)
+		}
+	}
+}
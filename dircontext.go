@@ -0,0 +1,88 @@
+package pathwalk
+
+import (
+	"os"
+	"path"
+	"time"
+)
+
+// ContextualDirFunc is called for every directory with the value that was
+// returned for its parent directory (nil for the root) and returns the value
+// to pass to the directory's children. Returning `ErrSkipDirectory` will skip
+// the contents of the directory. Any other error will terminate the walk.
+type ContextualDirFunc func(parentCtx interface{}, path string, info os.FileInfo) (childCtx interface{}, err error)
+
+// ContextualFileFunc is called for every file with the value that was
+// returned for its directory. Returning an error will terminate the walk.
+type ContextualFileFunc func(parentCtx interface{}, parentPath string, info os.FileInfo) (err error)
+
+// SetContextualDirFunc sets a callback that can thread per-subtree state
+// (e.g. configuration that accumulates as we descend) down the tree without a
+// global map. It's called for every directory, regardless of the path
+// filters, so that the values of deeper directories are still derived from
+// every ancestor. The path is formatted the same way as for the regular
+// callback.
+//
+// The children of a directory are processed concurrently, so the same value
+// will be used from multiple goroutines at once. It should be treated as
+// immutable: derive a new value for a subdirectory rather than modifying the
+// one that was received.
+//
+// When resuming from a checkpoint, the directories that are seeded from the
+// checkpoint receive a nil value since their ancestors aren't revisited.
+func (walk *Walk) SetContextualDirFunc(contextualDirFunc ContextualDirFunc) {
+	walk.contextualDirFunc = contextualDirFunc
+}
+
+// SetContextualFileFunc sets a callback that receives every file along with
+// the value that was returned by the contextual directory callback for its
+// directory. It's called after the regular callbacks. If a batch callback was
+// set, files are only delivered to it and not to this callback.
+func (walk *Walk) SetContextualFileFunc(contextualFileFunc ContextualFileFunc) {
+	walk.contextualFileFunc = contextualFileFunc
+}
+
+// callContextualDirFunc returns the value for the children of the given
+// directory.
+func (walk *Walk) callContextualDirFunc(jdn jobDirectoryNode) (childCtx interface{}, err error) {
+	if walk.contextualDirFunc == nil {
+		return nil, nil
+	}
+
+	parentNodePath, info := jdn.ParentNodePath(), jdn.Info()
+	if walk.isReformattingReportedPaths() == true {
+		parentNodePath, info = walk.reportPath(parentNodePath, info)
+	}
+
+	startTime := time.Now()
+
+	defer func() {
+		duration := time.Since(startTime)
+
+		walk.hotStats.addCallbackTime(duration)
+	}()
+
+	return walk.contextualDirFunc(jdn.dirContext, path.Join(parentNodePath, info.Name()), info)
+}
+
+// callContextualFileFunc delivers one file to the contextual file callback.
+func (walk *Walk) callContextualFileFunc(jfn jobFileNode) (err error) {
+	if walk.contextualFileFunc == nil {
+		return nil
+	}
+
+	parentNodePath, info := jfn.ParentNodePath(), jfn.Info()
+	if walk.isReformattingReportedPaths() == true {
+		parentNodePath, info = walk.reportPath(parentNodePath, info)
+	}
+
+	startTime := time.Now()
+
+	defer func() {
+		duration := time.Since(startTime)
+
+		walk.hotStats.addCallbackTime(duration)
+	}()
+
+	return walk.contextualFileFunc(jfn.dirContext, parentNodePath, info)
+}
@@ -0,0 +1,145 @@
+package pathwalk
+
+import (
+	"os"
+	"path"
+	"sync"
+	"testing"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+)
+
+func TestWalk_SetContextualDirFunc(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	err = os.MkdirAll(path.Join(tempPath, "dir1", "dir2"), 0755)
+	log.PanicIf(err)
+
+	err = os.MkdirAll(path.Join(tempPath, "dir3"), 0755)
+	log.PanicIf(err)
+
+	filepaths := []string{
+		"file0",
+		"dir1/file1",
+		"dir1/dir2/file2",
+		"dir3/file3",
+	}
+
+	for _, relFilepath := range filepaths {
+		err := ioutil.WriteFile(path.Join(tempPath, relFilepath), []byte{}, 0644)
+		log.PanicIf(err)
+	}
+
+	// Each directory's value is its relative path, which is derived solely
+	// from the value of its parent.
+	contextualDirFunc := func(parentCtx interface{}, dirPath string, info os.FileInfo) (childCtx interface{}, err error) {
+		if parentCtx == nil {
+			if dirPath != tempPath {
+				t.Fatalf("Only the root should receive a nil value: [%s]", dirPath)
+			}
+
+			return "", nil
+		}
+
+		return path.Join(parentCtx.(string), info.Name()), nil
+	}
+
+	m := sync.Mutex{}
+	fileContexts := make(map[string]string)
+
+	contextualFileFunc := func(parentCtx interface{}, parentPath string, info os.FileInfo) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		fileContexts[info.Name()] = parentCtx.(string)
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, nil)
+	walk.SetContextualDirFunc(contextualDirFunc)
+	walk.SetContextualFileFunc(contextualFileFunc)
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	expected := map[string]string{
+		"file0": "",
+		"file1": "dir1",
+		"file2": "dir1/dir2",
+		"file3": "dir3",
+	}
+
+	if len(fileContexts) != len(expected) {
+		t.Fatalf("Files not correct: %v", fileContexts)
+	}
+
+	for filename, expectedCtx := range expected {
+		if fileContexts[filename] != expectedCtx {
+			t.Fatalf("Value for [%s] not correct: [%s] != [%s]", filename, fileContexts[filename], expectedCtx)
+		}
+	}
+}
+
+func TestWalk_SetContextualDirFunc__skipDirectory(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	err = os.MkdirAll(path.Join(tempPath, "dir1"), 0755)
+	log.PanicIf(err)
+
+	err = ioutil.WriteFile(path.Join(tempPath, "dir1", "file1"), []byte{}, 0644)
+	log.PanicIf(err)
+
+	err = ioutil.WriteFile(path.Join(tempPath, "file2"), []byte{}, 0644)
+	log.PanicIf(err)
+
+	contextualDirFunc := func(parentCtx interface{}, dirPath string, info os.FileInfo) (childCtx interface{}, err error) {
+		if info.Name() == "dir1" {
+			return nil, ErrSkipDirectory
+		}
+
+		return nil, nil
+	}
+
+	m := sync.Mutex{}
+	visited := make([]string, 0)
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		visited = append(visited, info.Name())
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetContextualDirFunc(contextualDirFunc)
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	for _, name := range visited {
+		if name == "file1" {
+			t.Fatalf("Contents of the skipped directory were visited.")
+		}
+	}
+
+	if walk.Stats().DirectoriesIgnored != 1 {
+		t.Fatalf("DirectoriesIgnored not correct: (%d)", walk.Stats().DirectoriesIgnored)
+	} else if walk.Stats().FilesVisited != 1 {
+		t.Fatalf("FilesVisited not correct: (%d)", walk.Stats().FilesVisited)
+	}
+}
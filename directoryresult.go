@@ -0,0 +1,88 @@
+package pathwalk
+
+import (
+	"path"
+	"sort"
+
+	"github.com/dsoprea/go-logging"
+)
+
+// DirectoryResultFunc receives the entries of one directory, sorted by name.
+// Returning an error will terminate the walk.
+type DirectoryResultFunc func(directoryPath string, entries []WalkEntry) (err error)
+
+// SetDirectoryResultFunc sets a callback that receives the entries (files and
+// subdirectories) of each directory all at once, sorted by name, so that the
+// output for any one directory is the same from run to run even though the
+// directories are processed in parallel. The entries are collected as they're
+// delivered to the regular callbacks and are only passed on once the
+// directory and everything below it has been processed (post-order), so a
+// directory's result always follows the results of its subdirectories. The
+// path is formatted the same way as for the regular callback. This enables
+// the same directory tracking that checkpoints use and must be set before
+// calling `Run()`.
+//
+// The callback is called concurrently for unrelated directories. Directories
+// that have no delivered entries (e.g. empty or entirely filtered) are not
+// reported, and neither is the root, since it isn't an entry of any walked
+// directory. As with the visitors, files are only delivered to the batch
+// callback if one was set and they aren't included here.
+//
+// Every entry is held until its directory is complete. This costs memory in
+// proportion to the entries of all of the directories that are in progress
+// at the same time, so a single huge directory will be held in its entirety.
+func (walk *Walk) SetDirectoryResultFunc(directoryResultFunc DirectoryResultFunc) {
+	walk.directoryResultFunc = directoryResultFunc
+}
+
+// bufferDirectoryResult holds the given entry until its directory is
+// complete. `fqParentPath` is the unformatted path of the directory.
+func (walk *Walk) bufferDirectoryResult(fqParentPath string, entry WalkEntry) {
+	if walk.directoryResultFunc == nil {
+		return
+	}
+
+	fqPath := path.Join(fqParentPath, entry.Info.Name())
+	if fqPath == path.Clean(walk.rootPathOf(fqPath)) {
+		// The root doesn't belong to any directory that we'll complete.
+		return
+	}
+
+	walk.directoryResultsLocker.Lock()
+	defer walk.directoryResultsLocker.Unlock()
+
+	if walk.directoryResults == nil {
+		walk.directoryResults = make(map[string][]WalkEntry)
+	}
+
+	walk.directoryResults[fqParentPath] = append(walk.directoryResults[fqParentPath], entry)
+}
+
+// deliverDirectoryResult passes the held entries of the given completed
+// directory to the callback.
+func (walk *Walk) deliverDirectoryResult(ds DirectorySummary) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	walk.directoryResultsLocker.Lock()
+	entries, found := walk.directoryResults[ds.Path]
+	delete(walk.directoryResults, ds.Path)
+	walk.directoryResultsLocker.Unlock()
+
+	if found == false {
+		return nil
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Info.Name() < entries[j].Info.Name()
+	})
+
+	// All of the entries have the same, formatted parent path.
+	err = walk.directoryResultFunc(entries[0].ParentPath, entries)
+	log.PanicIf(err)
+
+	return nil
+}
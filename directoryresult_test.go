@@ -0,0 +1,125 @@
+package pathwalk
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"reflect"
+	"sync"
+	"testing"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+)
+
+func TestWalk_SetDirectoryResultFunc(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	err = os.MkdirAll(path.Join(tempPath, "dir1", "dir2"), 0755)
+	log.PanicIf(err)
+
+	err = os.MkdirAll(path.Join(tempPath, "empty"), 0755)
+	log.PanicIf(err)
+
+	// Enough files to be spread over several batches (and workers).
+
+	filenames := make([]string, 0)
+	for i := 0; i < 20; i++ {
+		filenames = append(filenames, fmt.Sprintf("file%02d", i))
+	}
+
+	expected := map[string][]string{
+		tempPath:                         append([]string{"dir1", "empty"}, filenames...),
+		path.Join(tempPath, "dir1"):      append([]string{"dir2"}, filenames...),
+		path.Join(tempPath, "dir1/dir2"): filenames,
+	}
+
+	for directoryPath := range expected {
+		for _, filename := range filenames {
+			err := ioutil.WriteFile(path.Join(directoryPath, filename), []byte{}, 0644)
+			log.PanicIf(err)
+		}
+	}
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		return nil
+	}
+
+	m := sync.Mutex{}
+	results := make(map[string][]string)
+	order := make([]string, 0)
+
+	directoryResultFunc := func(directoryPath string, entries []WalkEntry) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		if _, found := results[directoryPath]; found == true {
+			t.Fatalf("Directory reported more than once: [%s]", directoryPath)
+		}
+
+		names := make([]string, len(entries))
+		for i, entry := range entries {
+			if entry.ParentPath != directoryPath {
+				t.Fatalf("Entry not in the directory: [%s] [%s]", entry.ParentPath, directoryPath)
+			}
+
+			names[i] = entry.Info.Name()
+		}
+
+		results[directoryPath] = names
+		order = append(order, directoryPath)
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetDirectoryResultFunc(directoryResultFunc)
+	walk.SetBatchSize(3)
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	if reflect.DeepEqual(results, expected) != true {
+		t.Fatalf("Results not correct: %v", results)
+	}
+
+	// Post-order.
+	if len(order) != 3 || order[0] != path.Join(tempPath, "dir1/dir2") || order[2] != tempPath {
+		t.Fatalf("Order not correct: %v", order)
+	}
+}
+
+func TestWalk_SetDirectoryResultFunc__error(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	err = ioutil.WriteFile(path.Join(tempPath, "file1"), []byte{}, 0644)
+	log.PanicIf(err)
+
+	errTest := errors.New("test error")
+
+	directoryResultFunc := func(directoryPath string, entries []WalkEntry) (err error) {
+		return errTest
+	}
+
+	walk := NewWalk(tempPath, nil)
+	walk.SetDirectoryResultFunc(directoryResultFunc)
+
+	err = walk.Run()
+	if err == nil {
+		t.Fatalf("Expected error.")
+	} else if err.Error() != "worker terminated under error: "+errTest.Error() {
+		t.Fatalf("Error not correct: [%v]", err)
+	}
+}
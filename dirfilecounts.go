@@ -0,0 +1,43 @@
+package pathwalk
+
+// SetTrackDirectoryFileCounts enables counting the files directly in each
+// directory (see `DirectoryFileCounts()`). Only the files that pass the filters
+// are counted, and subdirectories and the files below them are not. This is
+// much cheaper than aggregating the whole subtree of each directory.
+func (walk *Walk) SetTrackDirectoryFileCounts(isTrackDirectoryFileCounts bool) {
+	walk.isTrackDirectoryFileCounts = isTrackDirectoryFileCounts
+}
+
+// DirectoryFileCounts returns the number of files directly in each directory
+// that was visited during the last run, keyed by the full-path of the
+// directory. Directories that had no included files are omitted. This is only
+// populated if enabled with `SetTrackDirectoryFileCounts()`. It can be called
+// while the walk is running, in which case the counts are partial.
+func (walk *Walk) DirectoryFileCounts() map[string]int {
+	walk.directoryFileCountsLocker.Lock()
+	defer walk.directoryFileCountsLocker.Unlock()
+
+	directoryFileCounts := make(map[string]int, len(walk.directoryFileCounts))
+	for directoryPath, count := range walk.directoryFileCounts {
+		directoryFileCounts[directoryPath] = count
+	}
+
+	return directoryFileCounts
+}
+
+// recordDirectoryFileCount adds to the count of the files in the given
+// directory if we're tracking them.
+func (walk *Walk) recordDirectoryFileCount(directoryPath string, count int) {
+	if walk.isTrackDirectoryFileCounts == false || count == 0 {
+		return
+	}
+
+	walk.directoryFileCountsLocker.Lock()
+	defer walk.directoryFileCountsLocker.Unlock()
+
+	if walk.directoryFileCounts == nil {
+		walk.directoryFileCounts = make(map[string]int)
+	}
+
+	walk.directoryFileCounts[directoryPath] += count
+}
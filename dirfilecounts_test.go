@@ -0,0 +1,84 @@
+package pathwalk
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+)
+
+func TestWalk_SetTrackDirectoryFileCounts(t *testing.T) {
+	tempPath := createDepthTestTree()
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walk := NewWalk(tempPath, nil)
+	walk.SetTrackDirectoryFileCounts(true)
+
+	filter := Filter{
+		ExcludeFilenames: []string{"file3"},
+	}
+
+	walk.SetFilter(filter)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	expected := map[string]int{
+		tempPath:                            1,
+		path.Join(tempPath, "dir1"):         1,
+		path.Join(tempPath, "dir1", "dir2"): 1,
+	}
+
+	directoryFileCounts := walk.DirectoryFileCounts()
+	if reflect.DeepEqual(directoryFileCounts, expected) != true {
+		t.Fatalf("Counts not correct: %v", directoryFileCounts)
+	}
+}
+
+func TestWalk_SetTrackDirectoryFileCounts__namesOnly(t *testing.T) {
+	tempPath := createDepthTestTree()
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walk := NewWalk(tempPath, nil)
+	walk.SetNamesOnly(true)
+	walk.SetTrackDirectoryFileCounts(true)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	expected := map[string]int{
+		tempPath:                            1,
+		path.Join(tempPath, "dir1"):         1,
+		path.Join(tempPath, "dir1", "dir2"): 2,
+	}
+
+	directoryFileCounts := walk.DirectoryFileCounts()
+	if reflect.DeepEqual(directoryFileCounts, expected) != true {
+		t.Fatalf("Counts not correct: %v", directoryFileCounts)
+	}
+}
+
+func TestWalk_SetTrackDirectoryFileCounts__disabled(t *testing.T) {
+	tempPath := createDepthTestTree()
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walk := NewWalk(tempPath, nil)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	if len(walk.DirectoryFileCounts()) != 0 {
+		t.Fatalf("Expected no counts: %v", walk.DirectoryFileCounts())
+	}
+}
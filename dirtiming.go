@@ -0,0 +1,119 @@
+package pathwalk
+
+import (
+	"container/heap"
+	"sort"
+	"time"
+)
+
+const (
+	// maxDirectoryTimings is the number of the slowest directories that are
+	// kept when tracking directory timing.
+	maxDirectoryTimings = 100
+)
+
+// DirTiming describes how long it took to list one directory.
+type DirTiming struct {
+	// Path is the full-path of the directory.
+	Path string
+
+	// Duration is the total time spent reading the directory's entries. This
+	// includes opening it but not processing the entries.
+	Duration time.Duration
+
+	// EntryCount is the number of entries that were read.
+	EntryCount int
+}
+
+// dirTimingHeap is a min-heap of directory timings so that the fastest of the
+// slowest directories can be replaced cheaply.
+type dirTimingHeap []DirTiming
+
+func (dth dirTimingHeap) Len() int {
+	return len(dth)
+}
+
+func (dth dirTimingHeap) Less(i, j int) bool {
+	return dth[i].Duration < dth[j].Duration
+}
+
+func (dth dirTimingHeap) Swap(i, j int) {
+	dth[i], dth[j] = dth[j], dth[i]
+}
+
+func (dth *dirTimingHeap) Push(x interface{}) {
+	*dth = append(*dth, x.(DirTiming))
+}
+
+func (dth *dirTimingHeap) Pop() interface{} {
+	old := *dth
+	n := len(old)
+	x := old[n-1]
+	*dth = old[:n-1]
+
+	return x
+}
+
+// SetTrackDirectoryTiming enables recording how long it takes to list each
+// directory so that the slowest ones can be found (see
+// `SlowestDirectories()`), e.g. on network storage. This isolates the cost of
+// the filesystem metadata from the cost of the callbacks (see
+// `SetTrackCallbackTiming()`). Only the slowest `maxDirectoryTimings`
+// directories are kept, so the memory is bounded regardless of the size of the
+// tree.
+func (walk *Walk) SetTrackDirectoryTiming(isTrackDirectoryTiming bool) {
+	walk.isTrackDirectoryTiming = isTrackDirectoryTiming
+}
+
+// SlowestDirectories returns up to `n` of the directories that took the
+// longest to list during the last run, slowest first. Zero or less returns all
+// of the ones that were kept. This is only populated if enabled with
+// `SetTrackDirectoryTiming()`. It can be called while the walk is running, in
+// which case the timings are partial.
+func (walk *Walk) SlowestDirectories(n int) []DirTiming {
+	walk.directoryTimingsLocker.Lock()
+
+	slowest := make([]DirTiming, len(walk.directoryTimings))
+	copy(slowest, walk.directoryTimings)
+
+	walk.directoryTimingsLocker.Unlock()
+
+	sort.SliceStable(slowest, func(i, j int) bool {
+		return slowest[i].Duration > slowest[j].Duration
+	})
+
+	if n > 0 && len(slowest) > n {
+		slowest = slowest[:n]
+	}
+
+	return slowest
+}
+
+// recordDirectoryTiming records the time spent listing one directory if we're
+// tracking it and it's one of the slowest so far.
+func (walk *Walk) recordDirectoryTiming(directoryPath string, duration time.Duration, entryCount int) {
+	if walk.isTrackDirectoryTiming == false {
+		return
+	}
+
+	dt := DirTiming{
+		Path:       directoryPath,
+		Duration:   duration,
+		EntryCount: entryCount,
+	}
+
+	walk.directoryTimingsLocker.Lock()
+	defer walk.directoryTimingsLocker.Unlock()
+
+	if len(walk.directoryTimings) < maxDirectoryTimings {
+		heap.Push(&walk.directoryTimings, dt)
+		return
+	}
+
+	if duration <= walk.directoryTimings[0].Duration {
+		return
+	}
+
+	walk.directoryTimings[0] = dt
+	heap.Fix(&walk.directoryTimings, 0)
+}
@@ -0,0 +1,119 @@
+package pathwalk
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+	"time"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+)
+
+func TestWalk_recordDirectoryTiming(t *testing.T) {
+	walk := NewWalk("/root", nil)
+	walk.SetTrackDirectoryTiming(true)
+
+	count := maxDirectoryTimings + 50
+	for i := 0; i < count; i++ {
+		walk.recordDirectoryTiming(fmt.Sprintf("/root/dir%d", i), time.Duration(i)*time.Millisecond, i)
+	}
+
+	slowest := walk.SlowestDirectories(3)
+
+	expected := []DirTiming{
+		{Path: "/root/dir149", Duration: 149 * time.Millisecond, EntryCount: 149},
+		{Path: "/root/dir148", Duration: 148 * time.Millisecond, EntryCount: 148},
+		{Path: "/root/dir147", Duration: 147 * time.Millisecond, EntryCount: 147},
+	}
+
+	for i, dt := range slowest {
+		if dt != expected[i] {
+			t.Fatalf("Timing (%d) not correct: %v", i, dt)
+		}
+	}
+
+	if len(slowest) != 3 {
+		t.Fatalf("Count not correct: (%d)", len(slowest))
+	}
+
+	// Only the slowest are kept.
+
+	all := walk.SlowestDirectories(0)
+
+	if len(all) != maxDirectoryTimings {
+		t.Fatalf("Kept count not correct: (%d)", len(all))
+	} else if all[len(all)-1].Duration != 50*time.Millisecond {
+		t.Fatalf("Fastest kept timing not correct: [%s]", all[len(all)-1].Duration)
+	}
+}
+
+func TestWalk_recordDirectoryTiming__disabled(t *testing.T) {
+	walk := NewWalk("/root", nil)
+
+	walk.recordDirectoryTiming("/root/dir1", time.Second, 1)
+
+	if len(walk.SlowestDirectories(0)) != 0 {
+		t.Fatalf("Expected no timings.")
+	}
+}
+
+func TestWalk_SetTrackDirectoryTiming(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	relFilepaths := []string{
+		"file1",
+		"dir1/file2",
+		"dir1/file3",
+		"dir1/dir2/file4",
+	}
+
+	for _, relFilepath := range relFilepaths {
+		fqFilepath := path.Join(tempPath, relFilepath)
+
+		err := os.MkdirAll(path.Dir(fqFilepath), 0755)
+		log.PanicIf(err)
+
+		err = ioutil.WriteFile(fqFilepath, []byte{}, 0644)
+		log.PanicIf(err)
+	}
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetTrackDirectoryTiming(true)
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	slowest := walk.SlowestDirectories(0)
+
+	entryCounts := make(map[string]int)
+	for i, dt := range slowest {
+		if i > 0 && dt.Duration > slowest[i-1].Duration {
+			t.Fatalf("Timings not sorted: %v", slowest)
+		}
+
+		entryCounts[dt.Path] = dt.EntryCount
+	}
+
+	expected := map[string]int{
+		tempPath:                            2,
+		path.Join(tempPath, "dir1"):         3,
+		path.Join(tempPath, "dir1", "dir2"): 1,
+	}
+
+	if reflect.DeepEqual(entryCounts, expected) != true {
+		t.Fatalf("Timings not correct: %v", slowest)
+	}
+}
@@ -0,0 +1,55 @@
+package pathwalk
+
+import (
+	"os"
+	"sync/atomic"
+)
+
+// WalkEntry describes one visited entry.
+type WalkEntry struct {
+	// ParentPath is the path of the directory that contains the entry.
+	ParentPath string
+
+	// Info describes the entry.
+	Info os.FileInfo
+
+	// ParentInfo describes the directory that contains the entry, as it was
+	// when that directory was visited. This saves having to stat the parent
+	// again (e.g. to preserve its permissions on copy). This is nil for the
+	// root and for directories seeded from a checkpoint.
+	ParentInfo os.FileInfo
+
+	// Sequence is the order in which the entry was delivered relative to the
+	// other entries of the same run, starting from one. Since the entries
+	// are visited in parallel, this will vary between runs, but it is unique
+	// within a run and there are no gaps once the run has completed.
+	Sequence int64
+}
+
+// WalkEntryFunc is a callback that receives a `WalkEntry` for each entry.
+type WalkEntryFunc func(entry WalkEntry) (err error)
+
+// SetWalkEntryFunc sets a callback that receives each entry along with a
+// sequence number. It is called after the callback given to `NewWalk()` and
+// any visitors and it is subject to the same error handling. This must be
+// called before `Run()`.
+//
+// As with the visitors, files are only delivered to the batch callback if one
+// was set.
+func (walk *Walk) SetWalkEntryFunc(walkEntryFunc WalkEntryFunc) {
+	walk.walkEntryFunc = walkEntryFunc
+}
+
+// nextSequence returns the next sequence number.
+func (walk *Walk) nextSequence() int64 {
+	return atomic.AddInt64(&walk.sequence, 1)
+}
+
+// callWalkEntryFunc delivers one entry to the entry callback.
+func (walk *Walk) callWalkEntryFunc(entry WalkEntry) (err error) {
+	walkFunc := func(parentNodePath string, info os.FileInfo) (err error) {
+		return walk.walkEntryFunc(entry)
+	}
+
+	return walk.callVisitorSafely(walkFunc, entry.ParentPath, entry.Info)
+}
@@ -0,0 +1,197 @@
+package pathwalk
+
+import (
+	"errors"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-parallel-walker/internal/testing"
+)
+
+func TestWalk_SetWalkEntryFunc(t *testing.T) {
+	tempPath, tempFiles := pwtesting.FillHeirarchicalTempPath(50, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	m := sync.Mutex{}
+	sequences := make([]int, 0)
+	seenFiles := make(map[string]struct{})
+
+	walkEntryFunc := func(entry WalkEntry) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		sequences = append(sequences, int(entry.Sequence))
+
+		if entry.Info.IsDir() == false {
+			relFilepath := path.Join(entry.ParentPath, entry.Info.Name())[len(tempPath)+1:]
+			seenFiles[relFilepath] = struct{}{}
+		}
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, nil)
+	walk.SetWalkEntryFunc(walkEntryFunc)
+
+	for run := 0; run < 2; run++ {
+		sequences = sequences[:0]
+
+		err := walk.Run()
+		log.PanicIf(err)
+
+		stats := walk.Stats()
+		expectedCount := stats.FilesVisited + stats.DirectoriesVisited
+
+		if len(sequences) != expectedCount {
+			t.Fatalf("Entry count not correct: (%d) != (%d)", len(sequences), expectedCount)
+		}
+
+		// The sequence numbers should be unique and contiguous, and they should
+		// start over with every run.
+
+		sort.Ints(sequences)
+
+		for i, sequence := range sequences {
+			if sequence != i+1 {
+				t.Fatalf("Sequence numbers not unique and contiguous (run %d): (%d) != (%d)", run, sequence, i+1)
+			}
+		}
+	}
+
+	if len(seenFiles) != len(tempFiles) {
+		t.Fatalf("Visited files not correct: (%d) != (%d)", len(seenFiles), len(tempFiles))
+	}
+}
+
+func TestWalk_SetWalkEntryFunc__afterVisitors(t *testing.T) {
+	tempPath, _ := pwtesting.FillFlatTempPath(1, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	m := sync.Mutex{}
+	calls := make(map[string][]string)
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		calls[info.Name()] = append(calls[info.Name()], "walk")
+		return nil
+	}
+
+	walkEntryFunc := func(entry WalkEntry) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		calls[entry.Info.Name()] = append(calls[entry.Info.Name()], "entry")
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetWalkEntryFunc(walkEntryFunc)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	actual := calls["temp-0"]
+	if len(actual) != 2 || actual[0] != "walk" || actual[1] != "entry" {
+		t.Fatalf("Callbacks not called in order: %v", actual)
+	}
+}
+
+func TestWalk_SetWalkEntryFunc__error(t *testing.T) {
+	tempPath, _ := pwtesting.FillFlatTempPath(1, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	errTest := errors.New("test error")
+
+	walkEntryFunc := func(entry WalkEntry) (err error) {
+		if entry.Info.IsDir() == false {
+			return errTest
+		}
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, nil)
+	walk.SetWalkEntryFunc(walkEntryFunc)
+
+	err := walk.Run()
+	if err == nil {
+		t.Fatalf("Expected error.")
+	}
+}
+
+func TestWalk_SetWalkEntryFunc__parentInfo(t *testing.T) {
+	tempPath, _ := pwtesting.FillFlatTempPath(2, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	subPath := path.Join(tempPath, "subdirectory")
+
+	err := os.Mkdir(subPath, 0700)
+	log.PanicIf(err)
+
+	f, err := os.Create(path.Join(subPath, "subfile"))
+	log.PanicIf(err)
+
+	f.Close()
+
+	m := sync.Mutex{}
+	parentNames := make(map[string]string)
+
+	walkEntryFunc := func(entry WalkEntry) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		if entry.ParentInfo == nil {
+			parentNames[entry.Info.Name()] = ""
+			return nil
+		}
+
+		if entry.ParentInfo.IsDir() != true {
+			parentNames[entry.Info.Name()] = "(not a directory)"
+			return nil
+		}
+
+		parentNames[entry.Info.Name()] = entry.ParentInfo.Name()
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, nil)
+	walk.SetWalkEntryFunc(walkEntryFunc)
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	rootName := path.Base(tempPath)
+
+	expected := map[string]string{
+		rootName:       "",
+		"temp-0":       rootName,
+		"temp-1":       rootName,
+		"subdirectory": rootName,
+		"subfile":      "subdirectory",
+	}
+
+	if reflect.DeepEqual(parentNames, expected) != true {
+		t.Fatalf("Parent infos not correct: %v", parentNames)
+	}
+}
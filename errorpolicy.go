@@ -0,0 +1,116 @@
+package pathwalk
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+)
+
+// ErrorPolicy determines what happens when a callback returns an error.
+type ErrorPolicy int
+
+const (
+	// ErrorPolicyAbort terminates the walk with the error. This is the
+	// default.
+	ErrorPolicyAbort ErrorPolicy = iota
+
+	// ErrorPolicyContinue logs and counts the error and carries on as if the
+	// callback had succeeded.
+	ErrorPolicyContinue
+
+	// ErrorPolicySkip logs and counts the error and skips whatever depends on
+	// the entry. For files, there is nothing that depends on them, so this is
+	// the same as `ErrorPolicyContinue`.
+	ErrorPolicySkip
+)
+
+var (
+	// ErrCallbackPanicked is what a recovered callback panic is converted to
+	// if the panic wasn't for an error.
+	ErrCallbackPanicked = errors.New("callback panicked")
+)
+
+// SetCallbackErrorPolicy sets what happens when the callback returns an error
+// (other than `ErrSkipDirectory`) for a file. Tolerated errors are logged and
+// counted in `Stats().CallbackErrors`. Errors for directories abort the walk
+// unless `SetApplyErrorPolicyToDirectories()` is also enabled.
+func (walk *Walk) SetCallbackErrorPolicy(policy ErrorPolicy) {
+	walk.callbackErrorPolicy = policy
+}
+
+// SetApplyErrorPolicyToDirectories applies the callback error policy to the
+// errors returned by the callback for directories too. With
+// `ErrorPolicyContinue`, the directory is descended into as if the callback
+// had succeeded. With `ErrorPolicySkip`, its contents are skipped as if the
+// callback had returned `ErrSkipDirectory`.
+func (walk *Walk) SetApplyErrorPolicyToDirectories(isApplyErrorPolicyToDirectories bool) {
+	walk.isApplyErrorPolicyToDirectories = isApplyErrorPolicyToDirectories
+}
+
+// SetRecoverCallbackPanics recovers any panic in the callbacks (the one given
+// to `NewWalk()` and any visitors) and converts it to an error, which is then
+// handled according to the callback error policy. Panics are counted in
+// `Stats().CallbackPanics`. Without this, a panic in the callback terminates
+// the walk.
+func (walk *Walk) SetRecoverCallbackPanics(isRecoverCallbackPanics bool) {
+	walk.isRecoverCallbackPanics = isRecoverCallbackPanics
+}
+
+// callVisitorSafely calls one callback, recovering a panic if configured to.
+func (walk *Walk) callVisitorSafely(walkFunc WalkFunc, parentNodePath string, info os.FileInfo) (err error) {
+	if walk.isRecoverCallbackPanics == true {
+		defer func() {
+			if state := recover(); state != nil {
+				walk.statsLocker.Lock()
+				walk.stats.CallbackPanics++
+				walk.statsLocker.Unlock()
+
+				if stateErr, ok := state.(error); ok == true {
+					err = fmt.Errorf("%s: %s", ErrCallbackPanicked.Error(), stateErr.Error())
+				} else {
+					err = fmt.Errorf("%s: %v", ErrCallbackPanicked.Error(), state)
+				}
+			}
+		}()
+	}
+
+	return walkFunc(parentNodePath, info)
+}
+
+// applyFileErrorPolicy returns nil if the given callback error for a file is
+// to be tolerated.
+func (walk *Walk) applyFileErrorPolicy(parentNodePath string, info os.FileInfo, callbackErr error) (err error) {
+	if callbackErr == nil || walk.callbackErrorPolicy == ErrorPolicyAbort {
+		return callbackErr
+	}
+
+	walk.tolerateCallbackError(parentNodePath, info, callbackErr)
+
+	return nil
+}
+
+// applyDirectoryErrorPolicy returns nil if the given callback error for a
+// directory is to be tolerated, in which case `isSkipped` indicates whether
+// its contents should be skipped.
+func (walk *Walk) applyDirectoryErrorPolicy(parentNodePath string, info os.FileInfo, callbackErr error) (isSkipped bool, err error) {
+	if callbackErr == nil {
+		return false, nil
+	} else if walk.isApplyErrorPolicyToDirectories == false || walk.callbackErrorPolicy == ErrorPolicyAbort {
+		return false, callbackErr
+	}
+
+	walk.tolerateCallbackError(parentNodePath, info, callbackErr)
+
+	return walk.callbackErrorPolicy == ErrorPolicySkip, nil
+}
+
+// tolerateCallbackError logs and counts a callback error that won't terminate
+// the walk.
+func (walk *Walk) tolerateCallbackError(parentNodePath string, info os.FileInfo, callbackErr error) {
+	walk.logWarningf("callback failed for [%s]; continuing: [%s]", path.Join(parentNodePath, info.Name()), callbackErr.Error())
+
+	walk.statsLocker.Lock()
+	walk.stats.CallbackErrors++
+	walk.statsLocker.Unlock()
+}
@@ -0,0 +1,221 @@
+package pathwalk
+
+import (
+	"errors"
+	"os"
+	"path"
+	"sync"
+	"testing"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-parallel-walker/internal/testing"
+)
+
+func TestWalk_SetCallbackErrorPolicy__continue(t *testing.T) {
+	fileCount := 20
+	tempPath, _ := pwtesting.FillFlatTempPath(fileCount, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	m := sync.Mutex{}
+	visited := 0
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		if info.Name() == "temp-3" || info.Name() == "temp-7" {
+			return errors.New("callback failed")
+		}
+
+		m.Lock()
+		defer m.Unlock()
+
+		if info.IsDir() == false {
+			visited++
+		}
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetCallbackErrorPolicy(ErrorPolicyContinue)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	if walk.Outcome() != OutcomeCompleted {
+		t.Fatalf("Outcome not correct: [%s]", walk.Outcome())
+	} else if visited != fileCount-2 {
+		t.Fatalf("Visited count not correct: (%d)", visited)
+	} else if walk.Stats().CallbackErrors != 2 {
+		t.Fatalf("CallbackErrors not correct: (%d)", walk.Stats().CallbackErrors)
+	}
+}
+
+func TestWalk_SetRecoverCallbackPanics__continue(t *testing.T) {
+	fileCount := 20
+	tempPath, _ := pwtesting.FillFlatTempPath(fileCount, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	m := sync.Mutex{}
+	visited := 0
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		if info.Name() == "temp-3" {
+			var nilMap map[string]int
+			nilMap["key"] = 1
+		}
+
+		m.Lock()
+		defer m.Unlock()
+
+		if info.IsDir() == false {
+			visited++
+		}
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetRecoverCallbackPanics(true)
+	walk.SetCallbackErrorPolicy(ErrorPolicyContinue)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	stats := walk.Stats()
+
+	if walk.Outcome() != OutcomeCompleted {
+		t.Fatalf("Outcome not correct: [%s]", walk.Outcome())
+	} else if visited != fileCount-1 {
+		t.Fatalf("Visited count not correct: (%d)", visited)
+	} else if stats.CallbackPanics != 1 {
+		t.Fatalf("CallbackPanics not correct: (%d)", stats.CallbackPanics)
+	} else if stats.CallbackErrors != 1 {
+		t.Fatalf("CallbackErrors not correct: (%d)", stats.CallbackErrors)
+	}
+}
+
+func TestWalk_SetRecoverCallbackPanics__abort(t *testing.T) {
+	tempPath, _ := pwtesting.FillFlatTempPath(20, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		if info.Name() == "temp-3" {
+			panic("callback exploded")
+		}
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetRecoverCallbackPanics(true)
+
+	err := walk.Run()
+	if err == nil {
+		t.Fatalf("Expected error.")
+	} else if walk.Outcome() != OutcomeError {
+		t.Fatalf("Outcome not correct: [%s]", walk.Outcome())
+	} else if walk.Stats().CallbackPanics != 1 {
+		t.Fatalf("CallbackPanics not correct: (%d)", walk.Stats().CallbackPanics)
+	}
+}
+
+// runDirectoryErrorPolicy walks a tree with two subdirectories, failing the
+// callback for one of them, and returns the number of files that were
+// visited.
+func runDirectoryErrorPolicy(policy ErrorPolicy, isApplyErrorPolicyToDirectories bool) (walk *Walk, filesVisited int, err error) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	for _, directoryName := range []string{"good", "bad"} {
+		err := os.Mkdir(path.Join(tempPath, directoryName), 0755)
+		log.PanicIf(err)
+
+		for _, filename := range []string{"file1", "file2"} {
+			err := ioutil.WriteFile(path.Join(tempPath, directoryName, filename), []byte{}, 0644)
+			log.PanicIf(err)
+		}
+	}
+
+	m := sync.Mutex{}
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		if info.IsDir() == true && info.Name() == "bad" {
+			return errors.New("callback failed")
+		}
+
+		if info.IsDir() == false {
+			m.Lock()
+			filesVisited++
+			m.Unlock()
+		}
+
+		return nil
+	}
+
+	walk = NewWalk(tempPath, walkFunc)
+	walk.SetCallbackErrorPolicy(policy)
+	walk.SetApplyErrorPolicyToDirectories(isApplyErrorPolicyToDirectories)
+
+	err = walk.Run()
+
+	return walk, filesVisited, err
+}
+
+func TestWalk_SetApplyErrorPolicyToDirectories__continue(t *testing.T) {
+	walk, filesVisited, err := runDirectoryErrorPolicy(ErrorPolicyContinue, true)
+	log.PanicIf(err)
+
+	if filesVisited != 4 {
+		t.Fatalf("Visited count not correct: (%d)", filesVisited)
+	} else if walk.Stats().CallbackErrors != 1 {
+		t.Fatalf("CallbackErrors not correct: (%d)", walk.Stats().CallbackErrors)
+	} else if walk.Stats().DirectoriesIgnored != 0 {
+		t.Fatalf("DirectoriesIgnored not correct: (%d)", walk.Stats().DirectoriesIgnored)
+	} else if walk.Config().IsApplyErrorPolicyToDirectories != true {
+		t.Fatalf("Config not correct.")
+	}
+}
+
+func TestWalk_SetApplyErrorPolicyToDirectories__skip(t *testing.T) {
+	walk, filesVisited, err := runDirectoryErrorPolicy(ErrorPolicySkip, true)
+	log.PanicIf(err)
+
+	if filesVisited != 2 {
+		t.Fatalf("Visited count not correct: (%d)", filesVisited)
+	} else if walk.Stats().CallbackErrors != 1 {
+		t.Fatalf("CallbackErrors not correct: (%d)", walk.Stats().CallbackErrors)
+	} else if walk.Stats().DirectoriesIgnored != 1 {
+		t.Fatalf("DirectoriesIgnored not correct: (%d)", walk.Stats().DirectoriesIgnored)
+	}
+}
+
+func TestWalk_SetApplyErrorPolicyToDirectories__abort(t *testing.T) {
+	_, _, err := runDirectoryErrorPolicy(ErrorPolicyAbort, true)
+	if err == nil {
+		t.Fatalf("Expected error with the abort policy.")
+	}
+}
+
+func TestWalk_SetApplyErrorPolicyToDirectories__notApplied(t *testing.T) {
+	// By default, directory errors are fatal regardless of the policy.
+
+	_, _, err := runDirectoryErrorPolicy(ErrorPolicyContinue, false)
+	if err == nil {
+		t.Fatalf("Expected error when not applied to directories.")
+	}
+}
@@ -0,0 +1,77 @@
+package pathwalk
+
+// ExcludedPath is one entry that was removed by the filters.
+type ExcludedPath struct {
+	// Path is formatted the same way as for the regular callback.
+	Path string
+
+	// Reason is the filter that excluded the entry. `SkipFilterPath` means
+	// that the entry (or its directory) didn't pass the path filters, and
+	// `SkipFilterFilename` means that a file didn't pass the filename
+	// filters.
+	Reason SkipReason
+}
+
+// SetRecordExcluded sets whether the entries that are excluded by the filters
+// are recorded for `ExcludedPaths()`. This is intended for auditing what a
+// set of filters actually removes from a real tree. Entries skipped for other
+// reasons (e.g. unreadable entries) aren't recorded.
+func (walk *Walk) SetRecordExcluded(isRecordExcluded bool) {
+	walk.isRecordExcluded = isRecordExcluded
+}
+
+// SetMaxExcludedPaths sets how many excluded entries are kept for
+// `ExcludedPaths()` in order to bound the memory. Any further entries are
+// only counted in `Stats().ExcludedPathsDropped`. Zero (the default) means no
+// limit.
+func (walk *Walk) SetMaxExcludedPaths(maxExcludedPaths int) {
+	walk.maxExcludedPaths = maxExcludedPaths
+}
+
+// ExcludedPaths returns the entries that were excluded by the filters during
+// the last run, in the order that they were encountered. Since the walk is
+// parallel, that order is not deterministic. This can be called while the walk
+// is running.
+func (walk *Walk) ExcludedPaths() []ExcludedPath {
+	walk.excludedPathsLocker.Lock()
+	defer walk.excludedPathsLocker.Unlock()
+
+	excludedPaths := make([]ExcludedPath, len(walk.excludedPaths))
+	copy(excludedPaths, walk.excludedPaths)
+
+	return excludedPaths
+}
+
+// isFilterSkipReason returns whether the reason is one of the filters.
+func isFilterSkipReason(reason SkipReason) bool {
+	switch reason {
+	case SkipFilterPath, SkipFilterFilename, SkipFilterOwner, SkipFilterContent, SkipFilterTopLevelDirectory, SkipFilterCreationTime:
+		return true
+	}
+
+	return false
+}
+
+// recordExcludedPath records one entry that was excluded by the filters. The
+// path should already be formatted for reporting.
+func (walk *Walk) recordExcludedPath(reportedPath string, reason SkipReason) {
+	walk.excludedPathsLocker.Lock()
+
+	isKept := walk.maxExcludedPaths <= 0 || len(walk.excludedPaths) < walk.maxExcludedPaths
+	if isKept == true {
+		ep := ExcludedPath{
+			Path:   reportedPath,
+			Reason: reason,
+		}
+
+		walk.excludedPaths = append(walk.excludedPaths, ep)
+	}
+
+	walk.excludedPathsLocker.Unlock()
+
+	if isKept == false {
+		walk.statsLocker.Lock()
+		walk.stats.ExcludedPathsDropped++
+		walk.statsLocker.Unlock()
+	}
+}
@@ -0,0 +1,151 @@
+package pathwalk
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"testing"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+)
+
+// createExcludedTestTree creates a tree with entries that are excluded by
+// the filters for several reasons.
+func createExcludedTestTree() (tempPath string) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	err = os.MkdirAll(path.Join(tempPath, "dir1"), 0755)
+	log.PanicIf(err)
+
+	err = os.MkdirAll(path.Join(tempPath, "dir2"), 0755)
+	log.PanicIf(err)
+
+	relFilepaths := []string{
+		"dir1/file1.jpg",
+		"dir1/file2.tmp",
+		"dir1/very-long-filename.jpg",
+		"dir2/file3.jpg",
+	}
+
+	for _, relFilepath := range relFilepaths {
+		err := ioutil.WriteFile(path.Join(tempPath, relFilepath), []byte{}, 0644)
+		log.PanicIf(err)
+	}
+
+	return tempPath
+}
+
+func TestWalk_SetRecordExcluded(t *testing.T) {
+	tempPath := createExcludedTestTree()
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walk := NewWalk(tempPath, nil)
+	walk.SetRecordExcluded(true)
+	walk.SetPathStyle(PathStyleRelative)
+
+	filter := Filter{
+		IncludePaths:     []string{"dir1"},
+		ExcludeFilenames: []string{"*.tmp"},
+		MaxPathLength:    len(tempPath) + 1 + len("dir1/file2.tmp"),
+		SuppressRoot:     true,
+	}
+
+	walk.SetFilter(filter)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	excludedPaths := walk.ExcludedPaths()
+
+	sort.Slice(excludedPaths, func(i, j int) bool {
+		return excludedPaths[i].Path < excludedPaths[j].Path
+	})
+
+	// The long filename is skipped but not by a filter, and the root doesn't
+	// match the include (it's suppressed).
+	expected := []ExcludedPath{
+		{Path: ".", Reason: SkipFilterPath},
+		{Path: "dir1/file2.tmp", Reason: SkipFilterFilename},
+		{Path: "dir2", Reason: SkipFilterPath},
+		{Path: "dir2/file3.jpg", Reason: SkipFilterPath},
+	}
+
+	if reflect.DeepEqual(excludedPaths, expected) != true {
+		t.Fatalf("Excluded paths not correct: %v", excludedPaths)
+	}
+
+	stats := walk.Stats()
+	if stats.ExcludedPathsDropped != 0 {
+		t.Fatalf("Expected no dropped paths: (%d)", stats.ExcludedPathsDropped)
+	}
+}
+
+func TestWalk_SetRecordExcluded__disabled(t *testing.T) {
+	tempPath := createExcludedTestTree()
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walk := NewWalk(tempPath, nil)
+
+	filter := Filter{
+		ExcludeFilenames: []string{"*.tmp"},
+	}
+
+	walk.SetFilter(filter)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	if len(walk.ExcludedPaths()) != 0 {
+		t.Fatalf("Expected no excluded paths to be recorded: %v", walk.ExcludedPaths())
+	}
+}
+
+func TestWalk_SetMaxExcludedPaths(t *testing.T) {
+	tempPath := createExcludedTestTree()
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walk := NewWalk(tempPath, nil)
+	walk.SetRecordExcluded(true)
+	walk.SetMaxExcludedPaths(2)
+
+	filter := Filter{
+		IncludePaths:     []string{"dir1"},
+		ExcludeFilenames: []string{"*.tmp"},
+		SuppressRoot:     true,
+	}
+
+	walk.SetFilter(filter)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	if len(walk.ExcludedPaths()) != 2 {
+		t.Fatalf("Excluded paths not capped: %v", walk.ExcludedPaths())
+	}
+
+	stats := walk.Stats()
+	if stats.ExcludedPathsDropped != 2 {
+		t.Fatalf("Dropped count not correct: (%d)", stats.ExcludedPathsDropped)
+	}
+}
+
+func TestIsFilterSkipReason(t *testing.T) {
+	if isFilterSkipReason(SkipFilterFilename) != true {
+		t.Fatalf("Expected the filename filter to be a filter reason.")
+	} else if isFilterSkipReason(SkipUnreadable) != false {
+		t.Fatalf("Expected an unreadable entry not to be a filter reason.")
+	}
+}
@@ -0,0 +1,56 @@
+package pathwalk
+
+import (
+	"sort"
+)
+
+// SetMaxFailedDirectories sets how many directories are kept for
+// `FailedDirectories()`. Any further failed directories are still counted in
+// `Stats().DirectoriesWithErrors` but, since we can no longer tell whether
+// we've seen them before, a directory with more than one error may then be
+// counted more than once. Zero means no limit. This defaults to
+// `defaultMaxFailedDirectories`.
+func (walk *Walk) SetMaxFailedDirectories(maxFailedDirectories int) {
+	walk.maxFailedDirectories = maxFailedDirectories
+}
+
+// FailedDirectories returns the directories that had an error during the last
+// run: the directory couldn't be opened or read, or one of its children
+// couldn't be stat'd. This is a remediation list for walks over partially-
+// inaccessible trees. It's sorted and can be called while the walk is
+// running.
+func (walk *Walk) FailedDirectories() []string {
+	walk.failedDirectoriesLocker.Lock()
+	defer walk.failedDirectoriesLocker.Unlock()
+
+	failedDirectories := make([]string, 0, len(walk.failedDirectories))
+	for directoryPath := range walk.failedDirectories {
+		failedDirectories = append(failedDirectories, directoryPath)
+	}
+
+	sort.Strings(failedDirectories)
+
+	return failedDirectories
+}
+
+// recordFailedDirectory records a directory that had an error.
+func (walk *Walk) recordFailedDirectory(directoryPath string) {
+	walk.failedDirectoriesLocker.Lock()
+	defer walk.failedDirectoriesLocker.Unlock()
+
+	if walk.failedDirectories == nil {
+		walk.failedDirectories = make(map[string]struct{})
+	}
+
+	if _, found := walk.failedDirectories[directoryPath]; found == true {
+		return
+	}
+
+	if walk.maxFailedDirectories <= 0 || len(walk.failedDirectories) < walk.maxFailedDirectories {
+		walk.failedDirectories[directoryPath] = struct{}{}
+	}
+
+	walk.statsLocker.Lock()
+	walk.stats.DirectoriesWithErrors++
+	walk.statsLocker.Unlock()
+}
@@ -0,0 +1,63 @@
+package pathwalk
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+)
+
+func TestWalk_FailedDirectories(t *testing.T) {
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		return nil
+	}
+
+	tucl := newTestUnreadableChildLister()
+	tucl.children["/root/container1"] = []string{"bad6", "good3"}
+	tucl.children["/root"] = append(tucl.children["/root"], "container1", "container2")
+	tucl.children["/root/container2"] = []string{"good4"}
+
+	walk := NewWalk("/root", walkFunc)
+	walk.SetChildLister(tucl)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	expected := []string{
+		"/root",
+		"/root/container1",
+	}
+
+	failedDirectories := walk.FailedDirectories()
+
+	if reflect.DeepEqual(failedDirectories, expected) != true {
+		t.Fatalf("FailedDirectories not correct: %v", failedDirectories)
+	} else if walk.Stats().DirectoriesWithErrors != 2 {
+		t.Fatalf("DirectoriesWithErrors not correct: (%d)", walk.Stats().DirectoriesWithErrors)
+	}
+}
+
+func TestWalk_SetMaxFailedDirectories(t *testing.T) {
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		return nil
+	}
+
+	tucl := newTestUnreadableChildLister()
+	tucl.children["/root/container1"] = []string{"bad6"}
+	tucl.children["/root/container2"] = []string{"bad7"}
+	tucl.children["/root"] = append(tucl.children["/root"], "container1", "container2")
+
+	walk := NewWalk("/root", walkFunc)
+	walk.SetChildLister(tucl)
+	walk.SetMaxFailedDirectories(1)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	if len(walk.FailedDirectories()) != 1 {
+		t.Fatalf("FailedDirectories not capped: %v", walk.FailedDirectories())
+	} else if walk.Stats().DirectoriesWithErrors != 3 {
+		t.Fatalf("DirectoriesWithErrors not correct: (%d)", walk.Stats().DirectoriesWithErrors)
+	}
+}
@@ -0,0 +1,85 @@
+package pathwalk
+
+import (
+	"os"
+	"time"
+
+	"github.com/dsoprea/go-logging"
+)
+
+const (
+	// defaultFdExhaustionMaxRetries is how many times the opening of a
+	// directory is retried when we've run out of file descriptors.
+	defaultFdExhaustionMaxRetries = 8
+
+	// defaultFdExhaustionBackoff is how long we wait before the first retry.
+	// This doubles with every retry.
+	defaultFdExhaustionBackoff = 10 * time.Millisecond
+)
+
+// fdExhaustionReporter can be implemented by a `ChildLister` that retries
+// when file descriptors are exhausted in order to have the retries counted.
+type fdExhaustionReporter interface {
+	setFdExhaustionFunc(fdExhaustionFunc func())
+}
+
+// isFdExhaustion indicates whether the given error is because there are too
+// many open files (either for the process or for the system).
+func isFdExhaustion(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	// Unwrap it if it was wrapped with a stack.
+	err = log.Wrap(err).Err
+
+	switch e := err.(type) {
+	case *os.PathError:
+		err = e.Err
+	case *os.SyscallError:
+		err = e.Err
+	}
+
+	return isFdExhaustionErrno(err)
+}
+
+// setFdExhaustionFunc sets a function that is called for every retry.
+func (fcl *filesystemChildLister) setFdExhaustionFunc(fdExhaustionFunc func()) {
+	fcl.fdExhaustionFunc = fdExhaustionFunc
+}
+
+// openWithRetry opens the given directory. If we've run out of file
+// descriptors, we back off and try again a limited number of times since the
+// other workers will be closing theirs.
+func (fcl *filesystemChildLister) openWithRetry(path string) (f *os.File, err error) {
+	backoff := fcl.fdExhaustionBackoff
+
+	for i := 0; ; i++ {
+		f, err = fcl.openFunc(path)
+		if err == nil || isFdExhaustion(err) == false || i >= fcl.fdExhaustionMaxRetries {
+			return f, err
+		}
+
+		if fcl.fdExhaustionFunc != nil {
+			fcl.fdExhaustionFunc()
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// reportFdExhaustion hooks the child-lister's retries up to the stats if it
+// supports it.
+func (walk *Walk) reportFdExhaustion() {
+	fer, ok := walk.childLister.(fdExhaustionReporter)
+	if ok == false {
+		return
+	}
+
+	fer.setFdExhaustionFunc(func() {
+		walk.statsLocker.Lock()
+		walk.stats.FdExhaustionRetries++
+		walk.statsLocker.Unlock()
+	})
+}
@@ -0,0 +1,14 @@
+//go:build !plan9
+// +build !plan9
+
+package pathwalk
+
+import (
+	"syscall"
+)
+
+// isFdExhaustionErrno indicates whether the given (unwrapped) error is the
+// errno for too many open files, either for the process or for the system.
+func isFdExhaustionErrno(err error) bool {
+	return err == syscall.EMFILE || err == syscall.ENFILE
+}
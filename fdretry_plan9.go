@@ -0,0 +1,10 @@
+//go:build plan9
+// +build plan9
+
+package pathwalk
+
+// isFdExhaustionErrno always returns false since Plan 9 doesn't have an errno
+// for running out of file descriptors. Opens are not retried.
+func isFdExhaustionErrno(err error) bool {
+	return false
+}
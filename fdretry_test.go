@@ -0,0 +1,127 @@
+//go:build !plan9
+// +build !plan9
+
+// Plan 9 has no errno for running out of file descriptors.
+
+package pathwalk
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-parallel-walker/internal/testing"
+)
+
+func TestIsFdExhaustion(t *testing.T) {
+	emfile := &os.PathError{Op: "open", Path: "/some/path", Err: syscall.EMFILE}
+	enfile := &os.SyscallError{Syscall: "openat", Err: syscall.ENFILE}
+
+	if isFdExhaustion(emfile) != true {
+		t.Fatalf("Expected EMFILE to be exhaustion.")
+	} else if isFdExhaustion(enfile) != true {
+		t.Fatalf("Expected ENFILE to be exhaustion.")
+	} else if isFdExhaustion(log.Wrap(emfile)) != true {
+		t.Fatalf("Expected wrapped EMFILE to be exhaustion.")
+	} else if isFdExhaustion(syscall.EMFILE) != true {
+		t.Fatalf("Expected bare EMFILE to be exhaustion.")
+	} else if isFdExhaustion(&os.PathError{Op: "open", Path: "/some/path", Err: syscall.ENOENT}) != false {
+		t.Fatalf("Expected ENOENT to not be exhaustion.")
+	} else if isFdExhaustion(errors.New("test error")) != false {
+		t.Fatalf("Expected other error to not be exhaustion.")
+	} else if isFdExhaustion(nil) != false {
+		t.Fatalf("Expected nil to not be exhaustion.")
+	}
+}
+
+// newExhaustedOpenFunc returns an open function that fails with EMFILE for the
+// given number of calls before opening normally.
+func newExhaustedOpenFunc(failures int) func(path string) (*os.File, error) {
+	m := sync.Mutex{}
+
+	return func(path string) (*os.File, error) {
+		m.Lock()
+		defer m.Unlock()
+
+		if failures > 0 {
+			failures--
+			return nil, &os.PathError{Op: "open", Path: path, Err: syscall.EMFILE}
+		}
+
+		return os.Open(path)
+	}
+}
+
+func TestWalk_Run__fdExhaustionRetried(t *testing.T) {
+	tempPath, tempFilenames := pwtesting.FillFlatTempPath(10, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walk := NewWalk(tempPath, nil)
+
+	fcl := walk.childLister.(*filesystemChildLister)
+	fcl.openFunc = newExhaustedOpenFunc(3)
+	fcl.fdExhaustionBackoff = 0
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	stats := walk.Stats()
+
+	if stats.FdExhaustionRetries != 3 {
+		t.Fatalf("FdExhaustionRetries not correct: (%d)", stats.FdExhaustionRetries)
+	} else if stats.FilesVisited != len(tempFilenames) {
+		t.Fatalf("FilesVisited not correct: (%d)", stats.FilesVisited)
+	}
+}
+
+func TestWalk_Run__fdExhaustionRetriesExceeded(t *testing.T) {
+	tempPath, _ := pwtesting.FillFlatTempPath(1, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walk := NewWalk(tempPath, nil)
+
+	fcl := walk.childLister.(*filesystemChildLister)
+	fcl.openFunc = newExhaustedOpenFunc(defaultFdExhaustionMaxRetries + 1)
+	fcl.fdExhaustionBackoff = 0
+
+	err := walk.Run()
+	if err == nil {
+		t.Fatalf("Expected failure once the retries were exhausted.")
+	} else if strings.Contains(err.Error(), syscall.EMFILE.Error()) != true {
+		t.Fatalf("Expected the exhaustion error: [%v]", err)
+	}
+
+	if walk.Stats().FdExhaustionRetries != defaultFdExhaustionMaxRetries {
+		t.Fatalf("FdExhaustionRetries not correct: (%d)", walk.Stats().FdExhaustionRetries)
+	}
+}
+
+func TestFilesystemChildLister_openWithRetry__otherError(t *testing.T) {
+	walk := NewWalk("", nil)
+
+	fcl := walk.childLister.(*filesystemChildLister)
+
+	calls := 0
+	fcl.openFunc = func(path string) (*os.File, error) {
+		calls++
+		return nil, &os.PathError{Op: "open", Path: path, Err: syscall.ENOENT}
+	}
+
+	_, err := fcl.openWithRetry("/some/path")
+	if err == nil {
+		t.Fatalf("Expected error.")
+	} else if calls != 1 {
+		t.Fatalf("Expected no retries: (%d)", calls)
+	}
+}
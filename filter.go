@@ -1,8 +1,13 @@
 package pathwalk
 
 import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
 	"sort"
 	"strings"
+	"time"
 
 	"path/filepath"
 
@@ -10,6 +15,25 @@ import (
 	"github.com/gobwas/glob"
 )
 
+// FilterPrecedence determines whether includes or excludes are evaluated first
+// when both are given.
+type FilterPrecedence int
+
+const (
+	// IncludeFirst checks the includes first. If any includes are given, an
+	// entry that matches one is included even if it also matches an exclude
+	// and an entry that doesn't match any is excluded. The excludes only
+	// apply if there are no includes. With an include of "filename2" and an
+	// exclude of "filename2", "filename2" is included. This is the default.
+	IncludeFirst FilterPrecedence = iota
+
+	// ExcludeFirst checks the excludes first. An entry that matches an exclude
+	// is excluded regardless of the includes, and the includes are then only
+	// applied to what remains. With an include of "filename2" and an exclude
+	// of "filename2", "filename2" is excluded.
+	ExcludeFirst
+)
+
 // Filter define the parameters that can be provided by the user to control the
 // walk.
 type Filter struct {
@@ -19,6 +43,126 @@ type Filter struct {
 	ExcludeFilenames []string
 
 	IsCaseInsensitive bool
+
+	// Precedence determines how includes and excludes interact when both are
+	// given. Defaults to `IncludeFirst`.
+	Precedence FilterPrecedence
+
+	// IncludeCompoundExtensions is zero or more extensions that may span
+	// several dots (e.g. "tar.gz", with or without the leading dot). If given,
+	// a file is only visited if its name ends in one of them, so "tar.gz"
+	// matches "archive.tar.gz" but not "archive.gz" (which `path.Ext()` can't
+	// distinguish). Matching is always case-insensitive. This is applied
+	// after the filename filters and files must pass both.
+	IncludeCompoundExtensions []string
+
+	// ContentMagic is zero or more byte-prefixes ("magic bytes"). If given, a
+	// file is only visited if its content starts with one of them, regardless
+	// of its extension. Only regular files can match (FIFOs, devices, etc.. are
+	// never opened). This is applied after the filename filters. Note that
+	// this requires opening and reading the head of every file that passes
+	// the other filters, which is dramatically more expensive than filtering
+	// on names alone.
+	ContentMagic [][]byte
+
+	// OwnerUIDs is zero or more user IDs. If given, a file is only visited if
+	// it's owned by one of them.
+	OwnerUIDs []int
+
+	// OwnerGIDs is zero or more group IDs. If given, a file is only visited if
+	// its group is one of them. If both UIDs and GIDs are given, a file must
+	// match both. Ownership is only available on POSIX platforms; elsewhere,
+	// these are ignored (with a warning).
+	OwnerGIDs []int
+
+	// CreatedAfter, if not zero, excludes any file that was created before
+	// this time. This uses the filesystem's birth time (not the modification
+	// time), which is only recorded by some platforms and filesystems. Files
+	// whose creation time isn't available are excluded, so the filter can't be
+	// satisfied at all on platforms that never provide it (a warning is
+	// logged when the filter is set). Like the owner filters, this can't be
+	// combined with names-only mode.
+	CreatedAfter time.Time
+
+	// CreatedBefore, if not zero, excludes any file that was created at or
+	// after this time. See `CreatedAfter`.
+	CreatedBefore time.Time
+
+	// SuppressRoot filters the root like any other directory (as an empty
+	// relative path). By default, the callback is called for the root even if
+	// it's excluded by the path filters (e.g. with an include of "src/**",
+	// which the root doesn't match), although its files are still filtered as
+	// usual. A root that is excluded by name with `SetApplyFilterToRoot()` is
+	// never delivered.
+	SuppressRoot bool
+
+	// MaxPathLength, if not zero, excludes any file or directory whose
+	// full-path (as walked, in bytes) is longer. Directories that are too long
+	// are not descended into, since everything below them would be longer
+	// still.
+	MaxPathLength int
+
+	// MaxPathComponents, if not zero, excludes any file or directory whose
+	// path, relative to the root, has more components (including its own
+	// name). Directories that are excluded are not descended into. Unlike a
+	// depth limit, which only stops the traversal, this filters the entries
+	// themselves (e.g. to sanitize input for a system with a nesting limit).
+	MaxPathComponents int
+
+	// ExcludeSymlinkTargetsOutsideRoot excludes any symlink whose target
+	// resolves to somewhere outside of the root (e.g. to guard against
+	// escapes when walking untrusted trees). Symlinked directories aren't
+	// descended into. This requires an extra `lstat()` for every entry and
+	// can't be combined with names-only mode.
+	ExcludeSymlinkTargetsOutsideRoot bool
+
+	// AllowedTopLevelDirs, if given, limits the walk to the directories
+	// directly under the root that have one of these names. The other
+	// directories at that level are neither delivered nor descended into,
+	// which is much cheaper than a path filter (since everything is still
+	// descended in case deeper paths match). The files directly under the
+	// root are unaffected and the path filters still apply as usual below
+	// the allowed directories. The names are matched exactly (or case-
+	// insensitively with `IsCaseInsensitive`).
+	AllowedTopLevelDirs []string
+}
+
+// copy returns a deep copy of the filter.
+func (filter Filter) copy() Filter {
+	copied := filter
+
+	copied.IncludePaths = copyStrings(filter.IncludePaths)
+	copied.ExcludePaths = copyStrings(filter.ExcludePaths)
+	copied.IncludeFilenames = copyStrings(filter.IncludeFilenames)
+	copied.ExcludeFilenames = copyStrings(filter.ExcludeFilenames)
+	copied.AllowedTopLevelDirs = copyStrings(filter.AllowedTopLevelDirs)
+	copied.IncludeCompoundExtensions = copyStrings(filter.IncludeCompoundExtensions)
+
+	if filter.ContentMagic != nil {
+		copied.ContentMagic = make([][]byte, len(filter.ContentMagic))
+		for i, magic := range filter.ContentMagic {
+			copied.ContentMagic[i] = append([]byte(nil), magic...)
+		}
+	}
+
+	if filter.OwnerUIDs != nil {
+		copied.OwnerUIDs = append([]int(nil), filter.OwnerUIDs...)
+	}
+
+	if filter.OwnerGIDs != nil {
+		copied.OwnerGIDs = append([]int(nil), filter.OwnerGIDs...)
+	}
+
+	return copied
+}
+
+// copyStrings returns a copy of the given slice, preserving nil.
+func copyStrings(values []string) []string {
+	if values == nil {
+		return nil
+	}
+
+	return append([]string(nil), values...)
 }
 
 // internalFilter is a conditioned copy of the user filtering parameters.
@@ -29,6 +173,28 @@ type internalFilter struct {
 	excludeFilenames sort.StringSlice
 
 	isCaseInsensitive bool
+	precedence        FilterPrecedence
+
+	// compoundExtensions are lowercased and always have a single leading dot.
+	compoundExtensions []string
+
+	contentMagic       [][]byte
+	contentMagicMaxLen int
+
+	ownerUIDs map[int]struct{}
+	ownerGIDs map[int]struct{}
+
+	createdAfter  time.Time
+	createdBefore time.Time
+
+	maxPathLength     int
+	maxPathComponents int
+
+	suppressRoot bool
+
+	excludeSymlinkTargetsOutsideRoot bool
+
+	allowedTopLevelDirs map[string]struct{}
 }
 
 // IsFileIncluded determines if the given filename should be visited.
@@ -37,80 +203,378 @@ func (filter internalFilter) IsFileIncluded(filename string) bool {
 		filename = strings.ToLower(filename)
 	}
 
+	if filter.precedence == ExcludeFirst {
+		if isFilenameMatched(filter.excludeFilenames, filename) == true {
+			return false
+		}
+	}
+
 	if len(filter.includeFilenames) > 0 {
 		// If any included-files are declared, then any unmatched files will be
 		// skipped.
 
-		for _, includePattern := range filter.includeFilenames {
-			hit, err := filepath.Match(includePattern, filename)
-			log.PanicIf(err)
+		return isFilenameMatched(filter.includeFilenames, filename)
+	}
 
-			if hit == true {
-				return true
-			}
+	if filter.precedence == IncludeFirst {
+		if isFilenameMatched(filter.excludeFilenames, filename) == true {
+			return false
 		}
+	}
 
-		return false
+	// No include filters or matching exclude filters. Include.
+	return true
+}
+
+// HasCompoundExtensions returns whether any compound extensions were given.
+func (filter internalFilter) HasCompoundExtensions() bool {
+	return len(filter.compoundExtensions) > 0
+}
+
+// IsCompoundExtensionIncluded determines if the given filename ends in one of
+// the compound extensions. There must be something in front of the extension,
+// so a filename of ".tar.gz" doesn't match "tar.gz".
+func (filter internalFilter) IsCompoundExtensionIncluded(filename string) bool {
+	filename = strings.ToLower(filename)
+
+	for _, extension := range filter.compoundExtensions {
+		if len(filename) > len(extension) && strings.HasSuffix(filename, extension) == true {
+			return true
+		}
 	}
 
-	if len(filter.excludeFilenames) > 0 {
-		// If any included-files are declared, then any unmatched files will be
-		// skipped.
+	return false
+}
 
-		for _, excludePattern := range filter.excludeFilenames {
-			hit, err := filepath.Match(excludePattern, filename)
-			log.PanicIf(err)
+// isFilenameMatched returns whether the filename matches any of the patterns.
+func isFilenameMatched(patterns []string, filename string) bool {
+	for _, pattern := range patterns {
+		hit, err := filepath.Match(pattern, filename)
+		log.PanicIf(err)
 
-			if hit == true {
-				return false
-			}
+		if hit == true {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsTopLevelDirectoryAllowed determines if the given directory, directly
+// under the root, should be descended into.
+func (filter internalFilter) IsTopLevelDirectoryAllowed(name string) bool {
+	if len(filter.allowedTopLevelDirs) == 0 {
+		return true
+	}
+
+	if filter.isCaseInsensitive == true {
+		name = strings.ToLower(name)
+	}
+
+	_, found := filter.allowedTopLevelDirs[name]
+	return found
+}
+
+// HasContentMagic returns whether any content signatures were given.
+func (filter internalFilter) HasContentMagic() bool {
+	return len(filter.contentMagic) > 0
+}
+
+// IsPathLengthIncluded returns whether the given full-path is within the
+// maximum length, if one was given.
+func (filter internalFilter) IsPathLengthIncluded(fqPath string) bool {
+	return filter.maxPathLength <= 0 || len(fqPath) <= filter.maxPathLength
+}
 
+// IsPathComponentCountIncluded returns whether the given number of path
+// components is within the maximum, if one was given.
+func (filter internalFilter) IsPathComponentCountIncluded(componentCount int) bool {
+	return filter.maxPathComponents <= 0 || componentCount <= filter.maxPathComponents
+}
+
+// HasOwnerFilter returns whether any owner UIDs or GIDs were given.
+func (filter internalFilter) HasOwnerFilter() bool {
+	return len(filter.ownerUIDs) > 0 || len(filter.ownerGIDs) > 0
+}
+
+// IsOwnerIncluded determines if the given file has one of the required owners.
+// Files whose ownership is not available are included.
+func (filter internalFilter) IsOwnerIncluded(info os.FileInfo) bool {
+	uid, gid, ok := getOwnerIds(info)
+	if ok == false {
+		return true
+	}
+
+	if len(filter.ownerUIDs) > 0 {
+		if _, found := filter.ownerUIDs[uid]; found == false {
+			return false
 		}
 	}
 
-	// No include filters or matching exclude filters. Include.
+	if len(filter.ownerGIDs) > 0 {
+		if _, found := filter.ownerGIDs[gid]; found == false {
+			return false
+		}
+	}
+
+	return true
+}
+
+// HasCreationTimeFilter returns whether either creation-time bound was given.
+func (filter internalFilter) HasCreationTimeFilter() bool {
+	return filter.createdAfter.IsZero() == false || filter.createdBefore.IsZero() == false
+}
+
+// IsCreationTimeIncluded determines if the given creation time is within the
+// bounds.
+func (filter internalFilter) IsCreationTimeIncluded(btime time.Time) bool {
+	if filter.createdAfter.IsZero() == false && btime.Before(filter.createdAfter) == true {
+		return false
+	}
+
+	if filter.createdBefore.IsZero() == false && btime.Before(filter.createdBefore) == false {
+		return false
+	}
+
 	return true
 }
 
+// IsContentIncluded determines if the given file-head matches any of the
+// content signatures. The head should have been read using the length
+// returned by `ContentMagicMaxLen()`.
+func (filter internalFilter) IsContentIncluded(head []byte) bool {
+	for _, magic := range filter.contentMagic {
+		if bytes.HasPrefix(head, magic) == true {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ContentMagicMaxLen is the number of bytes that need to be read from the head
+// of a file in order to check all of the content signatures.
+func (filter internalFilter) ContentMagicMaxLen() int {
+	return filter.contentMagicMaxLen
+}
+
 // IsPathIncluded determines if the given path should be visited.
 func (filter internalFilter) IsPathIncluded(currentPath string) bool {
 	if filter.isCaseInsensitive == true {
 		currentPath = strings.ToLower(currentPath)
 	}
 
+	if filter.precedence == ExcludeFirst {
+		if isPathMatched(filter.excludePaths, currentPath) == true {
+			return false
+		}
+	}
+
 	if len(filter.includePaths) > 0 {
-		// If any included-files are declared, then any unmatched files will be
+		// If any included-paths are declared, then any unmatched paths will be
 		// skipped.
 
-		for _, includePattern := range filter.includePaths {
-			if includePattern.Match(currentPath) == true {
-				return true
-			}
+		return isPathMatched(filter.includePaths, currentPath)
+	}
+
+	if filter.precedence == IncludeFirst {
+		if isPathMatched(filter.excludePaths, currentPath) == true {
+			return false
 		}
+	}
 
-		return false
+	// No include filters or matching exclude filters. Include.
+	return true
+}
+
+// isPathMatched returns whether the path matches any of the patterns.
+func isPathMatched(patterns []glob.Glob, currentPath string) bool {
+	for _, pattern := range patterns {
+		if pattern.Match(currentPath) == true {
+			return true
+		}
 	}
 
-	if len(filter.excludePaths) > 0 {
-		// If any included-files are declared, then any unmatched files will be
-		// skipped.
+	return false
+}
+
+// FilterPatternError describes a filter pattern that couldn't be compiled.
+type FilterPatternError struct {
+	// Field is the name of the `Filter` field that has the pattern.
+	Field string
 
-		for _, excludePattern := range filter.excludePaths {
-			if excludePattern.Match(currentPath) == true {
-				return false
+	// Index is the position of the pattern in that field.
+	Index int
+
+	// Pattern is the offending pattern.
+	Pattern string
+
+	// Err is the error from the pattern compiler.
+	Err error
+}
+
+// Error returns a descriptive string.
+func (fpe *FilterPatternError) Error() string {
+	return fmt.Sprintf("filter pattern (%d) of %s is not valid [%s]: %s", fpe.Index, fpe.Field, fpe.Pattern, fpe.Err.Error())
+}
+
+// ValidateFilter checks that all of the patterns in the filter can be
+// compiled, without panicking. A `*FilterPatternError` naming the first
+// offending pattern is returned if not. This allows user-supplied patterns to
+// be rejected gracefully.
+func ValidateFilter(filter Filter) (err error) {
+	pathPatterns := map[string][]string{
+		"IncludePaths": filter.IncludePaths,
+		"ExcludePaths": filter.ExcludePaths,
+	}
+
+	for _, field := range []string{"IncludePaths", "ExcludePaths"} {
+		for i, pattern := range pathPatterns[field] {
+			_, err := glob.Compile(pattern, '/')
+			if err != nil {
+				return &FilterPatternError{
+					Field:   field,
+					Index:   i,
+					Pattern: pattern,
+					Err:     err,
+				}
 			}
 		}
 	}
 
-	// No include filters or matching exclude filters. Include.
+	filenamePatterns := map[string][]string{
+		"IncludeFilenames": filter.IncludeFilenames,
+		"ExcludeFilenames": filter.ExcludeFilenames,
+	}
+
+	for _, field := range []string{"IncludeFilenames", "ExcludeFilenames"} {
+		for i, pattern := range filenamePatterns[field] {
+			// The only error that can be returned is for a malformed pattern
+			// and that will be detected regardless of the name.
+			_, err := filepath.Match(pattern, "")
+			if err != nil {
+				return &FilterPatternError{
+					Field:   field,
+					Index:   i,
+					Pattern: pattern,
+					Err:     err,
+				}
+			}
+		}
+	}
+
+	for i, extension := range filter.IncludeCompoundExtensions {
+		if strings.Trim(extension, ".") == "" {
+			return fmt.Errorf("compound extension can not be empty: IncludeCompoundExtensions[%d] [%s]", i, extension)
+		}
+	}
+
+	if filter.CreatedAfter.IsZero() == false && filter.CreatedBefore.IsZero() == false && filter.CreatedBefore.After(filter.CreatedAfter) == false {
+		return fmt.Errorf("created-before must be later than created-after: [%s] <= [%s]", filter.CreatedBefore, filter.CreatedAfter)
+	}
+
+	if filter.MaxPathLength < 0 {
+		return fmt.Errorf("max path-length can not be negative: (%d)", filter.MaxPathLength)
+	}
+
+	if filter.MaxPathComponents < 0 {
+		return fmt.Errorf("max path-components can not be negative: (%d)", filter.MaxPathComponents)
+	}
+
+	return nil
+}
+
+// SetApplyFilterToRoot determines whether the root's own name is checked
+// against the path filters, as though it were a top-level directory. By
+// default, the root is only checked as an empty relative path, so its own
+// name is never matched (e.g. an exclude of "build" won't exclude a root of
+// "/tmp/build"). If excluded, the callback isn't called for the root and its
+// files aren't visited, but its subdirectories are still descended into
+// according to the usual recursive-filter rules.
+func (walk *Walk) SetApplyFilterToRoot(isApplyFilterToRoot bool) {
+	walk.isApplyFilterToRoot = isApplyFilterToRoot
+}
+
+// isRootFilteredByName returns true if the given directory is the root and
+// its name is excluded by the path filters.
+func (walk *Walk) isRootFilteredByName(jdn jobDirectoryNode, info os.FileInfo) bool {
+	if walk.isApplyFilterToRoot == false || jdn.depth != 0 {
+		return false
+	}
+
+	return walk.filter.IsPathIncluded(info.Name()) == false
+}
+
+// isCreationTimeIncluded determines if the given file was created within the
+// bounds. Files whose creation time isn't available are excluded.
+func (walk *Walk) isCreationTimeIncluded(fqPath string, info os.FileInfo) bool {
+	btime, ok := getBirthTime(fqPath, info)
+	if ok == false {
+		return false
+	}
+
+	return walk.filter.IsCreationTimeIncluded(btime)
+}
+
+// isCompoundExtensionExcluded returns true and updates the stats if
+// compound extensions were given and the given file doesn't end in one of
+// them.
+func (walk *Walk) isCompoundExtensionExcluded(fqPath string, filename string) bool {
+	if walk.filter.HasCompoundExtensions() == false {
+		return false
+	}
+
+	if walk.filter.IsCompoundExtensionIncluded(filename) == true {
+		walk.statsLocker.Lock()
+		walk.stats.CompoundExtensionMatches++
+		walk.statsLocker.Unlock()
+
+		return false
+	}
+
+	walk.logDebugf("File excluded by compound extension: [%s]", filename)
+
+	walk.statsFileFilterExcludeTickUp()
+	walk.notifySkip(fqPath, SkipFilterFilename)
+
 	return true
 }
 
+// isTopLevelDirectoryExcluded returns true and updates the stats if the given
+// directory is directly under the root and isn't one of the allowed ones.
+func (walk *Walk) isTopLevelDirectoryExcluded(fqPath string, depth int) bool {
+	if depth != 1 || walk.filter.IsTopLevelDirectoryAllowed(path.Base(fqPath)) == true {
+		return false
+	}
+
+	walk.logDebugf("Top-level directory excluded: [%s]", fqPath)
+
+	walk.notifySkip(fqPath, SkipFilterTopLevelDirectory)
+
+	walk.statsLocker.Lock()
+	walk.stats.TopLevelDirsExcluded++
+	walk.statsLocker.Unlock()
+
+	return true
+}
+
+// isRootAlwaysIncluded returns true if the given directory is the root and
+// its callback is to be called regardless of the path filters.
+func (walk *Walk) isRootAlwaysIncluded(jdn jobDirectoryNode) bool {
+	return walk.filter.suppressRoot == false && walk.isApplyFilterToRoot == false && jdn.depth == 0
+}
+
 // newInternalFilters constructs an `internalFilter` from a `Filter`.
 func newInternalFilter(filter Filter) internalFilter {
 
 	internalFilter := internalFilter{
 		isCaseInsensitive: filter.IsCaseInsensitive,
+		precedence:        filter.Precedence,
+		maxPathLength:     filter.MaxPathLength,
+		maxPathComponents: filter.MaxPathComponents,
+		suppressRoot:      filter.SuppressRoot,
+
+		excludeSymlinkTargetsOutsideRoot: filter.ExcludeSymlinkTargetsOutsideRoot,
 	}
 
 	internalFilter.includePaths = make([]glob.Glob, 0)
@@ -149,5 +613,50 @@ func newInternalFilter(filter Filter) internalFilter {
 		internalFilter.excludeFilenames.Sort()
 	}
 
+	if len(filter.IncludeCompoundExtensions) > 0 {
+		internalFilter.compoundExtensions = make([]string, len(filter.IncludeCompoundExtensions))
+		for i, extension := range filter.IncludeCompoundExtensions {
+			internalFilter.compoundExtensions[i] = "." + strings.ToLower(strings.TrimLeft(extension, "."))
+		}
+	}
+
+	if len(filter.ContentMagic) > 0 {
+		internalFilter.contentMagic = filter.ContentMagic
+
+		for _, magic := range filter.ContentMagic {
+			if len(magic) > internalFilter.contentMagicMaxLen {
+				internalFilter.contentMagicMaxLen = len(magic)
+			}
+		}
+	}
+
+	if len(filter.OwnerUIDs) > 0 {
+		internalFilter.ownerUIDs = make(map[int]struct{}, len(filter.OwnerUIDs))
+		for _, uid := range filter.OwnerUIDs {
+			internalFilter.ownerUIDs[uid] = struct{}{}
+		}
+	}
+
+	if len(filter.OwnerGIDs) > 0 {
+		internalFilter.ownerGIDs = make(map[int]struct{}, len(filter.OwnerGIDs))
+		for _, gid := range filter.OwnerGIDs {
+			internalFilter.ownerGIDs[gid] = struct{}{}
+		}
+	}
+
+	internalFilter.createdAfter = filter.CreatedAfter
+	internalFilter.createdBefore = filter.CreatedBefore
+
+	if len(filter.AllowedTopLevelDirs) > 0 {
+		internalFilter.allowedTopLevelDirs = make(map[string]struct{}, len(filter.AllowedTopLevelDirs))
+		for _, name := range filter.AllowedTopLevelDirs {
+			if filter.IsCaseInsensitive == true {
+				name = strings.ToLower(name)
+			}
+
+			internalFilter.allowedTopLevelDirs[name] = struct{}{}
+		}
+	}
+
 	return internalFilter
 }
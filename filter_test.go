@@ -1,9 +1,18 @@
 package pathwalk
 
 import (
+	"os"
+	"path"
 	"reflect"
 	"sort"
+	"sync"
 	"testing"
+	"time"
+
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/dsoprea/go-logging"
 )
 
 func TestinternalFilter_IsFileIncluded__includeOnly__hitOnInclude(t *testing.T) {
@@ -149,6 +158,42 @@ func TestinternalFilter_IsFileIncluded__includeAndExclude__includesCheckedBefore
 	}
 }
 
+func TestInternalFilter_IsFileIncluded__includeAndExclude__excludesCheckedBeforeIncludes__excludePreemptsInclude(t *testing.T) {
+	filter := Filter{
+		IncludeFilenames: []string{"filename2"},
+		ExcludeFilenames: []string{"filename2"},
+		Precedence:       ExcludeFirst,
+	}
+
+	internalFilter := newInternalFilter(filter)
+
+	if internalFilter.IsFileIncluded("filename2") != false {
+		t.Fatalf("Expected exclude.")
+	}
+}
+
+func TestInternalFilter_IsFileIncluded__includeAndExclude__excludesCheckedBeforeIncludes__includeOnPrefixAndExcludeOnWhole(t *testing.T) {
+	filter := Filter{
+		IncludeFilenames: []string{"included_file*"},
+		ExcludeFilenames: []string{"included_file_nevermind"},
+		Precedence:       ExcludeFirst,
+	}
+
+	internalFilter := newInternalFilter(filter)
+
+	if internalFilter.IsFileIncluded("included_file") != true {
+		t.Fatalf("Expected include.")
+	}
+
+	if internalFilter.IsFileIncluded("included_file_nevermind") != false {
+		t.Fatalf("Expected exclude.")
+	}
+
+	if internalFilter.IsFileIncluded("other_file") != false {
+		t.Fatalf("Expected exclude on include miss.")
+	}
+}
+
 func TestinternalFilter_IsFileIncluded__none__default(t *testing.T) {
 	filter := Filter{}
 	internalFilter := newInternalFilter(filter)
@@ -416,6 +461,38 @@ func TestinternalFilter_IsPathIncluded__includeAndExclude__includesCheckedBefore
 	}
 }
 
+func TestInternalFilter_IsPathIncluded__includeAndExclude__excludesCheckedBeforeIncludes__excludePreemptsInclude(t *testing.T) {
+	filter := Filter{
+		IncludePaths: []string{"path1/path2"},
+		ExcludePaths: []string{"path1/path2"},
+		Precedence:   ExcludeFirst,
+	}
+
+	internalFilter := newInternalFilter(filter)
+
+	if internalFilter.IsPathIncluded("path1/path2") != false {
+		t.Fatalf("Expected exclude.")
+	}
+}
+
+func TestInternalFilter_IsPathIncluded__includeAndExclude__excludesCheckedBeforeIncludes__excludeBroadIncludeNarrow(t *testing.T) {
+	filter := Filter{
+		IncludePaths: []string{"path1/**"},
+		ExcludePaths: []string{"path1/path2/**"},
+		Precedence:   ExcludeFirst,
+	}
+
+	internalFilter := newInternalFilter(filter)
+
+	if internalFilter.IsPathIncluded("path1/path3") != true {
+		t.Fatalf("Expected include.")
+	}
+
+	if internalFilter.IsPathIncluded("path1/path2/path3") != false {
+		t.Fatalf("Expected exclude.")
+	}
+}
+
 func TestinternalFilter_IsPathIncluded__none__default(t *testing.T) {
 	filter := Filter{}
 	internalFilter := newInternalFilter(filter)
@@ -434,6 +511,310 @@ func TestinternalFilter_IsPathIncluded__none__explicit(t *testing.T) {
 	}
 }
 
+func TestInternalFilter_IsContentIncluded(t *testing.T) {
+	filter := Filter{
+		ContentMagic: [][]byte{
+			[]byte("abc"),
+			[]byte("defgh"),
+		},
+	}
+
+	internalFilter := newInternalFilter(filter)
+
+	if internalFilter.HasContentMagic() != true {
+		t.Fatalf("Expected content magic to be present.")
+	} else if internalFilter.ContentMagicMaxLen() != 5 {
+		t.Fatalf("ContentMagicMaxLen() not correct: (%d)", internalFilter.ContentMagicMaxLen())
+	}
+
+	if internalFilter.IsContentIncluded([]byte("abcxx")) != true {
+		t.Fatalf("Expected include (1).")
+	}
+
+	if internalFilter.IsContentIncluded([]byte("defgh")) != true {
+		t.Fatalf("Expected include (2).")
+	}
+
+	if internalFilter.IsContentIncluded([]byte("def")) != false {
+		t.Fatalf("Expected exclude on short head.")
+	}
+
+	if internalFilter.IsContentIncluded([]byte{}) != false {
+		t.Fatalf("Expected exclude on empty head.")
+	}
+}
+
+func TestInternalFilter_IsPathLengthIncluded(t *testing.T) {
+	internalFilter := newInternalFilter(Filter{})
+
+	if internalFilter.IsPathLengthIncluded("/aa/bb/cc") != true {
+		t.Fatalf("Expected include without a maximum.")
+	}
+
+	filter := Filter{
+		MaxPathLength: 6,
+	}
+
+	internalFilter = newInternalFilter(filter)
+
+	if internalFilter.IsPathLengthIncluded("/aa/bb") != true {
+		t.Fatalf("Expected include at the maximum.")
+	} else if internalFilter.IsPathLengthIncluded("/aa/bbb") != false {
+		t.Fatalf("Expected exclude above the maximum.")
+	}
+}
+
+func TestInternalFilter_IsTopLevelDirectoryAllowed(t *testing.T) {
+	internalFilter := newInternalFilter(Filter{})
+
+	if internalFilter.IsTopLevelDirectoryAllowed("anything") != true {
+		t.Fatalf("Expected every directory to be allowed without an allowlist.")
+	}
+
+	filter := Filter{
+		AllowedTopLevelDirs: []string{"src", "Docs"},
+	}
+
+	internalFilter = newInternalFilter(filter)
+
+	if internalFilter.IsTopLevelDirectoryAllowed("src") != true {
+		t.Fatalf("Expected allowed directory to be allowed.")
+	} else if internalFilter.IsTopLevelDirectoryAllowed("docs") != false {
+		t.Fatalf("Expected a case-sensitive match.")
+	} else if internalFilter.IsTopLevelDirectoryAllowed("vendor") != false {
+		t.Fatalf("Expected other directory to not be allowed.")
+	}
+
+	filter.IsCaseInsensitive = true
+	internalFilter = newInternalFilter(filter)
+
+	if internalFilter.IsTopLevelDirectoryAllowed("docs") != true {
+		t.Fatalf("Expected a case-insensitive match.")
+	}
+}
+
+func TestInternalFilter_IsCompoundExtensionIncluded(t *testing.T) {
+	filter := Filter{
+		IncludeCompoundExtensions: []string{"tar.gz", ".ZIP"},
+	}
+
+	internalFilter := newInternalFilter(filter)
+
+	if internalFilter.HasCompoundExtensions() != true {
+		t.Fatalf("Expected compound extensions.")
+	}
+
+	// Compound extensions.
+
+	if internalFilter.IsCompoundExtensionIncluded("archive.tar.gz") != true {
+		t.Fatalf("Expected compound extension to match.")
+	} else if internalFilter.IsCompoundExtensionIncluded("backup.2020.TAR.GZ") != true {
+		t.Fatalf("Expected compound extension to match case-insensitively.")
+	} else if internalFilter.IsCompoundExtensionIncluded("archive.gz") != false {
+		t.Fatalf("Expected partial compound extension to not match.")
+	} else if internalFilter.IsCompoundExtensionIncluded("archive.xtar.gz") != false {
+		t.Fatalf("Expected match to be on a dot boundary.")
+	}
+
+	// Single extensions.
+
+	if internalFilter.IsCompoundExtensionIncluded("bundle.zip") != true {
+		t.Fatalf("Expected single extension to match.")
+	} else if internalFilter.IsCompoundExtensionIncluded("bundle.tar") != false {
+		t.Fatalf("Expected other single extension to not match.")
+	}
+
+	// No extensions.
+
+	if internalFilter.IsCompoundExtensionIncluded("README") != false {
+		t.Fatalf("Expected filename without extension to not match.")
+	} else if internalFilter.IsCompoundExtensionIncluded("zip") != false {
+		t.Fatalf("Expected filename equal to the extension to not match.")
+	} else if internalFilter.IsCompoundExtensionIncluded(".tar.gz") != false {
+		t.Fatalf("Expected filename consisting of only the extension to not match.")
+	}
+}
+
+func TestWalk_IncludeCompoundExtensions(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	relFilepaths := []string{
+		"README",
+		"archive.tar.gz",
+		"archive.gz",
+		"archive.tar",
+		"subdir/Other.TAR.GZ",
+		"subdir/notes.txt",
+	}
+
+	for _, relFilepath := range relFilepaths {
+		fqFilepath := path.Join(tempPath, relFilepath)
+
+		err := os.MkdirAll(path.Dir(fqFilepath), 0755)
+		log.PanicIf(err)
+
+		err = ioutil.WriteFile(fqFilepath, []byte{}, 0644)
+		log.PanicIf(err)
+	}
+
+	for _, isNamesOnly := range []bool{false, true} {
+		m := sync.Mutex{}
+		visited := make([]string, 0)
+
+		walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+			if info.IsDir() == true {
+				return nil
+			}
+
+			m.Lock()
+			defer m.Unlock()
+
+			visited = append(visited, path.Join(parentPath, info.Name()))
+
+			return nil
+		}
+
+		nameFunc := func(fqPath string, isDir bool) (err error) {
+			if isDir == true {
+				return nil
+			}
+
+			m.Lock()
+			defer m.Unlock()
+
+			visited = append(visited, fqPath)
+
+			return nil
+		}
+
+		walk := NewWalk(tempPath, walkFunc)
+		walk.SetPathStyle(PathStyleRelative)
+
+		if isNamesOnly == true {
+			walk.SetNamesOnly(true)
+			walk.SetNameFunc(nameFunc)
+		}
+
+		filter := Filter{
+			IncludeCompoundExtensions: []string{"tar.gz"},
+		}
+
+		err = walk.SetFilter(filter)
+		log.PanicIf(err)
+
+		err = walk.Run()
+		log.PanicIf(err)
+
+		sort.Strings(visited)
+
+		expected := []string{
+			"archive.tar.gz",
+			"subdir/Other.TAR.GZ",
+		}
+
+		if reflect.DeepEqual(visited, expected) != true {
+			t.Fatalf("Visited files not correct (names-only=%v): %v", isNamesOnly, visited)
+		}
+
+		stats := walk.Stats()
+
+		if stats.CompoundExtensionMatches != 2 {
+			t.Fatalf("CompoundExtensionMatches not correct (names-only=%v): (%d)", isNamesOnly, stats.CompoundExtensionMatches)
+		} else if stats.FileFilterExcludes != 4 {
+			t.Fatalf("FileFilterExcludes not correct (names-only=%v): (%d)", isNamesOnly, stats.FileFilterExcludes)
+		}
+	}
+}
+
+func TestWalk_AllowedTopLevelDirs(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	relFilepaths := []string{
+		"README",
+		"src/main.go",
+		"src/internal/util.go",
+		"docs/index.md",
+		"docs/private/notes.md",
+		"vendor/lib/lib.go",
+		"build/out.bin",
+	}
+
+	for _, relFilepath := range relFilepaths {
+		fqFilepath := path.Join(tempPath, relFilepath)
+
+		err := os.MkdirAll(path.Dir(fqFilepath), 0755)
+		log.PanicIf(err)
+
+		err = ioutil.WriteFile(fqFilepath, []byte{}, 0644)
+		log.PanicIf(err)
+	}
+
+	m := sync.Mutex{}
+	visited := make([]string, 0)
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		visited = append(visited, path.Join(parentPath, info.Name()))
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetPathStyle(PathStyleRelative)
+
+	// The path filters still apply below the allowed directories.
+	filter := Filter{
+		AllowedTopLevelDirs: []string{"src", "docs"},
+		ExcludePaths:        []string{"docs/private"},
+	}
+
+	err = walk.SetFilter(filter)
+	log.PanicIf(err)
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	sort.Strings(visited)
+
+	expected := []string{
+		".",
+		"README",
+		"docs",
+		"docs/index.md",
+		"src",
+		"src/internal",
+		"src/internal/util.go",
+		"src/main.go",
+	}
+
+	if reflect.DeepEqual(visited, expected) != true {
+		t.Fatalf("Visited entries not correct: %v", visited)
+	}
+
+	stats := walk.Stats()
+
+	if stats.TopLevelDirsExcluded != 2 {
+		t.Fatalf("TopLevelDirsExcluded not correct: (%d)", stats.TopLevelDirsExcluded)
+	}
+
+	// The disallowed directories weren't descended into.
+	if stats.DirectoriesVisited != 5 {
+		t.Fatalf("DirectoriesVisited not correct: (%d)", stats.DirectoriesVisited)
+	}
+}
+
 func TestNewInternalFilters(t *testing.T) {
 	f := Filter{
 		IncludePaths:     []string{"aa/bb"},
@@ -455,3 +836,102 @@ func TestNewInternalFilters(t *testing.T) {
 		t.Fatalf("Filters not correct: %v", internal)
 	}
 }
+
+func TestValidateFilter(t *testing.T) {
+	f := Filter{
+		IncludePaths:     []string{"aa/**", "*/bb"},
+		ExcludePaths:     []string{"cc/dd"},
+		IncludeFilenames: []string{"*.txt", "file[0-9]"},
+		ExcludeFilenames: []string{"filename3"},
+		MaxPathLength:    100,
+	}
+
+	err := ValidateFilter(f)
+	log.PanicIf(err)
+}
+
+func TestValidateFilter__invalidPathPattern(t *testing.T) {
+	f := Filter{
+		IncludePaths: []string{"aa/bb"},
+		ExcludePaths: []string{"cc/dd", "ee/[ff"},
+	}
+
+	err := ValidateFilter(f)
+	if err == nil {
+		t.Fatalf("Expected error.")
+	}
+
+	fpe, ok := err.(*FilterPatternError)
+	if ok != true {
+		t.Fatalf("Error not correct: [%v]", err)
+	} else if fpe.Field != "ExcludePaths" || fpe.Index != 1 || fpe.Pattern != "ee/[ff" {
+		t.Fatalf("Error details not correct: %v", fpe)
+	}
+}
+
+func TestValidateFilter__invalidFilenamePattern(t *testing.T) {
+	f := Filter{
+		IncludeFilenames: []string{"[abc"},
+	}
+
+	err := ValidateFilter(f)
+	if err == nil {
+		t.Fatalf("Expected error.")
+	}
+
+	fpe, ok := err.(*FilterPatternError)
+	if ok != true {
+		t.Fatalf("Error not correct: [%v]", err)
+	} else if fpe.Field != "IncludeFilenames" || fpe.Index != 0 || fpe.Pattern != "[abc" {
+		t.Fatalf("Error details not correct: %v", fpe)
+	} else if fpe.Err != filepath.ErrBadPattern {
+		t.Fatalf("Underlying error not correct: [%v]", fpe.Err)
+	}
+}
+
+func TestValidateFilter__invertedCreationTimes(t *testing.T) {
+	now := time.Now()
+
+	f := Filter{
+		CreatedAfter:  now,
+		CreatedBefore: now.Add(-time.Hour),
+	}
+
+	err := ValidateFilter(f)
+	if err == nil {
+		t.Fatalf("Expected error.")
+	}
+}
+
+func TestValidateFilter__emptyCompoundExtension(t *testing.T) {
+	f := Filter{
+		IncludeCompoundExtensions: []string{"tar.gz", "."},
+	}
+
+	err := ValidateFilter(f)
+	if err == nil {
+		t.Fatalf("Expected error.")
+	}
+}
+
+func TestValidateFilter__negativeMaxPathComponents(t *testing.T) {
+	f := Filter{
+		MaxPathComponents: -1,
+	}
+
+	err := ValidateFilter(f)
+	if err == nil {
+		t.Fatalf("Expected error.")
+	}
+}
+
+func TestValidateFilter__negativeMaxPathLength(t *testing.T) {
+	f := Filter{
+		MaxPathLength: -1,
+	}
+
+	err := ValidateFilter(f)
+	if err == nil {
+		t.Fatalf("Expected error.")
+	}
+}
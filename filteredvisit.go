@@ -0,0 +1,68 @@
+package pathwalk
+
+import (
+	"os"
+
+	"github.com/dsoprea/go-logging"
+)
+
+// FilteredVisitFunc is the function type for the callback that receives the
+// entries that were excluded by the filters.
+type FilteredVisitFunc func(parentPath string, info os.FileInfo, wasIncluded bool, reason SkipReason) (err error)
+
+// SetFilteredVisitFunc sets a callback that is called for the entries that are
+// excluded by the path, filename, owner, or content filters (for which the
+// regular callback is not called). Together with the regular callback, this
+// provides complete coverage of the tree along with the filtering outcome
+// (e.g. for indexing). `reason` identifies the filter that excluded the entry.
+// `wasIncluded` is true for a file that passed its own filters and is only
+// excluded because its directory was excluded by the path filters (the
+// filename filters aren't checked in that case, so this just means that it
+// wasn't excluded on its own account).
+//
+// Like the regular callback, it may return `ErrSkipDirectory` for an excluded
+// directory in order to not descend into it. Any other error is handled the
+// same way as one from the regular callback. The path is formatted the same
+// way as for the regular callback. This is not called in names-only mode and
+// there is no overhead if this isn't set.
+func (walk *Walk) SetFilteredVisitFunc(filteredVisitFunc FilteredVisitFunc) {
+	walk.filteredVisitFunc = filteredVisitFunc
+}
+
+// callFilteredVisitFunc delivers one filtered-out entry. Errors for files are
+// subject to the error policy.
+func (walk *Walk) callFilteredVisitFunc(parentNodePath string, info os.FileInfo, wasIncluded bool, reason SkipReason) (err error) {
+	if walk.filteredVisitFunc == nil || walk.isNamesOnly == true || walk.isCountingMatches == true {
+		return nil
+	}
+
+	reportedParentPath := parentNodePath
+	reportedInfo := info
+
+	if walk.isReformattingReportedPaths() == true {
+		reportedParentPath, reportedInfo = walk.reportPath(parentNodePath, info)
+	}
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		return walk.filteredVisitFunc(parentPath, info, wasIncluded, reason)
+	}
+
+	err = walk.callVisitorSafely(walkFunc, reportedParentPath, reportedInfo)
+	if info.IsDir() == true {
+		return err
+	}
+
+	return walk.applyFileErrorPolicy(parentNodePath, info, err)
+}
+
+// handleFilteredFile delivers one file that was excluded by the filters. Files
+// that couldn't be read in order to be filtered were skipped rather than
+// filtered and are ignored.
+func (walk *Walk) handleFilteredFile(parentNodePath string, info os.FileInfo, wasIncluded bool, reason SkipReason) {
+	if reason == SkipUnreadable {
+		return
+	}
+
+	err := walk.callFilteredVisitFunc(parentNodePath, info, wasIncluded, reason)
+	log.PanicIf(err)
+}
@@ -0,0 +1,183 @@
+package pathwalk
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+)
+
+func createFilteredVisitTestTree() (tempPath string) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	for _, directoryName := range []string{"keep", "drop"} {
+		err := os.Mkdir(path.Join(tempPath, directoryName), 0755)
+		log.PanicIf(err)
+	}
+
+	filepaths := []string{
+		"a.txt",
+		"b.log",
+		"keep/c.txt",
+		"drop/d.txt",
+	}
+
+	for _, relFilepath := range filepaths {
+		err := ioutil.WriteFile(path.Join(tempPath, relFilepath), []byte{}, 0644)
+		log.PanicIf(err)
+	}
+
+	return tempPath
+}
+
+func TestWalk_SetFilteredVisitFunc(t *testing.T) {
+	tempPath := createFilteredVisitTestTree()
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	m := sync.Mutex{}
+	visited := make([]string, 0)
+	filtered := make([]string, 0)
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		visited = append(visited, info.Name())
+		return nil
+	}
+
+	filteredVisitFunc := func(parentPath string, info os.FileInfo, wasIncluded bool, reason SkipReason) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		relPath := path.Join(parentPath, info.Name())[len(tempPath)+1:]
+		phrase := fmt.Sprintf("%s %v %s", relPath, wasIncluded, reason)
+
+		filtered = append(filtered, phrase)
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetFilteredVisitFunc(filteredVisitFunc)
+
+	filter := Filter{
+		ExcludePaths:     []string{"drop"},
+		ExcludeFilenames: []string{"*.log"},
+	}
+
+	err := walk.SetFilter(filter)
+	log.PanicIf(err)
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	sort.Strings(visited)
+	sort.Strings(filtered)
+
+	expectedVisited := []string{
+		"a.txt",
+		"c.txt",
+		"keep",
+		path.Base(tempPath),
+	}
+
+	sort.Strings(expectedVisited)
+
+	if reflect.DeepEqual(visited, expectedVisited) != true {
+		t.Fatalf("Visited entries not correct: %v", visited)
+	}
+
+	expectedFiltered := []string{
+		"b.log false filter-filename",
+		"drop false filter-path",
+		"drop/d.txt true filter-path",
+	}
+
+	if reflect.DeepEqual(filtered, expectedFiltered) != true {
+		t.Fatalf("Filtered entries not correct: %v", filtered)
+	}
+}
+
+func TestWalk_SetFilteredVisitFunc__skipDirectory(t *testing.T) {
+	tempPath := createFilteredVisitTestTree()
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	m := sync.Mutex{}
+	filtered := make([]string, 0)
+
+	filteredVisitFunc := func(parentPath string, info os.FileInfo, wasIncluded bool, reason SkipReason) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		filtered = append(filtered, info.Name())
+
+		if info.IsDir() == true {
+			return ErrSkipDirectory
+		}
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, nil)
+	walk.SetFilteredVisitFunc(filteredVisitFunc)
+
+	filter := Filter{
+		ExcludePaths: []string{"drop"},
+	}
+
+	err := walk.SetFilter(filter)
+	log.PanicIf(err)
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	if reflect.DeepEqual(filtered, []string{"drop"}) != true {
+		t.Fatalf("Filtered entries not correct: %v", filtered)
+	} else if walk.Stats().DirectoriesIgnored != 1 {
+		t.Fatalf("DirectoriesIgnored not correct: (%d)", walk.Stats().DirectoriesIgnored)
+	}
+}
+
+func TestWalk_SetFilteredVisitFunc__error(t *testing.T) {
+	tempPath := createFilteredVisitTestTree()
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	errTest := errors.New("test error")
+
+	filteredVisitFunc := func(parentPath string, info os.FileInfo, wasIncluded bool, reason SkipReason) (err error) {
+		return errTest
+	}
+
+	walk := NewWalk(tempPath, nil)
+	walk.SetFilteredVisitFunc(filteredVisitFunc)
+
+	filter := Filter{
+		ExcludeFilenames: []string{"*.log"},
+	}
+
+	err := walk.SetFilter(filter)
+	log.PanicIf(err)
+
+	err = walk.Run()
+	if err == nil {
+		t.Fatalf("Expected error.")
+	}
+}
@@ -0,0 +1,107 @@
+package pathwalk
+
+import (
+	"errors"
+)
+
+var (
+	// ErrMaxGoroutinesTooLow is returned by `Run()` if the maximum number of
+	// goroutines is less than the concurrency or leaves no room for a worker.
+	ErrMaxGoroutinesTooLow = errors.New("max-goroutines is lower than the concurrency")
+)
+
+// SetMaxGoroutines sets a hard limit on the number of goroutines that the walk
+// creates at any one time, which includes the workers as well as the helpers
+// (the progress reporter, the goroutine behind an `Iterator`, the serial
+// callback goroutine, and the reads
+// that enforce the per-directory timeout). It must be at least the
+// concurrency (or the maximum load-aware concurrency), which still bounds the
+// workers on their own. The helpers come out of the same budget, so the walk
+// might run with slightly fewer workers than the concurrency when they are
+// active. When there is no room for a timed read, the batch is read by the
+// worker directly and the per-directory timeout is only checked once that read
+// returns (see `SetPerDirectoryTimeout()`).
+// The caller's goroutine is not counted. Zero (the default) means no limit.
+func (walk *Walk) SetMaxGoroutines(maxGoroutines int) {
+	walk.maxGoroutines = maxGoroutines
+}
+
+// helperGoroutineCount returns the number of goroutines that run for the
+// whole of the current run alongside the workers.
+func (walk *Walk) helperGoroutineCount() int {
+	count := 0
+
+	if walk.progressFunc != nil && walk.progressInterval > 0 {
+		count++
+	}
+
+	if walk.iterator != nil {
+		count++
+	}
+
+	if walk.isSerialCallback == true {
+		count++
+	}
+
+	return count
+}
+
+// prepareGoroutineLimit determines how many workers and timed reads can exist
+// at once during the current run after the helpers are accounted for.
+func (walk *Walk) prepareGoroutineLimit() (err error) {
+	if walk.maxGoroutines <= 0 {
+		walk.workerGoroutineLimit = 0
+		return nil
+	}
+
+	concurrency := walk.concurrency
+
+	walk.loadLocker.Lock()
+	if walk.loadSampler != nil {
+		concurrency = walk.loadAwareMaxConcurrency
+	}
+	walk.loadLocker.Unlock()
+
+	limit := walk.maxGoroutines - walk.helperGoroutineCount()
+	if walk.maxGoroutines < concurrency || limit < 1 {
+		return ErrMaxGoroutinesTooLow
+	}
+
+	walk.workerGoroutineLimit = limit
+
+	return nil
+}
+
+// hasGoroutineRoom returns whether another worker or timed read can be started
+// without exceeding the limit. The state locker must be held.
+func (walk *Walk) hasGoroutineRoom() bool {
+	if walk.workerGoroutineLimit == 0 {
+		return true
+	}
+
+	return walk.workerCount+walk.readerGoroutineCount < walk.workerGoroutineLimit
+}
+
+// acquireReaderGoroutine reserves room for a timed read. It returns false if
+// there is none.
+func (walk *Walk) acquireReaderGoroutine() bool {
+	walk.stateLocker.Lock()
+	defer walk.stateLocker.Unlock()
+
+	if walk.hasGoroutineRoom() == false {
+		return false
+	}
+
+	walk.readerGoroutineCount++
+
+	return true
+}
+
+// releaseReaderGoroutine releases the room reserved for a timed read once its
+// goroutine is finishing.
+func (walk *Walk) releaseReaderGoroutine() {
+	walk.stateLocker.Lock()
+	defer walk.stateLocker.Unlock()
+
+	walk.readerGoroutineCount--
+}
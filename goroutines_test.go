@@ -0,0 +1,152 @@
+package pathwalk
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+)
+
+// sampledChildLister slows the reads down and calls a function during each one
+// so that the goroutines doing the reads can be observed.
+type sampledChildLister struct {
+	*filesystemChildLister
+
+	sample func()
+}
+
+func (scl *sampledChildLister) ListChildren(path string, batchSize int) (names []string, hasMore bool, err error) {
+	time.Sleep(time.Millisecond * 5)
+	scl.sample()
+
+	return scl.filesystemChildLister.ListChildren(path, batchSize)
+}
+
+func TestWalk_SetMaxGoroutines(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	for i := 0; i < 30; i++ {
+		directoryPath := path.Join(tempPath, fmt.Sprintf("dir%02d", i))
+
+		err := os.Mkdir(directoryPath, 0755)
+		log.PanicIf(err)
+
+		for j := 0; j < 10; j++ {
+			filepath := path.Join(directoryPath, fmt.Sprintf("file%02d", j))
+
+			err := ioutil.WriteFile(filepath, []byte{}, 0644)
+			log.PanicIf(err)
+		}
+	}
+
+	concurrency := 40
+	maxGoroutines := concurrency + 1
+
+	baseline := runtime.NumGoroutine()
+
+	locker := sync.Mutex{}
+	highest := 0
+
+	sample := func() {
+		count := runtime.NumGoroutine()
+
+		locker.Lock()
+		if count > highest {
+			highest = count
+		}
+		locker.Unlock()
+	}
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		time.Sleep(time.Millisecond)
+		sample()
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetConcurrency(concurrency)
+	walk.SetMaxGoroutines(maxGoroutines)
+	walk.SetPerDirectoryTimeout(time.Minute)
+
+	scl := &sampledChildLister{
+		filesystemChildLister: newFilesystemChildLister(),
+		sample:                sample,
+	}
+
+	walk.SetChildLister(scl)
+
+	walk.SetProgressFunc(time.Millisecond, func(stats Stats) {
+		sample()
+	})
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	if walk.Stats().FilesVisited != 300 {
+		t.Fatalf("Not all files were visited: (%d)", walk.Stats().FilesVisited)
+	} else if highest-baseline > maxGoroutines {
+		t.Fatalf("Too many goroutines: (%d) > (%d)", highest-baseline, maxGoroutines)
+	}
+}
+
+func TestWalk_SetMaxGoroutines__lowerThanConcurrency(t *testing.T) {
+	tempPath := createDepthTestTree()
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walk := NewWalk(tempPath, nil)
+	walk.SetConcurrency(10)
+	walk.SetMaxGoroutines(5)
+
+	err := walk.Run()
+	if err == nil || log.Is(err, ErrMaxGoroutinesTooLow) != true {
+		t.Fatalf("Expected too-low error: [%v]", err)
+	}
+}
+
+func TestWalk_prepareGoroutineLimit(t *testing.T) {
+	walk := NewWalk("root/path", nil)
+	walk.SetConcurrency(3)
+
+	err := walk.prepareGoroutineLimit()
+	log.PanicIf(err)
+
+	if walk.workerGoroutineLimit != 0 {
+		t.Fatalf("Expected no limit: (%d)", walk.workerGoroutineLimit)
+	}
+
+	walk.SetMaxGoroutines(5)
+	walk.SetProgressFunc(time.Second, func(stats Stats) {})
+
+	err = walk.prepareGoroutineLimit()
+	log.PanicIf(err)
+
+	if walk.workerGoroutineLimit != 4 {
+		t.Fatalf("Limit not correct: (%d)", walk.workerGoroutineLimit)
+	}
+
+	// The helper leaves no room for a worker.
+
+	walk.SetConcurrency(1)
+	walk.SetMaxGoroutines(1)
+
+	err = walk.prepareGoroutineLimit()
+	if err != ErrMaxGoroutinesTooLow {
+		t.Fatalf("Expected too-low error: [%v]", err)
+	}
+}
@@ -0,0 +1,73 @@
+package pathwalk
+
+import (
+	"os"
+	"path"
+)
+
+// DirectoryHint carries what a callback knows about a directory that it wants
+// the walk to take into account when reading it.
+type DirectoryHint struct {
+	// BatchSize is the number of entries to read at a time from the
+	// directory. A smaller value spreads a huge directory's children across
+	// more jobs (and, therefore, more workers). Zero means no preference.
+	BatchSize int
+}
+
+// SetDirectoryHint attaches a hint to the directory at the given path, which
+// is formatted the same way as the paths that are given to the callbacks
+// (see `SetPathStyle()`). This can be called from any callback and from any
+// goroutine. It's usually called from the regular callback when it receives
+// the directory itself or one of its ancestors (or from the contextual
+// directory callback), since both are called before the directory is read.
+// It only affects a directory that hasn't started to be read yet. This doesn't
+// apply to directories that are delivered bottom-up (see `RunBottomUp()` and
+// `SetPruneEmptyDirectories()`), since their callbacks are called after they
+// have been read.
+//
+// A hint's batch-size takes precedence over both `SetBatchSizeFunc()` and
+// `SetBatchSize()` for that directory. Sampling (see
+// `SetSampleEntriesPerDirectory()`) still caps it, it still decides whether
+// the directory is small enough to be coalesced (see `SetAutoTuneBatching()`),
+// and the number of workers is still bounded by the concurrency. A negative
+// batch-size will terminate the walk when the directory is read.
+//
+// Hints are kept, and apply to subsequent runs, until they're replaced or
+// removed by setting an empty hint. They're counted in
+// `Stats.DirectoryHintsApplied` when used.
+func (walk *Walk) SetDirectoryHint(directoryPath string, hint DirectoryHint) {
+	directoryPath = path.Clean(directoryPath)
+
+	walk.directoryHintsLocker.Lock()
+	defer walk.directoryHintsLocker.Unlock()
+
+	if hint == (DirectoryHint{}) {
+		delete(walk.directoryHints, directoryPath)
+		return
+	}
+
+	if walk.directoryHints == nil {
+		walk.directoryHints = make(map[string]DirectoryHint)
+	}
+
+	walk.directoryHints[directoryPath] = hint
+}
+
+// directoryHint returns the hint for the given directory, if any.
+func (walk *Walk) directoryHint(directoryPath string, info os.FileInfo) (hint DirectoryHint, found bool) {
+	walk.directoryHintsLocker.Lock()
+	defer walk.directoryHintsLocker.Unlock()
+
+	if len(walk.directoryHints) == 0 {
+		return DirectoryHint{}, false
+	}
+
+	if walk.isReformattingReportedPaths() == true {
+		parentPath, reportedInfo := walk.reportPath(path.Dir(directoryPath), info)
+		directoryPath = path.Join(parentPath, reportedInfo.Name())
+	}
+
+	hint, found = walk.directoryHints[path.Clean(directoryPath)]
+
+	return hint, found
+}
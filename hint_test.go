@@ -0,0 +1,159 @@
+package pathwalk
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+	"github.com/dsoprea/go-utility/filesystem"
+)
+
+func TestWalk_SetDirectoryHint(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	for _, directoryName := range []string{"huge", "normal"} {
+		err := os.Mkdir(path.Join(tempPath, directoryName), 0755)
+		log.PanicIf(err)
+
+		for i := 0; i < 10; i++ {
+			filepath := path.Join(tempPath, directoryName, fmt.Sprintf("file%d", i))
+
+			err := ioutil.WriteFile(filepath, []byte{}, 0644)
+			log.PanicIf(err)
+		}
+	}
+
+	m := sync.Mutex{}
+	batchSizes := make(map[string][]int)
+
+	var walk *Walk
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		if info.Name() == "huge" {
+			walk.SetDirectoryHint(path.Join(parentPath, info.Name()), DirectoryHint{BatchSize: 3})
+		}
+
+		return nil
+	}
+
+	batchWalkFunc := func(parentPath string, infos []os.FileInfo) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		name := path.Base(parentPath)
+		batchSizes[name] = append(batchSizes[name], len(infos))
+
+		return nil
+	}
+
+	// The hint takes precedence over the batch-size callback.
+	batchSizeFunc := func(directoryPath string, info os.FileInfo) int {
+		return 5
+	}
+
+	walk = NewWalk(tempPath, walkFunc)
+	walk.SetBatchCallback(batchWalkFunc)
+	walk.SetBatchSizeFunc(batchSizeFunc)
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	hugeBatchSizes := batchSizes["huge"]
+	if len(hugeBatchSizes) != 4 {
+		t.Fatalf("Huge directory not batched correctly: %v", hugeBatchSizes)
+	}
+
+	total := 0
+	for _, batchSize := range hugeBatchSizes {
+		if batchSize > 3 {
+			t.Fatalf("Batch exceeds the hinted batch-size: %v", hugeBatchSizes)
+		}
+
+		total += batchSize
+	}
+
+	if total != 10 {
+		t.Fatalf("Huge directory not complete: %v", hugeBatchSizes)
+	}
+
+	normalBatchSizes := batchSizes["normal"]
+	if len(normalBatchSizes) != 2 || normalBatchSizes[0] != 5 || normalBatchSizes[1] != 5 {
+		t.Fatalf("Normal directory not batched correctly: %v", normalBatchSizes)
+	}
+
+	if walk.Stats().DirectoryHintsApplied != 1 {
+		t.Fatalf("DirectoryHintsApplied not correct: (%d)", walk.Stats().DirectoryHintsApplied)
+	}
+}
+
+func TestWalk_directoryHint(t *testing.T) {
+	walk := NewWalk("/root", nil)
+
+	info := rifs.NewSimpleFileInfoWithDirectory("dir", time.Time{})
+
+	_, found := walk.directoryHint("/root/dir", info)
+	if found != false {
+		t.Fatalf("Expected no hint.")
+	}
+
+	walk.SetDirectoryHint("/root/dir/", DirectoryHint{BatchSize: 7})
+
+	hint, found := walk.directoryHint("/root/dir", info)
+	if found != true {
+		t.Fatalf("Expected a hint.")
+	} else if hint.BatchSize != 7 {
+		t.Fatalf("Hint not correct: %+v", hint)
+	}
+
+	batchSize, err := walk.directoryBatchSize("/root/dir", info)
+	log.PanicIf(err)
+
+	if batchSize != 7 {
+		t.Fatalf("Hinted batch-size not used: (%d)", batchSize)
+	}
+
+	// Paths are given as they're reported to the callbacks.
+
+	walk.SetPathStyle(PathStyleRelative)
+
+	_, found = walk.directoryHint("/root/dir", info)
+	if found != false {
+		t.Fatalf("Expected the hint to not match the reformatted path.")
+	}
+
+	walk.SetDirectoryHint("dir", DirectoryHint{BatchSize: 8})
+
+	hint, found = walk.directoryHint("/root/dir", info)
+	if found != true || hint.BatchSize != 8 {
+		t.Fatalf("Hint not correct for relative path: [%v] %+v", found, hint)
+	}
+
+	// An empty hint removes it.
+
+	walk.SetDirectoryHint("dir", DirectoryHint{})
+
+	_, found = walk.directoryHint("/root/dir", info)
+	if found != false {
+		t.Fatalf("Expected the hint to be removed.")
+	}
+
+	// A negative batch-size is invalid.
+
+	walk.SetDirectoryHint("dir", DirectoryHint{BatchSize: -1})
+
+	_, err = walk.directoryBatchSize("/root/dir", info)
+	if err == nil {
+		t.Fatalf("Expected error for negative batch-size.")
+	}
+}
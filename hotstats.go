@@ -0,0 +1,80 @@
+package pathwalk
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// hotStats are the counters that are updated for every entry. They're kept
+// apart from the rest of the stats and updated atomically rather than under
+// the stats lock. The lock is still used for the composite stats (maps,
+// minimums/maximums, etc.). These are all int64 and have to stay 64-bit
+// aligned.
+type hotStats struct {
+	filesVisited       int64
+	filesNotCounted    int64
+	directoriesVisited int64
+
+	pathFilterIncludes int64
+	pathFilterExcludes int64
+	fileFilterIncludes int64
+	fileFilterExcludes int64
+
+	statCalls int64
+
+	// callbackTime is in nanoseconds.
+	callbackTime int64
+
+	// matchedFiles and matchedDirectories are only counted by
+	// `CountMatches()` and aren't part of the stats.
+	matchedFiles       int64
+	matchedDirectories int64
+}
+
+// addTo adds the current counts to the given stats.
+func (hs *hotStats) addTo(stats *Stats) {
+	stats.FilesVisited += int(atomic.LoadInt64(&hs.filesVisited))
+	stats.FilesNotCounted += int(atomic.LoadInt64(&hs.filesNotCounted))
+	stats.DirectoriesVisited += int(atomic.LoadInt64(&hs.directoriesVisited))
+
+	stats.PathFilterIncludes += int(atomic.LoadInt64(&hs.pathFilterIncludes))
+	stats.PathFilterExcludes += int(atomic.LoadInt64(&hs.pathFilterExcludes))
+	stats.FileFilterIncludes += int(atomic.LoadInt64(&hs.fileFilterIncludes))
+	stats.FileFilterExcludes += int(atomic.LoadInt64(&hs.fileFilterExcludes))
+
+	stats.StatCalls += int(atomic.LoadInt64(&hs.statCalls))
+	stats.CallbackTime += time.Duration(atomic.LoadInt64(&hs.callbackTime))
+}
+
+// progressState returns the counts that indicate that the walk is making
+// progress.
+func (hs *hotStats) progressState() [3]int64 {
+	return [3]int64{
+		atomic.LoadInt64(&hs.filesVisited),
+		atomic.LoadInt64(&hs.filesNotCounted),
+		atomic.LoadInt64(&hs.directoriesVisited),
+	}
+}
+
+// reset clears the counts.
+func (hs *hotStats) reset() {
+	atomic.StoreInt64(&hs.filesVisited, 0)
+	atomic.StoreInt64(&hs.filesNotCounted, 0)
+	atomic.StoreInt64(&hs.directoriesVisited, 0)
+
+	atomic.StoreInt64(&hs.pathFilterIncludes, 0)
+	atomic.StoreInt64(&hs.pathFilterExcludes, 0)
+	atomic.StoreInt64(&hs.fileFilterIncludes, 0)
+	atomic.StoreInt64(&hs.fileFilterExcludes, 0)
+
+	atomic.StoreInt64(&hs.statCalls, 0)
+	atomic.StoreInt64(&hs.callbackTime, 0)
+
+	atomic.StoreInt64(&hs.matchedFiles, 0)
+	atomic.StoreInt64(&hs.matchedDirectories, 0)
+}
+
+// addCallbackTime adds the time spent in a callback.
+func (hs *hotStats) addCallbackTime(duration time.Duration) {
+	atomic.AddInt64(&hs.callbackTime, int64(duration))
+}
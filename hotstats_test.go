@@ -0,0 +1,126 @@
+package pathwalk
+
+import (
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-parallel-walker/internal/testing"
+)
+
+func TestHotStats_addTo(t *testing.T) {
+	hs := hotStats{}
+
+	atomic.AddInt64(&hs.filesVisited, 1)
+	atomic.AddInt64(&hs.filesNotCounted, 2)
+	atomic.AddInt64(&hs.directoriesVisited, 3)
+	atomic.AddInt64(&hs.pathFilterIncludes, 4)
+	atomic.AddInt64(&hs.pathFilterExcludes, 5)
+	atomic.AddInt64(&hs.fileFilterIncludes, 6)
+	atomic.AddInt64(&hs.fileFilterExcludes, 7)
+	atomic.AddInt64(&hs.statCalls, 8)
+	hs.addCallbackTime(9 * time.Second)
+
+	stats := Stats{
+		FilesVisited: 10,
+	}
+
+	hs.addTo(&stats)
+
+	if stats.FilesVisited != 11 || stats.FilesNotCounted != 2 || stats.DirectoriesVisited != 3 {
+		t.Fatalf("Visit counts not correct: %+v", stats)
+	} else if stats.PathFilterIncludes != 4 || stats.PathFilterExcludes != 5 || stats.FileFilterIncludes != 6 || stats.FileFilterExcludes != 7 {
+		t.Fatalf("Filter counts not correct: %+v", stats)
+	} else if stats.StatCalls != 8 || stats.CallbackTime != 9*time.Second {
+		t.Fatalf("Stat/callback counts not correct: %+v", stats)
+	}
+
+	if hs.progressState() != [3]int64{1, 2, 3} {
+		t.Fatalf("Progress state not correct: %v", hs.progressState())
+	}
+
+	hs.reset()
+
+	if hs != (hotStats{}) {
+		t.Fatalf("Counts not reset: %+v", hs)
+	}
+}
+
+func TestWalk_Stats__hotStats(t *testing.T) {
+	fileCount := 50
+	tempPath, _ := pwtesting.FillFlatTempPath(fileCount, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walk := NewWalk(tempPath, nil)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	stats := walk.Stats()
+	if stats.FilesVisited != fileCount {
+		t.Fatalf("FilesVisited not correct: (%d)", stats.FilesVisited)
+	} else if stats.DirectoriesVisited != 1 {
+		t.Fatalf("DirectoriesVisited not correct: (%d)", stats.DirectoriesVisited)
+	}
+
+	err = walk.ResetStats()
+	log.PanicIf(err)
+
+	stats = walk.Stats()
+	if stats.FilesVisited != 0 || stats.DirectoriesVisited != 0 {
+		t.Fatalf("Stats not reset: %+v", stats)
+	}
+}
+
+// BenchmarkStats__lockedCounter is how the per-entry counters used to be
+// updated.
+func BenchmarkStats__lockedCounter(b *testing.B) {
+	b.ReportAllocs()
+
+	m := sync.Mutex{}
+	stats := Stats{}
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			m.Lock()
+			stats.FilesVisited++
+			m.Unlock()
+		}
+	})
+}
+
+func BenchmarkStats__atomicCounter(b *testing.B) {
+	b.ReportAllocs()
+
+	hs := hotStats{}
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			atomic.AddInt64(&hs.filesVisited, 1)
+		}
+	})
+}
+
+func BenchmarkWalk_Run__largeFlat(b *testing.B) {
+	tempPath, _ := pwtesting.FillFlatTempPath(5000, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walk := NewWalk(tempPath, nil)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		err := walk.Run()
+		log.PanicIf(err)
+	}
+}
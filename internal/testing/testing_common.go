@@ -5,6 +5,7 @@ import (
 	"os"
 	"path"
 	"sort"
+	"time"
 
 	"io/ioutil"
 	"math/rand"
@@ -47,8 +48,16 @@ func FillFlatTempPath(fileCount int, pathPrefix []string) (tempPath string, temp
 }
 
 // FillHeirarchicalTempPath creates a temporary directory and filles a bunch of
-// random-depth subdirectories with test-files.
+// random-depth subdirectories with test-files. The tree is different every
+// time.
 func FillHeirarchicalTempPath(fileCount int, pathPrefix []string) (tempPath string, tempFiles sort.StringSlice) {
+	return FillHeirarchicalTempPathSeeded(fileCount, pathPrefix, time.Now().UnixNano())
+}
+
+// FillHeirarchicalTempPathSeeded is the same as FillHeirarchicalTempPath but
+// the tree (below the temporary directory) is reproducible for a given seed.
+// This helps with reproducing intermittent, ordering-sensitive failures.
+func FillHeirarchicalTempPathSeeded(fileCount int, pathPrefix []string, seed int64) (tempPath string, tempFiles sort.StringSlice) {
 	tempPath, err := ioutil.TempDir("", "")
 	log.PanicIf(err)
 
@@ -60,12 +69,14 @@ func FillHeirarchicalTempPath(fileCount int, pathPrefix []string) (tempPath stri
 		effectiveTempPath = tempPath
 	}
 
+	r := rand.New(rand.NewSource(seed))
+
 	tempFiles = make(sort.StringSlice, 0)
 	for i := 0; i < fileCount; i++ {
 		subdirectories := make([]string, 0)
-		j := rand.Intn(3)
+		j := r.Intn(3)
 		for ; j >= 0; j-- {
-			uuidPhrase := uuid.New().String()
+			uuidPhrase := newSeededUuid(r).String()
 			subdirectories = append(subdirectories, uuidPhrase)
 		}
 
@@ -89,3 +100,16 @@ func FillHeirarchicalTempPath(fileCount int, pathPrefix []string) (tempPath stri
 
 	return tempPath, tempFiles
 }
+
+// newSeededUuid returns a version-4 UUID whose bytes come from the given
+// source rather than from the system's random source.
+func newSeededUuid(r *rand.Rand) (u uuid.UUID) {
+	_, err := r.Read(u[:])
+	log.PanicIf(err)
+
+	// Version 4, variant 10 (RFC 4122).
+	u[6] = (u[6] & 0x0f) | 0x40
+	u[8] = (u[8] & 0x3f) | 0x80
+
+	return u
+}
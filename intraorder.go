@@ -0,0 +1,61 @@
+package pathwalk
+
+import (
+	"github.com/dsoprea/go-logging"
+)
+
+// IntraDirectoryOrder determines whether the files or the subdirectories in a
+// batch of directory entries are dispatched first.
+type IntraDirectoryOrder int
+
+const (
+	// ReadOrder dispatches files and subdirectories intermixed, in the order
+	// that they were read from the directory. This is the default.
+	ReadOrder IntraDirectoryOrder = iota
+
+	// FilesFirst dispatches all of the files in a batch before any of its
+	// subdirectories.
+	FilesFirst
+
+	// DirectoriesFirst dispatches all of the subdirectories in a batch before
+	// any of its files.
+	DirectoriesFirst
+)
+
+// SetIntraDirectoryOrder sets whether the files or the subdirectories of each
+// batch of directory entries are dispatched first. This is much cheaper than
+// sorting but it's also a much weaker guarantee: it only orders the dispatch
+// within a single batch (see `SetBatchSize()`), not across the batches of a
+// directory or across directories, and since dispatched jobs are processed in
+// parallel, the callbacks for the entries of one batch can still overlap.
+// This must be set before calling `Run()`.
+func (walk *Walk) SetIntraDirectoryOrder(order IntraDirectoryOrder) {
+	walk.intraDirectoryOrder = order
+}
+
+// isHeldForIntraDirectoryOrder returns whether a job for an entry of the given
+// type has to wait until the rest of its batch has been dispatched.
+func (walk *Walk) isHeldForIntraDirectoryOrder(isDir bool) bool {
+	if isDir == true {
+		return walk.intraDirectoryOrder == FilesFirst
+	}
+
+	return walk.intraDirectoryOrder == DirectoriesFirst
+}
+
+// pushHeldJobs pushes the jobs that were held back for the intra-directory
+// order.
+func (walk *Walk) pushHeldJobs(heldJobs []job) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	for _, j := range heldJobs {
+		err := walk.pushJob(j)
+		log.PanicIf(err)
+	}
+
+	return nil
+}
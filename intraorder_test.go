@@ -0,0 +1,174 @@
+package pathwalk
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+)
+
+// createIntraOrderTestTree creates a directory whose files and subdirectories
+// are intermixed and returns its path and its entries in that intermixed
+// order.
+func createIntraOrderTestTree() (tempPath string, childBatch []string) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	for _, name := range []string{"file0", "dir0", "file1", "dir1", "file2"} {
+		fqPath := path.Join(tempPath, name)
+
+		if name[:3] == "dir" {
+			err := os.Mkdir(fqPath, 0755)
+			log.PanicIf(err)
+		} else {
+			err := ioutil.WriteFile(fqPath, []byte("data"), 0644)
+			log.PanicIf(err)
+		}
+
+		childBatch = append(childBatch, name)
+	}
+
+	return tempPath, childBatch
+}
+
+// dispatchIntraOrderTestBatch handles one batch without starting any workers
+// and returns the names of the dispatched jobs in the order that they were
+// queued.
+func dispatchIntraOrderTestBatch(order IntraDirectoryOrder) []string {
+	tempPath, childBatch := createIntraOrderTestTree()
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walk := NewWalk("", nil)
+	walk.SetIntraDirectoryOrder(order)
+	walk.InitSync()
+
+	// Pretend that a worker is idle so that the jobs just stay queued.
+	walk.idleWorkerCount = 1
+
+	jdcb := newJobDirectoryContentsBatch(tempPath, 0, childBatch, true)
+
+	err := walk.handleJobDirectoryContentsBatch(jdcb)
+	log.PanicIf(err)
+
+	names := make([]string, 0)
+
+	for len(walk.jobsC) > 0 {
+		j := <-walk.jobsC
+
+		switch t := j.(type) {
+		case jobFileNode:
+			names = append(names, t.Info().Name())
+		case jobDirectoryNode:
+			names = append(names, t.Info().Name())
+		default:
+			log.Panicf("job not expected: %s", j)
+		}
+	}
+
+	return names
+}
+
+func TestWalk_SetIntraDirectoryOrder__readOrder(t *testing.T) {
+	names := dispatchIntraOrderTestBatch(ReadOrder)
+
+	expected := []string{"file0", "dir0", "file1", "dir1", "file2"}
+
+	if reflect.DeepEqual(names, expected) != true {
+		t.Fatalf("Dispatch order not correct: %v", names)
+	}
+}
+
+func TestWalk_SetIntraDirectoryOrder__filesFirst(t *testing.T) {
+	names := dispatchIntraOrderTestBatch(FilesFirst)
+
+	expected := []string{"file0", "file1", "file2", "dir0", "dir1"}
+
+	if reflect.DeepEqual(names, expected) != true {
+		t.Fatalf("Dispatch order not correct: %v", names)
+	}
+}
+
+func TestWalk_SetIntraDirectoryOrder__directoriesFirst(t *testing.T) {
+	names := dispatchIntraOrderTestBatch(DirectoriesFirst)
+
+	expected := []string{"dir0", "dir1", "file0", "file1", "file2"}
+
+	if reflect.DeepEqual(names, expected) != true {
+		t.Fatalf("Dispatch order not correct: %v", names)
+	}
+}
+
+func TestWalk_SetIntraDirectoryOrder__namesOnly(t *testing.T) {
+	tempPath, childBatch := createIntraOrderTestTree()
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walk := NewWalk("", nil)
+	walk.SetNamesOnly(true)
+	walk.SetIntraDirectoryOrder(DirectoriesFirst)
+
+	queuedCounts := make([]int, 0)
+
+	walk.SetNameFunc(func(fqPath string, isDir bool) (err error) {
+		queuedCounts = append(queuedCounts, len(walk.jobsC))
+		return nil
+	})
+
+	walk.InitSync()
+	walk.idleWorkerCount = 1
+
+	jdcb := newJobDirectoryContentsBatch(tempPath, 0, childBatch, true)
+
+	err := walk.handleJobDirectoryContentsBatch(jdcb)
+	log.PanicIf(err)
+
+	// Every file must have been delivered after both directories were
+	// queued.
+	expected := []int{2, 2, 2}
+
+	if reflect.DeepEqual(queuedCounts, expected) != true {
+		t.Fatalf("Files were not delivered after the directories: %v", queuedCounts)
+	}
+}
+
+func TestWalk_SetIntraDirectoryOrder__run(t *testing.T) {
+	tempPath, _ := createIntraOrderTestTree()
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	visited := make(map[string]struct{})
+	visitedC := make(chan string, 100)
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		visitedC <- info.Name()
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetIntraDirectoryOrder(FilesFirst)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	close(visitedC)
+
+	for name := range visitedC {
+		visited[name] = struct{}{}
+	}
+
+	// The root, three files, and two directories.
+	if len(visited) != 6 {
+		t.Fatalf("Not all entries were visited: %v", visited)
+	}
+}
@@ -0,0 +1,169 @@
+package pathwalk
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// iteratorBufferSize is the number of entries that can be waiting to be
+	// read from an iterator before the workers block.
+	iteratorBufferSize = 100
+)
+
+var (
+	// ErrIteratorConflict is returned by `Iterator()` if the walk was
+	// configured in a way that doesn't deliver every entry individually.
+	ErrIteratorConflict = errors.New("iterator can not be combined with a batch callback or names-only mode")
+)
+
+// Iterator delivers the entries of a run one at a time, as an alternative to
+// the callbacks. It's modeled on `bufio.Scanner`:
+//
+//	it, err := walk.Iterator()
+//	log.PanicIf(err)
+//
+//	defer it.Close()
+//
+//	for it.Next() == true {
+//	    entry := it.Entry()
+//	    // ...
+//	}
+//
+//	err = it.Err()
+//	log.PanicIf(err)
+//
+// An iterator isn't safe for concurrent use.
+type Iterator struct {
+	walk *Walk
+
+	entriesC chan WalkEntry
+
+	// closedC is closed when the consumer is no longer reading.
+	closedC   chan struct{}
+	closeOnce sync.Once
+
+	// runErr is the result of the run. It's only valid once entriesC is
+	// closed.
+	runErr error
+
+	entry  WalkEntry
+	err    error
+	isDone bool
+}
+
+// Iterator starts a run in the background and returns an iterator over its
+// entries. The callbacks that were set are still called; the entries are
+// delivered to the iterator after them. The entries are produced in parallel
+// and buffered, so the workers will block if the consumer falls behind.
+//
+// `Close()` must be called if the iterator isn't exhausted, which will stop
+// the walk (the outcome will be `OutcomeStopped`). It's safe to call it after
+// the iterator is exhausted, so it can always be deferred.
+//
+// The same errors as `Run()` are returned if the run can't be started, and
+// `ErrIteratorConflict` is returned if a batch callback was set or if in
+// names-only mode, since not every entry would be delivered. Any error from
+// the run itself is returned by `Err()`.
+func (walk *Walk) Iterator() (it *Iterator, err error) {
+	if walk.rootPath == "" {
+		return nil, ErrEmptyRootPath
+	} else if walk.batchWalkFunc != nil || walk.isNamesOnly == true {
+		return nil, ErrIteratorConflict
+	}
+
+	if atomic.CompareAndSwapInt32(&walk.isRunActive, 0, 1) == false {
+		return nil, ErrAlreadyRunning
+	}
+
+	it = &Iterator{
+		walk:     walk,
+		entriesC: make(chan WalkEntry, iteratorBufferSize),
+		closedC:  make(chan struct{}),
+	}
+
+	go func() {
+		err := walk.run(context.Background(), nil, it)
+
+		it.runErr = err
+		close(it.entriesC)
+	}()
+
+	return it, nil
+}
+
+// push delivers one entry to the consumer. This is called from the workers.
+// If the consumer has closed the iterator, the walk is stopped instead.
+func (it *Iterator) push(entry WalkEntry) {
+	// Otherwise, we might keep delivering into the space freed by `Close()`.
+	select {
+	case <-it.closedC:
+		it.walk.Stop()
+		return
+	default:
+	}
+
+	select {
+	case it.entriesC <- entry:
+	case <-it.closedC:
+		// This is called from a worker, so the run is definitely underway
+		// and it's safe to stop it. The stop is a no-op after the first.
+		it.walk.Stop()
+	}
+}
+
+// Next advances to the next entry and returns false once there are no more
+// entries, either because the run finished or failed or because the iterator
+// was closed.
+func (it *Iterator) Next() bool {
+	if it.isDone == true {
+		return false
+	}
+
+	entry, ok := <-it.entriesC
+	if ok == false {
+		it.isDone = true
+		it.err = it.runErr
+
+		return false
+	}
+
+	it.entry = entry
+
+	return true
+}
+
+// Entry returns the current entry.
+func (it *Iterator) Entry() WalkEntry {
+	return it.entry
+}
+
+// Err returns the error that the run failed with, if any. This is only
+// meaningful once `Next()` has returned false.
+func (it *Iterator) Err() error {
+	return it.err
+}
+
+// Close stops the walk if it's still running and waits for it to finish. The
+// walk stops as soon as a worker has another entry to deliver. Any remaining
+// entries are discarded. This returns the same error as `Err()`.
+func (it *Iterator) Close() error {
+	it.closeOnce.Do(func() {
+		close(it.closedC)
+	})
+
+	if it.isDone == true {
+		return it.err
+	}
+
+	// Anything that's already buffered is discarded.
+	for range it.entriesC {
+	}
+
+	it.isDone = true
+	it.err = it.runErr
+
+	return it.err
+}
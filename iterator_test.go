@@ -0,0 +1,185 @@
+package pathwalk
+
+import (
+	"errors"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-parallel-walker/internal/testing"
+)
+
+func TestWalk_Iterator(t *testing.T) {
+	fileCount := 50
+	tempPath, tempFilenames := pwtesting.FillFlatTempPath(fileCount, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walk := NewWalk(tempPath, nil)
+
+	it, err := walk.Iterator()
+	log.PanicIf(err)
+
+	defer it.Close()
+
+	files := make([]string, 0)
+	sequences := make(map[int64]struct{})
+	directoryCount := 0
+
+	for it.Next() == true {
+		entry := it.Entry()
+
+		sequences[entry.Sequence] = struct{}{}
+
+		if entry.Info.IsDir() == true {
+			directoryCount++
+			continue
+		}
+
+		files = append(files, path.Join(entry.ParentPath, entry.Info.Name())[len(tempPath)+1:])
+	}
+
+	err = it.Err()
+	log.PanicIf(err)
+
+	sort.Strings(files)
+	tempFilenames.Sort()
+
+	if len(files) != fileCount {
+		t.Fatalf("File count not correct: (%d)", len(files))
+	}
+
+	for i, filename := range files {
+		if filename != tempFilenames[i] {
+			t.Fatalf("File not correct: [%s] != [%s]", filename, tempFilenames[i])
+		}
+	}
+
+	if directoryCount != 1 {
+		t.Fatalf("Directory count not correct: (%d)", directoryCount)
+	} else if len(sequences) != fileCount+1 {
+		t.Fatalf("Sequences not unique: (%d)", len(sequences))
+	} else if walk.Outcome() != OutcomeCompleted {
+		t.Fatalf("Outcome not correct: [%s]", walk.Outcome())
+	}
+
+	// Exhausted iterators are inert.
+
+	if it.Next() != false {
+		t.Fatalf("Expected no more entries.")
+	}
+
+	err = it.Close()
+	log.PanicIf(err)
+}
+
+func TestWalk_Iterator__closeEarly(t *testing.T) {
+	tempPath, _ := pwtesting.FillFlatTempPath(1000, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walk := NewWalk(tempPath, nil)
+
+	it, err := walk.Iterator()
+	log.PanicIf(err)
+
+	for i := 0; i < 5; i++ {
+		if it.Next() != true {
+			t.Fatalf("Expected an entry.")
+		}
+	}
+
+	err = it.Close()
+	log.PanicIf(err)
+
+	if walk.Outcome() != OutcomeStopped {
+		t.Fatalf("Outcome not correct: [%s]", walk.Outcome())
+	} else if walk.Stats().FilesVisited >= 1000 {
+		t.Fatalf("Walk was not stopped early.")
+	}
+
+	if it.Next() != false {
+		t.Fatalf("Expected no more entries after close.")
+	}
+
+	// The walk is released once the iterator is closed.
+
+	err = walk.Run()
+	log.PanicIf(err)
+}
+
+func TestWalk_Iterator__runError(t *testing.T) {
+	tempPath, _ := pwtesting.FillFlatTempPath(10, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	errTest := errors.New("test failure")
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		if info.IsDir() == false {
+			return errTest
+		}
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+
+	it, err := walk.Iterator()
+	log.PanicIf(err)
+
+	defer it.Close()
+
+	for it.Next() == true {
+	}
+
+	err = it.Err()
+	if err == nil {
+		t.Fatalf("Expected error.")
+	} else if strings.Contains(err.Error(), errTest.Error()) == false {
+		t.Fatalf("Error not correct: [%v]", err)
+	}
+}
+
+func TestWalk_Iterator__notStartable(t *testing.T) {
+	walk := NewWalk("", nil)
+
+	_, err := walk.Iterator()
+	if err != ErrEmptyRootPath {
+		t.Fatalf("Expected ErrEmptyRootPath: [%v]", err)
+	}
+
+	walk = NewWalk("/root", nil)
+
+	walk.SetBatchCallback(func(parentPath string, infos []os.FileInfo) (err error) {
+		return nil
+	})
+
+	_, err = walk.Iterator()
+	if err != ErrIteratorConflict {
+		t.Fatalf("Expected ErrIteratorConflict: [%v]", err)
+	}
+
+	// A missing root is reported by the iterator.
+
+	walk = NewWalk("/does/not/exist", nil)
+
+	it, err := walk.Iterator()
+	log.PanicIf(err)
+
+	if it.Next() != false {
+		t.Fatalf("Expected no entries.")
+	} else if it.Err() == nil {
+		t.Fatalf("Expected error for missing root.")
+	}
+}
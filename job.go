@@ -14,6 +14,12 @@ type job interface {
 // jobNode is the default promoted type of our file and directory jbos.
 type jobNode struct {
 	parentNodePath string
+
+	// depth is the depth of the node below the root.
+	depth int
+
+	// parentInfo describes the parent node, if known.
+	parentInfo os.FileInfo
 }
 
 // ParentNodePath is the full-path of the parent node.
@@ -24,6 +30,13 @@ func (jn jobNode) ParentNodePath() string {
 type jobFileNode struct {
 	jobNode
 	info os.FileInfo
+
+	// dirContext is the contextual value of the parent directory.
+	dirContext interface{}
+
+	// workerState is the state of the worker that is processing the job, if
+	// any.
+	workerState interface{}
 }
 
 func newJobFileNode(parentNodePath string, info os.FileInfo) jobFileNode {
@@ -50,6 +63,28 @@ func (jfn jobFileNode) String() string {
 type jobDirectoryNode struct {
 	jobNode
 	info os.FileInfo
+
+	// skipCallback indicates that the callback shouldn't be called for the
+	// directory itself (it already was, in a previous run).
+	skipCallback bool
+
+	// skipSubdirectories indicates that only the files of the directory
+	// should be processed.
+	skipSubdirectories bool
+
+	// dirContext is the contextual value of the parent directory.
+	dirContext interface{}
+
+	// skipChildren indicates that the directory shouldn't be listed.
+	skipChildren bool
+
+	// ignoreRules are the ignore-file patterns that apply to the directory's
+	// children, from its ancestors.
+	ignoreRules *walkIgnoreRules
+
+	// parentDir is the handle of the directory that this one was found in if
+	// the child-lister resolves nodes relative to their directory.
+	parentDir *dirHandle
 }
 
 func newJobDirectoryNode(parentNodePath string, info os.FileInfo) jobDirectoryNode {
@@ -78,6 +113,35 @@ type jobDirectoryContentsBatch struct {
 	batchNumber    int
 	childBatch     []string
 	doProcessFiles bool
+
+	// skipDirectories indicates that any directories in the batch should be
+	// ignored.
+	skipDirectories bool
+
+	// dirContext is the contextual value of the directory being listed.
+	dirContext interface{}
+
+	// childIsDir describes whether each child is a directory, if this was
+	// known when the directory was listed.
+	childIsDir []bool
+
+	// depth is the depth of the directory being listed.
+	depth int
+
+	// parentInfo describes the directory being listed, if known.
+	parentInfo os.FileInfo
+
+	// isListed indicates that the children came from a user-supplied list
+	// rather than from the directory, so directories shouldn't be descended
+	// into.
+	isListed bool
+
+	// ignoreRules are the ignore-file patterns that apply to the children.
+	ignoreRules *walkIgnoreRules
+
+	// dir is the handle of the directory being listed if the child-lister
+	// resolves nodes relative to their directory.
+	dir *dirHandle
 }
 
 func newJobDirectoryContentsBatch(parentPath string, batchNumber int, childBatch []string, doProcessFiles bool) jobDirectoryContentsBatch {
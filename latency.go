@@ -0,0 +1,16 @@
+package pathwalk
+
+import (
+	"time"
+)
+
+// injectLatency sleeps for the artificial latency, if any, in order to
+// simulate slow storage. This is called before every stat, directory read,
+// and file open.
+func (walk *Walk) injectLatency() {
+	if walk.artificialLatency <= 0 {
+		return
+	}
+
+	time.Sleep(walk.artificialLatency)
+}
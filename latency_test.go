@@ -0,0 +1,70 @@
+package pathwalk
+
+import (
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-parallel-walker/internal/testing"
+)
+
+func TestWalk_injectLatency__perDirectoryTimeout(t *testing.T) {
+	fileCount := 30
+	tempPath, _ := pwtesting.FillFlatTempPath(fileCount, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walk := NewWalk(tempPath, nil)
+	walk.artificialLatency = time.Millisecond * 10
+	walk.SetPerDirectoryTimeout(time.Millisecond * 50)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	stats := walk.Stats()
+
+	if stats.DirectoriesTimedOut != 1 {
+		t.Fatalf("DirectoriesTimedOut not correct: (%d)", stats.DirectoriesTimedOut)
+	} else if stats.FilesVisited >= fileCount {
+		t.Fatalf("Expected the directory to be cut short: (%d)", stats.FilesVisited)
+	} else if walk.Outcome() != OutcomeTruncated {
+		t.Fatalf("Outcome not correct: [%s]", walk.Outcome())
+	}
+}
+
+func TestWalk_injectLatency__progress(t *testing.T) {
+	fileCount := 20
+	tempPath, _ := pwtesting.FillFlatTempPath(fileCount, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walk := NewWalk(tempPath, nil)
+	walk.artificialLatency = time.Millisecond * 20
+
+	calls := int32(0)
+
+	walk.SetProgressFunc(time.Millisecond*5, func(stats Stats) {
+		atomic.AddInt32(&calls, 1)
+	})
+
+	startedAt := time.Now()
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	// At least the root and the directory read are delayed.
+	if time.Since(startedAt) < time.Millisecond*40 {
+		t.Fatalf("Latency not applied: %s", time.Since(startedAt))
+	} else if atomic.LoadInt32(&calls) == 0 {
+		t.Fatalf("Progress not reported.")
+	} else if walk.Stats().FilesVisited != fileCount {
+		t.Fatalf("FilesVisited not correct: (%d)", walk.Stats().FilesVisited)
+	}
+}
@@ -0,0 +1,17 @@
+//go:build pathwalk_testing
+// +build pathwalk_testing
+
+package pathwalk
+
+import (
+	"time"
+)
+
+// SetArtificialLatency adds the given delay before every stat, directory read,
+// and file open in order to simulate slow storage. This is intended for testing
+// only (e.g. to exercise timeouts and progress reporting without a slow
+// filesystem) and is only available when built with the `pathwalk_testing`
+// tag. Zero (the default) disables it.
+func (walk *Walk) SetArtificialLatency(d time.Duration) {
+	walk.artificialLatency = d
+}
@@ -0,0 +1,129 @@
+//go:build pathwalk_testing
+// +build pathwalk_testing
+
+package pathwalk
+
+import (
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+)
+
+func TestWalk_SetArtificialLatency(t *testing.T) {
+	walk := NewWalk("root/path", nil)
+	walk.SetArtificialLatency(time.Millisecond * 5)
+
+	if walk.artificialLatency != time.Millisecond*5 {
+		t.Fatalf("Latency not set: %s", walk.artificialLatency)
+	}
+}
+
+func TestWalk_RunN__artificialLatency(t *testing.T) {
+	rootCount := 4
+
+	roots := make([]string, rootCount)
+	for i := 0; i < rootCount; i++ {
+		tempPath, err := ioutil.TempDir("", "")
+		log.PanicIf(err)
+
+		roots[i] = tempPath
+	}
+
+	defer func() {
+		for _, tempPath := range roots {
+			os.RemoveAll(tempPath)
+		}
+	}()
+
+	m := sync.Mutex{}
+	visited := make(map[string]struct{})
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		visited[path.Join(parentPath, info.Name())] = struct{}{}
+
+		return nil
+	}
+
+	walk := NewWalk("", nil)
+
+	// Each root is stat'd before it's pushed, so the empty roots that were
+	// already pushed can finish before the next one is.
+	walk.SetArtificialLatency(time.Millisecond * 20)
+
+	err := walk.RunN(roots, walkFunc)
+	log.PanicIf(err)
+
+	for _, rootPath := range roots {
+		if _, found := visited[rootPath]; found == false {
+			t.Fatalf("Root not visited: [%s] %v", rootPath, visited)
+		}
+	}
+
+	if walk.Stats().DirectoriesVisited != rootCount {
+		t.Fatalf("DirectoriesVisited not correct: (%d)", walk.Stats().DirectoriesVisited)
+	} else if walk.Outcome() != OutcomeCompleted {
+		t.Fatalf("Outcome not correct: [%s]", walk.Outcome())
+	}
+}
+
+func TestNewWalkFromList__artificialLatency(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	// One file in each of several directories, so that there is one job per
+	// directory and each directory is stat'd before its job is pushed.
+
+	listPaths := make([]string, 0)
+	for _, directoryName := range []string{"dir1", "dir2", "dir3", "dir4"} {
+		err := os.Mkdir(path.Join(tempPath, directoryName), 0755)
+		log.PanicIf(err)
+
+		relFilepath := path.Join(directoryName, "file")
+
+		err = ioutil.WriteFile(path.Join(tempPath, relFilepath), []byte{}, 0644)
+		log.PanicIf(err)
+
+		listPaths = append(listPaths, relFilepath)
+	}
+
+	m := sync.Mutex{}
+	visited := make(map[string]struct{})
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		visited[path.Join(parentPath, info.Name())[len(tempPath)+1:]] = struct{}{}
+
+		return nil
+	}
+
+	walk := NewWalkFromList(tempPath, listPaths, walkFunc)
+	walk.SetArtificialLatency(time.Millisecond * 20)
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	for _, relFilepath := range listPaths {
+		if _, found := visited[relFilepath]; found == false {
+			t.Fatalf("Listed file not visited: [%s] %v", relFilepath, visited)
+		}
+	}
+
+	if walk.Outcome() != OutcomeCompleted {
+		t.Fatalf("Outcome not correct: [%s]", walk.Outcome())
+	}
+}
@@ -0,0 +1,103 @@
+package pathwalk
+
+import (
+	"path"
+	"strings"
+
+	"github.com/dsoprea/go-logging"
+)
+
+// NewWalkFromList returns a walk over an explicit set of paths (e.g. a
+// pre-computed manifest) rather than over whatever is currently below the
+// root. The paths are relative to the root. Each one is still stat'd by the
+// workers and goes through the same filters, callbacks, and stats as in a
+// regular walk, but no directory is ever listed: directories in the list are
+// delivered to the callback but not descended into. Paths that no longer
+// exist are skipped and counted (see `SetMaxSkippedEntries()`).
+//
+// `Run()` will return `ErrPathOutsideRoot` if a path is absolute or refers to
+// something above the root. Checkpoints and the directory-leave callback are
+// not supported for these walks.
+func NewWalkFromList(rootPath string, paths []string, walkFunc WalkFunc) (walk *Walk) {
+	walk = NewWalk(rootPath, walkFunc)
+
+	walk.listPaths = make([]string, len(paths))
+	copy(walk.listPaths, paths)
+
+	return walk
+}
+
+// pushListJobs groups the listed paths by their parent directories and pushes
+// them in batches, exactly as if they were read from those directories. It
+// returns the number of jobs pushed.
+func (walk *Walk) pushListJobs() (count int, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	rootPath := path.Clean(walk.rootPath)
+
+	parentPaths := make([]string, 0)
+	children := make(map[string][]string)
+
+	for _, listPath := range walk.listPaths {
+		listPath = path.Clean(listPath)
+
+		if path.IsAbs(listPath) == true || listPath == "." || listPath == ".." || strings.HasPrefix(listPath, "../") == true {
+			walk.logWarningf("listed path is not under the root: [%s]", listPath)
+			log.Panic(ErrPathOutsideRoot)
+		}
+
+		fqPath := path.Join(rootPath, listPath)
+		parentPath := path.Dir(fqPath)
+
+		if _, found := children[parentPath]; found == false {
+			parentPaths = append(parentPaths, parentPath)
+		}
+
+		children[parentPath] = append(children[parentPath], path.Base(fqPath))
+	}
+
+	rootPrefixLen := len(rootPath) + 1
+
+	release := walk.holdSeeding()
+	defer release()
+
+	for _, parentPath := range parentPaths {
+		parentRelPath := ""
+		if len(parentPath) > rootPrefixLen {
+			parentRelPath = parentPath[rootPrefixLen:]
+		}
+
+		doProcessFiles := walk.filter.IsPathIncluded(parentRelPath)
+
+		// This is only informational, so it's not fatal if it can't be had.
+		parentInfo, err := walk.statNode(parentPath)
+		if err != nil {
+			parentInfo = nil
+		}
+
+		names := children[parentPath]
+		for batchNumber := 0; len(names) > 0; batchNumber++ {
+			batchSize := walk.batchSize
+			if batchSize > len(names) {
+				batchSize = len(names)
+			}
+
+			jdcb := newJobDirectoryContentsBatch(parentPath, batchNumber, names[:batchSize], doProcessFiles)
+			jdcb.isListed = true
+			jdcb.depth = walk.pathDepth(parentPath)
+			jdcb.parentInfo = parentInfo
+
+			err := walk.pushJob(jdcb)
+			log.PanicIf(err)
+
+			names = names[batchSize:]
+			count++
+		}
+	}
+
+	return count, nil
+}
@@ -0,0 +1,151 @@
+package pathwalk
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+)
+
+func TestNewWalkFromList(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	err = os.MkdirAll(path.Join(tempPath, "dir1", "dir2"), 0755)
+	log.PanicIf(err)
+
+	relFilepaths := []string{
+		"file1",
+		"file2",
+		"dir1/file3",
+		"dir1/dir2/file4",
+	}
+
+	for _, relFilepath := range relFilepaths {
+		err := ioutil.WriteFile(path.Join(tempPath, relFilepath), []byte{}, 0644)
+		log.PanicIf(err)
+	}
+
+	listPaths := []string{
+		"file1",
+		"missing1",
+		"dir1/file3",
+		"dir1/missing2",
+		"dir1/dir2",
+		"missing-dir/file5",
+	}
+
+	m := sync.Mutex{}
+	visited := make([]string, 0)
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		visited = append(visited, path.Join(parentPath, info.Name())[len(tempPath)+1:])
+
+		return nil
+	}
+
+	walk := NewWalkFromList(tempPath, listPaths, walkFunc)
+
+	// Force more than one batch for the root.
+	walk.SetBatchSize(1)
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	sort.Strings(visited)
+
+	// The listed directory is visited but not descended into, and nothing
+	// that wasn't listed is visited.
+	expected := []string{
+		"dir1/dir2",
+		"dir1/file3",
+		"file1",
+	}
+
+	if reflect.DeepEqual(visited, expected) != true {
+		t.Fatalf("Visited not correct: %v", visited)
+	} else if walk.Stats().SkippedEntries != 3 {
+		t.Fatalf("SkippedEntries not correct: (%d)", walk.Stats().SkippedEntries)
+	} else if walk.Outcome() != OutcomeCompleted {
+		t.Fatalf("Outcome not correct: [%s]", walk.Outcome())
+	}
+}
+
+func TestNewWalkFromList__filtered(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	relFilepaths := []string{
+		"file1.jpg",
+		"file2.png",
+	}
+
+	for _, relFilepath := range relFilepaths {
+		err := ioutil.WriteFile(path.Join(tempPath, relFilepath), []byte{}, 0644)
+		log.PanicIf(err)
+	}
+
+	m := sync.Mutex{}
+	visited := make([]string, 0)
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		visited = append(visited, info.Name())
+
+		return nil
+	}
+
+	walk := NewWalkFromList(tempPath, relFilepaths, walkFunc)
+
+	filter := Filter{
+		IncludeFilenames: []string{"*.jpg"},
+	}
+
+	walk.SetFilter(filter)
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	if reflect.DeepEqual(visited, []string{"file1.jpg"}) != true {
+		t.Fatalf("Visited not correct: %v", visited)
+	}
+}
+
+func TestNewWalkFromList__empty(t *testing.T) {
+	walk := NewWalkFromList("/root", []string{}, nil)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	if walk.Outcome() != OutcomeCompleted {
+		t.Fatalf("Outcome not correct: [%s]", walk.Outcome())
+	}
+}
+
+func TestNewWalkFromList__outsideRoot(t *testing.T) {
+	walk := NewWalkFromList("/root", []string{"../other"}, nil)
+
+	err := walk.Run()
+	if err == nil || log.Is(err, ErrPathOutsideRoot) != true {
+		t.Fatalf("Expected outside-root error: %v", err)
+	}
+}
@@ -0,0 +1,189 @@
+package pathwalk
+
+import (
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dsoprea/go-logging"
+)
+
+// ChildLister produces the names of the children of a container node. The
+// default implementation reads directories from the filesystem, but any
+// hierarchy (e.g. database- or API-backed) can be walked by providing a
+// different implementation.
+type ChildLister interface {
+	// ListChildren returns up to `batchSize` child names of the given path.
+	// It will be called repeatedly for the same path until `hasMore` is false
+	// or an error is returned, so the implementation is responsible for
+	// keeping track of its position for every path that is being listed.
+	// This will be called from multiple goroutines concurrently (though never
+	// concurrently for the same path).
+	ListChildren(path string, batchSize int) (names []string, hasMore bool, err error)
+}
+
+// ChildStatter can optionally be implemented by a `ChildLister` in order to
+// describe the nodes that it lists. If not implemented, nodes are stat'd on
+// the filesystem. A non-filesystem hierarchy will need to implement this. The
+// returned info for containers must report `IsDir()` as true.
+type ChildStatter interface {
+	// StatChild returns the info for the node at the given path.
+	StatChild(path string) (info os.FileInfo, err error)
+}
+
+// ChildListCloser can optionally be implemented by a `ChildLister` in order to
+// be told when the listing of a path is being abandoned before it was
+// exhausted (e.g. when sampling), so that any state kept for it can be
+// released.
+type ChildListCloser interface {
+	// CloseChildren releases the listing state of the given path.
+	CloseChildren(path string) (err error)
+}
+
+// filesystemChildLister lists the entries of directories on the filesystem.
+type filesystemChildLister struct {
+	openDirectories map[string]*os.File
+	locker          sync.Mutex
+
+	// openFunc opens a directory for reading.
+	openFunc func(path string) (*os.File, error)
+
+	fdExhaustionMaxRetries int
+	fdExhaustionBackoff    time.Duration
+
+	// fdExhaustionFunc, if not nil, is called whenever an open is retried
+	// because there were too many open files.
+	fdExhaustionFunc func()
+}
+
+func newFilesystemChildLister() *filesystemChildLister {
+	return &filesystemChildLister{
+		openDirectories: make(map[string]*os.File),
+		openFunc:        os.Open,
+
+		fdExhaustionMaxRetries: defaultFdExhaustionMaxRetries,
+		fdExhaustionBackoff:    defaultFdExhaustionBackoff,
+	}
+}
+
+// ListChildren returns the next batch of entries of the given directory. The
+// directory is opened on the first call and closed once it has been exhausted
+// or fails.
+func (fcl *filesystemChildLister) ListChildren(path string, batchSize int) (names []string, hasMore bool, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	f, err := fcl.open(path)
+	log.PanicIf(err)
+
+	names, err = f.Readdirnames(batchSize)
+	if err != nil {
+		fcl.close(path, f)
+
+		if err == io.EOF {
+			return nil, false, nil
+		}
+
+		log.Panic(err)
+	}
+
+	return names, true, nil
+}
+
+// ListTypedChildren is the same as ListChildren() but also returns whether
+// each child is a directory, as reported by the directory listing.
+func (fcl *filesystemChildLister) ListTypedChildren(path string, batchSize int) (children []typedName, hasMore bool, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	f, err := fcl.open(path)
+	log.PanicIf(err)
+
+	children, err = readTypedNames(f, batchSize)
+	if err != nil {
+		fcl.close(path, f)
+
+		if err == io.EOF {
+			return nil, false, nil
+		}
+
+		log.Panic(err)
+	}
+
+	return children, true, nil
+}
+
+// open returns the already-open directory or opens it.
+func (fcl *filesystemChildLister) open(path string) (f *os.File, err error) {
+	fcl.locker.Lock()
+	f, found := fcl.openDirectories[path]
+	fcl.locker.Unlock()
+
+	if found == true {
+		return f, nil
+	}
+
+	f, err = fcl.openWithRetry(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fcl.locker.Lock()
+	fcl.openDirectories[path] = f
+	fcl.locker.Unlock()
+
+	return f, nil
+}
+
+// close closes and forgets the given directory.
+func (fcl *filesystemChildLister) close(path string, f *os.File) {
+	fcl.locker.Lock()
+	delete(fcl.openDirectories, path)
+	fcl.locker.Unlock()
+
+	f.Close()
+}
+
+// CloseChildren closes the given directory if it's still open.
+func (fcl *filesystemChildLister) CloseChildren(path string) (err error) {
+	fcl.locker.Lock()
+	f, found := fcl.openDirectories[path]
+	fcl.locker.Unlock()
+
+	if found == false {
+		return nil
+	}
+
+	fcl.close(path, f)
+
+	return nil
+}
+
+// SetChildLister sets the source of child names for container nodes. This
+// replaces the filesystem as the source of directory contents.
+func (walk *Walk) SetChildLister(childLister ChildLister) {
+	walk.childLister = childLister
+	walk.reportFdExhaustion()
+}
+
+// statNode returns the info for the given path, using the child-lister if it
+// knows how.
+func (walk *Walk) statNode(path string) (info os.FileInfo, err error) {
+	atomic.AddInt64(&walk.hotStats.statCalls, 1)
+
+	walk.injectLatency()
+
+	if cs, ok := walk.childLister.(ChildStatter); ok == true {
+		return cs.StatChild(path)
+	}
+
+	return os.Stat(path)
+}
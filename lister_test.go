@@ -0,0 +1,162 @@
+package pathwalk
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dsoprea/go-logging"
+	"github.com/dsoprea/go-utility/filesystem"
+
+	"github.com/dsoprea/go-parallel-walker/internal/testing"
+)
+
+// testMapChildLister is an in-memory hierarchy. Every key of `children` is a
+// container.
+type testMapChildLister struct {
+	children map[string][]string
+	offsets  map[string]int
+	locker   sync.Mutex
+}
+
+func (tmcl *testMapChildLister) ListChildren(path string, batchSize int) (names []string, hasMore bool, err error) {
+	tmcl.locker.Lock()
+	defer tmcl.locker.Unlock()
+
+	children := tmcl.children[path]
+	offset := tmcl.offsets[path]
+
+	end := offset + batchSize
+	if end > len(children) {
+		end = len(children)
+	}
+
+	tmcl.offsets[path] = end
+
+	return children[offset:end], end < len(children), nil
+}
+
+func (tmcl *testMapChildLister) StatChild(nodePath string) (info os.FileInfo, err error) {
+	name := path.Base(nodePath)
+
+	if _, found := tmcl.children[nodePath]; found == true {
+		return rifs.NewSimpleFileInfoWithDirectory(name, time.Time{}), nil
+	}
+
+	return rifs.NewSimpleFileInfoWithFile(name, 0, 0, time.Time{}), nil
+}
+
+func TestWalk_Run__childLister(t *testing.T) {
+	tmcl := &testMapChildLister{
+		children: map[string][]string{
+			"/root":                       {"a", "b", "container1"},
+			"/root/container1":            {"c", "container2"},
+			"/root/container1/container2": {"d"},
+		},
+		offsets: make(map[string]int),
+	}
+
+	m := sync.Mutex{}
+	visited := make([]string, 0)
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		visited = append(visited, path.Join(parentPath, info.Name()))
+
+		return nil
+	}
+
+	walk := NewWalk("/root", walkFunc)
+	walk.SetChildLister(tmcl)
+
+	// Force more than one batch per container.
+	walk.SetBatchSize(1)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	sort.Strings(visited)
+
+	expected := []string{
+		"/root",
+		"/root/a",
+		"/root/b",
+		"/root/container1",
+		"/root/container1/c",
+		"/root/container1/container2",
+		"/root/container1/container2/d",
+	}
+
+	if reflect.DeepEqual(visited, expected) != true {
+		t.Fatalf("Visited nodes not correct: %v", visited)
+	}
+}
+
+func TestFilesystemChildLister_ListChildren(t *testing.T) {
+	fileCount := 5
+	tempPath, tempFilenames := pwtesting.FillFlatTempPath(fileCount, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	fcl := newFilesystemChildLister()
+
+	names := make([]string, 0)
+	for {
+		batch, hasMore, err := fcl.ListChildren(tempPath, 2)
+		log.PanicIf(err)
+
+		names = append(names, batch...)
+
+		if hasMore == false {
+			break
+		}
+	}
+
+	sort.Strings(names)
+	tempFilenames.Sort()
+
+	if reflect.DeepEqual(names, []string(tempFilenames)) != true {
+		t.Fatalf("Names not correct: %v", names)
+	} else if len(fcl.openDirectories) != 0 {
+		t.Fatalf("Directory was not closed.")
+	}
+}
+
+func TestFilesystemChildLister_CloseChildren(t *testing.T) {
+	tempPath, _ := pwtesting.FillFlatTempPath(10, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	fcl := newFilesystemChildLister()
+
+	names, hasMore, err := fcl.ListChildren(tempPath, 2)
+	log.PanicIf(err)
+
+	if len(names) != 2 || hasMore != true {
+		t.Fatalf("First batch not correct: %v %v", names, hasMore)
+	} else if len(fcl.openDirectories) != 1 {
+		t.Fatalf("Directory not kept open.")
+	}
+
+	err = fcl.CloseChildren(tempPath)
+	log.PanicIf(err)
+
+	if len(fcl.openDirectories) != 0 {
+		t.Fatalf("Directory not closed.")
+	}
+
+	// Closing again is harmless.
+
+	err = fcl.CloseChildren(tempPath)
+	log.PanicIf(err)
+}
@@ -0,0 +1,100 @@
+package pathwalk
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// loadSampleInterval is the minimum amount of time between load samples.
+	loadSampleInterval = time.Second
+)
+
+var (
+	// ErrLoadSamplerNotSupported is returned if the default load sampler isn't
+	// available on this platform.
+	ErrLoadSamplerNotSupported = errors.New("load sampling not supported on this platform")
+)
+
+// LoadSampler returns the current system load as a number between zero (idle)
+// and one (saturated). Values outside of that range are clamped.
+type LoadSampler func() float64
+
+// SetLoadAwareConcurrency makes the walk back off when the system is busy (e.g.
+// for a background indexer). The load is sampled (at most once per
+// `loadSampleInterval`) as new workers are needed and the maximum number of
+// workers is scaled between `max` when idle and one when saturated. Workers
+// aren't interrupted if the load goes up; they'll just not be replaced once
+// they go idle and exit. See `NewLoadAverageSampler()` for a default sampler.
+// This replaces the concurrency set via `SetConcurrency()`. Passing a nil
+// sampler disables this.
+func (walk *Walk) SetLoadAwareConcurrency(max int, sampler LoadSampler) {
+	if max < 1 {
+		max = 1
+	}
+
+	walk.loadLocker.Lock()
+	defer walk.loadLocker.Unlock()
+
+	walk.loadSampler = sampler
+	walk.loadAwareMaxConcurrency = max
+	walk.lastLoadSampleTime = time.Time{}
+}
+
+// effectiveConcurrency returns the current maximum number of workers.
+func (walk *Walk) effectiveConcurrency() int {
+	walk.loadLocker.Lock()
+	defer walk.loadLocker.Unlock()
+
+	if walk.loadSampler == nil {
+		return walk.concurrency
+	}
+
+	now := walk.getClock().Now()
+	if walk.lastLoadSampleTime.IsZero() == false && now.Sub(walk.lastLoadSampleTime) < loadSampleInterval {
+		return walk.lastEffectiveConcurrency
+	}
+
+	load := walk.loadSampler()
+	if load < 0 {
+		load = 0
+	} else if load > 1 {
+		load = 1
+	}
+
+	max := walk.loadAwareMaxConcurrency
+	concurrency := max - int(load*float64(max-1)+0.5)
+
+	walk.lastLoadSampleTime = now
+	walk.lastEffectiveConcurrency = concurrency
+
+	walk.statsLocker.Lock()
+	walk.stats.recordEffectiveConcurrency(concurrency)
+	walk.statsLocker.Unlock()
+
+	return concurrency
+}
+
+// recordEffectiveConcurrency adds one observation of the effective concurrency.
+func (stats *Stats) recordEffectiveConcurrency(concurrency int) {
+	if stats.LoadSamples == 0 || concurrency < stats.EffectiveConcurrencyMin {
+		stats.EffectiveConcurrencyMin = concurrency
+	}
+
+	if concurrency > stats.EffectiveConcurrencyMax {
+		stats.EffectiveConcurrencyMax = concurrency
+	}
+
+	stats.LoadSamples++
+	stats.EffectiveConcurrencyTotal += concurrency
+}
+
+// EffectiveConcurrencyAverage returns the average of the effective
+// concurrencies that were observed with load-aware concurrency.
+func (stats Stats) EffectiveConcurrencyAverage() float64 {
+	if stats.LoadSamples == 0 {
+		return 0
+	}
+
+	return float64(stats.EffectiveConcurrencyTotal) / float64(stats.LoadSamples)
+}
@@ -0,0 +1,33 @@
+package pathwalk
+
+import (
+	"runtime"
+	"syscall"
+)
+
+const (
+	// loadAverageShift is the fixed-point shift of the kernel's load averages.
+	loadAverageShift = 16
+)
+
+// NewLoadAverageSampler returns a sampler based on the one-minute load average
+// relative to the number of CPUs.
+func NewLoadAverageSampler() (sampler LoadSampler, err error) {
+	cpuCount := float64(runtime.NumCPU())
+
+	sampler = func() float64 {
+		info := syscall.Sysinfo_t{}
+
+		err := syscall.Sysinfo(&info)
+		if err != nil {
+			walkLogger.Warningf(nil, "could not read the load average: [%s]", err.Error())
+			return 0
+		}
+
+		loadAverage := float64(info.Loads[0]) / float64(1<<loadAverageShift)
+
+		return loadAverage / cpuCount
+	}
+
+	return sampler, nil
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+// +build !linux
+
+package pathwalk
+
+// NewLoadAverageSampler returns a sampler based on the one-minute load average
+// relative to the number of CPUs. This is only supported on Linux.
+func NewLoadAverageSampler() (sampler LoadSampler, err error) {
+	return nil, ErrLoadSamplerNotSupported
+}
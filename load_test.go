@@ -0,0 +1,142 @@
+package pathwalk
+
+import (
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-parallel-walker/internal/testing"
+)
+
+func TestWalk_effectiveConcurrency(t *testing.T) {
+	m := sync.Mutex{}
+	load := 0.0
+
+	sampler := func() float64 {
+		m.Lock()
+		defer m.Unlock()
+
+		return load
+	}
+
+	setLoad := func(value float64) {
+		m.Lock()
+		defer m.Unlock()
+
+		load = value
+	}
+
+	fc := newFakeClock()
+
+	walk := NewWalk("", nil)
+	walk.clock = fc
+
+	if walk.effectiveConcurrency() != defaultConcurrency {
+		t.Fatalf("Expected the regular concurrency when not load-aware.")
+	}
+
+	walk.SetLoadAwareConcurrency(9, sampler)
+
+	if walk.effectiveConcurrency() != 9 {
+		t.Fatalf("Expected the maximum when idle.")
+	}
+
+	// The load isn't resampled until the interval has elapsed.
+
+	setLoad(1)
+
+	if walk.effectiveConcurrency() != 9 {
+		t.Fatalf("Expected the previous sample to be used.")
+	}
+
+	fc.Advance(loadSampleInterval)
+
+	if walk.effectiveConcurrency() != 1 {
+		t.Fatalf("Expected one worker when saturated.")
+	}
+
+	setLoad(0.5)
+	fc.Advance(loadSampleInterval)
+
+	if walk.effectiveConcurrency() != 5 {
+		t.Fatalf("Expected the concurrency to be scaled.")
+	}
+
+	// Out-of-range samples are clamped.
+
+	setLoad(3)
+	fc.Advance(loadSampleInterval)
+
+	if walk.effectiveConcurrency() != 1 {
+		t.Fatalf("Expected the load to be clamped.")
+	}
+
+	stats := walk.Stats()
+
+	if stats.LoadSamples != 4 {
+		t.Fatalf("LoadSamples not correct: (%d)", stats.LoadSamples)
+	} else if stats.EffectiveConcurrencyMin != 1 || stats.EffectiveConcurrencyMax != 9 {
+		t.Fatalf("Effective concurrency range not correct: (%d) (%d)", stats.EffectiveConcurrencyMin, stats.EffectiveConcurrencyMax)
+	} else if stats.EffectiveConcurrencyAverage() != 4 {
+		t.Fatalf("Effective concurrency average not correct: (%.02f)", stats.EffectiveConcurrencyAverage())
+	}
+}
+
+func TestWalk_SetLoadAwareConcurrency(t *testing.T) {
+	tempPath, tempFiles := pwtesting.FillHeirarchicalTempPath(100, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	sampler := func() float64 {
+		return 1
+	}
+
+	walk := NewWalk(tempPath, nil)
+	walk.SetLoadAwareConcurrency(10, sampler)
+
+	if walk.Config().LoadAwareMaxConcurrency != 10 {
+		t.Fatalf("Config not correct: (%d)", walk.Config().LoadAwareMaxConcurrency)
+	}
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	stats := walk.Stats()
+
+	if stats.FilesVisited != len(tempFiles) {
+		t.Fatalf("FilesVisited not correct: (%d) != (%d)", stats.FilesVisited, len(tempFiles))
+	} else if stats.LoadSamples == 0 {
+		t.Fatalf("Load was never sampled.")
+	} else if stats.EffectiveConcurrencyMax != 1 {
+		t.Fatalf("Concurrency was not limited: (%d)", stats.EffectiveConcurrencyMax)
+	}
+}
+
+func TestNewLoadAverageSampler(t *testing.T) {
+	sampler, err := NewLoadAverageSampler()
+	if err == ErrLoadSamplerNotSupported {
+		t.Skip("Load sampling not supported on this platform.")
+	}
+
+	log.PanicIf(err)
+
+	load := sampler()
+	if load < 0 {
+		t.Fatalf("Load not valid: (%f)", load)
+	}
+
+	// Make sure we can use it for a walk.
+
+	walk := NewWalk("", nil)
+	walk.SetLoadAwareConcurrency(4, sampler)
+
+	concurrency := walk.effectiveConcurrency()
+	if concurrency < 1 || concurrency > 4 {
+		t.Fatalf("Effective concurrency not correct: (%d)", concurrency)
+	}
+
+}
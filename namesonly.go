@@ -0,0 +1,306 @@
+package pathwalk
+
+import (
+	"errors"
+	"os"
+	"path"
+	"sync/atomic"
+	"time"
+
+	"github.com/dsoprea/go-logging"
+)
+
+var (
+	// ErrNamesOnlyConflict is returned if names-only mode is combined with
+	// options that require the entries to be stat'd.
+	ErrNamesOnlyConflict = errors.New("names-only mode can not be combined with owner, creation-time, content, or symlink-target filters or with staying on one filesystem")
+)
+
+// NameFunc is the function type for the names-only callback.
+type NameFunc func(path string, isDir bool) (err error)
+
+// typedName is one child name along with whether it's a directory.
+type typedName struct {
+	name  string
+	isDir bool
+}
+
+// typedChildLister is implemented by listers that can determine the types of
+// the children while listing them, without a stat.
+type typedChildLister interface {
+	ListTypedChildren(path string, batchSize int) (children []typedName, hasMore bool, err error)
+}
+
+// nameOnlyFileInfo is a minimal `os.FileInfo` for directories that were never
+// stat'd.
+type nameOnlyFileInfo struct {
+	name string
+}
+
+// Name returns the name of the directory.
+func (noi nameOnlyFileInfo) Name() string {
+	return noi.name
+}
+
+// Size is not known.
+func (noi nameOnlyFileInfo) Size() int64 {
+	return 0
+}
+
+// Mode only describes that this is a directory.
+func (noi nameOnlyFileInfo) Mode() os.FileMode {
+	return os.ModeDir
+}
+
+// ModTime is not known.
+func (noi nameOnlyFileInfo) ModTime() time.Time {
+	return time.Time{}
+}
+
+// IsDir always returns true.
+func (noi nameOnlyFileInfo) IsDir() bool {
+	return true
+}
+
+// Sys is not available.
+func (noi nameOnlyFileInfo) Sys() interface{} {
+	return nil
+}
+
+// SetNamesOnly enables a lighter pipeline for when only the paths are needed
+// (e.g. just listing the files). Entries are not stat'd: the types are taken
+// from the directory listing itself and every entry is delivered to the
+// callback that was given to `SetNameFunc()` directly from the batch that
+// listed it rather than via its own job. The regular, batch, and contextual
+// file callbacks are not called in this mode.
+//
+// Since nothing is stat'd, symlinks are reported as non-directories and are
+// never descended into, even if they point to directories. The owner,
+// content, and symlink-target filters and staying on one filesystem all
+// require a stat and can not be combined with this mode (`Run()` will return
+// `ErrNamesOnlyConflict`). If a custom `ChildLister` is used, the children
+// are still stat'd in order to determine their types.
+func (walk *Walk) SetNamesOnly(isNamesOnly bool) {
+	walk.isNamesOnly = isNamesOnly
+}
+
+// SetNameFunc sets the callback for names-only mode. It receives the full-path
+// of every directory and file (formatted the same way as for the regular
+// callback). Returning `ErrSkipDirectory` for a directory will skip its
+// contents.
+func (walk *Walk) SetNameFunc(nameFunc NameFunc) {
+	walk.nameFunc = nameFunc
+}
+
+// checkNamesOnly makes sure that names-only mode isn't combined with anything
+// that needs a stat.
+func (walk *Walk) checkNamesOnly() (err error) {
+	if walk.isNamesOnly == false {
+		return nil
+	}
+
+	if walk.filter.HasOwnerFilter() == true || walk.filter.HasCreationTimeFilter() == true || walk.filter.HasContentMagic() == true || walk.filter.excludeSymlinkTargetsOutsideRoot == true || walk.isStayOnFilesystem == true {
+		return ErrNamesOnlyConflict
+	}
+
+	return nil
+}
+
+// listChildren returns the next batch of children of the given path. The
+// types are only returned if we're in names-only mode and the lister can
+// provide them.
+func (walk *Walk) listChildren(path string, batchSize int) (names []string, childIsDir []bool, hasMore bool, err error) {
+	walk.statsLocker.Lock()
+	walk.stats.DirectoryReadCalls++
+	walk.statsLocker.Unlock()
+
+	walk.injectLatency()
+
+	if walk.isNamesOnly == true {
+		if tcl, ok := walk.childLister.(typedChildLister); ok == true {
+			children, hasMore, err := tcl.ListTypedChildren(path, batchSize)
+			if err != nil {
+				return nil, nil, false, err
+			}
+
+			names = make([]string, len(children))
+			childIsDir = make([]bool, len(children))
+
+			for i, child := range children {
+				names[i] = child.name
+				childIsDir[i] = child.isDir
+			}
+
+			return names, childIsDir, hasMore, nil
+		}
+	}
+
+	names, hasMore, err = walk.childLister.ListChildren(path, batchSize)
+	return names, nil, hasMore, err
+}
+
+// handleJobDirectoryContentsBatchNamesOnly processes a batch of directory
+// entries in names-only mode. Files are delivered immediately.
+func (walk *Walk) handleJobDirectoryContentsBatchNamesOnly(jdcb jobDirectoryContentsBatch) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	filesVisited := 0
+
+	defer func() {
+		atomic.AddInt64(&walk.hotStats.filesVisited, int64(filesVisited))
+
+		if filesVisited > 0 {
+			walk.recordDepth(jdcb.depth+1, filesVisited)
+			walk.recordDirectoryFileCount(jdcb.ParentNodePath(), filesVisited)
+		}
+	}()
+
+	// heldJobs and heldFilePaths are dispatched after the rest of the batch
+	// because of the intra-directory order.
+	var heldJobs []job
+	var heldFilePaths []string
+
+	deadline := walk.directoryDeadline()
+
+	parentNodePath := jdcb.ParentNodePath()
+	cpb := newChildPathBuilder(parentNodePath)
+
+	for i, childFilename := range jdcb.ChildBatch() {
+		childPath := cpb.Join(childFilename)
+
+		if _, found := walk.resumeSkipPaths[childPath]; found == true {
+			continue
+		}
+
+		if walk.isPathTooLong(childPath) == true || walk.isPathTooDeep(childPath) == true {
+			continue
+		}
+
+		if walk.isWalkIgnored(childPath, jdcb.ignoreRules) == true {
+			continue
+		}
+
+		var isDir bool
+		if jdcb.childIsDir != nil {
+			isDir = jdcb.childIsDir[i]
+		} else {
+			// The lister can't tell us the type.
+
+			if walk.checkDirectoryDeadline(parentNodePath, deadline) == true {
+				break
+			}
+
+			info, err := walk.statNode(childPath)
+			if err != nil {
+				walk.logWarningf("can not stat [%s]; it will be skipped: [%s]", childPath, err.Error())
+
+				walk.recordFailedDirectory(parentNodePath)
+
+				err := walk.recordSkippedEntry(childPath, err)
+				log.PanicIf(err)
+
+				continue
+			}
+
+			isDir = info.IsDir()
+		}
+
+		if isDir == true {
+			if jdcb.skipDirectories == true {
+				continue
+			}
+
+			if walk.isTopLevelDirectoryExcluded(childPath, jdcb.depth+1) == true {
+				continue
+			}
+
+			jdn := newJobDirectoryNode(parentNodePath, nameOnlyFileInfo{name: childFilename})
+			jdn.dirContext = jdcb.dirContext
+			jdn.skipChildren = jdcb.isListed
+			jdn.depth = jdcb.depth + 1
+			jdn.parentInfo = jdcb.parentInfo
+			jdn.ignoreRules = jdcb.ignoreRules
+			jdn.parentDir = jdcb.dir.acquire()
+
+			if walk.isHeldForIntraDirectoryOrder(true) == true {
+				heldJobs = append(heldJobs, jdn)
+				continue
+			}
+
+			err := walk.pushJob(jdn)
+			log.PanicIf(err)
+		} else if jdcb.DoProcessFiles() == true {
+			if walk.filter.IsFileIncluded(childFilename) != true {
+				walk.logDebugf("File excluded: [%s]", childFilename)
+
+				walk.statsFileFilterExcludeTickUp()
+				walk.notifySkip(childPath, SkipFilterFilename)
+
+				continue
+			}
+
+			if walk.isCompoundExtensionExcluded(childPath, childFilename) == true {
+				continue
+			}
+
+			walk.statsFileFilterIncludeTickUp()
+
+			filesVisited++
+
+			if walk.isPruneEmptyDirectories == true {
+				walk.markIncludedDescendant(parentNodePath)
+			}
+
+			if walk.isHeldForIntraDirectoryOrder(false) == true {
+				heldFilePaths = append(heldFilePaths, childPath)
+				continue
+			}
+
+			err := walk.callNameFunc(childPath, false)
+			log.PanicIf(err)
+		} else {
+			walk.notifySkip(childPath, SkipFilterPath)
+		}
+	}
+
+	for _, childPath := range heldFilePaths {
+		err := walk.callNameFunc(childPath, false)
+		log.PanicIf(err)
+	}
+
+	err = walk.pushHeldJobs(heldJobs)
+	log.PanicIf(err)
+
+	return nil
+}
+
+// callNameFunc delivers one path to the names-only callback.
+func (walk *Walk) callNameFunc(fqPath string, isDir bool) (err error) {
+	if walk.isCountingMatches == true {
+		walk.countMatch(isDir, 1)
+		return nil
+	}
+
+	if walk.nameFunc == nil {
+		return nil
+	}
+
+	if walk.isReformattingReportedPaths() == true {
+		parentNodePath, info := walk.reportPath(path.Dir(fqPath), nameOnlyFileInfo{name: path.Base(fqPath)})
+		fqPath = path.Join(parentNodePath, info.Name())
+	}
+
+	startTime := time.Now()
+
+	defer func() {
+		duration := time.Since(startTime)
+
+		walk.hotStats.addCallbackTime(duration)
+	}()
+
+	return walk.nameFunc(fqPath, isDir)
+}
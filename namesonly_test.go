@@ -0,0 +1,285 @@
+package pathwalk
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-parallel-walker/internal/testing"
+)
+
+func TestWalk_SetNamesOnly(t *testing.T) {
+	fileCount := 200
+	tempPath, tempFiles := pwtesting.FillHeirarchicalTempPath(fileCount, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	m := sync.Mutex{}
+	files := make([]string, 0)
+	directoryCount := 0
+
+	nameFunc := func(fqPath string, isDir bool) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		if isDir == true {
+			directoryCount++
+			return nil
+		}
+
+		files = append(files, fqPath[len(tempPath)+1:])
+
+		return nil
+	}
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		t.Fatalf("The regular callback should not be called.")
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetNamesOnly(true)
+	walk.SetNameFunc(nameFunc)
+
+	// Force more than one batch per directory.
+	walk.SetBatchSize(2)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	sort.Strings(files)
+
+	if reflect.DeepEqual(files, []string(tempFiles)) != true {
+		t.Fatalf("Files not correct: (%d) != (%d)", len(files), len(tempFiles))
+	} else if directoryCount != walk.Stats().DirectoriesVisited {
+		t.Fatalf("Directory count not correct: (%d) != (%d)", directoryCount, walk.Stats().DirectoriesVisited)
+	} else if walk.Stats().FilesVisited != fileCount {
+		t.Fatalf("FilesVisited not correct: (%d)", walk.Stats().FilesVisited)
+	}
+}
+
+func TestWalk_SetNamesOnly__symlinkNotFollowed(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	err = os.Mkdir(path.Join(tempPath, "dir1"), 0755)
+	log.PanicIf(err)
+
+	err = ioutil.WriteFile(path.Join(tempPath, "dir1", "file1"), []byte{}, 0644)
+	log.PanicIf(err)
+
+	err = os.Symlink(path.Join(tempPath, "dir1"), path.Join(tempPath, "link1"))
+	log.PanicIf(err)
+
+	m := sync.Mutex{}
+	visited := make(map[string]bool)
+
+	nameFunc := func(fqPath string, isDir bool) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		visited[path.Base(fqPath)] = isDir
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, nil)
+	walk.SetNamesOnly(true)
+	walk.SetNameFunc(nameFunc)
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	expected := map[string]bool{
+		path.Base(tempPath): true,
+		"dir1":              true,
+		"file1":             false,
+		"link1":             false,
+	}
+
+	if reflect.DeepEqual(visited, expected) != true {
+		t.Fatalf("Visited not correct: %v", visited)
+	}
+}
+
+func TestWalk_SetNamesOnly__childLister(t *testing.T) {
+	tmcl := &testMapChildLister{
+		children: map[string][]string{
+			"/root":            {"a", "container1"},
+			"/root/container1": {"b"},
+		},
+		offsets: make(map[string]int),
+	}
+
+	m := sync.Mutex{}
+	visited := make([]string, 0)
+
+	nameFunc := func(fqPath string, isDir bool) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		visited = append(visited, fqPath)
+
+		return nil
+	}
+
+	walk := NewWalk("/root", nil)
+	walk.SetChildLister(tmcl)
+	walk.SetNamesOnly(true)
+	walk.SetNameFunc(nameFunc)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	sort.Strings(visited)
+
+	expected := []string{
+		"/root",
+		"/root/a",
+		"/root/container1",
+		"/root/container1/b",
+	}
+
+	if reflect.DeepEqual(visited, expected) != true {
+		t.Fatalf("Visited not correct: %v", visited)
+	}
+}
+
+func TestWalk_SetNamesOnly__allowedTopLevelDirs(t *testing.T) {
+	tempPath := createDepthTestTree()
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walk := NewWalk(tempPath, nil)
+	walk.SetNamesOnly(true)
+
+	m := sync.Mutex{}
+	visited := make([]string, 0)
+
+	walk.SetNameFunc(func(fqPath string, isDir bool) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		visited = append(visited, fqPath[len(tempPath):])
+
+		return nil
+	})
+
+	filter := Filter{
+		AllowedTopLevelDirs: []string{"other"},
+	}
+
+	walk.SetFilter(filter)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	sort.Strings(visited)
+
+	expected := []string{
+		"",
+		"/file0",
+	}
+
+	if reflect.DeepEqual(visited, expected) != true {
+		t.Fatalf("Visited not correct: %v", visited)
+	}
+
+	if walk.Stats().TopLevelDirsExcluded != 1 {
+		t.Fatalf("TopLevelDirsExcluded not correct: (%d)", walk.Stats().TopLevelDirsExcluded)
+	}
+}
+
+func TestWalk_SetNamesOnly__conflict(t *testing.T) {
+	walk := NewWalk("/root", nil)
+	walk.SetNamesOnly(true)
+
+	filter := Filter{
+		OwnerUIDs: []int{0},
+	}
+
+	walk.SetFilter(filter)
+
+	err := walk.Run()
+	if err == nil || log.Is(err, ErrNamesOnlyConflict) != true {
+		t.Fatalf("Expected conflict error: %v", err)
+	}
+}
+
+func benchmarkWalk(b *testing.B, isNamesOnly bool) {
+	tempPath, _ := pwtesting.FillFlatTempPath(20000, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		return nil
+	}
+
+	nameFunc := func(fqPath string, isDir bool) (err error) {
+		return nil
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		walk := NewWalk(tempPath, walkFunc)
+
+		if isNamesOnly == true {
+			walk.SetNamesOnly(true)
+			walk.SetNameFunc(nameFunc)
+		}
+
+		err := walk.Run()
+		log.PanicIf(err)
+	}
+}
+
+func BenchmarkWalk_Run__standard(b *testing.B) {
+	benchmarkWalk(b, false)
+}
+
+func BenchmarkWalk_Run__namesOnly(b *testing.B) {
+	benchmarkWalk(b, true)
+}
+
+func TestWalk_SetNamesOnly__statCalls(t *testing.T) {
+	fileCount := 10
+	tempPath, _ := pwtesting.FillFlatTempPath(fileCount, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walk := NewWalk(tempPath, nil)
+	walk.SetNamesOnly(true)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	stats := walk.Stats()
+
+	// Only the root is stat'd. The types of the children come from the
+	// directory listing.
+	if stats.StatCalls != 1 {
+		t.Fatalf("StatCalls not correct: (%d)", stats.StatCalls)
+	} else if stats.DirectoryReadCalls != 2 {
+		t.Fatalf("DirectoryReadCalls not correct: (%d)", stats.DirectoryReadCalls)
+	}
+}
@@ -0,0 +1,52 @@
+package pathwalk
+
+import (
+	"fmt"
+)
+
+// Outcome describes how the last run ended.
+type Outcome int
+
+const (
+	// OutcomeNone indicates that no run has ended yet.
+	OutcomeNone Outcome = iota
+
+	// OutcomeCompleted indicates that every entry was visited and processed.
+	OutcomeCompleted
+
+	// OutcomeStopped indicates that the run was ended early via `Stop()`.
+	OutcomeStopped
+
+	// OutcomeCancelled indicates that the run was ended early because its
+	// context was cancelled.
+	OutcomeCancelled
+
+	// OutcomeError indicates that the run was ended by an error, either from
+	// the callback, from the filesystem, or from a detected dead-lock.
+	OutcomeError
+
+	// OutcomeTruncated indicates that the run completed but that a configured
+	// limit caused some of the tree to not be visited.
+	OutcomeTruncated
+)
+
+var (
+	outcomeNames = map[Outcome]string{
+		OutcomeNone:      "none",
+		OutcomeCompleted: "completed",
+		OutcomeStopped:   "stopped",
+		OutcomeCancelled: "cancelled",
+		OutcomeError:     "error",
+		OutcomeTruncated: "truncated",
+	}
+)
+
+// String returns a descriptive string.
+func (outcome Outcome) String() string {
+	name, found := outcomeNames[outcome]
+	if found == false {
+		return fmt.Sprintf("Outcome<%d>", int(outcome))
+	}
+
+	return name
+}
@@ -0,0 +1,15 @@
+package pathwalk
+
+import (
+	"testing"
+)
+
+func TestOutcome_String(t *testing.T) {
+	if OutcomeCompleted.String() != "completed" {
+		t.Fatalf("String() not correct for a known outcome: [%s]", OutcomeCompleted.String())
+	}
+
+	if Outcome(99).String() != "Outcome<99>" {
+		t.Fatalf("String() not correct for an unknown outcome: [%s]", Outcome(99).String())
+	}
+}
@@ -0,0 +1,20 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly && !solaris
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly,!solaris
+
+package pathwalk
+
+import (
+	"os"
+)
+
+const (
+	// isOwnershipSupported indicates that file ownership is not available on
+	// this platform.
+	isOwnershipSupported = false
+)
+
+// getOwnerIds always fails since ownership is not supported on this platform.
+// Owner filters will have no effect.
+func getOwnerIds(info os.FileInfo) (uid, gid int, ok bool) {
+	return 0, 0, false
+}
@@ -0,0 +1,26 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly || solaris
+// +build linux darwin freebsd netbsd openbsd dragonfly solaris
+
+package pathwalk
+
+import (
+	"os"
+	"syscall"
+)
+
+const (
+	// isOwnershipSupported indicates that file ownership is available on this
+	// platform.
+	isOwnershipSupported = true
+)
+
+// getOwnerIds returns the user and group that own the given node if they're
+// available.
+func getOwnerIds(info os.FileInfo) (uid, gid int, ok bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if ok == false {
+		return 0, 0, false
+	}
+
+	return int(stat.Uid), int(stat.Gid), true
+}
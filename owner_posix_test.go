@@ -0,0 +1,112 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly || solaris
+// +build linux darwin freebsd netbsd openbsd dragonfly solaris
+
+package pathwalk
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/dsoprea/go-logging"
+	"github.com/dsoprea/go-utility/filesystem"
+
+	"github.com/dsoprea/go-parallel-walker/internal/testing"
+)
+
+func newTestOwnedFileInfo(uid, gid int) os.FileInfo {
+	stat := &syscall.Stat_t{}
+	stat.Uid = uint32(uid)
+	stat.Gid = uint32(gid)
+
+	return testDeviceFileInfo{
+		FileInfo: rifs.NewSimpleFileInfoWithFile("file", 0, 0, time.Time{}),
+		stat:     stat,
+	}
+}
+
+func TestInternalFilter_IsOwnerIncluded(t *testing.T) {
+	filter := Filter{
+		OwnerUIDs: []int{1000, 1001},
+	}
+
+	ifilter := newInternalFilter(filter)
+
+	if ifilter.HasOwnerFilter() != true {
+		t.Fatalf("Expected an owner filter.")
+	} else if ifilter.IsOwnerIncluded(newTestOwnedFileInfo(1001, 0)) != true {
+		t.Fatalf("Expected a matching UID to be included.")
+	} else if ifilter.IsOwnerIncluded(newTestOwnedFileInfo(0, 0)) != false {
+		t.Fatalf("Expected a non-matching UID to be excluded.")
+	}
+
+	// Both must match if both are given.
+
+	filter.OwnerGIDs = []int{50}
+
+	ifilter = newInternalFilter(filter)
+
+	if ifilter.IsOwnerIncluded(newTestOwnedFileInfo(1000, 50)) != true {
+		t.Fatalf("Expected a matching UID and GID to be included.")
+	} else if ifilter.IsOwnerIncluded(newTestOwnedFileInfo(1000, 51)) != false {
+		t.Fatalf("Expected a non-matching GID to be excluded.")
+	}
+
+	// Ownership that isn't available doesn't exclude.
+
+	sfi := rifs.NewSimpleFileInfoWithFile("file", 0, 0, time.Time{})
+
+	if ifilter.IsOwnerIncluded(sfi) != true {
+		t.Fatalf("Expected a file without ownership to be included.")
+	}
+}
+
+func TestWalk_Run__ownerFilter(t *testing.T) {
+	fileCount := 10
+	tempPath, _ := pwtesting.FillFlatTempPath(fileCount, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		return nil
+	}
+
+	// Our own files.
+
+	walk := NewWalk(tempPath, walkFunc)
+
+	filter := Filter{
+		OwnerUIDs: []int{os.Getuid()},
+	}
+
+	walk.SetFilter(filter)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	stats := walk.Stats()
+
+	if stats.FilesVisited != fileCount || stats.OwnerFilterExcludes != 0 {
+		t.Fatalf("Expected all files to be included: (%d) (%d)", stats.FilesVisited, stats.OwnerFilterExcludes)
+	}
+
+	// Somebody else's files.
+
+	filter = Filter{
+		OwnerUIDs: []int{os.Getuid() + 1},
+	}
+
+	walk.SetFilter(filter)
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	stats = walk.Stats()
+
+	if stats.FilesVisited != 0 || stats.OwnerFilterExcludes != fileCount {
+		t.Fatalf("Expected all files to be excluded: (%d) (%d)", stats.FilesVisited, stats.OwnerFilterExcludes)
+	}
+}
@@ -0,0 +1,203 @@
+package pathwalk
+
+import (
+	"context"
+	"os"
+	"path"
+	"sort"
+	"sync"
+
+	"github.com/dsoprea/go-logging"
+)
+
+const (
+	pairSideA = 0
+	pairSideB = 1
+)
+
+// PairFunc receives one relative path that was found in either of the two
+// trees given to `WalkPair()`. `a` or `b` is nil if the path is missing from
+// that tree. The root is reported as ".".
+type PairFunc func(relPath string, a, b os.FileInfo) (err error)
+
+// pairJoin matches the entries of two concurrent walks by relative path.
+// Entries wait here until the same path is seen in the other tree or until
+// the other walk finishes.
+type pairJoin struct {
+	locker sync.Mutex
+
+	pending [2]map[string]os.FileInfo
+	isDone  [2]bool
+}
+
+func newPairJoin() *pairJoin {
+	return &pairJoin{
+		pending: [2]map[string]os.FileInfo{
+			make(map[string]os.FileInfo),
+			make(map[string]os.FileInfo),
+		},
+	}
+}
+
+// add registers an entry from one side. If it can already be delivered, the
+// infos for both sides are returned with `isReady` set.
+func (pj *pairJoin) add(side int, relPath string, info os.FileInfo) (infos [2]os.FileInfo, isReady bool) {
+	other := 1 - side
+
+	pj.locker.Lock()
+	defer pj.locker.Unlock()
+
+	if otherInfo, found := pj.pending[other][relPath]; found == true {
+		delete(pj.pending[other], relPath)
+
+		infos[side] = info
+		infos[other] = otherInfo
+
+		return infos, true
+	}
+
+	// Once the other side has finished, nothing can match anymore, so we
+	// don't hold onto it.
+	if pj.isDone[other] == true {
+		infos[side] = info
+		return infos, true
+	}
+
+	pj.pending[side][relPath] = info
+
+	return infos, false
+}
+
+// markDone records that one side has finished and returns the paths from the
+// other side that are waiting and can now never be matched, in order.
+func (pj *pairJoin) markDone(side int) (relPaths []string, infos []os.FileInfo) {
+	other := 1 - side
+
+	pj.locker.Lock()
+	defer pj.locker.Unlock()
+
+	pj.isDone[side] = true
+
+	orphans := pj.pending[other]
+	pj.pending[other] = make(map[string]os.FileInfo)
+
+	relPaths = make([]string, 0, len(orphans))
+	for relPath := range orphans {
+		relPaths = append(relPaths, relPath)
+	}
+
+	sort.Strings(relPaths)
+
+	infos = make([]os.FileInfo, len(relPaths))
+	for i, relPath := range relPaths {
+		infos[i] = orphans[relPath]
+	}
+
+	return relPaths, infos
+}
+
+// WalkPair walks two trees in parallel and calls `pairFunc` once for every
+// relative path that is present in either of them, which is what is needed to
+// determine what changed between two directories. Like `WalkFunc`, the
+// callback is called concurrently.
+//
+// Entries are delivered as soon as the same path has been seen in both trees.
+// Until then, they are held in memory, so memory use is proportional to the
+// number of entries that have been seen on one side but not yet on the other.
+// When one walk finishes, everything still held from the other side is
+// delivered and anything that the other side finds afterward is delivered
+// immediately. For trees of very different sizes, this means that only
+// the smaller tree's unmatched entries are held for long. For trees that
+// share few paths, the unmatched entries of the tree that finishes last are
+// held until the end.
+//
+// If either walk or the callback fails, the other walk is stopped and the
+// first error is returned.
+func WalkPair(rootA, rootB string, pairFunc PairFunc) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	pj := newPairJoin()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	deliver := func(relPath string, infos [2]os.FileInfo) error {
+		return pairFunc(relPath, infos[pairSideA], infos[pairSideB])
+	}
+
+	runSide := func(side int, rootPath string) (err error) {
+		defer func() {
+			if state := recover(); state != nil {
+				err = log.Wrap(state.(error))
+			}
+		}()
+
+		walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+			relPath := path.Join(parentPath, info.Name())
+
+			infos, isReady := pj.add(side, relPath, info)
+			if isReady == false {
+				return nil
+			}
+
+			return deliver(relPath, infos)
+		}
+
+		walk := NewWalk(rootPath, walkFunc)
+		walk.SetPathStyle(PathStyleRelative)
+
+		err = walk.RunContext(ctx)
+		log.PanicIf(err)
+
+		relPaths, orphans := pj.markDone(side)
+
+		for i, relPath := range relPaths {
+			infos := [2]os.FileInfo{}
+			infos[1-side] = orphans[i]
+
+			err := deliver(relPath, infos)
+			log.PanicIf(err)
+		}
+
+		return nil
+	}
+
+	roots := [2]string{rootA, rootB}
+	errs := [2]error{}
+
+	wg := new(sync.WaitGroup)
+	wg.Add(2)
+
+	for side := range roots {
+		go func(side int) {
+			defer wg.Done()
+
+			errs[side] = runSide(side, roots[side])
+			if errs[side] != nil {
+				cancel()
+			}
+		}(side)
+	}
+
+	wg.Wait()
+
+	// The side that failed first cancelled the other, so prefer an error that
+	// isn't just the cancellation.
+	for _, sideErr := range errs {
+		if sideErr != nil && log.Is(sideErr, context.Canceled) == false {
+			return sideErr
+		}
+	}
+
+	for _, sideErr := range errs {
+		if sideErr != nil {
+			return sideErr
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,188 @@
+package pathwalk
+
+import (
+	"errors"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+)
+
+func createPairTestTree(relFilepaths []string) (tempPath string) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	for _, relFilepath := range relFilepaths {
+		filepath := path.Join(tempPath, relFilepath)
+
+		err := os.MkdirAll(path.Dir(filepath), 0755)
+		log.PanicIf(err)
+
+		err = ioutil.WriteFile(filepath, []byte(relFilepath), 0644)
+		log.PanicIf(err)
+	}
+
+	return tempPath
+}
+
+func TestWalkPair(t *testing.T) {
+	tempPathA := createPairTestTree([]string{
+		"same",
+		"onlyA",
+		"dir1/same",
+		"dir1/onlyA",
+		"dirA/file",
+	})
+
+	defer func() {
+		os.RemoveAll(tempPathA)
+	}()
+
+	tempPathB := createPairTestTree([]string{
+		"same",
+		"onlyB",
+		"dir1/same",
+		"dirB/file",
+	})
+
+	defer func() {
+		os.RemoveAll(tempPathB)
+	}()
+
+	locker := sync.Mutex{}
+	collected := make(map[string]string)
+
+	pairFunc := func(relPath string, a, b os.FileInfo) (err error) {
+		locker.Lock()
+		defer locker.Unlock()
+
+		if _, found := collected[relPath]; found == true {
+			t.Fatalf("Path delivered more than once: [%s]", relPath)
+		}
+
+		if a != nil && b != nil {
+			if a.Name() != b.Name() || a.IsDir() != b.IsDir() {
+				t.Fatalf("Paired entries don't match: [%s] [%s]", a.Name(), b.Name())
+			}
+
+			collected[relPath] = "both"
+		} else if a != nil {
+			collected[relPath] = "a"
+		} else if b != nil {
+			collected[relPath] = "b"
+		} else {
+			t.Fatalf("Neither side was given: [%s]", relPath)
+		}
+
+		return nil
+	}
+
+	err := WalkPair(tempPathA, tempPathB, pairFunc)
+	log.PanicIf(err)
+
+	expected := map[string]string{
+		".":          "both",
+		"same":       "both",
+		"onlyA":      "a",
+		"onlyB":      "b",
+		"dir1":       "both",
+		"dir1/same":  "both",
+		"dir1/onlyA": "a",
+		"dirA":       "a",
+		"dirA/file":  "a",
+		"dirB":       "b",
+		"dirB/file":  "b",
+	}
+
+	if reflect.DeepEqual(collected, expected) != true {
+		t.Fatalf("Pairs not correct: %v", collected)
+	}
+}
+
+func TestWalkPair__asymmetric(t *testing.T) {
+	relFilepaths := make([]string, 0)
+	for i := 0; i < 10; i++ {
+		for j := 0; j < 50; j++ {
+			relFilepaths = append(relFilepaths, path.Join(string('a'+rune(i)), strings.Repeat("x", j+1)))
+		}
+	}
+
+	tempPathA := createPairTestTree(relFilepaths[:1])
+
+	defer func() {
+		os.RemoveAll(tempPathA)
+	}()
+
+	tempPathB := createPairTestTree(relFilepaths)
+
+	defer func() {
+		os.RemoveAll(tempPathB)
+	}()
+
+	locker := sync.Mutex{}
+	onlyB := 0
+	both := 0
+
+	pairFunc := func(relPath string, a, b os.FileInfo) (err error) {
+		locker.Lock()
+		defer locker.Unlock()
+
+		if a == nil {
+			onlyB++
+		} else if b != nil {
+			both++
+		} else {
+			t.Fatalf("Path only in the smaller tree: [%s]", relPath)
+		}
+
+		return nil
+	}
+
+	err := WalkPair(tempPathA, tempPathB, pairFunc)
+	log.PanicIf(err)
+
+	// The root, one directory, and one file are shared. The larger tree also
+	// has ten directories and the root on top of its files.
+	if both != 3 {
+		t.Fatalf("Shared count not correct: (%d)", both)
+	} else if onlyB != len(relFilepaths)+10+1-3 {
+		t.Fatalf("Unshared count not correct: (%d)", onlyB)
+	}
+}
+
+func TestWalkPair__callbackError(t *testing.T) {
+	tempPathA := createPairTestTree([]string{"file1", "file2"})
+
+	defer func() {
+		os.RemoveAll(tempPathA)
+	}()
+
+	tempPathB := createPairTestTree([]string{"file1", "file3"})
+
+	defer func() {
+		os.RemoveAll(tempPathB)
+	}()
+
+	errTest := errors.New("test error")
+
+	pairFunc := func(relPath string, a, b os.FileInfo) (err error) {
+		if relPath == "file1" {
+			return errTest
+		}
+
+		return nil
+	}
+
+	err := WalkPair(tempPathA, tempPathB, pairFunc)
+	if err == nil {
+		t.Fatalf("Expected error.")
+	} else if strings.Contains(err.Error(), errTest.Error()) == false {
+		t.Fatalf("Error not correct: [%s]", err)
+	}
+}
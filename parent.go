@@ -0,0 +1,17 @@
+package pathwalk
+
+import (
+	"path"
+)
+
+// NewWalkParent returns a walk of the directory that contains `rootPath`, one
+// level deep, in order to find the things next to it. The parent itself is
+// visited as the root, and its children (including `rootPath`) are visited
+// without descending into any of them. Everything else (filters, path
+// styles, etc.) applies as it would for `NewWalk()` of the parent.
+func NewWalkParent(rootPath string, walkFunc WalkFunc) (walk *Walk) {
+	walk = NewWalk(path.Dir(path.Clean(rootPath)), walkFunc)
+	walk.maxDepth = 1
+
+	return walk
+}
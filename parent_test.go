@@ -0,0 +1,86 @@
+package pathwalk
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+)
+
+func TestNewWalkParent(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	err = os.MkdirAll(path.Join(tempPath, "target", "inner-dir"), 0755)
+	log.PanicIf(err)
+
+	err = os.MkdirAll(path.Join(tempPath, "sibling-dir"), 0755)
+	log.PanicIf(err)
+
+	relFilepaths := []string{
+		"sibling-file",
+		"sibling-dir/deep-file",
+		"target/inner-file",
+	}
+
+	for _, relFilepath := range relFilepaths {
+		err := ioutil.WriteFile(path.Join(tempPath, relFilepath), []byte{}, 0644)
+		log.PanicIf(err)
+	}
+
+	m := sync.Mutex{}
+	visited := make([]string, 0)
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		fqPath := path.Join(parentPath, info.Name())
+		if fqPath == tempPath {
+			visited = append(visited, ".")
+		} else {
+			visited = append(visited, fqPath[len(tempPath)+1:])
+		}
+
+		return nil
+	}
+
+	walk := NewWalkParent(path.Join(tempPath, "target"), walkFunc)
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	sort.Strings(visited)
+
+	// The parent and its children are visited, but nothing deeper.
+	expected := []string{
+		".",
+		"sibling-dir",
+		"sibling-file",
+		"target",
+	}
+
+	if reflect.DeepEqual(visited, expected) != true {
+		t.Fatalf("Visited not correct: %v", visited)
+	} else if walk.Config().MaxDepth != 1 {
+		t.Fatalf("MaxDepth not correct: (%d)", walk.Config().MaxDepth)
+	}
+}
+
+func TestNewWalkParent__trailingSlash(t *testing.T) {
+	walk := NewWalkParent("/a/b/c/", nil)
+
+	if walk.rootPath != "/a/b" {
+		t.Fatalf("Root not correct: [%s]", walk.rootPath)
+	}
+}
@@ -0,0 +1,131 @@
+package pathwalk
+
+import (
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/dsoprea/go-logging"
+)
+
+// PathCaseNormalization determines how the case of the paths and names that
+// are delivered to the callback is normalized. This has no effect on
+// filtering.
+type PathCaseNormalization int
+
+const (
+	// PathCaseUnchanged delivers paths as they were given and found. This is
+	// the default.
+	PathCaseUnchanged PathCaseNormalization = iota
+
+	// PathCaseLower lowercases the parent path and name. This is useful for
+	// producing consistent keys on case-insensitive filesystems.
+	PathCaseLower
+
+	// PathCaseCanonical resolves the root path to the case that is actually
+	// stored on disk. Everything below the root is already read from disk, so
+	// only the root (as given by the user) can differ. This is resolved after
+	// the root is made absolute for `PathStyleAbsolute`. On case-sensitive
+	// filesystems (typically Linux), a path can only be found using its exact
+	// case, so this will generally have no effect. On case-insensitive
+	// filesystems (typically macOS and Windows), "/users/me" will be delivered
+	// as "/Users/me".
+	PathCaseCanonical
+)
+
+// renamedFileInfo overrides the name of an `os.FileInfo`.
+type renamedFileInfo struct {
+	os.FileInfo
+
+	name string
+}
+
+// Name returns the overridden name.
+func (rfi renamedFileInfo) Name() string {
+	return rfi.name
+}
+
+// SetPathCaseNormalization sets how the case of delivered paths is normalized.
+func (walk *Walk) SetPathCaseNormalization(mode PathCaseNormalization) {
+	walk.pathCaseNormalization = mode
+}
+
+// resolveCanonicalPath returns the given path with each component replaced by
+// the case that is stored on disk. Components that can not be found are left
+// unchanged.
+func resolveCanonicalPath(currentPath string) (canonicalPath string, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	currentPath = path.Clean(currentPath)
+
+	var parts []string
+	if strings.HasPrefix(currentPath, "/") == true {
+		canonicalPath = "/"
+		parts = strings.Split(currentPath[1:], "/")
+	} else {
+		canonicalPath = "."
+		parts = strings.Split(currentPath, "/")
+	}
+
+	for _, part := range parts {
+		if part == "" || part == "." || part == ".." {
+			canonicalPath = path.Join(canonicalPath, part)
+			continue
+		}
+
+		name, err := findNameCaseInsensitive(canonicalPath, part)
+		log.PanicIf(err)
+
+		canonicalPath = path.Join(canonicalPath, name)
+	}
+
+	return canonicalPath, nil
+}
+
+// findNameCaseInsensitive returns the on-disk name of the entry in the given
+// directory. An exact match is preferred. If nothing matches or the directory
+// can't be opened (e.g. it doesn't exist), the name is returned unchanged.
+func findNameCaseInsensitive(directoryPath, name string) (foundName string, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	f, err := os.Open(directoryPath)
+	if err != nil {
+		return name, nil
+	}
+
+	defer f.Close()
+
+	foundName = name
+	isFound := false
+
+	for {
+		names, err := f.Readdirnames(defaultDirectoryEntryBatchSize)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+
+			log.Panic(err)
+		}
+
+		for _, currentName := range names {
+			if currentName == name {
+				return name, nil
+			} else if isFound == false && strings.EqualFold(currentName, name) == true {
+				foundName = currentName
+				isFound = true
+			}
+		}
+	}
+
+	return foundName, nil
+}
@@ -0,0 +1,152 @@
+package pathwalk
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+	"github.com/dsoprea/go-utility/filesystem"
+)
+
+func TestWalk_Run__pathCaseLower(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	rootPath := path.Join(tempPath, "Root")
+
+	err = os.MkdirAll(path.Join(rootPath, "SubDir"), 0755)
+	log.PanicIf(err)
+
+	err = ioutil.WriteFile(path.Join(rootPath, "SubDir", "File.TXT"), []byte{}, 0644)
+	log.PanicIf(err)
+
+	m := sync.Mutex{}
+	visited := make([]string, 0)
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		visited = append(visited, path.Join(parentPath, info.Name()))
+
+		return nil
+	}
+
+	walk := NewWalk(rootPath, walkFunc)
+	walk.SetPathCaseNormalization(PathCaseLower)
+
+	// The filter still sees the on-disk case.
+	filter := Filter{
+		IncludeFilenames: []string{"File.TXT"},
+	}
+
+	walk.SetFilter(filter)
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	sort.Strings(visited)
+
+	lowerRootPath := strings.ToLower(rootPath)
+
+	expected := []string{
+		lowerRootPath,
+		path.Join(lowerRootPath, "subdir"),
+		path.Join(lowerRootPath, "subdir", "file.txt"),
+	}
+
+	if reflect.DeepEqual(visited, expected) != true {
+		t.Fatalf("Visited paths not correct: %v", visited)
+	}
+}
+
+func TestResolveCanonicalPath(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	err = os.MkdirAll(path.Join(tempPath, "SubDir", "Child"), 0755)
+	log.PanicIf(err)
+
+	canonicalPath, err := resolveCanonicalPath(path.Join(tempPath, "subdir", "CHILD"))
+	log.PanicIf(err)
+
+	if canonicalPath != path.Join(tempPath, "SubDir", "Child") {
+		t.Fatalf("Canonical path not correct: [%s]", canonicalPath)
+	}
+
+	// A component that doesn't exist is left alone.
+
+	canonicalPath, err = resolveCanonicalPath(path.Join(tempPath, "subdir", "Missing"))
+	log.PanicIf(err)
+
+	if canonicalPath != path.Join(tempPath, "SubDir", "Missing") {
+		t.Fatalf("Canonical path with missing component not correct: [%s]", canonicalPath)
+	}
+
+	// Nor is anything below it.
+
+	canonicalPath, err = resolveCanonicalPath(path.Join(tempPath, "subdir", "Missing", "child"))
+	log.PanicIf(err)
+
+	if canonicalPath != path.Join(tempPath, "SubDir", "Missing", "child") {
+		t.Fatalf("Canonical path below missing component not correct: [%s]", canonicalPath)
+	}
+}
+
+func TestFindNameCaseInsensitive__missingDirectory(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	foundName, err := findNameCaseInsensitive(path.Join(tempPath, "missing"), "Name")
+	log.PanicIf(err)
+
+	if foundName != "Name" {
+		t.Fatalf("Name not correct: [%s]", foundName)
+	}
+}
+
+func TestWalk_reportPath__canonical(t *testing.T) {
+	walk := NewWalk("/some/Root", nil)
+	walk.SetPathCaseNormalization(PathCaseCanonical)
+	walk.reportedRootPath = "/Some/Root"
+
+	info := rifs.NewSimpleFileInfoWithFile("File.txt", 0, 0, time.Time{})
+
+	parentPath, normalizedInfo := walk.reportPath("/some/Root/Dir", info)
+	if parentPath != "/Some/Root/Dir" {
+		t.Fatalf("Parent path not correct: [%s]", parentPath)
+	} else if normalizedInfo.Name() != "File.txt" {
+		t.Fatalf("Name not correct: [%s]", normalizedInfo.Name())
+	}
+
+	// The root itself.
+
+	info = rifs.NewSimpleFileInfoWithDirectory("Root", time.Time{})
+
+	parentPath, normalizedInfo = walk.reportPath("/some", info)
+	if parentPath != "/Some" {
+		t.Fatalf("Root parent path not correct: [%s]", parentPath)
+	} else if normalizedInfo.Name() != "Root" {
+		t.Fatalf("Root name not correct: [%s]", normalizedInfo.Name())
+	}
+}
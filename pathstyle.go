@@ -0,0 +1,190 @@
+package pathwalk
+
+import (
+	"os"
+	"path"
+	"strings"
+
+	"path/filepath"
+
+	"github.com/dsoprea/go-logging"
+)
+
+// PathStyle determines how the parent paths delivered to the callback are
+// formatted. The examples below are for a root of "photos" (relative to a
+// current directory of "/home/me") and a file at "photos/2020/a.jpg".
+type PathStyle int
+
+const (
+	// PathStyleAsGiven prefixes paths with the root exactly as it was given.
+	// This is the default.
+	//
+	// File: ("photos/2020", "a.jpg"). Root: (".", "photos").
+	PathStyleAsGiven PathStyle = iota
+
+	// PathStyleAbsolute prefixes paths with the absolute form of the root.
+	//
+	// File: ("/home/me/photos/2020", "a.jpg"). Root: ("/home/me", "photos").
+	PathStyleAbsolute
+
+	// PathStyleRelative makes paths relative to the root. Entries directly in
+	// the root have an empty parent path and the root itself is named ".".
+	//
+	// File: ("2020", "a.jpg"). Root: ("", ".").
+	PathStyleRelative
+
+	// PathStyleRootRelative makes paths relative to the parent of the root,
+	// so that they start with the name of the root. The root itself has an
+	// empty parent path.
+	//
+	// File: ("photos/2020", "a.jpg"). Root: ("", "photos").
+	PathStyleRootRelative
+)
+
+// SetPathStyle sets how reported paths are formatted. This has no effect on
+// filtering.
+func (walk *Walk) SetPathStyle(style PathStyle) {
+	walk.pathStyle = style
+}
+
+// SetReportPrefixStrip sets a prefix of one or more whole path components to
+// remove from the front of reported paths. This is matched against the path
+// *after* the path style is applied, so it should be given in terms of that
+// style (e.g. "2020" with `PathStyleRelative` or "photos/2020" with
+// `PathStyleRootRelative`). It is matched before any case normalization.
+// Entries below the prefix are reported relative to it. The entry at the
+// prefix itself and paths that don't start with the prefix are reported
+// unchanged (so the prefix directory can still be told apart from the root).
+// An empty prefix disables this. This has no effect on filtering.
+func (walk *Walk) SetReportPrefixStrip(prefix string) {
+	if prefix != "" {
+		prefix = path.Clean(prefix)
+	}
+
+	walk.reportPrefixStrip = prefix
+}
+
+// isReformattingReportedPaths indicates that paths have to be passed through
+// `reportPath()` before being delivered.
+func (walk *Walk) isReformattingReportedPaths() bool {
+	return walk.pathStyle != PathStyleAsGiven || walk.pathCaseNormalization != PathCaseUnchanged || walk.reportPrefixStrip != ""
+}
+
+// prepareReportedRootPath determines what the root is reported as given the
+// configured path style and case normalization.
+func (walk *Walk) prepareReportedRootPath() (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	reportedRootPath := path.Clean(walk.rootPath)
+
+	if walk.pathStyle == PathStyleAbsolute {
+		reportedRootPath, err = filepath.Abs(reportedRootPath)
+		log.PanicIf(err)
+
+		reportedRootPath = filepath.ToSlash(reportedRootPath)
+	}
+
+	if walk.pathCaseNormalization == PathCaseCanonical {
+		reportedRootPath, err = resolveCanonicalPath(reportedRootPath)
+		log.PanicIf(err)
+	}
+
+	switch walk.pathStyle {
+	case PathStyleRelative:
+		reportedRootPath = ""
+	case PathStyleRootRelative:
+		reportedRootPath = path.Base(reportedRootPath)
+	}
+
+	walk.reportedRootPath = reportedRootPath
+
+	return nil
+}
+
+// reportPath returns the parent path and info as they should be delivered to
+// the callback.
+func (walk *Walk) reportPath(parentPath string, info os.FileInfo) (string, os.FileInfo) {
+	name := info.Name()
+
+	if walk.pathStyle != PathStyleAsGiven || walk.pathCaseNormalization == PathCaseCanonical {
+		rootPath := path.Clean(walk.rootPath)
+
+		rootPrefix := rootPath
+		if strings.HasSuffix(rootPrefix, "/") == false {
+			rootPrefix += "/"
+		}
+
+		fqPath := path.Join(parentPath, name)
+
+		if fqPath == rootPath {
+			if walk.pathStyle == PathStyleRelative {
+				parentPath = ""
+				name = "."
+			} else if walk.pathStyle == PathStyleRootRelative {
+				parentPath = ""
+				name = walk.reportedRootPath
+			} else {
+				parentPath = path.Dir(walk.reportedRootPath)
+				name = path.Base(walk.reportedRootPath)
+			}
+		} else if strings.HasPrefix(fqPath, rootPrefix) == true {
+			// Manually-driven jobs might not be under the root, so we only
+			// reformat what is.
+
+			fqPath = path.Join(walk.reportedRootPath, fqPath[len(rootPrefix):])
+
+			parentPath = path.Dir(fqPath)
+			name = path.Base(fqPath)
+
+			if parentPath == "." && (walk.pathStyle == PathStyleRelative || walk.pathStyle == PathStyleRootRelative) {
+				parentPath = ""
+			}
+		}
+	}
+
+	if walk.reportPrefixStrip != "" {
+		parentPath, name = walk.stripReportPrefix(parentPath, name)
+	}
+
+	if walk.pathCaseNormalization == PathCaseLower {
+		parentPath = strings.ToLower(parentPath)
+		name = strings.ToLower(name)
+	}
+
+	if name != info.Name() {
+		info = renamedFileInfo{
+			FileInfo: info,
+			name:     name,
+		}
+	}
+
+	return parentPath, info
+}
+
+// stripReportPrefix removes the configured prefix from the given parent path
+// and name. They are returned unchanged if they are not below the prefix.
+func (walk *Walk) stripReportPrefix(parentPath, name string) (string, string) {
+	fqPath := path.Join(parentPath, name)
+
+	prefix := walk.reportPrefixStrip
+	if strings.HasSuffix(prefix, "/") == false {
+		prefix += "/"
+	}
+
+	if strings.HasPrefix(fqPath, prefix) == false {
+		return parentPath, name
+	}
+
+	fqPath = fqPath[len(prefix):]
+
+	parentPath = path.Dir(fqPath)
+	if parentPath == "." {
+		parentPath = ""
+	}
+
+	return parentPath, path.Base(fqPath)
+}
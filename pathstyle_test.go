@@ -0,0 +1,278 @@
+package pathwalk
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/dsoprea/go-logging"
+	"github.com/dsoprea/go-utility/filesystem"
+)
+
+func testPathStyleVisited(rootPath string, style PathStyle) (visited [][2]string) {
+	return testPathStyleVisitedWithStrip(rootPath, style, "")
+}
+
+func testPathStyleVisitedWithStrip(rootPath string, style PathStyle, prefix string) (visited [][2]string) {
+	m := sync.Mutex{}
+	visited = make([][2]string, 0)
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		visited = append(visited, [2]string{parentPath, info.Name()})
+
+		return nil
+	}
+
+	walk := NewWalk(rootPath, walkFunc)
+	walk.SetPathStyle(style)
+	walk.SetReportPrefixStrip(prefix)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	sort.Slice(visited, func(i, j int) bool {
+		return path.Join(visited[i][0], visited[i][1]) < path.Join(visited[j][0], visited[j][1])
+	})
+
+	return visited
+}
+
+func TestWalk_SetPathStyle(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	rootPath := path.Join(tempPath, "photos")
+
+	err = os.MkdirAll(path.Join(rootPath, "2020"), 0755)
+	log.PanicIf(err)
+
+	err = ioutil.WriteFile(path.Join(rootPath, "2020", "a.jpg"), []byte{}, 0644)
+	log.PanicIf(err)
+
+	visited := testPathStyleVisited(rootPath, PathStyleRelative)
+
+	expected := [][2]string{
+		{"", "."},
+		{"", "2020"},
+		{"2020", "a.jpg"},
+	}
+
+	if reflect.DeepEqual(visited, expected) != true {
+		t.Fatalf("Relative paths not correct: %v", visited)
+	}
+
+	visited = testPathStyleVisited(rootPath, PathStyleRootRelative)
+
+	expected = [][2]string{
+		{"", "photos"},
+		{"photos", "2020"},
+		{"photos/2020", "a.jpg"},
+	}
+
+	if reflect.DeepEqual(visited, expected) != true {
+		t.Fatalf("Root-relative paths not correct: %v", visited)
+	}
+
+	visited = testPathStyleVisited(rootPath, PathStyleAbsolute)
+
+	expected = [][2]string{
+		{tempPath, "photos"},
+		{rootPath, "2020"},
+		{path.Join(rootPath, "2020"), "a.jpg"},
+	}
+
+	if reflect.DeepEqual(visited, expected) != true {
+		t.Fatalf("Absolute paths not correct: %v", visited)
+	}
+}
+
+func TestWalk_reportPath__absoluteFromRelativeRoot(t *testing.T) {
+	walk := NewWalk("photos/", nil)
+	walk.SetPathStyle(PathStyleAbsolute)
+
+	err := walk.prepareReportedRootPath()
+	log.PanicIf(err)
+
+	currentPath, err := os.Getwd()
+	log.PanicIf(err)
+
+	absoluteRootPath := path.Join(filepath.ToSlash(currentPath), "photos")
+
+	if walk.reportedRootPath != absoluteRootPath {
+		t.Fatalf("Reported root not correct: [%s]", walk.reportedRootPath)
+	}
+
+	info := rifs.NewSimpleFileInfoWithFile("a.jpg", 0, 0, time.Time{})
+
+	parentPath, reportedInfo := walk.reportPath("photos/2020", info)
+	if parentPath != path.Join(absoluteRootPath, "2020") {
+		t.Fatalf("Parent path not correct: [%s]", parentPath)
+	} else if reportedInfo.Name() != "a.jpg" {
+		t.Fatalf("Name not correct: [%s]", reportedInfo.Name())
+	}
+
+	// The root itself (the root job's parent is derived from the uncleaned
+	// root).
+
+	info = rifs.NewSimpleFileInfoWithDirectory("photos", time.Time{})
+
+	parentPath, reportedInfo = walk.reportPath(".", info)
+	if parentPath != path.Dir(absoluteRootPath) {
+		t.Fatalf("Root parent path not correct: [%s]", parentPath)
+	} else if reportedInfo.Name() != "photos" {
+		t.Fatalf("Root name not correct: [%s]", reportedInfo.Name())
+	}
+}
+
+func TestWalk_SetReportPrefixStrip(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	rootPath := path.Join(tempPath, "photos")
+
+	err = os.MkdirAll(path.Join(rootPath, "2020", "summer"), 0755)
+	log.PanicIf(err)
+
+	err = os.MkdirAll(path.Join(rootPath, "2021"), 0755)
+	log.PanicIf(err)
+
+	err = ioutil.WriteFile(path.Join(rootPath, "2020", "summer", "a.jpg"), []byte{}, 0644)
+	log.PanicIf(err)
+
+	err = ioutil.WriteFile(path.Join(rootPath, "2021", "b.jpg"), []byte{}, 0644)
+	log.PanicIf(err)
+
+	// The prefix is given in terms of the path style.
+
+	visited := testPathStyleVisitedWithStrip(rootPath, PathStyleRelative, "2020/")
+
+	expected := [][2]string{
+		{"", "."},
+		{"", "2020"},
+		{"", "2021"},
+		{"", "summer"},
+		{"2021", "b.jpg"},
+		{"summer", "a.jpg"},
+	}
+
+	sort.Slice(visited, func(i, j int) bool {
+		if visited[i][0] != visited[j][0] {
+			return visited[i][0] < visited[j][0]
+		}
+
+		return visited[i][1] < visited[j][1]
+	})
+
+	if reflect.DeepEqual(visited, expected) != true {
+		t.Fatalf("Relative paths not correct: %v", visited)
+	}
+
+	visited = testPathStyleVisitedWithStrip(rootPath, PathStyleRootRelative, "photos/2020")
+
+	expected = [][2]string{
+		{"", "photos"},
+		{"", "summer"},
+		{"photos", "2020"},
+		{"photos", "2021"},
+		{"photos/2021", "b.jpg"},
+		{"summer", "a.jpg"},
+	}
+
+	sort.Slice(visited, func(i, j int) bool {
+		if visited[i][0] != visited[j][0] {
+			return visited[i][0] < visited[j][0]
+		}
+
+		return visited[i][1] < visited[j][1]
+	})
+
+	if reflect.DeepEqual(visited, expected) != true {
+		t.Fatalf("Root-relative paths not correct: %v", visited)
+	}
+
+	// A prefix that doesn't match anything leaves the paths unchanged.
+
+	visited = testPathStyleVisitedWithStrip(rootPath, PathStyleRelative, "2019")
+	unstripped := testPathStyleVisited(rootPath, PathStyleRelative)
+
+	if reflect.DeepEqual(visited, unstripped) != true {
+		t.Fatalf("Unmatched prefix should not change paths: %v", visited)
+	}
+}
+
+func TestWalk_SetReportPrefixStrip__prefixDirectory(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	err = os.Mkdir(path.Join(tempPath, "child"), 0755)
+	log.PanicIf(err)
+
+	err = ioutil.WriteFile(path.Join(tempPath, "child", "c.txt"), []byte{}, 0644)
+	log.PanicIf(err)
+
+	// The root and the directory at the prefix have to be distinguishable.
+
+	visited := testPathStyleVisitedWithStrip(tempPath, PathStyleRelative, "child")
+
+	expected := [][2]string{
+		{"", "."},
+		{"", "c.txt"},
+		{"", "child"},
+	}
+
+	if reflect.DeepEqual(visited, expected) != true {
+		t.Fatalf("Visited not correct: %v", visited)
+	}
+}
+
+func TestWalk_stripReportPrefix(t *testing.T) {
+	walk := NewWalk("root", nil)
+
+	testCases := []struct {
+		prefix         string
+		parentPath     string
+		name           string
+		expectedParent string
+		expectedName   string
+	}{
+		{"a", "a/b", "c", "b", "c"},
+		{"a", "a", "b", "", "b"},
+		{"a", "", "a", "", "a"},
+		{"a/b", "a", "b", "a", "b"},
+		{"a", "ab", "c", "ab", "c"},
+		{"a", "", "ab", "", "ab"},
+		{"/", "/a", "b", "a", "b"},
+		{"/x/y/", "/x/y/z", "f", "z", "f"},
+	}
+
+	for _, testCase := range testCases {
+		walk.SetReportPrefixStrip(testCase.prefix)
+
+		parentPath, name := walk.stripReportPrefix(testCase.parentPath, testCase.name)
+		if parentPath != testCase.expectedParent || name != testCase.expectedName {
+			t.Fatalf("Strip of [%s] from ([%s], [%s]) not correct: ([%s], [%s])", testCase.prefix, testCase.parentPath, testCase.name, parentPath, name)
+		}
+	}
+}
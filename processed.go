@@ -0,0 +1,71 @@
+package pathwalk
+
+import (
+	"os"
+	"path"
+	"sync/atomic"
+)
+
+// SkipProcessedFunc returns true if the given file was already processed and
+// the callbacks should not be called for it.
+type SkipProcessedFunc func(path string, info os.FileInfo) bool
+
+// SetSkipProcessedFunc sets a callback that is consulted before the callbacks
+// are called for each file so that files that were already handled (e.g. as
+// tracked in a database by a previous, interrupted job) can be skipped. This
+// is distinct from the filters: a skipped file is still counted as visited
+// (and as `AlreadyProcessed`), isn't reported as skipped, and still keeps its
+// directory from being pruned. The path is formatted the same way as for the
+// regular callback. Directories are always delivered and descended into. This
+// isn't consulted in names-only mode. It's called from the workers, so it will
+// be called concurrently.
+func (walk *Walk) SetSkipProcessedFunc(skipProcessedFunc SkipProcessedFunc) {
+	walk.skipProcessedFunc = skipProcessedFunc
+}
+
+// isAlreadyProcessed returns true and updates the stats if the given file
+// should not be delivered because it was already processed.
+func (walk *Walk) isAlreadyProcessed(parentNodePath string, info os.FileInfo) bool {
+	if walk.skipProcessedFunc == nil {
+		return false
+	}
+
+	reportedParentNodePath, reportedInfo := parentNodePath, info
+	if walk.isReformattingReportedPaths() == true {
+		reportedParentNodePath, reportedInfo = walk.reportPath(parentNodePath, info)
+	}
+
+	if walk.skipProcessedFunc(path.Join(reportedParentNodePath, reportedInfo.Name()), reportedInfo) == false {
+		return false
+	}
+
+	if walk.isPruneEmptyDirectories == true {
+		walk.markIncludedDescendant(parentNodePath)
+	}
+
+	walk.statsLocker.Lock()
+	walk.stats.AlreadyProcessed++
+	walk.statsLocker.Unlock()
+
+	return true
+}
+
+// skipProcessedBatch removes the files that were already processed from the
+// given batch. They are still counted as visited.
+func (walk *Walk) skipProcessedBatch(parentNodePath string, infos []os.FileInfo) []os.FileInfo {
+	if walk.skipProcessedFunc == nil {
+		return infos
+	}
+
+	filtered := infos[:0]
+	for _, info := range infos {
+		if walk.isAlreadyProcessed(parentNodePath, info) == true {
+			atomic.AddInt64(&walk.hotStats.filesVisited, 1)
+			continue
+		}
+
+		filtered = append(filtered, info)
+	}
+
+	return filtered
+}
@@ -0,0 +1,130 @@
+package pathwalk
+
+import (
+	"os"
+	"path"
+	"sync"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-parallel-walker/internal/testing"
+)
+
+// testProcessedFilenames returns the first half of the given filenames, as
+// though they had already been processed.
+func testProcessedFilenames(tempPath string, tempFilenames []string) map[string]struct{} {
+	processed := make(map[string]struct{})
+	for _, filename := range tempFilenames[:len(tempFilenames)/2] {
+		processed[path.Join(tempPath, filename)] = struct{}{}
+	}
+
+	return processed
+}
+
+func TestWalk_SetSkipProcessedFunc(t *testing.T) {
+	fileCount := 20
+	tempPath, tempFilenames := pwtesting.FillFlatTempPath(fileCount, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	processed := testProcessedFilenames(tempPath, tempFilenames)
+
+	m := sync.Mutex{}
+	delivered := make(map[string]struct{})
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		if info.IsDir() == true {
+			return nil
+		}
+
+		m.Lock()
+		defer m.Unlock()
+
+		delivered[path.Join(parentPath, info.Name())] = struct{}{}
+
+		return nil
+	}
+
+	skipProcessedFunc := func(filepath string, info os.FileInfo) bool {
+		_, found := processed[filepath]
+		return found
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetSkipProcessedFunc(skipProcessedFunc)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	if len(delivered) != fileCount-len(processed) {
+		t.Fatalf("Delivered count not correct: (%d)", len(delivered))
+	}
+
+	for filepath := range delivered {
+		if _, found := processed[filepath]; found == true {
+			t.Fatalf("Processed file was delivered: [%s]", filepath)
+		}
+	}
+
+	stats := walk.Stats()
+	if stats.AlreadyProcessed != len(processed) {
+		t.Fatalf("AlreadyProcessed not correct: (%d)", stats.AlreadyProcessed)
+	} else if stats.FilesVisited != fileCount {
+		t.Fatalf("FilesVisited not correct: (%d)", stats.FilesVisited)
+	}
+}
+
+func TestWalk_SetSkipProcessedFunc__batch(t *testing.T) {
+	fileCount := 20
+	tempPath, tempFilenames := pwtesting.FillFlatTempPath(fileCount, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	processed := testProcessedFilenames(tempPath, tempFilenames)
+
+	m := sync.Mutex{}
+	delivered := 0
+
+	batchWalkFunc := func(parentPath string, infos []os.FileInfo) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		for _, info := range infos {
+			if _, found := processed[path.Join(parentPath, info.Name())]; found == true {
+				t.Fatalf("Processed file was delivered: [%s]", info.Name())
+			}
+
+			delivered++
+		}
+
+		return nil
+	}
+
+	skipProcessedFunc := func(filepath string, info os.FileInfo) bool {
+		_, found := processed[filepath]
+		return found
+	}
+
+	walk := NewWalk(tempPath, nil)
+	walk.SetBatchCallback(batchWalkFunc)
+	walk.SetSkipProcessedFunc(skipProcessedFunc)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	if delivered != fileCount-len(processed) {
+		t.Fatalf("Delivered count not correct: (%d)", delivered)
+	}
+
+	stats := walk.Stats()
+	if stats.AlreadyProcessed != len(processed) {
+		t.Fatalf("AlreadyProcessed not correct: (%d)", stats.AlreadyProcessed)
+	} else if stats.FilesVisited != fileCount {
+		t.Fatalf("FilesVisited not correct: (%d)", stats.FilesVisited)
+	}
+}
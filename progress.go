@@ -0,0 +1,52 @@
+package pathwalk
+
+import (
+	"time"
+)
+
+// ProgressFunc receives a snapshot of the stats while the walk is running.
+type ProgressFunc func(stats Stats)
+
+// SetProgressFunc sets a callback that is given a snapshot of the stats every
+// `interval` while the walk is running (e.g. to show a live count). It's
+// called from its own goroutine, one call at a time, and never after `Run()`
+// returns. It isn't called when the run finishes; the final stats are
+// available from `Stats()`. A nil callback or a nonpositive interval disables
+// this.
+func (walk *Walk) SetProgressFunc(interval time.Duration, progressFunc ProgressFunc) {
+	walk.progressInterval = interval
+	walk.progressFunc = progressFunc
+}
+
+// startProgressReporter starts calling the progress callback, if one was set,
+// until the returned function is called. That function waits for any call
+// that is in progress.
+func (walk *Walk) startProgressReporter() (stop func()) {
+	if walk.progressFunc == nil || walk.progressInterval <= 0 {
+		return func() {}
+	}
+
+	stopC := make(chan struct{})
+	doneC := make(chan struct{})
+
+	tick := walk.getClock().NewTicker(walk.progressInterval)
+
+	go func() {
+		defer close(doneC)
+		defer tick.Stop()
+
+		for {
+			select {
+			case <-tick.C():
+				walk.progressFunc(walk.Stats())
+			case <-stopC:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(stopC)
+		<-doneC
+	}
+}
@@ -0,0 +1,147 @@
+package pathwalk
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-parallel-walker/internal/testing"
+)
+
+func TestWalk_SetProgressFunc(t *testing.T) {
+	tempPath, _ := pwtesting.FillFlatTempPath(10, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	m := sync.Mutex{}
+	snapshots := make([]Stats, 0)
+	progressSeenC := make(chan struct{})
+	isRunReturned := false
+	callsAfterReturn := 0
+
+	progressFunc := func(stats Stats) {
+		m.Lock()
+		defer m.Unlock()
+
+		if isRunReturned == true {
+			callsAfterReturn++
+		}
+
+		snapshots = append(snapshots, stats)
+
+		if len(snapshots) == 1 {
+			close(progressSeenC)
+		}
+	}
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		// Hold the walk until progress has been reported at least once.
+		<-progressSeenC
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetProgressFunc(time.Millisecond*10, progressFunc)
+
+	if walk.Config().ProgressInterval != time.Millisecond*10 {
+		t.Fatalf("Config does not reflect the progress interval: [%s]", walk.Config().ProgressInterval)
+	}
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	m.Lock()
+	isRunReturned = true
+	m.Unlock()
+
+	time.Sleep(time.Millisecond * 50)
+
+	m.Lock()
+	defer m.Unlock()
+
+	if len(snapshots) == 0 {
+		t.Fatalf("Progress was not reported.")
+	} else if callsAfterReturn != 0 {
+		t.Fatalf("Progress was reported after the run returned: (%d)", callsAfterReturn)
+	}
+
+	// The counts only go up.
+
+	for i := 1; i < len(snapshots); i++ {
+		if snapshots[i].FilesVisited < snapshots[i-1].FilesVisited {
+			t.Fatalf("Progress went backwards: (%d) < (%d)", snapshots[i].FilesVisited, snapshots[i-1].FilesVisited)
+		}
+	}
+}
+
+func TestWalk_startProgressReporter(t *testing.T) {
+	fc := newFakeClock()
+
+	m := sync.Mutex{}
+	calls := 0
+
+	progressFunc := func(stats Stats) {
+		m.Lock()
+		defer m.Unlock()
+
+		calls++
+	}
+
+	walk := NewWalk("root", nil)
+	walk.clock = fc
+	walk.SetProgressFunc(time.Second, progressFunc)
+
+	stop := walk.startProgressReporter()
+
+	isCalled := advanceUntil(fc, time.Second, func() bool {
+		m.Lock()
+		defer m.Unlock()
+
+		return calls > 0
+	})
+
+	stop()
+
+	if isCalled != true {
+		t.Fatalf("Progress was not reported.")
+	}
+
+	m.Lock()
+	lastCalls := calls
+	m.Unlock()
+
+	fc.Advance(time.Second)
+	time.Sleep(time.Millisecond * 10)
+
+	m.Lock()
+	defer m.Unlock()
+
+	if calls != lastCalls {
+		t.Fatalf("Progress was reported after being stopped.")
+	}
+}
+
+func TestWalk_startProgressReporter__disabled(t *testing.T) {
+	walk := NewWalk("root", nil)
+
+	// This should be a no-op.
+	stop := walk.startProgressReporter()
+	stop()
+
+	walk.SetProgressFunc(0, func(stats Stats) {
+		t.Fatalf("Progress should not be reported.")
+	})
+
+	stop = walk.startProgressReporter()
+	stop()
+
+	if walk.Config().ProgressInterval != 0 {
+		t.Fatalf("Config should not reflect a disabled progress callback.")
+	}
+}
@@ -0,0 +1,46 @@
+package pathwalk
+
+import (
+	"path"
+)
+
+// SetPruneEmptyDirectories only delivers the directories that have at least
+// one file delivered somewhere below them, so that only the branches that lead
+// to matches are seen (e.g. with an include of "*.go"). The pruned directories
+// are counted in `Stats().EmptyBranchesPruned`.
+//
+// Whether a directory has any matches below it isn't known until everything
+// below it has been processed, so the directories are delivered bottom-up, as
+// with `RunBottomUp()`, and returning `ErrSkipDirectory` for a directory has
+// no effect. Filter the directories with the path filters instead.
+func (walk *Walk) SetPruneEmptyDirectories(isPruneEmptyDirectories bool) {
+	walk.isPruneEmptyDirectories = isPruneEmptyDirectories
+}
+
+// markIncludedDescendant records that a file was delivered from the given
+// directory so that it and its ancestors aren't pruned.
+func (walk *Walk) markIncludedDescendant(directoryPath string) {
+	rootPathLen := len(path.Clean(walk.rootPathOf(directoryPath)))
+
+	walk.deferredDirectoriesLocker.Lock()
+	defer walk.deferredDirectoriesLocker.Unlock()
+
+	for {
+		if dd, found := walk.deferredDirectories[directoryPath]; found == true {
+			if dd.hasIncludedDescendants == true {
+				// Its ancestors have already been marked.
+				return
+			}
+
+			dd.hasIncludedDescendants = true
+			walk.deferredDirectories[directoryPath] = dd
+		}
+
+		parentPath := path.Dir(directoryPath)
+		if parentPath == directoryPath || len(parentPath) < rootPathLen {
+			return
+		}
+
+		directoryPath = parentPath
+	}
+}
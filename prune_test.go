@@ -0,0 +1,196 @@
+package pathwalk
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+)
+
+// createPruneTree creates a tree where only some branches have Go files.
+func createPruneTree() (tempPath string) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	directories := []string{
+		"a/b/c",
+		"a/empty",
+		"d/e",
+		"f",
+	}
+
+	for _, directory := range directories {
+		err := os.MkdirAll(path.Join(tempPath, directory), 0755)
+		log.PanicIf(err)
+	}
+
+	files := []string{
+		"a/b/c/main.go",
+		"a/readme.txt",
+		"d/e/notes.txt",
+		"f/other.go",
+	}
+
+	for _, file := range files {
+		err := ioutil.WriteFile(path.Join(tempPath, file), []byte{}, 0644)
+		log.PanicIf(err)
+	}
+
+	return tempPath
+}
+
+func TestWalk_SetPruneEmptyDirectories(t *testing.T) {
+	tempPath := createPruneTree()
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	m := sync.Mutex{}
+	visited := make([]string, 0)
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		relPath := path.Join(parentPath, info.Name())[len(tempPath):]
+		if relPath == "" {
+			relPath = "."
+		}
+
+		visited = append(visited, relPath)
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetPruneEmptyDirectories(true)
+
+	if walk.Config().IsPruneEmptyDirectories != true {
+		t.Fatalf("Config not correct.")
+	}
+
+	filter := Filter{
+		IncludeFilenames: []string{"*.go"},
+	}
+
+	err := walk.SetFilter(filter)
+	log.PanicIf(err)
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	sort.Strings(visited)
+
+	expected := []string{
+		".",
+		"/a",
+		"/a/b",
+		"/a/b/c",
+		"/a/b/c/main.go",
+		"/f",
+		"/f/other.go",
+	}
+
+	if reflect.DeepEqual(visited, expected) != true {
+		t.Fatalf("Visited entries not correct: %v", visited)
+	}
+
+	// "a/empty", "d", and "d/e".
+	if walk.Stats().EmptyBranchesPruned != 3 {
+		t.Fatalf("EmptyBranchesPruned not correct: (%d)", walk.Stats().EmptyBranchesPruned)
+	}
+}
+
+func TestWalk_SetPruneEmptyDirectories__noMatches(t *testing.T) {
+	tempPath := createPruneTree()
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	count := 0
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		count++
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetPruneEmptyDirectories(true)
+	walk.SetConcurrency(2)
+
+	filter := Filter{
+		IncludeFilenames: []string{"*.md"},
+	}
+
+	err := walk.SetFilter(filter)
+	log.PanicIf(err)
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	// Even the root is pruned.
+	if count != 0 {
+		t.Fatalf("Expected nothing to be delivered: (%d)", count)
+	} else if walk.Stats().EmptyBranchesPruned != walk.Stats().DirectoriesVisited {
+		t.Fatalf("EmptyBranchesPruned not correct: (%d) != (%d)", walk.Stats().EmptyBranchesPruned, walk.Stats().DirectoriesVisited)
+	}
+}
+
+func TestWalk_SetPruneEmptyDirectories__batchCallback(t *testing.T) {
+	tempPath := createPruneTree()
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	m := sync.Mutex{}
+	directories := make([]string, 0)
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		directories = append(directories, path.Join(parentPath, info.Name())[len(tempPath):])
+
+		return nil
+	}
+
+	batchWalkFunc := func(parentPath string, infos []os.FileInfo) (err error) {
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetPruneEmptyDirectories(true)
+	walk.SetBatchCallback(batchWalkFunc)
+
+	filter := Filter{
+		IncludeFilenames: []string{"*.txt"},
+	}
+
+	err := walk.SetFilter(filter)
+	log.PanicIf(err)
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	sort.Strings(directories)
+
+	expected := []string{
+		"",
+		"/a",
+		"/d",
+		"/d/e",
+	}
+
+	if reflect.DeepEqual(directories, expected) != true {
+		t.Fatalf("Directories not correct: %v", directories)
+	}
+}
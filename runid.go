@@ -0,0 +1,36 @@
+package pathwalk
+
+import (
+	"fmt"
+)
+
+// RunID returns the unique ID of the current (or last) run. It's assigned
+// when the run is initialized and is prefixed to the messages that the walk
+// logs, so that the output of simultaneous walks can be told apart. It's empty
+// if the walk has never been run.
+func (walk *Walk) RunID() string {
+	walk.runIdLocker.Lock()
+	defer walk.runIdLocker.Unlock()
+
+	return walk.runId
+}
+
+// logFormat prefixes the given log format with the run ID, if there is one.
+func (walk *Walk) logFormat(format string) string {
+	runId := walk.RunID()
+	if runId == "" {
+		return format
+	}
+
+	return fmt.Sprintf("[run=%s] ", runId) + format
+}
+
+// logDebugf logs a debug message for this run.
+func (walk *Walk) logDebugf(format string, args ...interface{}) {
+	walkLogger.Debugf(nil, walk.logFormat(format), args...)
+}
+
+// logWarningf logs a warning for this run.
+func (walk *Walk) logWarningf(format string, args ...interface{}) {
+	walkLogger.Warningf(nil, walk.logFormat(format), args...)
+}
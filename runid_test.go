@@ -0,0 +1,63 @@
+package pathwalk
+
+import (
+	"os"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+)
+
+func TestWalk_RunID(t *testing.T) {
+	tempPath := createDepthTestTree()
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	runIds := make([]string, 0)
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+
+	if walk.RunID() != "" {
+		t.Fatalf("Expected no run ID before the first run: [%s]", walk.RunID())
+	}
+
+	for i := 0; i < 2; i++ {
+		err := walk.Run()
+		log.PanicIf(err)
+
+		runId := walk.RunID()
+		if runId == "" {
+			t.Fatalf("Expected a run ID.")
+		} else if walk.Config().RunID != runId {
+			t.Fatalf("Config run ID not correct: [%s] != [%s]", walk.Config().RunID, runId)
+		}
+
+		runIds = append(runIds, runId)
+	}
+
+	if runIds[0] == runIds[1] {
+		t.Fatalf("Expected a different run ID for each run: [%s]", runIds[0])
+	}
+}
+
+func TestWalk_logFormat(t *testing.T) {
+	walk := NewWalk("root/path", nil)
+
+	if walk.logFormat("File excluded: [%s]") != "File excluded: [%s]" {
+		t.Fatalf("Expected no prefix before the first run.")
+	}
+
+	walk.InitSync()
+
+	format := walk.logFormat("File excluded: [%s]")
+
+	expected := "[run=" + walk.RunID() + "] File excluded: [%s]"
+	if format != expected {
+		t.Fatalf("Format not correct: [%s] != [%s]", format, expected)
+	}
+}
@@ -0,0 +1,203 @@
+package pathwalk
+
+import (
+	"context"
+	"errors"
+	"path"
+	"sort"
+	"sync/atomic"
+
+	"github.com/dsoprea/go-logging"
+)
+
+var (
+	// ErrNoRoots is returned by `RunN()` if no roots were given.
+	ErrNoRoots = errors.New("no roots given")
+
+	// ErrOverlappingRoots is returned by `RunN()` if one root is the same as
+	// or below another, which would deliver the same entries twice.
+	ErrOverlappingRoots = errors.New("roots overlap")
+
+	// ErrNotSupportedWithRoots is returned by `RunN()` if a setting that
+	// depends on there being a single root is enabled.
+	ErrNotSupportedWithRoots = errors.New("setting not supported with multiple roots")
+)
+
+// RunN walks several independent roots at the same time with the given
+// callback, which is used instead of the one given to `NewWalk()` (and the
+// root given there is ignored). Unlike running one walk per root, all of the
+// roots share the one pool of workers, so the concurrency (see
+// `SetConcurrency()`) is bounded no matter how many roots there are. The
+// stats and the outcome are for all of the roots together. Since the paths
+// are reported as given, the callback can attribute entries to their root by
+// their parent path (see `RootOf()`).
+//
+// The roots can't overlap (unless they're collapsed; see
+// `SetCollapseOverlappingRoots()`), and checkpoints, lists, staying on one
+// filesystem, excluding symlinks outside the root, and any path style or case
+// normalization that reformats the root aren't supported.
+func (walk *Walk) RunN(roots []string, walkFunc WalkFunc) (err error) {
+	return walk.RunNContext(context.Background(), roots, walkFunc)
+}
+
+// RunNContext is the same as RunN() but will stop early if the given context
+// is cancelled, in which case the context's error is returned and the outcome
+// is `OutcomeCancelled`.
+func (walk *Walk) RunNContext(ctx context.Context, roots []string, walkFunc WalkFunc) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if len(roots) == 0 {
+		return ErrNoRoots
+	}
+
+	cleanedRoots := make([]string, len(roots))
+	for i, rootPath := range roots {
+		if rootPath == "" {
+			return ErrEmptyRootPath
+		}
+
+		cleanedRoots[i] = path.Clean(rootPath)
+	}
+
+	if walk.isCollapseOverlappingRoots == true {
+		cleanedRoots, err = walk.collapseRoots(cleanedRoots)
+		log.PanicIf(err)
+	}
+
+	for i, rootPath := range cleanedRoots {
+		for j, otherRootPath := range cleanedRoots {
+			if i != j && isPathUnderRoot(rootPath, otherRootPath) == true {
+				return ErrOverlappingRoots
+			}
+		}
+	}
+
+	if walk.isMultipleRootsSupported() == false {
+		return ErrNotSupportedWithRoots
+	}
+
+	if atomic.CompareAndSwapInt32(&walk.isRunActive, 0, 1) == false {
+		return ErrAlreadyRunning
+	}
+
+	// These are cleared when the run finishes.
+	walk.runRoots = cleanedRoots
+	walk.runWalkFunc = walkFunc
+
+	return walk.run(ctx, nil, nil)
+}
+
+// SetCollapseOverlappingRoots makes `RunN()` canonicalize the roots (made
+// absolute with any symlinks resolved) and then drop any root that's the same
+// as or below another one, with a warning, rather than failing with
+// `ErrOverlappingRoots`. Each entry is then visited (and counted) once. Since
+// the roots are canonicalized, entries are reported under the canonical roots
+// rather than as they were given, so two roots that are different paths to
+// the same directory (e.g. via a symlink) are recognized as duplicates. A
+// root that doesn't exist fails the run.
+func (walk *Walk) SetCollapseOverlappingRoots(isCollapseOverlappingRoots bool) {
+	walk.isCollapseOverlappingRoots = isCollapseOverlappingRoots
+}
+
+// collapseRoots canonicalizes the given roots and returns the ones that
+// aren't the same as or below any other, sorted.
+func (walk *Walk) collapseRoots(roots []string) (collapsed []string, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	canonicalRoots := make([]string, len(roots))
+	for i, rootPath := range roots {
+		canonicalRootPath, err := resolvePath(rootPath)
+		log.PanicIf(err)
+
+		canonicalRoots[i] = canonicalRootPath
+	}
+
+	// An ancestor always sorts before its descendants.
+	sort.Strings(canonicalRoots)
+
+	collapsed = make([]string, 0, len(canonicalRoots))
+	for _, rootPath := range canonicalRoots {
+		isCovered := false
+		for _, keptRootPath := range collapsed {
+			if isPathUnderRoot(rootPath, keptRootPath) == true {
+				walk.logWarningf("root [%s] is already covered by root [%s]; it will not be walked separately", rootPath, keptRootPath)
+
+				isCovered = true
+				break
+			}
+		}
+
+		if isCovered == false {
+			collapsed = append(collapsed, rootPath)
+		}
+	}
+
+	return collapsed, nil
+}
+
+// RootOf returns the root that the given path is at or below during a
+// `RunN()` run, or the root given to `NewWalk()` otherwise. This is intended
+// for attributing entries to their root from within the callback (by the
+// joined parent path and name). The path is expected to be formatted the same
+// way as the root.
+func (walk *Walk) RootOf(fqPath string) string {
+	return walk.rootPathOf(path.Clean(fqPath))
+}
+
+// isMultipleRootsSupported returns false if any of the settings depend on
+// there being a single root.
+func (walk *Walk) isMultipleRootsSupported() bool {
+	if walk.isCheckpointsEnabled == true || walk.resumeDirectories != nil || walk.listPaths != nil {
+		return false
+	} else if walk.isStayOnFilesystem == true || walk.filter.excludeSymlinkTargetsOutsideRoot == true {
+		return false
+	} else if walk.pathStyle != PathStyleAsGiven || walk.pathCaseNormalization == PathCaseCanonical {
+		return false
+	}
+
+	return true
+}
+
+// rootPathOf returns the root of the given clean path: the matching root
+// during a `RunN()` run or the one given to `NewWalk()` otherwise.
+func (walk *Walk) rootPathOf(fqPath string) string {
+	for _, rootPath := range walk.runRoots {
+		if isPathUnderRoot(fqPath, rootPath) == true {
+			return rootPath
+		}
+	}
+
+	return walk.rootPath
+}
+
+// pushRootJobs seeds the run with one job for each of the roots.
+func (walk *Walk) pushRootJobs() (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	release := walk.holdSeeding()
+	defer release()
+
+	for _, rootPath := range walk.runRoots {
+		info, err := walk.statNode(rootPath)
+		log.PanicIf(err)
+
+		jdn := newJobDirectoryNode(path.Dir(rootPath), info)
+
+		err = walk.pushJob(jdn)
+		log.PanicIf(err)
+	}
+
+	return nil
+}
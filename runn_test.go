@@ -0,0 +1,342 @@
+package pathwalk
+
+import (
+	"context"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-parallel-walker/internal/testing"
+)
+
+func TestWalk_RunN(t *testing.T) {
+	rootCount := 5
+	fileCount := 50
+	concurrency := 20
+
+	roots := make([]string, rootCount)
+	for i := 0; i < rootCount; i++ {
+		tempPath, _ := pwtesting.FillFlatTempPath(fileCount, nil)
+		roots[i] = tempPath
+	}
+
+	defer func() {
+		for _, tempPath := range roots {
+			os.RemoveAll(tempPath)
+		}
+	}()
+
+	// The callback given here shouldn't be called.
+	walk := NewWalk("", func(parentPath string, info os.FileInfo) (err error) {
+		t.Fatalf("Original callback was called.")
+		return nil
+	})
+
+	walk.SetConcurrency(concurrency)
+
+	m := sync.Mutex{}
+	filesByRoot := make(map[string]int)
+	maxWorkerCount := 0
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		walk.stateLocker.Lock()
+		workerCount := walk.workerCount
+		walk.stateLocker.Unlock()
+
+		m.Lock()
+		defer m.Unlock()
+
+		if workerCount > maxWorkerCount {
+			maxWorkerCount = workerCount
+		}
+
+		if info.IsDir() == false {
+			rootPath := walk.RootOf(path.Join(parentPath, info.Name()))
+			filesByRoot[rootPath]++
+		}
+
+		return nil
+	}
+
+	err := walk.RunN(roots, walkFunc)
+	log.PanicIf(err)
+
+	if len(filesByRoot) != rootCount {
+		t.Fatalf("Files not attributed to every root: %v", filesByRoot)
+	}
+
+	for _, rootPath := range roots {
+		if filesByRoot[rootPath] != fileCount {
+			t.Fatalf("File count for root [%s] not correct: (%d)", rootPath, filesByRoot[rootPath])
+		}
+	}
+
+	// The roots share the one pool.
+	if maxWorkerCount > concurrency {
+		t.Fatalf("Worker count exceeded the concurrency: (%d) > (%d)", maxWorkerCount, concurrency)
+	}
+
+	stats := walk.Stats()
+	if stats.FilesVisited != rootCount*fileCount {
+		t.Fatalf("FilesVisited not correct: (%d)", stats.FilesVisited)
+	} else if stats.DirectoriesVisited != rootCount {
+		t.Fatalf("DirectoriesVisited not correct: (%d)", stats.DirectoriesVisited)
+	} else if stats.RootVisited != true {
+		t.Fatalf("Expected the roots to be visited.")
+	}
+
+	if walk.Outcome() != OutcomeCompleted {
+		t.Fatalf("Outcome not correct: [%s]", walk.Outcome())
+	}
+}
+
+func TestWalk_RunN__filter(t *testing.T) {
+	tempPath1 := createDepthTestTree()
+	tempPath2 := createDepthTestTree()
+
+	defer func() {
+		os.RemoveAll(tempPath1)
+		os.RemoveAll(tempPath2)
+	}()
+
+	walk := NewWalk("", nil)
+
+	// The path filters are relative to each root.
+	filter := Filter{
+		IncludePaths: []string{"dir1/dir2"},
+	}
+
+	walk.SetFilter(filter)
+
+	m := sync.Mutex{}
+	visited := make(map[string]int)
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		if info.IsDir() == true {
+			return nil
+		}
+
+		m.Lock()
+		defer m.Unlock()
+
+		fqPath := path.Join(parentPath, info.Name())
+		rootPath := walk.RootOf(fqPath)
+
+		visited[fqPath[len(rootPath)+1:]]++
+
+		return nil
+	}
+
+	err := walk.RunN([]string{tempPath1, tempPath2}, walkFunc)
+	log.PanicIf(err)
+
+	expected := map[string]int{
+		"dir1/dir2/file2": 2,
+		"dir1/dir2/file3": 2,
+	}
+
+	if len(visited) != len(expected) {
+		t.Fatalf("Visited files not correct: %v", visited)
+	}
+
+	for relPath, count := range expected {
+		if visited[relPath] != count {
+			t.Fatalf("Visited files not correct: %v", visited)
+		}
+	}
+}
+
+func TestWalk_RunNContext__cancelled(t *testing.T) {
+	tempPath1, _ := pwtesting.FillFlatTempPath(1000, nil)
+	tempPath2, _ := pwtesting.FillFlatTempPath(1000, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath1)
+		os.RemoveAll(tempPath2)
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		cancel()
+
+		// Make sure that the frontend notices the cancellation before we
+		// run out of work.
+		time.Sleep(time.Millisecond * 10)
+
+		return nil
+	}
+
+	walk := NewWalk("", nil)
+
+	err := walk.RunNContext(ctx, []string{tempPath1, tempPath2}, walkFunc)
+	if err != context.Canceled {
+		t.Fatalf("Expected cancellation error: %v", err)
+	} else if walk.Outcome() != OutcomeCancelled {
+		t.Fatalf("Outcome not correct: [%s]", walk.Outcome())
+	}
+}
+
+func TestWalk_RunN__errors(t *testing.T) {
+	walk := NewWalk("", nil)
+
+	err := walk.RunN(nil, nil)
+	if err != ErrNoRoots {
+		t.Fatalf("Expected ErrNoRoots: [%v]", err)
+	}
+
+	err = walk.RunN([]string{"a", ""}, nil)
+	if err != ErrEmptyRootPath {
+		t.Fatalf("Expected ErrEmptyRootPath: [%v]", err)
+	}
+
+	err = walk.RunN([]string{"a/b", "a"}, nil)
+	if err != ErrOverlappingRoots {
+		t.Fatalf("Expected ErrOverlappingRoots: [%v]", err)
+	}
+
+	err = walk.RunN([]string{"a", "a/"}, nil)
+	if err != ErrOverlappingRoots {
+		t.Fatalf("Expected ErrOverlappingRoots for the same root: [%v]", err)
+	}
+
+	walk.SetPathStyle(PathStyleRelative)
+
+	err = walk.RunN([]string{"a", "ab"}, nil)
+	if err != ErrNotSupportedWithRoots {
+		t.Fatalf("Expected ErrNotSupportedWithRoots: [%v]", err)
+	}
+}
+
+func TestWalk_RunN__collapseOverlappingRoots(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	relFilepaths := []string{
+		"a/file1",
+		"a/b/file2",
+		"c/file3",
+		"d/file4",
+	}
+
+	for _, relFilepath := range relFilepaths {
+		fqFilepath := path.Join(tempPath, relFilepath)
+
+		err := os.MkdirAll(path.Dir(fqFilepath), 0755)
+		log.PanicIf(err)
+
+		err = ioutil.WriteFile(fqFilepath, []byte{}, 0644)
+		log.PanicIf(err)
+	}
+
+	// A second path to "c".
+	err = os.Symlink(path.Join(tempPath, "c"), path.Join(tempPath, "alias"))
+	log.PanicIf(err)
+
+	resolvedTempPath, err := resolvePath(tempPath)
+	log.PanicIf(err)
+
+	m := sync.Mutex{}
+	visited := make([]string, 0)
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		if info.IsDir() == false {
+			visited = append(visited, path.Join(parentPath, info.Name()))
+		}
+
+		return nil
+	}
+
+	roots := []string{
+		path.Join(tempPath, "a", "b"),
+		path.Join(tempPath, "a"),
+		path.Join(tempPath, "a") + "/",
+		path.Join(tempPath, "alias"),
+		path.Join(tempPath, "c"),
+		path.Join(tempPath, "d"),
+	}
+
+	walk := NewWalk("", nil)
+
+	// The overlapping roots are rejected by default.
+	err = walk.RunN(roots, walkFunc)
+	if err != ErrOverlappingRoots {
+		t.Fatalf("Expected ErrOverlappingRoots: [%v]", err)
+	}
+
+	walk.SetCollapseOverlappingRoots(true)
+
+	err = walk.RunN(roots, walkFunc)
+	log.PanicIf(err)
+
+	sort.Strings(visited)
+
+	// Every file is visited once, under the canonical roots.
+	expected := []string{
+		path.Join(resolvedTempPath, "a", "b", "file2"),
+		path.Join(resolvedTempPath, "a", "file1"),
+		path.Join(resolvedTempPath, "c", "file3"),
+		path.Join(resolvedTempPath, "d", "file4"),
+	}
+
+	if reflect.DeepEqual(visited, expected) != true {
+		t.Fatalf("Visited not correct: %v", visited)
+	}
+
+	stats := walk.Stats()
+	if stats.FilesVisited != 4 {
+		t.Fatalf("FilesVisited not correct: (%d)", stats.FilesVisited)
+	} else if stats.DirectoriesVisited != 4 {
+		t.Fatalf("DirectoriesVisited not correct: (%d)", stats.DirectoriesVisited)
+	}
+
+	// A root that doesn't exist can't be canonicalized.
+	err = walk.RunN([]string{path.Join(tempPath, "missing")}, walkFunc)
+	if err == nil {
+		t.Fatalf("Expected error for a missing root.")
+	}
+}
+
+func TestWalk_collapseRoots(t *testing.T) {
+	walk := NewWalk("", nil)
+
+	collapsed, err := walk.collapseRoots([]string{"/", "/usr"})
+	log.PanicIf(err)
+
+	if reflect.DeepEqual(collapsed, []string{"/"}) != true {
+		t.Fatalf("Roots under the filesystem root not collapsed: %v", collapsed)
+	}
+}
+
+func TestWalk_RootOf(t *testing.T) {
+	walk := NewWalk("/original", nil)
+
+	if walk.RootOf("/original/a/b") != "/original" {
+		t.Fatalf("Expected the original root outside of RunN().")
+	}
+
+	walk.runRoots = []string{"/a", "/ab", "/"}
+
+	if walk.RootOf("/a/file") != "/a" {
+		t.Fatalf("Root not correct: [%s]", walk.RootOf("/a/file"))
+	} else if walk.RootOf("/ab/file/") != "/ab" {
+		t.Fatalf("Root not correct: [%s]", walk.RootOf("/ab/file/"))
+	} else if walk.RootOf("/ab") != "/ab" {
+		t.Fatalf("Root not correct for the root itself: [%s]", walk.RootOf("/ab"))
+	}
+}
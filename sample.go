@@ -0,0 +1,61 @@
+package pathwalk
+
+import (
+	"github.com/dsoprea/go-logging"
+)
+
+// SetSampleEntriesPerDirectory limits each directory to the first N entries
+// that are read from it, for a fast, partial preview of very wide
+// directories. The rest of the directory is not read. Entries are read in
+// batches of up to the batch size as usual. Zero (the default) reads every
+// entry.
+//
+// Results are not complete when this is enabled. Which entries are returned
+// depends on the order that the filesystem returns them in, and, if any
+// directory was cut short, the outcome will be `OutcomeTruncated` rather than
+// `OutcomeCompleted`.
+func (walk *Walk) SetSampleEntriesPerDirectory(n int) {
+	walk.sampleEntriesPerDirectory = n
+}
+
+// finishSample is called once the sample for the given directory has been
+// read. It checks whether there was anything left and, if so, abandons the
+// listing and records the directory as sampled.
+func (walk *Walk) finishSample(path string) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	for {
+		walk.statsLocker.Lock()
+		walk.stats.DirectoryReadCalls++
+		walk.statsLocker.Unlock()
+
+		names, hasMore, err := walk.childLister.ListChildren(path, 1)
+		log.PanicIf(err)
+
+		if len(names) > 0 {
+			break
+		} else if hasMore == false {
+			// The directory had exactly as many entries as the sample.
+			return nil
+		}
+	}
+
+	if clc, ok := walk.childLister.(ChildListCloser); ok == true {
+		err := clc.CloseChildren(path)
+		log.PanicIf(err)
+	}
+
+	walk.statsLocker.Lock()
+	walk.stats.DirectoriesSampled++
+	walk.statsLocker.Unlock()
+
+	walk.counterLocker.Lock()
+	walk.isTruncated = true
+	walk.counterLocker.Unlock()
+
+	return nil
+}
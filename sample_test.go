@@ -0,0 +1,86 @@
+package pathwalk
+
+import (
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-parallel-walker/internal/testing"
+)
+
+func TestWalk_SetSampleEntriesPerDirectory(t *testing.T) {
+	fileCount := defaultDirectoryEntryBatchSize*2 + 50
+	tempPath, _ := pwtesting.FillFlatTempPath(fileCount, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	m := sync.Mutex{}
+	files := 0
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		if info.IsDir() == false {
+			files++
+		}
+
+		return nil
+	}
+
+	sampleSize := defaultDirectoryEntryBatchSize + 20
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetSampleEntriesPerDirectory(sampleSize)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	if files != sampleSize {
+		t.Fatalf("Sampled file count not correct: (%d)", files)
+	} else if walk.Stats().DirectoriesSampled != 1 {
+		t.Fatalf("DirectoriesSampled not correct: (%d)", walk.Stats().DirectoriesSampled)
+	} else if walk.Stats().EntryBatchesProcessed != 2 {
+		t.Fatalf("EntryBatchesProcessed not correct: (%d)", walk.Stats().EntryBatchesProcessed)
+	} else if walk.Outcome() != OutcomeTruncated {
+		t.Fatalf("Outcome not correct: [%s]", walk.Outcome())
+	} else if walk.HasFinished() != false {
+		t.Fatalf("HasFinished() should be false after a truncated walk.")
+	}
+
+	fcl := walk.childLister.(*filesystemChildLister)
+	if len(fcl.openDirectories) != 0 {
+		t.Fatalf("Sampled directory was left open.")
+	}
+}
+
+func TestWalk_SetSampleEntriesPerDirectory__exactSize(t *testing.T) {
+	fileCount := 50
+	tempPath, _ := pwtesting.FillFlatTempPath(fileCount, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetSampleEntriesPerDirectory(fileCount)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	if walk.Stats().FilesVisited != fileCount {
+		t.Fatalf("FilesVisited not correct: (%d)", walk.Stats().FilesVisited)
+	} else if walk.Stats().DirectoriesSampled != 0 {
+		t.Fatalf("DirectoriesSampled not correct: (%d)", walk.Stats().DirectoriesSampled)
+	} else if walk.Outcome() != OutcomeCompleted {
+		t.Fatalf("Outcome not correct: [%s]", walk.Outcome())
+	}
+}
@@ -0,0 +1,107 @@
+package pathwalk
+
+// SchedulingBias determines the order in which discovered work is processed.
+type SchedulingBias int
+
+const (
+	// BreadthFirst processes jobs in the order that they were discovered,
+	// through one bounded queue. Memory use is bounded by the queue and by
+	// the workers blocking when it's full, but a subtree won't be complete
+	// until most of the tree above it has been enumerated. This is the
+	// default.
+	BreadthFirst SchedulingBias = iota
+
+	// DepthFirst processes the most recently discovered directories (and
+	// batches of directory entries) first, ahead of any pending file jobs.
+	// Subtrees complete early, which is useful for early partial results.
+	// Directory jobs are kept on an unbounded stack so that pushing them
+	// never blocks, which means that memory grows with the number of
+	// directories that have been discovered but not processed (this is
+	// proportional to the breadth of the tree, not to the number of files).
+	// File jobs still go through the bounded queue, so files are delivered
+	// later than they would be breadth-first.
+	DepthFirst
+)
+
+// SetSchedulingBias sets whether directories or files are prioritized. This
+// must be set before calling `Run()`.
+func (walk *Walk) SetSchedulingBias(bias SchedulingBias) {
+	walk.schedulingBias = bias
+}
+
+// isPriorityJob returns whether the given job should be pushed onto the
+// directory stack rather than the job channel.
+func (walk *Walk) isPriorityJob(j job) bool {
+	if walk.schedulingBias != DepthFirst {
+		return false
+	}
+
+	switch j.(type) {
+	case jobDirectoryNode, jobDirectoryContentsBatch, jobCoalescedBatches:
+		return true
+	}
+
+	return false
+}
+
+// pushDirectoryJob pushes a job onto the directory stack and wakes a worker.
+func (walk *Walk) pushDirectoryJob(j job) {
+	walk.directoryStackLocker.Lock()
+	walk.directoryStack = append(walk.directoryStack, j)
+	walk.directoryStackLocker.Unlock()
+
+	walk.ringDirectoryReady()
+}
+
+// popDirectoryJob pops the most recently pushed job from the directory stack.
+// If more jobs remain, another worker is woken to process them.
+func (walk *Walk) popDirectoryJob() (j job, found bool) {
+	if walk.schedulingBias != DepthFirst {
+		return nil, false
+	}
+
+	walk.directoryStackLocker.Lock()
+
+	count := len(walk.directoryStack)
+	if count == 0 {
+		walk.directoryStackLocker.Unlock()
+		return nil, false
+	}
+
+	j = walk.directoryStack[count-1]
+
+	walk.directoryStack[count-1] = nil
+	walk.directoryStack = walk.directoryStack[:count-1]
+
+	hasMore := count > 1
+
+	walk.directoryStackLocker.Unlock()
+
+	if hasMore == true {
+		walk.ringDirectoryReady()
+	}
+
+	return j, true
+}
+
+// ringDirectoryReady wakes up one idle worker if one isn't already being
+// woken.
+func (walk *Walk) ringDirectoryReady() {
+	select {
+	case walk.directoryReadyC <- struct{}{}:
+	default:
+	}
+}
+
+// drainDirectoryJobs discards any jobs that are still on the directory stack.
+func (walk *Walk) drainDirectoryJobs() {
+	for {
+		j, found := walk.popDirectoryJob()
+		if found == false {
+			return
+		}
+
+		releaseJobDirectories(j)
+		walk.jobTickDown()
+	}
+}
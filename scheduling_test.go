@@ -0,0 +1,146 @@
+package pathwalk
+
+import (
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dsoprea/go-logging"
+	"github.com/dsoprea/go-utility/filesystem"
+
+	"github.com/dsoprea/go-parallel-walker/internal/testing"
+)
+
+func TestWalk_popDirectoryJob(t *testing.T) {
+	walk := NewWalk("", nil)
+	walk.SetSchedulingBias(DepthFirst)
+	walk.InitSync()
+
+	for _, name := range []string{"first", "second", "third"} {
+		sfi := rifs.NewSimpleFileInfoWithDirectory(name, time.Time{})
+		walk.pushDirectoryJob(newJobDirectoryNode("/", sfi))
+	}
+
+	for _, expectedName := range []string{"third", "second", "first"} {
+		j, found := walk.popDirectoryJob()
+		if found != true {
+			t.Fatalf("Expected a job for [%s].", expectedName)
+		}
+
+		jdn := j.(jobDirectoryNode)
+		if jdn.Info().Name() != expectedName {
+			t.Fatalf("Job not correct: [%s] != [%s]", jdn.Info().Name(), expectedName)
+		}
+	}
+
+	if _, found := walk.popDirectoryJob(); found != false {
+		t.Fatalf("Expected the stack to be empty.")
+	}
+}
+
+func TestWalk_isPriorityJob(t *testing.T) {
+	walk := NewWalk("", nil)
+
+	sfi := rifs.NewSimpleFileInfoWithDirectory("dir", time.Time{})
+	jdn := newJobDirectoryNode("/", sfi)
+
+	if walk.isPriorityJob(jdn) != false {
+		t.Fatalf("Expected no priority jobs when breadth-first.")
+	}
+
+	walk.SetSchedulingBias(DepthFirst)
+
+	if walk.isPriorityJob(jdn) != true {
+		t.Fatalf("Expected directory jobs to have priority when depth-first.")
+	}
+
+	ffi := rifs.NewSimpleFileInfoWithFile("file", 0, 0, time.Time{})
+	jfn := newJobFileNode("/", ffi)
+
+	if walk.isPriorityJob(jfn) != false {
+		t.Fatalf("Expected file jobs to not have priority.")
+	}
+}
+
+func TestWalk_Run__depthFirst(t *testing.T) {
+	fileCount := 300
+	tempPath, tempFiles := pwtesting.FillHeirarchicalTempPath(fileCount, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	m := sync.Mutex{}
+	visited := make(map[string]struct{})
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		if info.IsDir() == true {
+			return nil
+		}
+
+		m.Lock()
+		defer m.Unlock()
+
+		visited[path.Join(parentPath, info.Name())[len(tempPath)+1:]] = struct{}{}
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetSchedulingBias(DepthFirst)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	if walk.Outcome() != OutcomeCompleted {
+		t.Fatalf("Outcome not correct: [%s]", walk.Outcome())
+	} else if len(visited) != len(tempFiles) {
+		t.Fatalf("Visited count not correct: (%d) != (%d)", len(visited), len(tempFiles))
+	}
+
+	for _, relFilepath := range tempFiles {
+		if _, found := visited[relFilepath]; found == false {
+			t.Fatalf("File not visited: [%s]", relFilepath)
+		}
+	}
+}
+
+func TestWalk_Stop__depthFirst(t *testing.T) {
+	fileCount := 300
+	tempPath, _ := pwtesting.FillHeirarchicalTempPath(fileCount, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	var walk *Walk
+
+	once := sync.Once{}
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		if info.IsDir() == false {
+			once.Do(walk.Stop)
+		}
+
+		return nil
+	}
+
+	walk = NewWalk(tempPath, walkFunc)
+	walk.SetSchedulingBias(DepthFirst)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	if walk.Outcome() != OutcomeStopped {
+		t.Fatalf("Outcome not correct: [%s]", walk.Outcome())
+	}
+
+	walk.directoryStackLocker.Lock()
+	remaining := len(walk.directoryStack)
+	walk.directoryStackLocker.Unlock()
+
+	if remaining != 0 {
+		t.Fatalf("Directory stack was not drained: (%d)", remaining)
+	}
+}
@@ -0,0 +1,97 @@
+package pathwalk
+
+// serialCall is one delivery to the callbacks on the serial goroutine.
+type serialCall struct {
+	entry WalkEntry
+
+	// resultC receives the outcome of the call.
+	resultC chan serialCallResult
+}
+
+// serialCallResult is the outcome of one serial call. `state` is the value of
+// any panic, which is re-raised on the worker that made the call.
+type serialCallResult struct {
+	err   error
+	state interface{}
+}
+
+// SetSerialCallback funnels every invocation of the callback (along with any
+// visitors, entry callback, and counting callback) through a single dedicated
+// goroutine, so that the callback never runs concurrently with itself and
+// doesn't need to be thread-safe. The directories are still read and the
+// entries stat'd and filtered by all of the workers in parallel; each worker
+// waits for its entry's callback to return before moving on, so returning
+// `ErrSkipDirectory` works as usual.
+//
+// This serializes all of the callback work, so a callback that does anything
+// expensive will become the bottleneck of the walk. The batch, contextual,
+// worker, special-file, and filtered-visit callbacks are not affected. The
+// goroutine counts as a helper for `SetMaxGoroutines()`.
+func (walk *Walk) SetSerialCallback(isSerialCallback bool) {
+	walk.isSerialCallback = isSerialCallback
+}
+
+// startSerialCallback starts the serial callback goroutine if enabled. The
+// returned function stops it and must only be called after the workers have
+// finished.
+func (walk *Walk) startSerialCallback() (stop func()) {
+	if walk.isSerialCallback == false {
+		return func() {}
+	}
+
+	serialCallsC := make(chan serialCall)
+	doneC := make(chan struct{})
+
+	walk.serialCallsC = serialCallsC
+
+	go func() {
+		defer close(doneC)
+
+		for sc := range serialCallsC {
+			sc.resultC <- walk.callVisitorsRecovered(sc.entry)
+		}
+	}()
+
+	return func() {
+		close(serialCallsC)
+		<-doneC
+
+		walk.serialCallsC = nil
+	}
+}
+
+// callVisitorsRecovered calls the callbacks and captures any panic rather than
+// letting it take down the serial goroutine.
+func (walk *Walk) callVisitorsRecovered(entry WalkEntry) (result serialCallResult) {
+	defer func() {
+		if state := recover(); state != nil {
+			result.state = state
+		}
+	}()
+
+	result.err = walk.callVisitors(entry)
+
+	return result
+}
+
+// callVisitorsSerially calls the callbacks on the serial goroutine if it's
+// running or directly otherwise.
+func (walk *Walk) callVisitorsSerially(entry WalkEntry) (err error) {
+	if walk.serialCallsC == nil {
+		return walk.callVisitors(entry)
+	}
+
+	sc := serialCall{
+		entry:   entry,
+		resultC: make(chan serialCallResult, 1),
+	}
+
+	walk.serialCallsC <- sc
+
+	result := <-sc.resultC
+	if result.state != nil {
+		panic(result.state)
+	}
+
+	return result.err
+}
@@ -0,0 +1,136 @@
+package pathwalk
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"path/filepath"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-parallel-walker/internal/testing"
+)
+
+func TestWalk_SetSerialCallback(t *testing.T) {
+	tempPath, tempFiles := pwtesting.FillHeirarchicalTempPath(200, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	var inFlight int32
+	var maxInFlight int32
+
+	m := sync.Mutex{}
+	visited := make(map[string]struct{})
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+
+		if current > atomic.LoadInt32(&maxInFlight) {
+			atomic.StoreInt32(&maxInFlight, current)
+		}
+
+		// Give any other callback a chance to overlap with this one.
+		time.Sleep(time.Microsecond * 100)
+
+		if info.IsDir() == false {
+			relFilepath, err := filepath.Rel(tempPath, filepath.Join(parentPath, info.Name()))
+			log.PanicIf(err)
+
+			m.Lock()
+			visited[relFilepath] = struct{}{}
+			m.Unlock()
+		}
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetSerialCallback(true)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	if maxInFlight != 1 {
+		t.Fatalf("Callbacks ran concurrently: (%d)", maxInFlight)
+	} else if len(visited) != len(tempFiles) {
+		t.Fatalf("Not all files were visited: (%d) != (%d)", len(visited), len(tempFiles))
+	}
+
+	for _, relFilepath := range tempFiles {
+		if _, found := visited[relFilepath]; found == false {
+			t.Fatalf("File not visited: [%s]", relFilepath)
+		}
+	}
+
+	// The goroutine is gone and the walk can be run again.
+
+	if walk.serialCallsC != nil {
+		t.Fatalf("Serial callback goroutine not stopped.")
+	}
+
+	err = walk.Run()
+	log.PanicIf(err)
+}
+
+func TestWalk_SetSerialCallback__error(t *testing.T) {
+	tempPath, _ := pwtesting.FillFlatTempPath(10, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	errTest := errors.New("test error")
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		if info.IsDir() == false {
+			return errTest
+		}
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetSerialCallback(true)
+
+	err := walk.Run()
+	if err == nil {
+		t.Fatalf("Expected error.")
+	} else if err.Error() != "worker terminated under error: "+errTest.Error() {
+		t.Fatalf("Error not correct: [%v]", err)
+	}
+}
+
+func TestWalk_SetSerialCallback__panic(t *testing.T) {
+	tempPath, _ := pwtesting.FillFlatTempPath(10, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	errTest := errors.New("test panic")
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		if info.IsDir() == false {
+			log.Panic(errTest)
+		}
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetSerialCallback(true)
+
+	err := walk.Run()
+	if err == nil {
+		t.Fatalf("Expected error.")
+	} else if err.Error() != "worker terminated under error: "+errTest.Error() {
+		t.Fatalf("Error not correct: [%v]", err)
+	}
+}
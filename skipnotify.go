@@ -0,0 +1,145 @@
+package pathwalk
+
+import (
+	"fmt"
+	"path"
+)
+
+// SkipReason describes why an entry was skipped.
+type SkipReason int
+
+const (
+	// SkipFilterPath indicates that a directory didn't pass the path filters
+	// (its contents are still descended into in case deeper paths match) or
+	// that a file was in such a directory.
+	SkipFilterPath SkipReason = iota
+
+	// SkipFilterFilename indicates that a file didn't pass the filename
+	// filters.
+	SkipFilterFilename
+
+	// SkipFilterOwner indicates that a file didn't have one of the required
+	// owners.
+	SkipFilterOwner
+
+	// SkipFilterContent indicates that a file's content didn't match any of
+	// the content signatures.
+	SkipFilterContent
+
+	// SkipPathTooLong indicates that an entry's path exceeded the maximum
+	// length. Directories are not descended into.
+	SkipPathTooLong
+
+	// SkipOtherFilesystem indicates that a directory was not descended into
+	// because it's on a different filesystem than the root.
+	SkipOtherFilesystem
+
+	// SkipUnreadable indicates that an entry couldn't be read (e.g. a stat
+	// failure).
+	SkipUnreadable
+
+	// SkipTimedOut indicates that the rest of a directory wasn't read because
+	// it exceeded the per-directory timeout.
+	SkipTimedOut
+
+	// SkipSymlinkOutsideRoot indicates that an entry was a symlink whose
+	// target is outside of the root. See
+	// `Filter.ExcludeSymlinkTargetsOutsideRoot`.
+	SkipSymlinkOutsideRoot
+
+	// SkipWalkIgnore indicates that an entry matched a pattern in an ignore
+	// file. Directories are not descended into. See
+	// `SetWalkIgnoreFilename()`.
+	SkipWalkIgnore
+
+	// SkipFilterTopLevelDirectory indicates that a directory directly under
+	// the root wasn't one of the allowed ones. It's not descended into. See
+	// `Filter.AllowedTopLevelDirs`.
+	SkipFilterTopLevelDirectory
+
+	// SkipFilterCreationTime indicates that a file's creation time was
+	// outside of the bounds (or wasn't available). See `Filter.CreatedAfter`.
+	SkipFilterCreationTime
+
+	// SkipVanished indicates that an entry was listed but no longer existed by
+	// the time it was stat'd or read. This is only reported if such changes
+	// are tolerated. See `SetToleratePostEnumerationChanges()`.
+	SkipVanished
+
+	// SkipPathTooDeep indicates that an entry's path had more components below
+	// the root than the maximum. Directories are not descended into. See
+	// `Filter.MaxPathComponents`.
+	SkipPathTooDeep
+
+	// SkipDirectoryCycle indicates that a directory was the same directory as
+	// one that was already visited. See `SetDetectDirectoryCycles()`.
+	SkipDirectoryCycle
+)
+
+var (
+	skipReasonNames = map[SkipReason]string{
+		SkipFilterPath:      "filter-path",
+		SkipFilterFilename:  "filter-filename",
+		SkipFilterOwner:     "filter-owner",
+		SkipFilterContent:   "filter-content",
+		SkipPathTooLong:     "path-too-long",
+		SkipOtherFilesystem: "other-filesystem",
+		SkipUnreadable:      "unreadable",
+		SkipTimedOut:        "timed-out",
+
+		SkipSymlinkOutsideRoot: "symlink-outside-root",
+		SkipWalkIgnore:         "walk-ignore",
+
+		SkipFilterTopLevelDirectory: "filter-top-level-directory",
+		SkipFilterCreationTime:      "filter-creation-time",
+		SkipVanished:                "vanished",
+		SkipPathTooDeep:             "path-too-deep",
+		SkipDirectoryCycle:          "directory-cycle",
+	}
+)
+
+// String returns a descriptive string.
+func (reason SkipReason) String() string {
+	name, found := skipReasonNames[reason]
+	if found == false {
+		return fmt.Sprintf("SkipReason<%d>", int(reason))
+	}
+
+	return name
+}
+
+// SkipNotifyFunc is the function type for the skip notification callback.
+type SkipNotifyFunc func(path string, reason SkipReason)
+
+// SetSkipNotifyFunc sets a callback that is called whenever an entry is
+// skipped, with the reason, in order to give visibility into the filtering
+// for debugging and auditing. This complements the stats counters. The path
+// is formatted the same way as for the regular callback. It's called from
+// the workers, so it will be called concurrently. There is no overhead if
+// this isn't set.
+func (walk *Walk) SetSkipNotifyFunc(skipNotifyFunc SkipNotifyFunc) {
+	walk.skipNotifyFunc = skipNotifyFunc
+}
+
+// notifySkip reports the given skipped entry if anyone is listening and
+// records it if it was excluded by the filters and they're being recorded.
+func (walk *Walk) notifySkip(fqPath string, reason SkipReason) {
+	isRecorded := walk.isRecordExcluded == true && isFilterSkipReason(reason) == true
+
+	if walk.skipNotifyFunc == nil && isRecorded == false {
+		return
+	}
+
+	if walk.isReformattingReportedPaths() == true {
+		parentNodePath, info := walk.reportPath(path.Dir(fqPath), nameOnlyFileInfo{name: path.Base(fqPath)})
+		fqPath = path.Join(parentNodePath, info.Name())
+	}
+
+	if isRecorded == true {
+		walk.recordExcludedPath(fqPath, reason)
+	}
+
+	if walk.skipNotifyFunc != nil {
+		walk.skipNotifyFunc(fqPath, reason)
+	}
+}
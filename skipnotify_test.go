@@ -0,0 +1,97 @@
+package pathwalk
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"sync"
+	"testing"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+)
+
+func TestSkipReason_String(t *testing.T) {
+	if SkipFilterFilename.String() != "filter-filename" {
+		t.Fatalf("String() not correct for a known reason: [%s]", SkipFilterFilename.String())
+	}
+
+	if SkipReason(99).String() != "SkipReason<99>" {
+		t.Fatalf("String() not correct for an unknown reason: [%s]", SkipReason(99).String())
+	}
+}
+
+func TestWalk_SetSkipNotifyFunc(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	err = os.MkdirAll(path.Join(tempPath, "dir1"), 0755)
+	log.PanicIf(err)
+
+	err = os.MkdirAll(path.Join(tempPath, "dir2"), 0755)
+	log.PanicIf(err)
+
+	relFilepaths := []string{
+		"dir1/file1.jpg",
+		"dir1/file2.tmp",
+		"dir1/very-long-filename.jpg",
+		"dir2/file3.jpg",
+	}
+
+	for _, relFilepath := range relFilepaths {
+		err := ioutil.WriteFile(path.Join(tempPath, relFilepath), []byte{}, 0644)
+		log.PanicIf(err)
+	}
+
+	m := sync.Mutex{}
+	skipped := make(map[string]SkipReason)
+
+	skipNotifyFunc := func(fqPath string, reason SkipReason) {
+		m.Lock()
+		defer m.Unlock()
+
+		relPath := "."
+		if fqPath != tempPath {
+			relPath = fqPath[len(tempPath)+1:]
+		}
+
+		skipped[relPath] = reason
+	}
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetSkipNotifyFunc(skipNotifyFunc)
+
+	filter := Filter{
+		IncludePaths:     []string{"dir1"},
+		ExcludeFilenames: []string{"*.tmp"},
+		MaxPathLength:    len(tempPath) + 1 + len("dir1/file2.tmp"),
+		SuppressRoot:     true,
+	}
+
+	walk.SetFilter(filter)
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	// The root doesn't match the include either (it's suppressed).
+	expected := map[string]SkipReason{
+		".":                           SkipFilterPath,
+		"dir1/file2.tmp":              SkipFilterFilename,
+		"dir1/very-long-filename.jpg": SkipPathTooLong,
+		"dir2":                        SkipFilterPath,
+		"dir2/file3.jpg":              SkipFilterPath,
+	}
+
+	if reflect.DeepEqual(skipped, expected) != true {
+		t.Fatalf("Skipped entries not correct: %v", skipped)
+	}
+}
@@ -0,0 +1,32 @@
+package pathwalk
+
+import (
+	"fmt"
+)
+
+// SetMaxSkippedEntries sets the number of entries that can be skipped because
+// they couldn't be read (which are otherwise just logged as warnings) before
+// the walk is aborted. A large number of these usually indicates a systemic
+// problem (like a disconnected mount) that would otherwise silently produce a
+// drastically incomplete result. Zero (the default) means no limit. The count
+// is always available via `Stats().SkippedEntries`.
+func (walk *Walk) SetMaxSkippedEntries(maxSkippedEntries int) {
+	walk.maxSkippedEntries = maxSkippedEntries
+}
+
+// recordSkippedEntry counts an entry that was skipped because it couldn't be
+// read. An error is returned if this exceeds the maximum.
+func (walk *Walk) recordSkippedEntry(entryPath string, reason error) (err error) {
+	walk.notifySkip(entryPath, SkipUnreadable)
+
+	walk.statsLocker.Lock()
+	walk.stats.SkippedEntries++
+	skippedEntries := walk.stats.SkippedEntries
+	walk.statsLocker.Unlock()
+
+	if walk.maxSkippedEntries > 0 && skippedEntries > walk.maxSkippedEntries {
+		return fmt.Errorf("too many entries were skipped because they couldn't be read (%d > %d); the last was [%s]: [%s]", skippedEntries, walk.maxSkippedEntries, entryPath, reason.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package pathwalk
+
+import (
+	"errors"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+)
+
+// testUnreadableChildLister fails to stat any child whose name starts with
+// "bad".
+type testUnreadableChildLister struct {
+	*testMapChildLister
+}
+
+func (tucl *testUnreadableChildLister) StatChild(nodePath string) (info os.FileInfo, err error) {
+	if strings.HasPrefix(path.Base(nodePath), "bad") == true {
+		return nil, errors.New("stat failed")
+	}
+
+	return tucl.testMapChildLister.StatChild(nodePath)
+}
+
+func newTestUnreadableChildLister() *testUnreadableChildLister {
+	return &testUnreadableChildLister{
+		testMapChildLister: &testMapChildLister{
+			children: map[string][]string{
+				"/root": {"good1", "bad1", "bad2", "good2", "bad3", "bad4", "bad5"},
+			},
+			offsets: make(map[string]int),
+		},
+	}
+}
+
+func TestWalk_SetMaxSkippedEntries__underLimit(t *testing.T) {
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		return nil
+	}
+
+	walk := NewWalk("/root", walkFunc)
+	walk.SetChildLister(newTestUnreadableChildLister())
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	stats := walk.Stats()
+
+	if stats.SkippedEntries != 5 {
+		t.Fatalf("SkippedEntries not correct: (%d)", stats.SkippedEntries)
+	} else if stats.FilesVisited != 2 {
+		t.Fatalf("FilesVisited not correct: (%d)", stats.FilesVisited)
+	}
+}
+
+func TestWalk_SetMaxSkippedEntries__overLimit(t *testing.T) {
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		return nil
+	}
+
+	walk := NewWalk("/root", walkFunc)
+	walk.SetChildLister(newTestUnreadableChildLister())
+	walk.SetMaxSkippedEntries(3)
+
+	err := walk.Run()
+	if err == nil {
+		t.Fatalf("Expected error for too many skipped entries.")
+	} else if strings.Contains(err.Error(), "too many entries were skipped") != true {
+		t.Fatalf("Error not correct: [%s]", err.Error())
+	}
+
+	if walk.Outcome() != OutcomeError {
+		t.Fatalf("Outcome not correct: [%s]", walk.Outcome())
+	}
+}
@@ -0,0 +1,98 @@
+package pathwalk
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+
+	"github.com/dsoprea/go-logging"
+)
+
+// SpecialFileFunc is the function type for the callback that receives special
+// files.
+type SpecialFileFunc func(parentPath string, info os.FileInfo) (err error)
+
+// SetSpecialFileFunc sets a callback that receives the special files (FIFOs,
+// sockets, and devices) instead of the regular callback, so that the regular
+// callback only sees files that it's reasonable to open and read. Special
+// files are otherwise delivered to the regular callback like any other file.
+// They're still subject to the filters and are counted in both
+// `Stats().FilesVisited` and `Stats().SpecialFilesVisited`.
+//
+// Errors are handled the same way as for the regular callback. The path is
+// formatted the same way as for the regular callback. The batch, contextual,
+// and worker callbacks don't receive special files when this is set. This is
+// not called in names-only mode (which doesn't know the modes of entries).
+func (walk *Walk) SetSpecialFileFunc(specialFileFunc SpecialFileFunc) {
+	walk.specialFileFunc = specialFileFunc
+}
+
+// jobSpecialNode is a file node for a special file, which is delivered to
+// the special-file callback.
+type jobSpecialNode struct {
+	jobFileNode
+}
+
+func newJobSpecialNode(parentNodePath string, info os.FileInfo) jobSpecialNode {
+	return jobSpecialNode{
+		jobFileNode: newJobFileNode(parentNodePath, info),
+	}
+}
+
+// String returns a descriptive string.
+func (jsn jobSpecialNode) String() string {
+	return fmt.Sprintf("JobSpecialNode<PARENT=[%s] NAME=[%s]>", jsn.jobNode.parentNodePath, jsn.info.Name())
+}
+
+// isSpecialFileMode returns whether the given mode describes something other
+// than a regular file, directory, or symlink.
+func isSpecialFileMode(mode os.FileMode) bool {
+	return mode.IsRegular() == false && mode.IsDir() == false && mode&os.ModeSymlink == 0
+}
+
+// isSpecialFileDispatched returns whether the given file is to be delivered to
+// the special-file callback.
+func (walk *Walk) isSpecialFileDispatched(info os.FileInfo) bool {
+	return walk.specialFileFunc != nil && walk.isCountingMatches == false && isSpecialFileMode(info.Mode()) == true
+}
+
+// handleJobSpecialNode delivers one special file to the special-file
+// callback.
+func (walk *Walk) handleJobSpecialNode(jsn jobSpecialNode) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	atomic.AddInt64(&walk.hotStats.filesVisited, 1)
+
+	walk.statsLocker.Lock()
+	walk.stats.SpecialFilesVisited++
+	walk.statsLocker.Unlock()
+
+	walk.recordDepth(jsn.depth, 1)
+
+	parentNodePath := jsn.ParentNodePath()
+	info := jsn.Info()
+
+	if walk.isAlreadyProcessed(parentNodePath, info) == true {
+		return nil
+	}
+
+	if walk.isPruneEmptyDirectories == true {
+		walk.markIncludedDescendant(parentNodePath)
+	}
+
+	reportedParentPath, reportedInfo := parentNodePath, info
+	if walk.isReformattingReportedPaths() == true {
+		reportedParentPath, reportedInfo = walk.reportPath(parentNodePath, info)
+	}
+
+	err = walk.callVisitorSafely(WalkFunc(walk.specialFileFunc), reportedParentPath, reportedInfo)
+
+	err = walk.applyFileErrorPolicy(parentNodePath, info, err)
+	log.PanicIf(err)
+
+	return nil
+}
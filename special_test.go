@@ -0,0 +1,185 @@
+package pathwalk
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+)
+
+// testModeFileInfo reports the given mode.
+type testModeFileInfo struct {
+	os.FileInfo
+
+	mode os.FileMode
+}
+
+func (tmfi testModeFileInfo) Mode() os.FileMode {
+	return tmfi.mode
+}
+
+// testSpecialChildLister reports any child whose name starts with "fifo",
+// "sock", or "dev" as the corresponding special file.
+type testSpecialChildLister struct {
+	*testMapChildLister
+}
+
+func (tscl *testSpecialChildLister) StatChild(nodePath string) (info os.FileInfo, err error) {
+	info, err = tscl.testMapChildLister.StatChild(nodePath)
+	if err != nil {
+		return nil, err
+	}
+
+	name := path.Base(nodePath)
+
+	if strings.HasPrefix(name, "fifo") == true {
+		return testModeFileInfo{FileInfo: info, mode: os.ModeNamedPipe | 0644}, nil
+	} else if strings.HasPrefix(name, "sock") == true {
+		return testModeFileInfo{FileInfo: info, mode: os.ModeSocket | 0755}, nil
+	} else if strings.HasPrefix(name, "dev") == true {
+		return testModeFileInfo{FileInfo: info, mode: os.ModeDevice | os.ModeCharDevice | 0666}, nil
+	}
+
+	return info, nil
+}
+
+func newTestSpecialChildLister() *testSpecialChildLister {
+	return &testSpecialChildLister{
+		testMapChildLister: &testMapChildLister{
+			children: map[string][]string{
+				"/root":            {"regular1", "fifo1", "sock1", "container1"},
+				"/root/container1": {"dev1", "regular2"},
+			},
+			offsets: make(map[string]int),
+		},
+	}
+}
+
+func TestIsSpecialFileMode(t *testing.T) {
+	if isSpecialFileMode(0644) != false {
+		t.Fatalf("Regular file should not be special.")
+	} else if isSpecialFileMode(os.ModeDir|0755) != false {
+		t.Fatalf("Directory should not be special.")
+	} else if isSpecialFileMode(os.ModeSymlink|0777) != false {
+		t.Fatalf("Symlink should not be special.")
+	} else if isSpecialFileMode(os.ModeNamedPipe|0644) != true {
+		t.Fatalf("FIFO should be special.")
+	} else if isSpecialFileMode(os.ModeSocket|0755) != true {
+		t.Fatalf("Socket should be special.")
+	} else if isSpecialFileMode(os.ModeDevice|0660) != true {
+		t.Fatalf("Block device should be special.")
+	} else if isSpecialFileMode(os.ModeDevice|os.ModeCharDevice|0666) != true {
+		t.Fatalf("Character device should be special.")
+	}
+}
+
+func TestWalk_SetSpecialFileFunc(t *testing.T) {
+	m := sync.Mutex{}
+	visited := make([]string, 0)
+	special := make([]string, 0)
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		visited = append(visited, path.Join(parentPath, info.Name()))
+
+		return nil
+	}
+
+	specialFileFunc := func(parentPath string, info os.FileInfo) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		if isSpecialFileMode(info.Mode()) != true {
+			t.Fatalf("Special-file callback received a non-special file: [%s] (%s)", info.Name(), info.Mode())
+		}
+
+		special = append(special, path.Join(parentPath, info.Name()))
+
+		return nil
+	}
+
+	walk := NewWalk("/root", walkFunc)
+	walk.SetChildLister(newTestSpecialChildLister())
+	walk.SetSpecialFileFunc(specialFileFunc)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	sort.Strings(visited)
+	sort.Strings(special)
+
+	expectedVisited := []string{
+		"/root",
+		"/root/container1",
+		"/root/container1/regular2",
+		"/root/regular1",
+	}
+
+	if reflect.DeepEqual(visited, expectedVisited) != true {
+		t.Fatalf("Visited entries not correct: %v", visited)
+	}
+
+	expectedSpecial := []string{
+		"/root/container1/dev1",
+		"/root/fifo1",
+		"/root/sock1",
+	}
+
+	if reflect.DeepEqual(special, expectedSpecial) != true {
+		t.Fatalf("Special files not correct: %v", special)
+	}
+
+	stats := walk.Stats()
+
+	if stats.SpecialFilesVisited != 3 {
+		t.Fatalf("SpecialFilesVisited not correct: (%d)", stats.SpecialFilesVisited)
+	} else if stats.FilesVisited != 5 {
+		t.Fatalf("FilesVisited not correct: (%d)", stats.FilesVisited)
+	}
+}
+
+func TestWalk_SetSpecialFileFunc__notSet(t *testing.T) {
+	m := sync.Mutex{}
+	visited := make([]string, 0)
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		visited = append(visited, path.Join(parentPath, info.Name()))
+
+		return nil
+	}
+
+	walk := NewWalk("/root", walkFunc)
+	walk.SetChildLister(newTestSpecialChildLister())
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	sort.Strings(visited)
+
+	// The special files fall back to the regular callback.
+	expected := []string{
+		"/root",
+		"/root/container1",
+		"/root/container1/dev1",
+		"/root/container1/regular2",
+		"/root/fifo1",
+		"/root/regular1",
+		"/root/sock1",
+	}
+
+	if reflect.DeepEqual(visited, expected) != true {
+		t.Fatalf("Visited entries not correct: %v", visited)
+	} else if walk.Stats().SpecialFilesVisited != 0 {
+		t.Fatalf("SpecialFilesVisited not correct: (%d)", walk.Stats().SpecialFilesVisited)
+	}
+}
@@ -0,0 +1,331 @@
+package pathwalk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+)
+
+// spilledJob is a batch of directory entries whose names were written to the
+// spill file. Everything else about the job is kept in memory.
+type spilledJob struct {
+	jdcb jobDirectoryContentsBatch
+
+	offset int64
+	size   int
+}
+
+// SetSpillThreshold enables spilling to disk for trees that are so large
+// that even the pending batches of directory entries would exhaust the
+// memory. Once more than `threshold` batches are waiting to be processed, the
+// names of any further batches are written to a temporary file (see
+// `SetSpillDirectory()`) instead of being held in memory, and they're read
+// back one at a time as the pending batches are processed. Only a few words
+// of each spilled batch remain in memory. Zero (the default) disables
+// spilling.
+//
+// This trades throughput for memory: every spilled batch is written and read
+// back once, and the spilled batches are processed after the ones in memory,
+// so a walk that spills is slower (and delivers the entries in a different
+// order) than one that doesn't. The file is emptied whenever everything that
+// was spilled has been read back and is removed at the end of the run. The
+// number of spilled batches is reported in `Stats().JobsSpilledToDisk`.
+func (walk *Walk) SetSpillThreshold(threshold int) {
+	walk.spillThreshold = threshold
+}
+
+// SetSpillDirectory sets the directory that the spill file is created in.
+// Empty (the default) means the system's temporary directory.
+func (walk *Walk) SetSpillDirectory(directoryPath string) {
+	walk.spillDirectory = directoryPath
+}
+
+// spillJobIfOverThreshold writes the names of the given job to the spill file
+// if there are already too many batches pending. The job must have already
+// been counted as in-flight. If `isSpilled` is false, the caller has to
+// dispatch the job as usual.
+func (walk *Walk) spillJobIfOverThreshold(j job) (isSpilled bool) {
+	jdcb, ok := j.(jobDirectoryContentsBatch)
+	if ok == false || walk.spillThreshold <= 0 {
+		return false
+	}
+
+	walk.spillLocker.Lock()
+	defer walk.spillLocker.Unlock()
+
+	if walk.pendingBatchCount < walk.spillThreshold {
+		walk.pendingBatchCount++
+		return false
+	}
+
+	err := walk.writeSpilledJob(jdcb)
+	if err != nil {
+		// We can still make progress by keeping it in memory.
+		walk.logWarningf("could not spill a batch of [%s] to disk; it will be kept in memory: [%s]", jdcb.parentPath, err.Error())
+
+		walk.pendingBatchCount++
+		return false
+	}
+
+	walk.statsLocker.Lock()
+	walk.stats.JobsSpilledToDisk++
+	walk.statsLocker.Unlock()
+
+	// Only the names are kept, so the children will be resolved by path when
+	// it's read back.
+	jdcb.dir.release()
+
+	return true
+}
+
+// writeSpilledJob appends the names of the given job to the spill file. The
+// spill locker must be held.
+func (walk *Walk) writeSpilledJob(jdcb jobDirectoryContentsBatch) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if walk.spillFile == nil {
+		walk.spillFile, err = ioutil.TempFile(walk.spillDirectory, "pathwalk-spill-")
+		log.PanicIf(err)
+
+		walk.spillOffset = 0
+	}
+
+	encoded := encodeSpilledNames(jdcb.childBatch, jdcb.childIsDir)
+
+	_, err = walk.spillFile.WriteAt(encoded, walk.spillOffset)
+	log.PanicIf(err)
+
+	sj := spilledJob{
+		jdcb:   jdcb,
+		offset: walk.spillOffset,
+		size:   len(encoded),
+	}
+
+	sj.jdcb.childBatch = nil
+	sj.jdcb.childIsDir = nil
+
+	walk.spilledJobs = append(walk.spilledJobs, sj)
+	walk.spillOffset += int64(len(encoded))
+
+	return nil
+}
+
+// batchStarted is called as a pending batch starts to be processed. If any
+// batches were spilled, the oldest one is read back and dispatched in its
+// place.
+func (walk *Walk) batchStarted() (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if walk.spillThreshold <= 0 {
+		return nil
+	}
+
+	walk.spillLocker.Lock()
+
+	walk.pendingBatchCount--
+
+	if len(walk.spilledJobs) == 0 {
+		walk.spillLocker.Unlock()
+		return nil
+	}
+
+	sj := walk.spilledJobs[0]
+
+	walk.spilledJobs[0] = spilledJob{}
+	walk.spilledJobs = walk.spilledJobs[1:]
+
+	jdcb, err := walk.readSpilledJob(sj)
+	if err != nil {
+		walk.spillLocker.Unlock()
+
+		// The job will never be processed.
+		walk.jobTickDown()
+
+		log.Panic(err)
+	}
+
+	walk.pendingBatchCount++
+
+	walk.spillLocker.Unlock()
+
+	walk.dispatchSpilledJob(jdcb)
+
+	return nil
+}
+
+// readSpilledJob reads the names of the given job back from the spill file.
+// Once nothing else is spilled, the file is emptied. The spill locker must be
+// held.
+func (walk *Walk) readSpilledJob(sj spilledJob) (jdcb jobDirectoryContentsBatch, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	encoded := make([]byte, sj.size)
+
+	_, err = walk.spillFile.ReadAt(encoded, sj.offset)
+	log.PanicIf(err)
+
+	jdcb = sj.jdcb
+
+	jdcb.childBatch, jdcb.childIsDir, err = decodeSpilledNames(encoded)
+	log.PanicIf(err)
+
+	if len(walk.spilledJobs) == 0 {
+		err := walk.spillFile.Truncate(0)
+		log.PanicIf(err)
+
+		walk.spillOffset = 0
+		walk.spilledJobs = nil
+	}
+
+	return jdcb, nil
+}
+
+// dispatchSpilledJob queues a job that was read back from the spill file. It
+// was already counted as in-flight when it was first pushed.
+func (walk *Walk) dispatchSpilledJob(j job) {
+	walk.counterLocker.Lock()
+
+	if walk.hasStopped == true {
+		walk.counterLocker.Unlock()
+
+		// We've been stopped. Quietly discard the job.
+		walk.jobTickDown()
+
+		return
+	}
+
+	walk.pushWg.Add(1)
+
+	walk.counterLocker.Unlock()
+
+	defer walk.pushWg.Done()
+
+	walk.dispatchJob(j)
+}
+
+// drainSpilledJobs discards any jobs that are still spilled.
+func (walk *Walk) drainSpilledJobs() {
+	walk.spillLocker.Lock()
+
+	count := len(walk.spilledJobs)
+	walk.spilledJobs = nil
+
+	walk.spillLocker.Unlock()
+
+	for i := 0; i < count; i++ {
+		walk.jobTickDown()
+	}
+}
+
+// closeSpillFile removes the spill file, if one was created.
+func (walk *Walk) closeSpillFile() {
+	walk.spillLocker.Lock()
+	defer walk.spillLocker.Unlock()
+
+	if walk.spillFile == nil {
+		return
+	}
+
+	walk.spillFile.Close()
+	os.Remove(walk.spillFile.Name())
+
+	walk.spillFile = nil
+	walk.spillOffset = 0
+	walk.spilledJobs = nil
+}
+
+// encodeSpilledNames serializes the names (and types, if known) of a batch.
+// Each name is prefixed by its length since names can contain anything but a
+// separator.
+func encodeSpilledNames(names []string, isDir []bool) []byte {
+	b := new(bytes.Buffer)
+	scratch := make([]byte, binary.MaxVarintLen64)
+
+	n := binary.PutUvarint(scratch, uint64(len(names)))
+	b.Write(scratch[:n])
+
+	for _, name := range names {
+		n := binary.PutUvarint(scratch, uint64(len(name)))
+		b.Write(scratch[:n])
+
+		b.WriteString(name)
+	}
+
+	if isDir == nil {
+		b.WriteByte(0)
+		return b.Bytes()
+	}
+
+	b.WriteByte(1)
+
+	for _, flag := range isDir {
+		if flag == true {
+			b.WriteByte(1)
+		} else {
+			b.WriteByte(0)
+		}
+	}
+
+	return b.Bytes()
+}
+
+// decodeSpilledNames is the inverse of `encodeSpilledNames()`.
+func decodeSpilledNames(encoded []byte) (names []string, isDir []bool, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	r := bytes.NewReader(encoded)
+
+	count, err := binary.ReadUvarint(r)
+	log.PanicIf(err)
+
+	names = make([]string, count)
+	for i := range names {
+		size, err := binary.ReadUvarint(r)
+		log.PanicIf(err)
+
+		raw := make([]byte, size)
+
+		_, err = io.ReadFull(r, raw)
+		log.PanicIf(err)
+
+		names[i] = string(raw)
+	}
+
+	hasTypes, err := r.ReadByte()
+	log.PanicIf(err)
+
+	if hasTypes == 0 {
+		return names, nil, nil
+	}
+
+	isDir = make([]bool, count)
+	for i := range isDir {
+		flag, err := r.ReadByte()
+		log.PanicIf(err)
+
+		isDir[i] = flag == 1
+	}
+
+	return names, isDir, nil
+}
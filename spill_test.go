@@ -0,0 +1,177 @@
+package pathwalk
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-parallel-walker/internal/testing"
+)
+
+func TestEncodeSpilledNames(t *testing.T) {
+	names := []string{"file1", "", "ünïcode", "with\nnewline"}
+	isDir := []bool{false, true, false, true}
+
+	encoded := encodeSpilledNames(names, isDir)
+
+	recoveredNames, recoveredIsDir, err := decodeSpilledNames(encoded)
+	log.PanicIf(err)
+
+	if reflect.DeepEqual(recoveredNames, names) != true {
+		t.Fatalf("Names not correct: %v", recoveredNames)
+	} else if reflect.DeepEqual(recoveredIsDir, isDir) != true {
+		t.Fatalf("Types not correct: %v", recoveredIsDir)
+	}
+
+	// Without the types.
+
+	encoded = encodeSpilledNames(names, nil)
+
+	recoveredNames, recoveredIsDir, err = decodeSpilledNames(encoded)
+	log.PanicIf(err)
+
+	if reflect.DeepEqual(recoveredNames, names) != true {
+		t.Fatalf("Names without types not correct: %v", recoveredNames)
+	} else if recoveredIsDir != nil {
+		t.Fatalf("Expected no types: %v", recoveredIsDir)
+	}
+}
+
+func TestDecodeSpilledNames__truncated(t *testing.T) {
+	encoded := encodeSpilledNames([]string{"file1", "file2"}, nil)
+
+	_, _, err := decodeSpilledNames(encoded[:len(encoded)-3])
+	if err == nil {
+		t.Fatalf("Expected error for truncated data.")
+	}
+}
+
+func testWalkSpill(t *testing.T, bias SchedulingBias) {
+	fileCount := 2000
+	tempPath, tempFilenames := pwtesting.FillFlatTempPath(fileCount, nil)
+
+	spillPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+		os.RemoveAll(spillPath)
+	}()
+
+	m := sync.Mutex{}
+	visited := make(sort.StringSlice, 0)
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		if info.IsDir() == true {
+			return nil
+		}
+
+		m.Lock()
+		visited = append(visited, info.Name())
+		m.Unlock()
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetSchedulingBias(bias)
+	walk.SetBatchSize(10)
+	walk.SetSpillThreshold(2)
+	walk.SetSpillDirectory(spillPath)
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	visited.Sort()
+
+	if reflect.DeepEqual(visited, tempFilenames) != true {
+		t.Fatalf("Visited files not correct: (%d) != (%d)", len(visited), len(tempFilenames))
+	} else if walk.Stats().JobsSpilledToDisk == 0 {
+		t.Fatalf("Expected batches to be spilled.")
+	}
+
+	// The spill file is removed at the end of the run.
+
+	files, err := ioutil.ReadDir(spillPath)
+	log.PanicIf(err)
+
+	if len(files) != 0 {
+		t.Fatalf("Expected the spill file to be removed: [%s]", path.Join(spillPath, files[0].Name()))
+	}
+}
+
+func TestWalk_SetSpillThreshold(t *testing.T) {
+	testWalkSpill(t, BreadthFirst)
+}
+
+func TestWalk_SetSpillThreshold__depthFirst(t *testing.T) {
+	testWalkSpill(t, DepthFirst)
+}
+
+func TestWalk_SetSpillThreshold__stopped(t *testing.T) {
+	tempPath, _ := pwtesting.FillFlatTempPath(2000, nil)
+
+	spillPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+		os.RemoveAll(spillPath)
+	}()
+
+	var walk *Walk
+
+	once := sync.Once{}
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		if info.IsDir() == false && walk.Stats().JobsSpilledToDisk > 0 {
+			once.Do(walk.Stop)
+		}
+
+		return nil
+	}
+
+	walk = NewWalk(tempPath, walkFunc)
+	walk.SetBatchSize(10)
+	walk.SetSpillThreshold(2)
+	walk.SetSpillDirectory(spillPath)
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	if walk.Outcome() != OutcomeStopped {
+		t.Fatalf("Outcome not correct: [%s]", walk.Outcome())
+	}
+
+	files, err := ioutil.ReadDir(spillPath)
+	log.PanicIf(err)
+
+	if len(files) != 0 {
+		t.Fatalf("Expected the spill file to be removed.")
+	}
+}
+
+func TestWalk_SetSpillThreshold__disabled(t *testing.T) {
+	tempPath, _ := pwtesting.FillFlatTempPath(200, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walk := NewWalk(tempPath, nil)
+	walk.SetBatchSize(10)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	if walk.Stats().JobsSpilledToDisk != 0 {
+		t.Fatalf("Expected nothing to be spilled.")
+	}
+}
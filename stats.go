@@ -2,6 +2,8 @@ package pathwalk
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -15,26 +17,160 @@ type Stats struct {
 	// an available, idle worker rather than starting a new one.
 	JobsDispatchedToIdleWorker int
 
+	// WorkersWarmedUp is the number of workers that were started before the
+	// first job was queued. See `WarmUp()`.
+	WorkersWarmedUp int
+
+	// WorkerLifecycleStarts and WorkerLifecycleStops are the number of times
+	// that the worker lifecycle was started and stopped. See
+	// `SetWorkerLifecycleFuncs()`.
+	WorkerLifecycleStarts int
+	WorkerLifecycleStops  int
+
 	// FilesVisited is the number of files that were visited.
 	FilesVisited int
 
-	// DirectoriesVisited is the number of directories that were visited.
+	// SpecialFilesVisited is the number of special files (FIFOs, sockets, and
+	// devices) that were delivered to the special-file callback. These are
+	// included in `FilesVisited`. See `SetSpecialFileFunc()`.
+	SpecialFilesVisited int
+
+	// FilesNotCounted is the number of files that were visited but that the
+	// counting callback didn't count. See `SetCountingWalkFunc()`.
+	FilesNotCounted int
+
+	// DirectoriesVisited is the number of directories that were visited. This
+	// includes the root if `RootVisited` is true. See
+	// `DirectoriesVisitedExcludingRoot()`.
 	DirectoriesVisited int
 
+	// RootVisited indicates that the root was visited as a directory and
+	// counted in `DirectoriesVisited`. The root is counted even if the filters
+	// keep its callback from being called.
+	RootVisited bool
+
 	// EntryBatchesProcessed is the number of batches that directory entries
 	// were parceled into while processing.
 	EntryBatchesProcessed int
 
+	// DirectoriesBatched is the number of directories that had at least one
+	// entry (and so produced at least one batch). See
+	// `AverageBatchesPerDirectory()`.
+	DirectoriesBatched int
+
+	// DirectoryHintsApplied is the number of directories that were read with
+	// a batch-size from a hint. See `SetDirectoryHint()`.
+	DirectoryHintsApplied int
+
+	// BatchesCoalesced is the number of directory listings that were held to
+	// share a job with others rather than getting their own (see
+	// `SetAutoTuneBatching()`).
+	BatchesCoalesced int
+
+	// JobsSpilledToDisk is the number of batches of directory entries that
+	// were written to the spill file. See `SetSpillThreshold()`.
+	JobsSpilledToDisk int
+
 	// IdleWorkerTime is the duration of all between-job time spent by workers.
 	// Only includes time between jobs and time between last job and timeout
 	// (leading to shutdown). Does not include time between the last job and a
 	// closed channel being detected (which is not true idleness).
 	IdleWorkerTime time.Duration
 
+	// CallbackTime is the duration of all time spent inside the callback,
+	// summed across all workers. Compare with `IdleWorkerTime` and the total
+	// elapsed time to determine whether the callback or the filesystem is the
+	// bottleneck.
+	CallbackTime time.Duration
+
+	// SlowestCallbackDuration and SlowestCallbackPath describe the single
+	// entry whose callback took the longest. These are only populated if
+	// enabled with `SetTrackCallbackTiming()`.
+	SlowestCallbackDuration time.Duration
+	SlowestCallbackPath     string
+
+	// CallbackErrors is the number of callback errors that were tolerated
+	// because of the error policy.
+	CallbackErrors int
+
+	// CallbackPanics is the number of panics in the callback that were
+	// recovered.
+	CallbackPanics int
+
+	// EmptyBranchesPruned is the number of directories that weren't delivered
+	// because no files were delivered below them. See
+	// `SetPruneEmptyDirectories()`.
+	EmptyBranchesPruned int
+
+	// AlreadyProcessed is the number of files whose callbacks weren't called
+	// because they were already processed. These are included in
+	// `FilesVisited`. See `SetSkipProcessedFunc()`.
+	AlreadyProcessed int
+
 	// DirectoriesIgnored is the number of directories that were signaled to be
 	// skipped using `ErrSkipDirectory`.
 	DirectoriesIgnored int
 
+	// SkippedEntries is the number of entries that were skipped because they
+	// couldn't be read (e.g. stat failures).
+	SkippedEntries int
+
+	// VanishedEntries is the number of entries that were listed but no longer
+	// existed by the time they were stat'd or read. This is only counted if
+	// such changes are tolerated. See `SetToleratePostEnumerationChanges()`.
+	VanishedEntries int
+
+	// ExcludedPathsDropped is the number of excluded entries that weren't
+	// kept for `ExcludedPaths()` because the maximum was reached.
+	ExcludedPathsDropped int
+
+	// TopLevelDirsExcluded is the number of directories directly under the
+	// root that weren't descended into because they weren't allowed (see
+	// `Filter.AllowedTopLevelDirs`).
+	TopLevelDirsExcluded int
+
+	// DirectoriesWithErrors is the number of directories that couldn't be
+	// read or that had children that couldn't be stat'd. See
+	// `FailedDirectories()`.
+	DirectoriesWithErrors int
+
+	// MountPointsSkipped is the number of directories that weren't descended
+	// into because they were on a different filesystem than the root.
+	MountPointsSkipped int
+
+	// DirectoriesSampled is the number of directories that had more entries
+	// than the sample size and were therefore not read completely.
+	DirectoriesSampled int
+
+	// FdExhaustionRetries is the number of times that opening a directory was
+	// retried because there were too many open files.
+	FdExhaustionRetries int
+
+	// DirectoryReadCalls is the number of times that a batch of entries was
+	// read from a directory.
+	DirectoryReadCalls int
+
+	// StatCalls is the number of times that an entry was stat'd.
+	StatCalls int
+
+	// LoadSamples is the number of times that the load was sampled with
+	// load-aware concurrency.
+	LoadSamples int
+
+	// EffectiveConcurrencyMin and EffectiveConcurrencyMax are the lowest and
+	// highest maximum number of workers that resulted from the load samples.
+	EffectiveConcurrencyMin int
+	EffectiveConcurrencyMax int
+
+	// EffectiveConcurrencyTotal is the sum of the maximum numbers of workers
+	// that resulted from the load samples. See
+	// `EffectiveConcurrencyAverage()`.
+	EffectiveConcurrencyTotal int
+
+	// DirectoriesTimedOut is the number of directories that took longer than
+	// the per-directory timeout to read and were therefore abandoned.
+	DirectoriesTimedOut int
+
 	// PathFilterIncludes is the number of path include hits or exclude misses
 	// if at least one filter rule was provided.
 	PathFilterIncludes int
@@ -50,6 +186,192 @@ type Stats struct {
 	// FileFilterExcludes is the number of file include misses or exclude hits
 	// if at least one filter rule was provided.
 	FileFilterExcludes int
+
+	// ContentFilterMatches is the number of files whose content matched one of
+	// the content signatures if any were provided.
+	ContentFilterMatches int
+
+	// CompoundExtensionMatches is the number of files that ended in one of
+	// the compound extensions if any were provided.
+	CompoundExtensionMatches int
+
+	// PathsTooLong is the number of files and directories that were excluded
+	// because their paths exceeded the maximum length.
+	PathsTooLong int
+
+	// PathsTooDeep is the number of files and directories that were excluded
+	// because their paths had more components than the maximum.
+	PathsTooDeep int
+
+	// SymlinksOutsideRoot is the number of symlinks that were excluded
+	// because their targets are outside of the root.
+	SymlinksOutsideRoot int
+
+	// SymlinkedDirectoriesNotFollowed is the number of symlinks to
+	// directories that weren't descended into. See `SetSymlinkDirMode()`.
+	SymlinkedDirectoriesNotFollowed int
+
+	// DirectoryCyclesSkipped is the number of directories that were skipped
+	// because they had already been visited. See
+	// `SetDetectDirectoryCycles()`.
+	DirectoryCyclesSkipped int
+
+	// OwnerFilterExcludes is the number of files that were excluded because
+	// they didn't have one of the required owners.
+	OwnerFilterExcludes int
+
+	// CreationTimeFilterExcludes is the number of files that were excluded
+	// because their creation times were outside of the bounds or weren't
+	// available.
+	CreationTimeFilterExcludes int
+
+	// WalkIgnoreExcludes is the number of files and directories that were
+	// excluded by an ignore file. See `SetWalkIgnoreFilename()`.
+	WalkIgnoreExcludes int
+
+	// EntriesByDepth is the number of visited files and directories at each
+	// depth below the root (the root is at depth zero). This is only
+	// populated if enabled with `SetTrackDepthStats()`.
+	EntriesByDepth map[int]int
+}
+
+// copy returns a copy that doesn't share the depth map.
+func (stats Stats) copy() Stats {
+	if stats.EntriesByDepth != nil {
+		entriesByDepth := make(map[int]int, len(stats.EntriesByDepth))
+		for depth, count := range stats.EntriesByDepth {
+			entriesByDepth[depth] = count
+		}
+
+		stats.EntriesByDepth = entriesByDepth
+	}
+
+	return stats
+}
+
+// Add returns the combination of these stats and the given stats. The counters
+// and durations are summed, the depth counts are merged, the effective-
+// concurrency range spans both, and the slowest callback is the slower of the
+// two. Neither original is modified.
+func (stats Stats) Add(other Stats) Stats {
+	merged := stats.copy()
+
+	merged.JobsDispatchedToNewWorker += other.JobsDispatchedToNewWorker
+	merged.JobsDispatchedToIdleWorker += other.JobsDispatchedToIdleWorker
+	merged.WorkersWarmedUp += other.WorkersWarmedUp
+	merged.WorkerLifecycleStarts += other.WorkerLifecycleStarts
+	merged.WorkerLifecycleStops += other.WorkerLifecycleStops
+	merged.FilesVisited += other.FilesVisited
+	merged.SpecialFilesVisited += other.SpecialFilesVisited
+	merged.FilesNotCounted += other.FilesNotCounted
+	merged.DirectoriesVisited += other.DirectoriesVisited
+	merged.RootVisited = merged.RootVisited || other.RootVisited
+	merged.EntryBatchesProcessed += other.EntryBatchesProcessed
+	merged.DirectoriesBatched += other.DirectoriesBatched
+	merged.DirectoryHintsApplied += other.DirectoryHintsApplied
+	merged.BatchesCoalesced += other.BatchesCoalesced
+	merged.JobsSpilledToDisk += other.JobsSpilledToDisk
+	merged.IdleWorkerTime += other.IdleWorkerTime
+	merged.CallbackTime += other.CallbackTime
+	merged.CallbackErrors += other.CallbackErrors
+
+	if other.SlowestCallbackDuration > merged.SlowestCallbackDuration {
+		merged.SlowestCallbackDuration = other.SlowestCallbackDuration
+		merged.SlowestCallbackPath = other.SlowestCallbackPath
+	}
+
+	merged.CallbackPanics += other.CallbackPanics
+	merged.EmptyBranchesPruned += other.EmptyBranchesPruned
+	merged.AlreadyProcessed += other.AlreadyProcessed
+	merged.DirectoriesIgnored += other.DirectoriesIgnored
+	merged.SkippedEntries += other.SkippedEntries
+	merged.VanishedEntries += other.VanishedEntries
+	merged.ExcludedPathsDropped += other.ExcludedPathsDropped
+	merged.TopLevelDirsExcluded += other.TopLevelDirsExcluded
+	merged.DirectoriesWithErrors += other.DirectoriesWithErrors
+	merged.MountPointsSkipped += other.MountPointsSkipped
+	merged.DirectoriesSampled += other.DirectoriesSampled
+	merged.FdExhaustionRetries += other.FdExhaustionRetries
+	merged.DirectoryReadCalls += other.DirectoryReadCalls
+	merged.StatCalls += other.StatCalls
+	merged.DirectoriesTimedOut += other.DirectoriesTimedOut
+	merged.PathFilterIncludes += other.PathFilterIncludes
+	merged.PathFilterExcludes += other.PathFilterExcludes
+	merged.FileFilterIncludes += other.FileFilterIncludes
+	merged.FileFilterExcludes += other.FileFilterExcludes
+	merged.ContentFilterMatches += other.ContentFilterMatches
+	merged.CompoundExtensionMatches += other.CompoundExtensionMatches
+	merged.PathsTooLong += other.PathsTooLong
+	merged.PathsTooDeep += other.PathsTooDeep
+	merged.SymlinksOutsideRoot += other.SymlinksOutsideRoot
+	merged.SymlinkedDirectoriesNotFollowed += other.SymlinkedDirectoriesNotFollowed
+	merged.DirectoryCyclesSkipped += other.DirectoryCyclesSkipped
+	merged.OwnerFilterExcludes += other.OwnerFilterExcludes
+	merged.CreationTimeFilterExcludes += other.CreationTimeFilterExcludes
+	merged.WalkIgnoreExcludes += other.WalkIgnoreExcludes
+
+	// The range only means something for stats that actually have samples.
+	if other.LoadSamples > 0 {
+		if merged.LoadSamples == 0 || other.EffectiveConcurrencyMin < merged.EffectiveConcurrencyMin {
+			merged.EffectiveConcurrencyMin = other.EffectiveConcurrencyMin
+		}
+
+		if merged.LoadSamples == 0 || other.EffectiveConcurrencyMax > merged.EffectiveConcurrencyMax {
+			merged.EffectiveConcurrencyMax = other.EffectiveConcurrencyMax
+		}
+	}
+
+	merged.LoadSamples += other.LoadSamples
+	merged.EffectiveConcurrencyTotal += other.EffectiveConcurrencyTotal
+
+	if other.EntriesByDepth != nil {
+		if merged.EntriesByDepth == nil {
+			merged.EntriesByDepth = make(map[int]int, len(other.EntriesByDepth))
+		}
+
+		for depth, count := range other.EntriesByDepth {
+			merged.EntriesByDepth[depth] += count
+		}
+	}
+
+	return merged
+}
+
+// DirectoriesVisitedExcludingRoot returns the number of directories that were
+// visited below the root, which is what averages over the directories of the
+// tree should usually be computed from. This is only meaningful for the stats
+// of a single walk, since merged stats only record whether any root was
+// visited.
+func (stats Stats) DirectoriesVisitedExcludingRoot() int {
+	if stats.RootVisited == true {
+		return stats.DirectoriesVisited - 1
+	}
+
+	return stats.DirectoriesVisited
+}
+
+// AverageBatchesPerDirectory returns the average number of batches that the
+// entries of each non-empty directory were parceled into. This is close to one
+// if most directories fit in a single batch and grows as the batch-size
+// becomes small relative to the directories, which is a signal for tuning
+// the batch-size. This is zero if no directory had any entries.
+func (stats Stats) AverageBatchesPerDirectory() float64 {
+	if stats.DirectoriesBatched == 0 {
+		return 0
+	}
+
+	return float64(stats.EntryBatchesProcessed) / float64(stats.DirectoriesBatched)
+}
+
+// MergeStats returns the combination of all of the given stats (e.g. from
+// several walks that were run in parallel). See `Stats.Add()`.
+func MergeStats(statsList ...Stats) Stats {
+	merged := Stats{}
+	for _, stats := range statsList {
+		merged = merged.Add(stats)
+	}
+
+	return merged
 }
 
 // Dump prints all statistics.
@@ -59,15 +381,103 @@ func (stats Stats) Dump() {
 
 	fmt.Printf("JobsDispatchedToNewWorker: (%d)\n", stats.JobsDispatchedToNewWorker)
 	fmt.Printf("JobsDispatchedToIdleWorker: (%d)\n", stats.JobsDispatchedToIdleWorker)
+	fmt.Printf("WorkersWarmedUp: (%d)\n", stats.WorkersWarmedUp)
+
+	if stats.WorkerLifecycleStarts > 0 {
+		fmt.Printf("WorkerLifecycleStarts: (%d)\n", stats.WorkerLifecycleStarts)
+		fmt.Printf("WorkerLifecycleStops: (%d)\n", stats.WorkerLifecycleStops)
+	}
+
 	fmt.Printf("FilesVisited: (%d)\n", stats.FilesVisited)
+	fmt.Printf("SpecialFilesVisited: (%d)\n", stats.SpecialFilesVisited)
+
+	if stats.FilesNotCounted > 0 {
+		fmt.Printf("FilesNotCounted: (%d)\n", stats.FilesNotCounted)
+	}
+
 	fmt.Printf("DirectoriesVisited: (%d)\n", stats.DirectoriesVisited)
+	fmt.Printf("RootVisited: [%v]\n", stats.RootVisited)
 	fmt.Printf("EntryBatchesProcessed: (%d)\n", stats.EntryBatchesProcessed)
+	fmt.Printf("DirectoriesBatched: (%d)\n", stats.DirectoriesBatched)
+	fmt.Printf("DirectoryHintsApplied: (%d)\n", stats.DirectoryHintsApplied)
+	fmt.Printf("AverageBatchesPerDirectory: (%.02f)\n", stats.AverageBatchesPerDirectory())
+	fmt.Printf("BatchesCoalesced: (%d)\n", stats.BatchesCoalesced)
+	fmt.Printf("JobsSpilledToDisk: (%d)\n", stats.JobsSpilledToDisk)
 	fmt.Printf("IdleWorkerTime: (%.03f) seconds\n", float64(stats.IdleWorkerTime)/float64(time.Second))
+	fmt.Printf("CallbackTime: (%.03f) seconds\n", float64(stats.CallbackTime)/float64(time.Second))
+
+	if stats.SlowestCallbackPath != "" {
+		fmt.Printf("SlowestCallback: (%.03f) seconds [%s]\n", float64(stats.SlowestCallbackDuration)/float64(time.Second), stats.SlowestCallbackPath)
+	}
+
+	fmt.Printf("CallbackErrors: (%d)\n", stats.CallbackErrors)
+	fmt.Printf("CallbackPanics: (%d)\n", stats.CallbackPanics)
+	fmt.Printf("EmptyBranchesPruned: (%d)\n", stats.EmptyBranchesPruned)
+	fmt.Printf("AlreadyProcessed: (%d)\n", stats.AlreadyProcessed)
 	fmt.Printf("DirectoriesIgnored: (%d)\n", stats.DirectoriesIgnored)
+	fmt.Printf("SkippedEntries: (%d)\n", stats.SkippedEntries)
+	fmt.Printf("VanishedEntries: (%d)\n", stats.VanishedEntries)
+	fmt.Printf("ExcludedPathsDropped: (%d)\n", stats.ExcludedPathsDropped)
+	fmt.Printf("TopLevelDirsExcluded: (%d)\n", stats.TopLevelDirsExcluded)
+	fmt.Printf("DirectoriesWithErrors: (%d)\n", stats.DirectoriesWithErrors)
+	fmt.Printf("MountPointsSkipped: (%d)\n", stats.MountPointsSkipped)
+	fmt.Printf("DirectoriesSampled: (%d)\n", stats.DirectoriesSampled)
+	fmt.Printf("DirectoriesTimedOut: (%d)\n", stats.DirectoriesTimedOut)
+	fmt.Printf("FdExhaustionRetries: (%d)\n", stats.FdExhaustionRetries)
+	fmt.Printf("DirectoryReadCalls: (%d)\n", stats.DirectoryReadCalls)
+	fmt.Printf("StatCalls: (%d)\n", stats.StatCalls)
+
+	if stats.LoadSamples > 0 {
+		fmt.Printf("LoadSamples: (%d)\n", stats.LoadSamples)
+		fmt.Printf("EffectiveConcurrency: MIN=(%d) MAX=(%d) AVERAGE=(%.02f)\n", stats.EffectiveConcurrencyMin, stats.EffectiveConcurrencyMax, stats.EffectiveConcurrencyAverage())
+	}
+
 	fmt.Printf("PathFilterIncludes: (%d)\n", stats.PathFilterIncludes)
 	fmt.Printf("PathFilterExcludes: (%d)\n", stats.PathFilterExcludes)
 	fmt.Printf("FileFilterIncludes: (%d)\n", stats.FileFilterIncludes)
 	fmt.Printf("FileFilterExcludes: (%d)\n", stats.FileFilterExcludes)
+	fmt.Printf("ContentFilterMatches: (%d)\n", stats.ContentFilterMatches)
+	fmt.Printf("CompoundExtensionMatches: (%d)\n", stats.CompoundExtensionMatches)
+	fmt.Printf("PathsTooLong: (%d)\n", stats.PathsTooLong)
+	fmt.Printf("PathsTooDeep: (%d)\n", stats.PathsTooDeep)
+	fmt.Printf("SymlinksOutsideRoot: (%d)\n", stats.SymlinksOutsideRoot)
+	fmt.Printf("SymlinkedDirectoriesNotFollowed: (%d)\n", stats.SymlinkedDirectoriesNotFollowed)
+	fmt.Printf("DirectoryCyclesSkipped: (%d)\n", stats.DirectoryCyclesSkipped)
+	fmt.Printf("OwnerFilterExcludes: (%d)\n", stats.OwnerFilterExcludes)
+	fmt.Printf("CreationTimeFilterExcludes: (%d)\n", stats.CreationTimeFilterExcludes)
+	fmt.Printf("WalkIgnoreExcludes: (%d)\n", stats.WalkIgnoreExcludes)
+
+	if len(stats.EntriesByDepth) > 0 {
+		stats.dumpEntriesByDepth()
+	}
 
 	fmt.Printf("\n")
 }
+
+// dumpEntriesByDepth prints a histogram of the entries at each depth.
+func (stats Stats) dumpEntriesByDepth() {
+	depths := make([]int, 0, len(stats.EntriesByDepth))
+	maxCount := 0
+	for depth, count := range stats.EntriesByDepth {
+		depths = append(depths, depth)
+
+		if count > maxCount {
+			maxCount = count
+		}
+	}
+
+	sort.Ints(depths)
+
+	fmt.Printf("EntriesByDepth:\n")
+
+	for _, depth := range depths {
+		count := stats.EntriesByDepth[depth]
+
+		barLength := 0
+		if maxCount > 0 {
+			barLength = count * entriesByDepthBarWidth / maxCount
+		}
+
+		fmt.Printf("  (%d) (%d) %s\n", depth, count, strings.Repeat("#", barLength))
+	}
+}
@@ -1,10 +1,219 @@
 package pathwalk
 
 import (
+	"fmt"
+	"os"
+	"path"
+	"reflect"
 	"testing"
+	"time"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-parallel-walker/internal/testing"
 )
 
 func TestStats_Dump(t *testing.T) {
 	stats := Stats{}
 	stats.Dump()
 }
+
+func TestStats_Dump__entriesByDepth(t *testing.T) {
+	stats := Stats{
+		EntriesByDepth: map[int]int{
+			0: 1,
+			1: 20,
+			2: 7,
+		},
+	}
+
+	stats.Dump()
+}
+
+// fillStats sets every counter and duration in a `Stats` to a distinct
+// multiple of the given factor so that any field that isn't merged is caught.
+func fillStats(factor int) Stats {
+	stats := Stats{}
+
+	v := reflect.ValueOf(&stats).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+
+		switch field.Kind() {
+		case reflect.Int, reflect.Int64:
+			field.SetInt(int64((i + 1) * factor))
+		case reflect.String:
+			field.SetString(fmt.Sprintf("path%d", factor))
+		case reflect.Bool:
+			field.SetBool(factor == 1)
+		case reflect.Map:
+		default:
+			log.Panicf("stats field not handled by test: [%s]", v.Type().Field(i).Name)
+		}
+	}
+
+	stats.EntriesByDepth = map[int]int{
+		0:      factor,
+		factor: factor,
+	}
+
+	return stats
+}
+
+func TestStats_Add(t *testing.T) {
+	a := fillStats(1)
+	b := fillStats(10)
+
+	merged := a.Add(b)
+
+	v := reflect.ValueOf(merged)
+	av := reflect.ValueOf(a)
+	bv := reflect.ValueOf(b)
+
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Name
+
+		kind := v.Field(i).Kind()
+		if kind == reflect.Map || kind == reflect.String || kind == reflect.Bool {
+			continue
+		}
+
+		actual := v.Field(i).Int()
+		expected := av.Field(i).Int() + bv.Field(i).Int()
+
+		if name == "EffectiveConcurrencyMin" {
+			expected = av.Field(i).Int()
+		} else if name == "EffectiveConcurrencyMax" || name == "SlowestCallbackDuration" {
+			expected = bv.Field(i).Int()
+		}
+
+		if actual != expected {
+			t.Fatalf("Field [%s] not merged correctly: (%d) != (%d)", name, actual, expected)
+		}
+	}
+
+	expectedEntriesByDepth := map[int]int{
+		0:  11,
+		1:  1,
+		10: 10,
+	}
+
+	if reflect.DeepEqual(merged.EntriesByDepth, expectedEntriesByDepth) != true {
+		t.Fatalf("EntriesByDepth not merged correctly: %v", merged.EntriesByDepth)
+	}
+
+	if merged.RootVisited != true {
+		t.Fatalf("RootVisited not merged correctly.")
+	}
+
+	if merged.SlowestCallbackPath != b.SlowestCallbackPath {
+		t.Fatalf("SlowestCallbackPath not merged correctly: [%s]", merged.SlowestCallbackPath)
+	}
+
+	// Make sure that the originals weren't touched.
+	if reflect.DeepEqual(a, fillStats(1)) != true || reflect.DeepEqual(b, fillStats(10)) != true {
+		t.Fatalf("Original stats were modified.")
+	}
+}
+
+func TestStats_Add__effectiveConcurrencyWithoutSamples(t *testing.T) {
+	a := Stats{}
+
+	b := Stats{
+		LoadSamples:               2,
+		EffectiveConcurrencyMin:   3,
+		EffectiveConcurrencyMax:   5,
+		EffectiveConcurrencyTotal: 8,
+	}
+
+	merged := a.Add(b).Add(Stats{})
+
+	if reflect.DeepEqual(merged, b) != true {
+		t.Fatalf("Stats without samples affected the range: %v", merged)
+	}
+}
+
+func TestMergeStats(t *testing.T) {
+	merged := MergeStats(
+		Stats{FilesVisited: 1, CallbackTime: time.Second},
+		Stats{FilesVisited: 2, CallbackTime: time.Second},
+		Stats{FilesVisited: 3, EntriesByDepth: map[int]int{2: 4}},
+	)
+
+	expected := Stats{
+		FilesVisited:   6,
+		CallbackTime:   2 * time.Second,
+		EntriesByDepth: map[int]int{2: 4},
+	}
+
+	if reflect.DeepEqual(merged, expected) != true {
+		t.Fatalf("Stats not merged correctly: %v", merged)
+	}
+
+	if reflect.DeepEqual(MergeStats(), Stats{}) != true {
+		t.Fatalf("Expected empty stats.")
+	}
+}
+
+func TestStats_DirectoriesVisitedExcludingRoot(t *testing.T) {
+	stats := Stats{
+		DirectoriesVisited: 5,
+	}
+
+	if stats.DirectoriesVisitedExcludingRoot() != 5 {
+		t.Fatalf("Count without root not correct: (%d)", stats.DirectoriesVisitedExcludingRoot())
+	}
+
+	stats.RootVisited = true
+
+	if stats.DirectoriesVisitedExcludingRoot() != 4 {
+		t.Fatalf("Count with root not correct: (%d)", stats.DirectoriesVisitedExcludingRoot())
+	}
+}
+
+func TestStats_AverageBatchesPerDirectory(t *testing.T) {
+	stats := Stats{}
+
+	if stats.AverageBatchesPerDirectory() != 0 {
+		t.Fatalf("Average without directories not correct: (%f)", stats.AverageBatchesPerDirectory())
+	}
+
+	stats.EntryBatchesProcessed = 5
+	stats.DirectoriesBatched = 2
+
+	if stats.AverageBatchesPerDirectory() != 2.5 {
+		t.Fatalf("Average not correct: (%f)", stats.AverageBatchesPerDirectory())
+	}
+}
+
+func TestWalk_Stats__directoriesBatched(t *testing.T) {
+	tempPath, _ := pwtesting.FillFlatTempPath(249, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	// The empty directory doesn't produce any batches.
+	err := os.Mkdir(path.Join(tempPath, "empty"), 0755)
+	log.PanicIf(err)
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetBatchSize(100)
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	stats := walk.Stats()
+
+	if stats.DirectoriesBatched != 1 {
+		t.Fatalf("DirectoriesBatched not correct: (%d)", stats.DirectoriesBatched)
+	} else if stats.EntryBatchesProcessed != 3 {
+		t.Fatalf("EntryBatchesProcessed not correct: (%d)", stats.EntryBatchesProcessed)
+	} else if stats.AverageBatchesPerDirectory() != 3 {
+		t.Fatalf("AverageBatchesPerDirectory not correct: (%f)", stats.AverageBatchesPerDirectory())
+	}
+}
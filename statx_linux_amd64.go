@@ -0,0 +1,6 @@
+package pathwalk
+
+const (
+	// statxTrap is the `statx()` system-call number on this architecture.
+	statxTrap = 332
+)
@@ -0,0 +1,29 @@
+package pathwalk
+
+// DirectorySummary describes a directory once it and everything below it has
+// been processed.
+type DirectorySummary struct {
+	// Path is the full-path of the directory.
+	Path string
+
+	// ChildCount is the number of immediate entries in the directory,
+	// regardless of filtering.
+	ChildCount int
+
+	// DescendantCount is the number of entries anywhere below the directory,
+	// regardless of filtering.
+	DescendantCount int
+}
+
+// DirectoryLeaveFunc is called once for every directory after all of its
+// descendants have been processed (post-order). Returning an error will
+// terminate the walk.
+type DirectoryLeaveFunc func(summary DirectorySummary) (err error)
+
+// SetDirectoryLeaveFunc sets a callback that is called as each directory is
+// completed. This enables the same directory tracking that checkpoints use and
+// must be set before calling `Run()`. When resuming from a checkpoint, the
+// counts only reflect what was processed by the resumed run.
+func (walk *Walk) SetDirectoryLeaveFunc(directoryLeaveFunc DirectoryLeaveFunc) {
+	walk.directoryLeaveFunc = directoryLeaveFunc
+}
@@ -0,0 +1,82 @@
+package pathwalk
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"sync"
+	"testing"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+)
+
+func TestWalk_SetDirectoryLeaveFunc(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	err = os.MkdirAll(path.Join(tempPath, "dir1", "dir2"), 0755)
+	log.PanicIf(err)
+
+	filepaths := []string{
+		path.Join(tempPath, "file1"),
+		path.Join(tempPath, "dir1", "file2"),
+		path.Join(tempPath, "dir1", "file3"),
+		path.Join(tempPath, "dir1", "dir2", "file4"),
+	}
+
+	for _, filepath := range filepaths {
+		err := ioutil.WriteFile(filepath, []byte{}, 0644)
+		log.PanicIf(err)
+	}
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		return nil
+	}
+
+	m := sync.Mutex{}
+	summaries := make(map[string]DirectorySummary)
+	order := make([]string, 0)
+
+	directoryLeaveFunc := func(summary DirectorySummary) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		summaries[summary.Path] = summary
+		order = append(order, summary.Path)
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetDirectoryLeaveFunc(directoryLeaveFunc)
+
+	// Make sure that counts accumulate across batches.
+	walk.SetBatchSize(1)
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	dir1Path := path.Join(tempPath, "dir1")
+	dir2Path := path.Join(dir1Path, "dir2")
+
+	expected := map[string]DirectorySummary{
+		tempPath: {Path: tempPath, ChildCount: 2, DescendantCount: 6},
+		dir1Path: {Path: dir1Path, ChildCount: 3, DescendantCount: 4},
+		dir2Path: {Path: dir2Path, ChildCount: 1, DescendantCount: 1},
+	}
+
+	if reflect.DeepEqual(summaries, expected) != true {
+		t.Fatalf("Summaries not correct: %v", summaries)
+	}
+
+	// Post-order.
+	if reflect.DeepEqual(order, []string{dir2Path, dir1Path, tempPath}) != true {
+		t.Fatalf("Order not correct: %v", order)
+	}
+}
@@ -0,0 +1,66 @@
+package pathwalk
+
+import (
+	"os"
+)
+
+// SymlinkDirMode determines how symlinks to directories are handled.
+type SymlinkDirMode int
+
+const (
+	// SymlinkDirFollow treats a symlink to a directory as the directory that
+	// it points to: it's reported as a directory and descended into. This is
+	// the default.
+	SymlinkDirFollow SymlinkDirMode = iota
+
+	// SymlinkDirAsDir reports a symlink to a directory as a directory (with
+	// the info of the directory that it points to) but doesn't descend into
+	// it.
+	SymlinkDirAsDir
+
+	// SymlinkDirAsFile reports a symlink to a directory as a single leaf
+	// entry, like a file, with the info of the symlink itself (so `IsDir()` is
+	// false and the mode has `os.ModeSymlink`). It's subject to the file
+	// filters and is delivered to the file callbacks.
+	SymlinkDirAsFile
+)
+
+// SetSymlinkDirMode sets how symlinks to directories below the root are
+// handled. The root itself is always followed. Any mode other than the
+// default requires an extra `lstat()` for every directory in order to tell
+// whether it's a symlink. Symlinks to anything other than directories are
+// unaffected and this has no effect for a custom `ChildLister` that
+// describes its own nodes or in names-only mode (which never follows
+// symlinks).
+func (walk *Walk) SetSymlinkDirMode(mode SymlinkDirMode) {
+	walk.symlinkDirMode = mode
+}
+
+// applySymlinkDirMode checks whether the given directory is a symlink and, if
+// so, returns how it's to be handled. `info` is the info that should be
+// dispatched: it's only a directory if it should be dispatched as a directory.
+// `skipChildren` is true if the directory shouldn't be descended into.
+func (walk *Walk) applySymlinkDirMode(fqPath string, dirInfo os.FileInfo) (info os.FileInfo, skipChildren bool) {
+	if walk.symlinkDirMode == SymlinkDirFollow {
+		return dirInfo, false
+	}
+
+	if _, ok := walk.childLister.(ChildStatter); ok == true {
+		return dirInfo, false
+	}
+
+	linfo, err := os.Lstat(fqPath)
+	if err != nil || linfo.Mode()&os.ModeSymlink == 0 {
+		return dirInfo, false
+	}
+
+	walk.statsLocker.Lock()
+	walk.stats.SymlinkedDirectoriesNotFollowed++
+	walk.statsLocker.Unlock()
+
+	if walk.symlinkDirMode == SymlinkDirAsFile {
+		return linfo, false
+	}
+
+	return dirInfo, true
+}
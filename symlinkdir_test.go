@@ -0,0 +1,114 @@
+package pathwalk
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+)
+
+// testSymlinkDirWalk walks a tree with a symlink ("link") to a directory
+// ("real") using the given mode and returns what was reported as directories
+// and as files.
+func testSymlinkDirWalk(mode SymlinkDirMode) (directories, files []string, linkMode os.FileMode, stats Stats) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	err = os.Mkdir(path.Join(tempPath, "real"), 0755)
+	log.PanicIf(err)
+
+	err = ioutil.WriteFile(path.Join(tempPath, "real", "file1"), []byte{}, 0644)
+	log.PanicIf(err)
+
+	err = os.Symlink(path.Join(tempPath, "real"), path.Join(tempPath, "link"))
+	log.PanicIf(err)
+
+	m := sync.Mutex{}
+	directories = make([]string, 0)
+	files = make([]string, 0)
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		relPath := path.Join(parentPath, info.Name())
+
+		if info.IsDir() == true {
+			directories = append(directories, relPath)
+		} else {
+			files = append(files, relPath)
+		}
+
+		if relPath == "link" {
+			linkMode = info.Mode()
+		}
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetPathStyle(PathStyleRelative)
+	walk.SetSymlinkDirMode(mode)
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	sort.Strings(directories)
+	sort.Strings(files)
+
+	return directories, files, linkMode, walk.Stats()
+}
+
+func TestWalk_SetSymlinkDirMode__follow(t *testing.T) {
+	directories, files, linkMode, stats := testSymlinkDirWalk(SymlinkDirFollow)
+
+	if reflect.DeepEqual(directories, []string{".", "link", "real"}) != true {
+		t.Fatalf("Directories not correct: %v", directories)
+	} else if reflect.DeepEqual(files, []string{"link/file1", "real/file1"}) != true {
+		t.Fatalf("Files not correct: %v", files)
+	} else if linkMode.IsDir() != true {
+		t.Fatalf("Symlink should have been reported with the mode of its target: (%s)", linkMode)
+	} else if stats.SymlinkedDirectoriesNotFollowed != 0 {
+		t.Fatalf("SymlinkedDirectoriesNotFollowed not correct: (%d)", stats.SymlinkedDirectoriesNotFollowed)
+	}
+}
+
+func TestWalk_SetSymlinkDirMode__asDir(t *testing.T) {
+	directories, files, linkMode, stats := testSymlinkDirWalk(SymlinkDirAsDir)
+
+	if reflect.DeepEqual(directories, []string{".", "link", "real"}) != true {
+		t.Fatalf("Directories not correct: %v", directories)
+	} else if reflect.DeepEqual(files, []string{"real/file1"}) != true {
+		t.Fatalf("Files not correct: %v", files)
+	} else if linkMode.IsDir() != true {
+		t.Fatalf("Symlink should have been reported with the mode of its target: (%s)", linkMode)
+	} else if stats.SymlinkedDirectoriesNotFollowed != 1 {
+		t.Fatalf("SymlinkedDirectoriesNotFollowed not correct: (%d)", stats.SymlinkedDirectoriesNotFollowed)
+	}
+}
+
+func TestWalk_SetSymlinkDirMode__asFile(t *testing.T) {
+	directories, files, linkMode, stats := testSymlinkDirWalk(SymlinkDirAsFile)
+
+	if reflect.DeepEqual(directories, []string{".", "real"}) != true {
+		t.Fatalf("Directories not correct: %v", directories)
+	} else if reflect.DeepEqual(files, []string{"link", "real/file1"}) != true {
+		t.Fatalf("Files not correct: %v", files)
+	} else if linkMode&os.ModeSymlink == 0 {
+		t.Fatalf("Symlink should have been reported with its own mode: (%s)", linkMode)
+	} else if stats.SymlinkedDirectoriesNotFollowed != 1 {
+		t.Fatalf("SymlinkedDirectoriesNotFollowed not correct: (%d)", stats.SymlinkedDirectoriesNotFollowed)
+	} else if stats.FilesVisited != 2 {
+		t.Fatalf("FilesVisited not correct: (%d)", stats.FilesVisited)
+	}
+}
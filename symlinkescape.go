@@ -0,0 +1,97 @@
+package pathwalk
+
+import (
+	"os"
+	"strings"
+
+	"path/filepath"
+
+	"github.com/dsoprea/go-logging"
+)
+
+// prepareResolvedRootPath determines the absolute root with any symlinks
+// resolved, for comparing against symlink targets.
+func (walk *Walk) prepareResolvedRootPath() (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	walk.resolvedRootPath = ""
+
+	if walk.filter.excludeSymlinkTargetsOutsideRoot == false {
+		return nil
+	}
+
+	resolvedRootPath, err := resolvePath(walk.rootPath)
+	log.PanicIf(err)
+
+	walk.resolvedRootPath = resolvedRootPath
+
+	return nil
+}
+
+// resolvePath returns the absolute form of the given path with all symlinks
+// resolved.
+func resolvePath(currentPath string) (resolvedPath string, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	resolvedPath, err = filepath.EvalSymlinks(currentPath)
+	log.PanicIf(err)
+
+	resolvedPath, err = filepath.Abs(resolvedPath)
+	log.PanicIf(err)
+
+	return filepath.ToSlash(resolvedPath), nil
+}
+
+// isPathUnderRoot returns whether the given path is the given root or below
+// it. Both must be clean.
+func isPathUnderRoot(currentPath, rootPath string) bool {
+	if currentPath == rootPath {
+		return true
+	}
+
+	rootPrefix := rootPath
+	if strings.HasSuffix(rootPrefix, "/") == false {
+		rootPrefix += "/"
+	}
+
+	return strings.HasPrefix(currentPath, rootPrefix)
+}
+
+// isSymlinkOutsideRoot returns true and updates the stats if the given entry
+// is a symlink whose target is outside of the root and these are being
+// excluded. The whole chain is resolved, so a symlink to a symlink inside the
+// root that in turn points outside of it is also caught. A target that can't
+// be resolved is treated as being outside.
+func (walk *Walk) isSymlinkOutsideRoot(fqPath string) bool {
+	if walk.filter.excludeSymlinkTargetsOutsideRoot == false {
+		return false
+	}
+
+	linfo, err := os.Lstat(fqPath)
+	if err != nil || linfo.Mode()&os.ModeSymlink == 0 {
+		return false
+	}
+
+	targetPath, err := resolvePath(fqPath)
+	if err == nil && isPathUnderRoot(targetPath, walk.resolvedRootPath) == true {
+		return false
+	}
+
+	walk.logDebugf("Symlink target outside of root: [%s] -> [%s]", fqPath, targetPath)
+
+	walk.notifySkip(fqPath, SkipSymlinkOutsideRoot)
+
+	walk.statsLocker.Lock()
+	walk.stats.SymlinksOutsideRoot++
+	walk.statsLocker.Unlock()
+
+	return true
+}
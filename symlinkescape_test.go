@@ -0,0 +1,180 @@
+package pathwalk
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+)
+
+// makeSymlinkEscapeTree creates a root with symlinks both within it and out of
+// it and returns the root.
+func makeSymlinkEscapeTree(tempPath string) (rootPath string) {
+	rootPath = path.Join(tempPath, "root")
+	outsidePath := path.Join(tempPath, "outside")
+
+	err := os.MkdirAll(path.Join(outsidePath, "dir"), 0755)
+	log.PanicIf(err)
+
+	err = ioutil.WriteFile(path.Join(outsidePath, "secret"), []byte{}, 0644)
+	log.PanicIf(err)
+
+	err = os.MkdirAll(path.Join(rootPath, "sub"), 0755)
+	log.PanicIf(err)
+
+	err = ioutil.WriteFile(path.Join(rootPath, "inside.txt"), []byte{}, 0644)
+	log.PanicIf(err)
+
+	symlinks := map[string]string{
+		"link-inside":       path.Join(rootPath, "inside.txt"),
+		"link-inside-rel":   "sub",
+		"link-outside-file": "../outside/secret",
+		"link-outside-dir":  path.Join(outsidePath, "dir"),
+
+		// This is inside the root but leads outside.
+		"link-chain": "link-outside-file",
+	}
+
+	for name, target := range symlinks {
+		err := os.Symlink(target, path.Join(rootPath, name))
+		log.PanicIf(err)
+	}
+
+	return rootPath
+}
+
+func testSymlinkEscapeWalk(rootPath string) (visited []string, skipped map[string]SkipReason, stats Stats) {
+	m := sync.Mutex{}
+	visited = make([]string, 0)
+	skipped = make(map[string]SkipReason)
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		visited = append(visited, path.Join(parentPath, info.Name()))
+
+		return nil
+	}
+
+	skipNotifyFunc := func(fqPath string, reason SkipReason) {
+		m.Lock()
+		defer m.Unlock()
+
+		skipped[path.Base(fqPath)] = reason
+	}
+
+	walk := NewWalk(rootPath, walkFunc)
+	walk.SetPathStyle(PathStyleRelative)
+	walk.SetSkipNotifyFunc(skipNotifyFunc)
+
+	filter := Filter{
+		ExcludeSymlinkTargetsOutsideRoot: true,
+	}
+
+	err := walk.SetFilter(filter)
+	log.PanicIf(err)
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	sort.Strings(visited)
+
+	return visited, skipped, walk.Stats()
+}
+
+func TestWalk_Run__excludeSymlinkTargetsOutsideRoot(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	rootPath := makeSymlinkEscapeTree(tempPath)
+
+	expectedVisited := []string{
+		".",
+		"inside.txt",
+		"link-inside",
+		"link-inside-rel",
+		"sub",
+	}
+
+	expectedSkipped := map[string]SkipReason{
+		"link-outside-file": SkipSymlinkOutsideRoot,
+		"link-outside-dir":  SkipSymlinkOutsideRoot,
+		"link-chain":        SkipSymlinkOutsideRoot,
+	}
+
+	visited, skipped, stats := testSymlinkEscapeWalk(rootPath)
+
+	if reflect.DeepEqual(visited, expectedVisited) != true {
+		t.Fatalf("Visited not correct: %v", visited)
+	} else if reflect.DeepEqual(skipped, expectedSkipped) != true {
+		t.Fatalf("Skipped not correct: %v", skipped)
+	} else if stats.SymlinksOutsideRoot != 3 {
+		t.Fatalf("SymlinksOutsideRoot not correct: (%d)", stats.SymlinksOutsideRoot)
+	}
+
+	// The root is resolved too, so walking it through a symlink doesn't make
+	// everything look like it's outside.
+
+	rootLinkPath := path.Join(tempPath, "root-link")
+
+	err = os.Symlink(rootPath, rootLinkPath)
+	log.PanicIf(err)
+
+	visited, skipped, stats = testSymlinkEscapeWalk(rootLinkPath)
+
+	if reflect.DeepEqual(visited, expectedVisited) != true {
+		t.Fatalf("Visited not correct via symlinked root: %v", visited)
+	} else if reflect.DeepEqual(skipped, expectedSkipped) != true {
+		t.Fatalf("Skipped not correct via symlinked root: %v", skipped)
+	} else if stats.SymlinksOutsideRoot != 3 {
+		t.Fatalf("SymlinksOutsideRoot not correct via symlinked root: (%d)", stats.SymlinksOutsideRoot)
+	}
+}
+
+func TestWalk_Run__excludeSymlinkTargetsOutsideRoot__namesOnly(t *testing.T) {
+	walk := NewWalk("/root", nil)
+	walk.SetNamesOnly(true)
+
+	filter := Filter{
+		ExcludeSymlinkTargetsOutsideRoot: true,
+	}
+
+	err := walk.SetFilter(filter)
+	log.PanicIf(err)
+
+	err = walk.Run()
+	if err == nil || log.Is(err, ErrNamesOnlyConflict) != true {
+		t.Fatalf("Expected conflict error: %v", err)
+	}
+}
+
+func TestIsPathUnderRoot(t *testing.T) {
+	testCases := []struct {
+		currentPath string
+		rootPath    string
+		expected    bool
+	}{
+		{"/a/b", "/a/b", true},
+		{"/a/b/c", "/a/b", true},
+		{"/a/bc", "/a/b", false},
+		{"/a", "/a/b", false},
+		{"/x", "/", true},
+	}
+
+	for _, testCase := range testCases {
+		if isPathUnderRoot(testCase.currentPath, testCase.rootPath) != testCase.expected {
+			t.Fatalf("Result not correct for [%s] under [%s].", testCase.currentPath, testCase.rootPath)
+		}
+	}
+}
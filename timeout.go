@@ -0,0 +1,206 @@
+package pathwalk
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrDirectoryTimedOut is returned internally when a directory took longer
+	// than the per-directory timeout to read.
+	ErrDirectoryTimedOut = errors.New("directory timed out")
+)
+
+// SetPerDirectoryTimeout bounds how long the enumeration of any one directory
+// may take before the rest of it is abandoned. This isolates slow subtrees
+// (e.g. a hung network mount) without failing the whole walk. The limit
+// applies to the total time spent reading the directory's entries and,
+// separately, to stat'ing each batch of its children, so time spent waiting
+// for a free worker doesn't count. Zero (the default) means no limit.
+//
+// A read that is hung can't actually be interrupted, so it will be left to
+// finish in the background. Results are not complete if any directory times
+// out, in which case the outcome will be `OutcomeTruncated` rather than
+// `OutcomeCompleted`.
+//
+// The background reads count against `SetMaxGoroutines()`. When there's no
+// room for one, the batch is read by the worker directly and that read can't
+// be cut short; the limit is then only enforced between batches (the rest of
+// the directory is skipped once a read has used up the time).
+func (walk *Walk) SetPerDirectoryTimeout(d time.Duration) {
+	walk.perDirectoryTimeout = d
+}
+
+// directoryDeadline returns the time at which work on a directory that is
+// starting now has to be finished by, or the zero time if there is no limit.
+func (walk *Walk) directoryDeadline() time.Time {
+	if walk.perDirectoryTimeout <= 0 {
+		return time.Time{}
+	}
+
+	return time.Now().Add(walk.perDirectoryTimeout)
+}
+
+// isPastDeadline returns true if the given deadline has passed.
+func isPastDeadline(deadline time.Time) bool {
+	return deadline.IsZero() == false && time.Now().After(deadline) == true
+}
+
+// listChildrenResult is the result of an asynchronous listChildren().
+type listChildrenResult struct {
+	names      []string
+	childIsDir []bool
+	hasMore    bool
+	err        error
+}
+
+// listChildrenWithTimeout is the same as listChildren() but gives up with
+// `ErrDirectoryTimedOut` if the read takes longer than the remaining time,
+// which is then reduced by however long the read took. The abandoned read
+// will release its listing state whenever it returns. If the goroutine limit
+// leaves no room for the read, it's done directly and can overrun the
+// remaining time, but the next read will then fail.
+func (walk *Walk) listChildrenWithTimeout(path string, batchSize int, remaining *time.Duration) (names []string, childIsDir []bool, hasMore bool, err error) {
+	if walk.perDirectoryTimeout <= 0 {
+		return walk.listChildren(path, batchSize)
+	}
+
+	if *remaining <= 0 {
+		walk.closeChildren(path)
+
+		return nil, nil, false, ErrDirectoryTimedOut
+	}
+
+	startedAt := time.Now()
+
+	defer func() {
+		*remaining -= time.Since(startedAt)
+	}()
+
+	if walk.acquireReaderGoroutine() == false {
+		// There's no room to read in the background, so the read can't be
+		// abandoned. Its time still counts against the directory, though.
+		return walk.listChildren(path, batchSize)
+	}
+
+	// Buffered so that the read never blocks on delivering its result.
+	resultC := make(chan listChildrenResult, 1)
+
+	// These are used to decide, exactly once, whether the result will be
+	// consumed or whether the read was abandoned.
+	var isDelivered, isAbandoned bool
+	m := sync.Mutex{}
+
+	go func() {
+		defer walk.releaseReaderGoroutine()
+
+		names, childIsDir, hasMore, err := walk.listChildren(path, batchSize)
+
+		m.Lock()
+		defer m.Unlock()
+
+		if isAbandoned == true {
+			// Nobody is waiting for this anymore. Release the listing if it
+			// wasn't already released by being exhausted or failing.
+			if err == nil && hasMore == true {
+				walk.closeChildren(path)
+			}
+
+			return
+		}
+
+		isDelivered = true
+
+		resultC <- listChildrenResult{
+			names:      names,
+			childIsDir: childIsDir,
+			hasMore:    hasMore,
+			err:        err,
+		}
+	}()
+
+	timer := time.NewTimer(*remaining)
+	defer timer.Stop()
+
+	select {
+	case result := <-resultC:
+		return result.names, result.childIsDir, result.hasMore, result.err
+	case <-timer.C:
+		m.Lock()
+
+		if isDelivered == true {
+			// The read finished just as we gave up.
+
+			m.Unlock()
+
+			result := <-resultC
+			return result.names, result.childIsDir, result.hasMore, result.err
+		}
+
+		isAbandoned = true
+		m.Unlock()
+
+		return nil, nil, false, ErrDirectoryTimedOut
+	}
+}
+
+// closeChildren abandons the listing of the given path if the lister supports
+// it.
+func (walk *Walk) closeChildren(path string) {
+	clc, ok := walk.childLister.(ChildListCloser)
+	if ok == false {
+		return
+	}
+
+	err := clc.CloseChildren(path)
+	if err != nil {
+		walk.logWarningf("could not close the listing of [%s]: [%s]", path, err.Error())
+	}
+}
+
+// recordTimedOutDirectory records a directory whose enumeration was abandoned
+// because it took too long. A directory is only counted once even if several
+// of its batches notice.
+func (walk *Walk) recordTimedOutDirectory(directoryPath string) {
+	walk.timedOutDirectoriesLocker.Lock()
+
+	if walk.timedOutDirectories == nil {
+		walk.timedOutDirectories = make(map[string]struct{})
+	}
+
+	_, found := walk.timedOutDirectories[directoryPath]
+	if found == false {
+		walk.timedOutDirectories[directoryPath] = struct{}{}
+	}
+
+	walk.timedOutDirectoriesLocker.Unlock()
+
+	if found == true {
+		return
+	}
+
+	walk.logWarningf("directory [%s] took longer than (%s) to read; the rest of it will be skipped", directoryPath, walk.perDirectoryTimeout)
+
+	walk.notifySkip(directoryPath, SkipTimedOut)
+
+	walk.statsLocker.Lock()
+	walk.stats.DirectoriesTimedOut++
+	walk.statsLocker.Unlock()
+
+	walk.counterLocker.Lock()
+	walk.isTruncated = true
+	walk.counterLocker.Unlock()
+}
+
+// checkDirectoryDeadline records the directory as timed-out if the deadline
+// has passed and returns true.
+func (walk *Walk) checkDirectoryDeadline(directoryPath string, deadline time.Time) bool {
+	if isPastDeadline(deadline) == false {
+		return false
+	}
+
+	walk.recordTimedOutDirectory(directoryPath)
+
+	return true
+}
@@ -0,0 +1,260 @@
+package pathwalk
+
+import (
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-parallel-walker/internal/testing"
+)
+
+// hangingChildLister hangs when listing one particular path until released.
+type hangingChildLister struct {
+	*filesystemChildLister
+
+	hangingPath string
+	releaseC    chan struct{}
+}
+
+func (hcl *hangingChildLister) ListChildren(path string, batchSize int) (names []string, hasMore bool, err error) {
+	if path == hcl.hangingPath {
+		<-hcl.releaseC
+	}
+
+	return hcl.filesystemChildLister.ListChildren(path, batchSize)
+}
+
+// slowChildStatter takes a while to stat anything.
+type slowChildStatter struct {
+	*filesystemChildLister
+
+	delay time.Duration
+}
+
+func (scs *slowChildStatter) StatChild(path string) (info os.FileInfo, err error) {
+	time.Sleep(scs.delay)
+
+	return os.Stat(path)
+}
+
+func TestWalk_SetPerDirectoryTimeout__hungRead(t *testing.T) {
+	tempPath, _ := pwtesting.FillFlatTempPath(10, []string{"fast"})
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	slowPath := path.Join(tempPath, "slow")
+
+	err := os.Mkdir(slowPath, 0755)
+	log.PanicIf(err)
+
+	f, err := os.Create(path.Join(slowPath, "never-seen"))
+	log.PanicIf(err)
+
+	f.Close()
+
+	m := sync.Mutex{}
+	files := make([]string, 0)
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		if info.IsDir() == false {
+			files = append(files, path.Join(parentPath, info.Name()))
+		}
+
+		return nil
+	}
+
+	hcl := &hangingChildLister{
+		filesystemChildLister: newFilesystemChildLister(),
+		hangingPath:           slowPath,
+		releaseC:              make(chan struct{}),
+	}
+
+	defer close(hcl.releaseC)
+
+	skipped := make(map[string]SkipReason)
+
+	skipNotifyFunc := func(path string, reason SkipReason) {
+		m.Lock()
+		defer m.Unlock()
+
+		skipped[path] = reason
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetChildLister(hcl)
+	walk.SetSkipNotifyFunc(skipNotifyFunc)
+	walk.SetPerDirectoryTimeout(time.Millisecond * 50)
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	stats := walk.Stats()
+
+	if stats.DirectoriesTimedOut != 1 {
+		t.Fatalf("DirectoriesTimedOut not correct: (%d)", stats.DirectoriesTimedOut)
+	} else if len(files) != 10 {
+		t.Fatalf("The rest of the walk was not completed: %v", files)
+	} else if walk.Outcome() != OutcomeTruncated {
+		t.Fatalf("Outcome not correct: [%s]", walk.Outcome())
+	} else if skipped[slowPath] != SkipTimedOut {
+		t.Fatalf("Skip notification not correct: %v", skipped)
+	}
+
+	for _, filepath := range files {
+		if path.Dir(filepath) == slowPath {
+			t.Fatalf("File in timed-out directory was visited: [%s]", filepath)
+		}
+	}
+}
+
+func TestWalk_SetPerDirectoryTimeout__slowStats(t *testing.T) {
+	fileCount := 30
+	tempPath, _ := pwtesting.FillFlatTempPath(fileCount, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	scs := &slowChildStatter{
+		filesystemChildLister: newFilesystemChildLister(),
+		delay:                 time.Millisecond * 10,
+	}
+
+	walk := NewWalk(tempPath, nil)
+	walk.SetChildLister(scs)
+	walk.SetPerDirectoryTimeout(time.Millisecond * 50)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	stats := walk.Stats()
+
+	if stats.DirectoriesTimedOut != 1 {
+		t.Fatalf("DirectoriesTimedOut not correct: (%d)", stats.DirectoriesTimedOut)
+	} else if stats.FilesVisited >= fileCount {
+		t.Fatalf("Expected the directory to be cut short: (%d)", stats.FilesVisited)
+	} else if walk.Outcome() != OutcomeTruncated {
+		t.Fatalf("Outcome not correct: [%s]", walk.Outcome())
+	}
+}
+
+func TestWalk_SetPerDirectoryTimeout__notExceeded(t *testing.T) {
+	tempPath, tempFiles := pwtesting.FillHeirarchicalTempPath(50, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walk := NewWalk(tempPath, nil)
+	walk.SetPerDirectoryTimeout(time.Minute)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	stats := walk.Stats()
+
+	if stats.DirectoriesTimedOut != 0 {
+		t.Fatalf("DirectoriesTimedOut not correct: (%d)", stats.DirectoriesTimedOut)
+	} else if stats.FilesVisited != len(tempFiles) {
+		t.Fatalf("FilesVisited not correct: (%d) != (%d)", stats.FilesVisited, len(tempFiles))
+	} else if walk.Outcome() != OutcomeCompleted {
+		t.Fatalf("Outcome not correct: [%s]", walk.Outcome())
+	}
+
+	fcl := walk.childLister.(*filesystemChildLister)
+	if len(fcl.openDirectories) != 0 {
+		t.Fatalf("A directory was left open.")
+	}
+}
+
+func TestWalk_listChildrenWithTimeout__exhausted(t *testing.T) {
+	tempPath, _ := pwtesting.FillFlatTempPath(10, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walk := NewWalk(tempPath, nil)
+	walk.SetPerDirectoryTimeout(time.Minute)
+
+	remaining := time.Minute
+
+	names, _, hasMore, err := walk.listChildrenWithTimeout(tempPath, 5, &remaining)
+	log.PanicIf(err)
+
+	if len(names) != 5 || hasMore != true {
+		t.Fatalf("First read not correct: (%d) [%v]", len(names), hasMore)
+	}
+
+	// With no time left, the listing is abandoned without reading.
+
+	remaining = 0
+
+	_, _, _, err = walk.listChildrenWithTimeout(tempPath, 5, &remaining)
+	if err != ErrDirectoryTimedOut {
+		t.Fatalf("Expected timeout: [%v]", err)
+	}
+
+	fcl := walk.childLister.(*filesystemChildLister)
+	if len(fcl.openDirectories) != 0 {
+		t.Fatalf("Abandoned directory was left open.")
+	}
+}
+
+func TestWalk_listChildrenWithTimeout__noGoroutineRoom(t *testing.T) {
+	tempPath, _ := pwtesting.FillFlatTempPath(10, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walk := NewWalk(tempPath, nil)
+	walk.SetPerDirectoryTimeout(time.Minute)
+
+	scl := &sampledChildLister{
+		filesystemChildLister: newFilesystemChildLister(),
+		sample:                func() {},
+	}
+
+	walk.SetChildLister(scl)
+
+	// Leave no room for a background read.
+
+	walk.workerGoroutineLimit = 1
+	walk.workerCount = 1
+
+	// The read takes longer than this but can't be abandoned, so it finishes.
+
+	remaining := time.Millisecond
+
+	names, _, hasMore, err := walk.listChildrenWithTimeout(tempPath, 5, &remaining)
+	log.PanicIf(err)
+
+	if len(names) != 5 || hasMore != true {
+		t.Fatalf("First read not correct: (%d) [%v]", len(names), hasMore)
+	} else if remaining > 0 {
+		t.Fatalf("Time spent reading was not counted: (%s)", remaining)
+	}
+
+	// The next read isn't attempted.
+
+	_, _, _, err = walk.listChildrenWithTimeout(tempPath, 5, &remaining)
+	if err != ErrDirectoryTimedOut {
+		t.Fatalf("Expected timeout: [%v]", err)
+	}
+
+	if len(scl.openDirectories) != 0 {
+		t.Fatalf("Abandoned directory was left open.")
+	} else if walk.readerGoroutineCount != 0 {
+		t.Fatalf("A background read was started: (%d)", walk.readerGoroutineCount)
+	}
+}
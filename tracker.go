@@ -0,0 +1,256 @@
+package pathwalk
+
+import (
+	"path"
+	"sort"
+	"sync"
+)
+
+// trackedDirectory is the outstanding work for one directory.
+type trackedDirectory struct {
+	// parentPath is the full-path of the parent directory.
+	parentPath string
+
+	// pendingJobs is the number of jobs belonging to this directory (its own
+	// node, its batches, and its files) that haven't completed.
+	pendingJobs int
+
+	// pendingEnumerationJobs is the number of jobs belonging to this
+	// directory (its own node and its batches) that haven't completed. Once
+	// this reaches zero, all of its children have been dispatched.
+	pendingEnumerationJobs int
+
+	// pendingChildDirectories is the number of child directories that have
+	// been dispatched but haven't completed.
+	pendingChildDirectories int
+
+	// isVisited indicates that the directory node itself has been processed
+	// (the callback has been called for it).
+	isVisited bool
+
+	// childCount is the number of entries that have been listed for the
+	// directory.
+	childCount int
+
+	// descendantCount is the number of entries below the directory's
+	// completed child directories.
+	descendantCount int
+
+	// completedChildren are the names of the files and child directories that
+	// have completed. This is only populated if the tracker is recording them.
+	completedChildren map[string]struct{}
+}
+
+// addCompletedChild records that the given child has completed.
+func (td *trackedDirectory) addCompletedChild(name string) {
+	if td.completedChildren == nil {
+		td.completedChildren = make(map[string]struct{})
+	}
+
+	td.completedChildren[name] = struct{}{}
+}
+
+// completedDirectory is a directory that has no more outstanding work but
+// that hasn't yet been counted as done by its parent.
+type completedDirectory struct {
+	summary    DirectorySummary
+	parentPath string
+}
+
+// directoryTracker accounts for the outstanding work of every directory that
+// has been dispatched but hasn't completed. A directory completes once all of
+// its own jobs and all of its child directories have completed and been
+// released.
+//
+// Jobs are registered when they are pushed, which always happens before the
+// job that produced them completes, so the tracked state is consistent at any
+// point that the locker is held.
+type directoryTracker struct {
+	directories map[string]*trackedDirectory
+	locker      sync.Mutex
+
+	// doRecordCompletedChildren indicates that the names of completed
+	// children should be kept until their directory completes so that they
+	// can be skipped when resuming.
+	doRecordCompletedChildren bool
+
+	// areFilesCompletedWithBatches indicates that files are delivered by the
+	// batch job that lists them rather than by their own jobs.
+	areFilesCompletedWithBatches bool
+}
+
+func newDirectoryTracker() *directoryTracker {
+	return &directoryTracker{
+		directories: make(map[string]*trackedDirectory),
+	}
+}
+
+// jobDirectoryPath returns the full-path of the directory that the given job
+// belongs to.
+func jobDirectoryPath(j job) string {
+	if jdn, ok := j.(jobDirectoryNode); ok == true {
+		return path.Join(jdn.ParentNodePath(), jdn.Info().Name())
+	}
+
+	return j.ParentNodePath()
+}
+
+// JobQueued registers a job that was just pushed.
+func (dt *directoryTracker) JobQueued(j job) {
+	dt.locker.Lock()
+	defer dt.locker.Unlock()
+
+	directoryPath := jobDirectoryPath(j)
+
+	if jdn, ok := j.(jobDirectoryNode); ok == true {
+		td := &trackedDirectory{
+			parentPath:             jdn.ParentNodePath(),
+			pendingJobs:            1,
+			pendingEnumerationJobs: 1,
+		}
+
+		dt.directories[directoryPath] = td
+
+		if parent, found := dt.directories[td.parentPath]; found == true {
+			parent.pendingChildDirectories++
+		}
+
+		return
+	}
+
+	td, found := dt.directories[directoryPath]
+	if found == false {
+		// This is a job for a directory that we're not tracking, which
+		// should only happen if jobs are being driven manually.
+		return
+	}
+
+	td.pendingJobs++
+
+	if jdcb, ok := j.(jobDirectoryContentsBatch); ok == true {
+		td.pendingEnumerationJobs++
+		td.childCount += len(jdcb.ChildBatch())
+	}
+}
+
+// JobCompleted registers that a job was successfully processed. It returns the
+// directory that was completed as a result, if any. Its parent isn't told
+// until `DirectoryReleased()` is called for it, so that the parent can't be
+// completed (and delivered) by another worker before the child has been.
+func (dt *directoryTracker) JobCompleted(j job) (completed *completedDirectory) {
+	dt.locker.Lock()
+	defer dt.locker.Unlock()
+
+	directoryPath := jobDirectoryPath(j)
+
+	td, found := dt.directories[directoryPath]
+	if found == false {
+		return nil
+	}
+
+	td.pendingJobs--
+
+	switch t := j.(type) {
+	case jobDirectoryNode:
+		td.pendingEnumerationJobs--
+		td.isVisited = true
+	case jobDirectoryContentsBatch:
+		td.pendingEnumerationJobs--
+
+		if dt.doRecordCompletedChildren == true && dt.areFilesCompletedWithBatches == true {
+			// Any subdirectories in the batch were dispatched rather than
+			// completed, but they're either in the checkpoint (and skipped on
+			// resume) or will be recorded once they complete, either of which
+			// is equivalent.
+			for _, name := range t.ChildBatch() {
+				td.addCompletedChild(name)
+			}
+		}
+	case jobFileNode:
+		if dt.doRecordCompletedChildren == true {
+			td.addCompletedChild(t.Info().Name())
+		}
+	case jobSpecialNode:
+		if dt.doRecordCompletedChildren == true {
+			td.addCompletedChild(t.Info().Name())
+		}
+	}
+
+	return dt.checkCompleted(directoryPath, td)
+}
+
+// DirectoryReleased registers that a completed directory has been fully
+// handled by its worker and can now be counted as done by its parent. It
+// returns the parent if that completed it.
+func (dt *directoryTracker) DirectoryReleased(cd completedDirectory) (completed *completedDirectory) {
+	dt.locker.Lock()
+	defer dt.locker.Unlock()
+
+	parent, found := dt.directories[cd.parentPath]
+	if found == false {
+		return nil
+	}
+
+	parent.pendingChildDirectories--
+	parent.descendantCount += cd.summary.DescendantCount
+
+	if dt.doRecordCompletedChildren == true {
+		parent.addCompletedChild(path.Base(cd.summary.Path))
+	}
+
+	return dt.checkCompleted(cd.parentPath, parent)
+}
+
+// checkCompleted forgets the given directory if it has no more outstanding
+// work. The locker must be held.
+func (dt *directoryTracker) checkCompleted(directoryPath string, td *trackedDirectory) (completed *completedDirectory) {
+	if td.pendingJobs > 0 || td.pendingChildDirectories > 0 {
+		return nil
+	}
+
+	delete(dt.directories, directoryPath)
+
+	completed = &completedDirectory{
+		summary: DirectorySummary{
+			Path:            directoryPath,
+			ChildCount:      td.childCount,
+			DescendantCount: td.childCount + td.descendantCount,
+		},
+		parentPath: td.parentPath,
+	}
+
+	return completed
+}
+
+// Snapshot returns the state of all incomplete directories, sorted by path.
+func (dt *directoryTracker) Snapshot() []checkpointDirectory {
+	dt.locker.Lock()
+	defer dt.locker.Unlock()
+
+	directories := make([]checkpointDirectory, 0, len(dt.directories))
+	for directoryPath, td := range dt.directories {
+		cd := checkpointDirectory{
+			Path:           directoryPath,
+			IsVisited:      td.isVisited,
+			IsEnumerated:   td.pendingEnumerationJobs <= 0,
+			HasPendingWork: td.pendingJobs > 0,
+		}
+
+		if len(td.completedChildren) > 0 {
+			cd.CompletedChildren = make([]string, 0, len(td.completedChildren))
+			for name := range td.completedChildren {
+				cd.CompletedChildren = append(cd.CompletedChildren, name)
+			}
+
+			sort.Strings(cd.CompletedChildren)
+		}
+
+		directories = append(directories, cd)
+	}
+
+	sort.Slice(directories, func(i, j int) bool {
+		return directories[i].Path < directories[j].Path
+	})
+
+	return directories
+}
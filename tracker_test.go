@@ -0,0 +1,102 @@
+package pathwalk
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dsoprea/go-utility/filesystem"
+)
+
+func TestDirectoryTracker(t *testing.T) {
+	dt := newDirectoryTracker()
+	dt.doRecordCompletedChildren = true
+
+	rootInfo := rifs.NewSimpleFileInfoWithDirectory("root", time.Time{})
+	rootJob := newJobDirectoryNode("/parent", rootInfo)
+
+	dt.JobQueued(rootJob)
+
+	// The root dispatches one batch with one file and one subdirectory.
+
+	batchJob := newJobDirectoryContentsBatch("/parent/root", 0, []string{"file", "child"}, true)
+	dt.JobQueued(batchJob)
+
+	dt.JobCompleted(rootJob)
+
+	fileInfo := rifs.NewSimpleFileInfoWithFile("file", 0, 0, time.Time{})
+	fileJob := newJobFileNode("/parent/root", fileInfo)
+	dt.JobQueued(fileJob)
+
+	childInfo := rifs.NewSimpleFileInfoWithDirectory("child", time.Time{})
+	childJob := newJobDirectoryNode("/parent/root", childInfo)
+	dt.JobQueued(childJob)
+
+	dt.JobCompleted(batchJob)
+
+	snapshot := dt.Snapshot()
+
+	expected := []checkpointDirectory{
+		{Path: "/parent/root", IsVisited: true, IsEnumerated: true, HasPendingWork: true},
+		{Path: "/parent/root/child", IsVisited: false, IsEnumerated: false, HasPendingWork: true},
+	}
+
+	if reflect.DeepEqual(snapshot, expected) != true {
+		t.Fatalf("Snapshot not correct (1): %v", snapshot)
+	}
+
+	// The file completes. The root is now only waiting on the child.
+
+	dt.JobCompleted(fileJob)
+
+	snapshot = dt.Snapshot()
+
+	if len(snapshot) != 2 || snapshot[0].HasPendingWork != false {
+		t.Fatalf("Snapshot not correct (2): %v", snapshot)
+	} else if reflect.DeepEqual(snapshot[0].CompletedChildren, []string{"file"}) != true {
+		t.Fatalf("Completed children not correct: %v", snapshot[0].CompletedChildren)
+	}
+
+	// The (empty) child completes. The root is still waiting on it until it's
+	// released.
+
+	completed := dt.JobCompleted(childJob)
+
+	expectedCompleted := &completedDirectory{
+		summary:    DirectorySummary{Path: "/parent/root/child", ChildCount: 0, DescendantCount: 0},
+		parentPath: "/parent/root",
+	}
+
+	if reflect.DeepEqual(completed, expectedCompleted) != true {
+		t.Fatalf("Completed child not correct: %v", completed)
+	}
+
+	snapshot = dt.Snapshot()
+
+	if len(snapshot) != 1 || snapshot[0].Path != "/parent/root" {
+		t.Fatalf("Snapshot not correct (3): %v", snapshot)
+	}
+
+	// Releasing the child completes the root.
+
+	completed = dt.DirectoryReleased(*completed)
+
+	expectedCompleted = &completedDirectory{
+		summary:    DirectorySummary{Path: "/parent/root", ChildCount: 2, DescendantCount: 2},
+		parentPath: "/parent",
+	}
+
+	if reflect.DeepEqual(completed, expectedCompleted) != true {
+		t.Fatalf("Completed root not correct: %v", completed)
+	}
+
+	if dt.DirectoryReleased(*completed) != nil {
+		t.Fatalf("Expected nothing above the root.")
+	}
+
+	snapshot = dt.Snapshot()
+
+	if len(snapshot) != 0 {
+		t.Fatalf("Snapshot not correct (4): %v", snapshot)
+	}
+}
@@ -0,0 +1,27 @@
+//go:build go1.16
+// +build go1.16
+
+package pathwalk
+
+import (
+	"os"
+)
+
+// readTypedNames reads up to `n` entries from the given directory. The types
+// come from the directory listing itself.
+func readTypedNames(f *os.File, n int) (children []typedName, err error) {
+	entries, err := f.ReadDir(n)
+	if err != nil {
+		return nil, err
+	}
+
+	children = make([]typedName, len(entries))
+	for i, entry := range entries {
+		children[i] = typedName{
+			name:  entry.Name(),
+			isDir: entry.IsDir(),
+		}
+	}
+
+	return children, nil
+}
@@ -0,0 +1,28 @@
+//go:build !go1.16
+// +build !go1.16
+
+package pathwalk
+
+import (
+	"os"
+)
+
+// readTypedNames reads up to `n` entries from the given directory. Before Go
+// 1.16, the types can only be had by lstat'ing every entry, which is still
+// cheaper than a separate stat for each.
+func readTypedNames(f *os.File, n int) (children []typedName, err error) {
+	infos, err := f.Readdir(n)
+	if err != nil {
+		return nil, err
+	}
+
+	children = make([]typedName, len(infos))
+	for i, info := range infos {
+		children[i] = typedName{
+			name:  info.Name(),
+			isDir: info.IsDir(),
+		}
+	}
+
+	return children, nil
+}
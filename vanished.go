@@ -0,0 +1,40 @@
+package pathwalk
+
+import (
+	"os"
+)
+
+// SetToleratePostEnumerationChanges supports callbacks that change the tree
+// while it's being walked (e.g. a cleanup pass that deletes or moves what it
+// visits). Normally, an entry that was listed but no longer exists by the time
+// it's stat'd (or a directory that no longer exists by the time it's read) is
+// warned about and counted as an error. With this set, those are silently
+// skipped and counted in `Stats().VanishedEntries` instead. Other failures are
+// still handled as usual.
+//
+// This is inherently racy: whether a given change is observed depends on
+// where the workers are at the time. An entry that the callback removes may
+// or may not have been delivered already, and an entry that's added may or
+// may not be visited. Nothing is guaranteed beyond the walk not complaining
+// about what went missing.
+func (walk *Walk) SetToleratePostEnumerationChanges(isTolerated bool) {
+	walk.isToleratePostEnumerationChanges = isTolerated
+}
+
+// isVanishedEntry returns true and updates the stats if the given error means
+// that the entry no longer exists and such changes are tolerated.
+func (walk *Walk) isVanishedEntry(fqPath string, err error) bool {
+	if walk.isToleratePostEnumerationChanges == false || os.IsNotExist(err) == false {
+		return false
+	}
+
+	walk.logDebugf("Entry vanished after enumeration: [%s]", fqPath)
+
+	walk.notifySkip(fqPath, SkipVanished)
+
+	walk.statsLocker.Lock()
+	walk.stats.VanishedEntries++
+	walk.statsLocker.Unlock()
+
+	return true
+}
@@ -0,0 +1,139 @@
+package pathwalk
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+)
+
+// testVanishingChildLister lists the filesystem but removes the "gone*"
+// entries of every batch after they're listed (as a concurrent callback
+// would).
+type testVanishingChildLister struct {
+	*filesystemChildLister
+}
+
+func (tvcl testVanishingChildLister) ListChildren(directoryPath string, batchSize int) (names []string, hasMore bool, err error) {
+	names, hasMore, err = tvcl.filesystemChildLister.ListChildren(directoryPath, batchSize)
+	if err != nil {
+		return nil, false, err
+	}
+
+	for _, name := range names {
+		if strings.HasPrefix(name, "gone") == true {
+			err := os.RemoveAll(path.Join(directoryPath, name))
+			log.PanicIf(err)
+		}
+	}
+
+	return names, hasMore, nil
+}
+
+func testVanishingWalk(isTolerated bool) (visited []string, stats Stats) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	relFilepaths := []string{
+		"kept1",
+		"gone1",
+		"gone2",
+		"doomed/kept2",
+		"subdir/kept3",
+		"subdir/gone3",
+	}
+
+	for _, relFilepath := range relFilepaths {
+		fqFilepath := path.Join(tempPath, relFilepath)
+
+		err := os.MkdirAll(path.Dir(fqFilepath), 0755)
+		log.PanicIf(err)
+
+		err = ioutil.WriteFile(fqFilepath, []byte{}, 0644)
+		log.PanicIf(err)
+	}
+
+	m := sync.Mutex{}
+	visited = make([]string, 0)
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		relPath := path.Join(parentPath, info.Name())
+		visited = append(visited, relPath)
+
+		// The callback removes the directory before it's read.
+		if relPath == "doomed" {
+			err := os.RemoveAll(path.Join(tempPath, relPath))
+			log.PanicIf(err)
+		}
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetPathStyle(PathStyleRelative)
+	walk.SetChildLister(testVanishingChildLister{newFilesystemChildLister()})
+	walk.SetToleratePostEnumerationChanges(isTolerated)
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	sort.Strings(visited)
+
+	return visited, walk.Stats()
+}
+
+func TestWalk_SetToleratePostEnumerationChanges(t *testing.T) {
+	visited, stats := testVanishingWalk(true)
+
+	expected := []string{
+		".",
+		"doomed",
+		"kept1",
+		"subdir",
+		"subdir/kept3",
+	}
+
+	if reflect.DeepEqual(visited, expected) != true {
+		t.Fatalf("Visited entries not correct: %v", visited)
+	} else if stats.VanishedEntries != 4 {
+		t.Fatalf("VanishedEntries not correct: (%d)", stats.VanishedEntries)
+	} else if stats.SkippedEntries != 0 {
+		t.Fatalf("SkippedEntries not correct: (%d)", stats.SkippedEntries)
+	} else if stats.DirectoriesWithErrors != 0 {
+		t.Fatalf("DirectoriesWithErrors not correct: (%d)", stats.DirectoriesWithErrors)
+	}
+}
+
+func TestWalk_SetToleratePostEnumerationChanges__notTolerated(t *testing.T) {
+	visited, stats := testVanishingWalk(false)
+
+	expected := []string{
+		".",
+		"doomed",
+		"kept1",
+		"subdir",
+		"subdir/kept3",
+	}
+
+	if reflect.DeepEqual(visited, expected) != true {
+		t.Fatalf("Visited entries not correct: %v", visited)
+	} else if stats.VanishedEntries != 0 {
+		t.Fatalf("VanishedEntries not correct: (%d)", stats.VanishedEntries)
+	} else if stats.SkippedEntries != 4 {
+		t.Fatalf("SkippedEntries not correct: (%d)", stats.SkippedEntries)
+	}
+}
@@ -0,0 +1,76 @@
+package pathwalk
+
+// AddVisitor registers an additional callback to receive every entry so that
+// several independent processors can share one walk. This must be called
+// before `Run()`.
+//
+// For each entry, the callback given to `NewWalk()` (if not nil) is called
+// first and then the visitors are called in the order that they were added,
+// one after the other, from the same goroutine. Different entries are still
+// processed in parallel.
+//
+// If any callback returns `ErrSkipDirectory` for a directory, the remaining
+// callbacks still see that directory but none of them will see its contents.
+// Any other error stops the remaining callbacks from being called for that
+// entry and is then handled according to the callback error policy (by
+// default, the walk is terminated).
+//
+// If a batch callback was set, files are only delivered to it and not to the
+// visitors.
+func (walk *Walk) AddVisitor(walkFunc WalkFunc) {
+	walk.visitors = append(walk.visitors, walkFunc)
+}
+
+// callVisitors calls the counting callback, the primary callback, the
+// additional visitors, and then the entry callback for one entry.
+func (walk *Walk) callVisitors(entry WalkEntry) (err error) {
+	parentNodePath := entry.ParentPath
+	info := entry.Info
+
+	if walk.countingWalkFunc != nil {
+		err = walk.callCountingWalkFunc(parentNodePath, info)
+		if err != nil && err != ErrSkipDirectory {
+			return err
+		}
+	}
+
+	primaryWalkFunc := walk.walkFunc
+	if walk.bottomUpWalkFunc != nil {
+		primaryWalkFunc = walk.bottomUpWalkFunc
+	} else if walk.runWalkFunc != nil {
+		primaryWalkFunc = walk.runWalkFunc
+	}
+
+	if primaryWalkFunc != nil {
+		walkFuncErr := walk.callVisitorSafely(primaryWalkFunc, parentNodePath, info)
+		if walkFuncErr == ErrSkipDirectory {
+			err = walkFuncErr
+		} else if walkFuncErr != nil {
+			return walkFuncErr
+		}
+	}
+
+	for _, walkFunc := range walk.visitors {
+		visitorErr := walk.callVisitorSafely(walkFunc, parentNodePath, info)
+		if visitorErr == nil {
+			continue
+		} else if visitorErr != ErrSkipDirectory {
+			return visitorErr
+		}
+
+		err = visitorErr
+	}
+
+	if walk.walkEntryFunc != nil {
+		entryErr := walk.callWalkEntryFunc(entry)
+		if entryErr != nil {
+			return entryErr
+		}
+	}
+
+	if walk.iterator != nil {
+		walk.iterator.push(entry)
+	}
+
+	return err
+}
@@ -0,0 +1,115 @@
+package pathwalk
+
+import (
+	"os"
+	"path"
+	"sync"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-parallel-walker/internal/testing"
+)
+
+func TestWalk_AddVisitor(t *testing.T) {
+	fileCount := 50
+	tempPath, tempFiles := pwtesting.FillHeirarchicalTempPath(fileCount, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	m := sync.Mutex{}
+	seen := make(map[string][]int)
+
+	getWalkFunc := func(id int) WalkFunc {
+		return func(parentPath string, info os.FileInfo) (err error) {
+			m.Lock()
+			defer m.Unlock()
+
+			if info.IsDir() == true {
+				return nil
+			}
+
+			relFilepath := path.Join(parentPath, info.Name())[len(tempPath)+1:]
+			seen[relFilepath] = append(seen[relFilepath], id)
+
+			return nil
+		}
+	}
+
+	walk := NewWalk(tempPath, getWalkFunc(0))
+	walk.AddVisitor(getWalkFunc(1))
+	walk.AddVisitor(getWalkFunc(2))
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	if len(seen) != len(tempFiles) {
+		t.Fatalf("Visited files not correct: (%d) != (%d)", len(seen), len(tempFiles))
+	}
+
+	for _, relFilepath := range tempFiles {
+		ids := seen[relFilepath]
+		if len(ids) != 3 || ids[0] != 0 || ids[1] != 1 || ids[2] != 2 {
+			t.Fatalf("Visitors not called in order for [%s]: %v", relFilepath, ids)
+		}
+	}
+}
+
+func TestWalk_AddVisitor__skipDirectory(t *testing.T) {
+	tempPath, _ := pwtesting.FillHeirarchicalTempPath(50, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	m := sync.Mutex{}
+	directories := 0
+	files := 0
+
+	skipFunc := func(parentPath string, info os.FileInfo) (err error) {
+		if info.IsDir() == true && parentPath == path.Dir(tempPath) {
+			return nil
+		} else if info.IsDir() == true {
+			return ErrSkipDirectory
+		}
+
+		return nil
+	}
+
+	countFunc := func(parentPath string, info os.FileInfo) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		if info.IsDir() == true {
+			directories++
+		} else {
+			files++
+		}
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, nil)
+	walk.AddVisitor(skipFunc)
+	walk.AddVisitor(countFunc)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	// The later visitor still sees the skipped directories themselves but
+	// nothing below them.
+
+	if directories < 2 {
+		t.Fatalf("Skipped directories not delivered to the other visitor: (%d)", directories)
+	} else if directories-1 != walk.Stats().DirectoriesIgnored {
+		t.Fatalf("DirectoriesIgnored not correct: (%d) (%d)", directories, walk.Stats().DirectoriesIgnored)
+	}
+
+	// All of the files are in subdirectories.
+
+	if files != 0 {
+		t.Fatalf("Files below skipped directories were visited: (%d)", files)
+	}
+}
@@ -0,0 +1,57 @@
+package pathwalk
+
+import (
+	"sync/atomic"
+)
+
+// runState tracks the completion of one run.
+type runState struct {
+	doneC chan struct{}
+
+	// err is the result of the run. It's only valid once doneC is closed.
+	err error
+}
+
+// Wait blocks until the current run completes and returns the same error that
+// `Run()` returned. This allows a walk to be started in another goroutine,
+// other work to be done, and the walk to be waited on later. As with `Run()`,
+// all workers will have quit by the time this returns. Any number of callers
+// may wait. If the walk has already finished, this returns the result
+// of the last run immediately. If the walk was never run, this returns nil
+// immediately, so this should only be called once the run has been started.
+func (walk *Walk) Wait() (err error) {
+	walk.stateLocker.Lock()
+	rs := walk.runState
+	walk.stateLocker.Unlock()
+
+	if rs == nil {
+		return nil
+	}
+
+	<-rs.doneC
+
+	return rs.err
+}
+
+// beginRun records that a run has started.
+func (walk *Walk) beginRun() *runState {
+	walk.stateLocker.Lock()
+	defer walk.stateLocker.Unlock()
+
+	walk.runState = &runState{
+		doneC: make(chan struct{}),
+	}
+
+	return walk.runState
+}
+
+// finishRun records the result of the given run, allows the walk to be run
+// again, and releases any waiters. The walk is released first so that a
+// waiter can immediately run it again.
+func (walk *Walk) finishRun(rs *runState, err error) {
+	rs.err = err
+
+	atomic.StoreInt32(&walk.isRunActive, 0)
+
+	close(rs.doneC)
+}
@@ -0,0 +1,109 @@
+package pathwalk
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-parallel-walker/internal/testing"
+)
+
+func TestWalk_Wait__notRun(t *testing.T) {
+	walk := NewWalk("", nil)
+
+	err := walk.Wait()
+	if err != nil {
+		t.Fatalf("Expected no error: [%v]", err)
+	}
+}
+
+func TestWalk_Wait(t *testing.T) {
+	tempPath, _ := pwtesting.FillFlatTempPath(10, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	releaseC := make(chan struct{})
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		<-releaseC
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+
+	runErrC := make(chan error, 1)
+
+	go func() {
+		runErrC <- walk.Run()
+	}()
+
+	for walk.IsRunning() == false {
+		time.Sleep(time.Millisecond)
+	}
+
+	waitErrC := make(chan error, 1)
+
+	go func() {
+		waitErrC <- walk.Wait()
+	}()
+
+	select {
+	case <-waitErrC:
+		t.Fatalf("Wait() returned before the run completed.")
+	case <-time.After(time.Millisecond * 100):
+	}
+
+	close(releaseC)
+
+	err := <-waitErrC
+	log.PanicIf(err)
+
+	err = <-runErrC
+	log.PanicIf(err)
+
+	if walk.IsRunning() != false {
+		t.Fatalf("Walk still running after Wait() returned.")
+	} else if walk.Stats().FilesVisited != 10 {
+		t.Fatalf("FilesVisited not correct: (%d)", walk.Stats().FilesVisited)
+	}
+
+	// Once finished, it returns immediately.
+
+	err = walk.Wait()
+	log.PanicIf(err)
+}
+
+func TestWalk_Wait__error(t *testing.T) {
+	tempPath, _ := pwtesting.FillFlatTempPath(1, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	errTest := errors.New("test error")
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		if info.IsDir() == false {
+			return errTest
+		}
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+
+	runErr := walk.Run()
+	if runErr == nil {
+		t.Fatalf("Expected error from run.")
+	}
+
+	err := walk.Wait()
+	if err != runErr {
+		t.Fatalf("Wait() error not correct: [%v] != [%v]", err, runErr)
+	}
+}
@@ -1,15 +1,18 @@
 package pathwalk
 
 import (
+	"context"
 	"errors"
-	"io"
 	"os"
 	"path"
 	"reflect"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/dsoprea/go-logging"
+	"github.com/google/uuid"
 )
 
 var (
@@ -20,6 +23,22 @@ var (
 	// ErrSkipDirectory can be returned by the visitor if a directory to skip
 	// walking its contents.
 	ErrSkipDirectory = errors.New("skip directory")
+
+	// ErrDeadlocked is returned if no progress has been made within the
+	// global timeout duration.
+	ErrDeadlocked = errors.New("walk appears to be dead-locked; if this is not the case, provide a higher timeout duration")
+
+	// ErrWalkRunning is returned by operations that can only be performed
+	// between runs.
+	ErrWalkRunning = errors.New("walk is running")
+
+	// ErrAlreadyRunning is returned by `Run()` if the walk is already being
+	// run by another caller.
+	ErrAlreadyRunning = errors.New("walk is already running")
+
+	// ErrEmptyRootPath is returned by `Run()` if the walk was created without
+	// a root path.
+	ErrEmptyRootPath = errors.New("root path is empty")
 )
 
 // WalkFunc is the function type for the callback.
@@ -27,6 +46,14 @@ type WalkFunc func(parentPath string, info os.FileInfo) (err error)
 
 // Walk knows how to traverse a tree in parallel.
 type Walk struct {
+	// sequence is the last sequence number that was assigned to an entry.
+	// This is accessed atomically and must stay first in the struct in order
+	// to be 64-bit aligned on 32-bit platforms.
+	sequence int64
+
+	// hotStats must follow sequence for the same reason.
+	hotStats hotStats
+
 	rootPath string
 
 	concurrency     int
@@ -34,15 +61,149 @@ type Walk struct {
 	batchSize       int
 	timeoutDuration time.Duration
 
+	// batchSizeFunc optionally overrides batchSize per directory.
+	batchSizeFunc BatchSizeFunc
+
+	// directoryHints are the hints given by the callbacks, by path.
+	directoryHints       map[string]DirectoryHint
+	directoryHintsLocker sync.Mutex
+
+	progressInterval time.Duration
+	progressFunc     ProgressFunc
+
+	skipProcessedFunc SkipProcessedFunc
+
+	// walkIgnoreFilename is the name of the per-directory ignore files, if
+	// they're enabled.
+	walkIgnoreFilename string
+
+	// jobHandlers are the handlers for custom jobs by type.
+	jobHandlers map[reflect.Type]JobHandler
+
+	// iterator receives the entries if the run was started by `Iterator()`.
+	iterator *Iterator
+
+	workerStartFunc WorkerStartFunc
+	workerStopFunc  WorkerStopFunc
+	workerWalkFunc  WorkerWalkFunc
+
+	// workerIdsUsed indicates which worker IDs are held by running workers.
+	// This is protected by stateLocker.
+	workerIdsUsed []bool
+
 	jobsC   chan job
 	errorsC chan error
 	wg      *sync.WaitGroup
 
+	// stopC is closed to signal an early stop to the workers and to any
+	// pushes that are blocked on the job channel.
+	stopC chan struct{}
+
+	// pushWg tracks the pushes that are currently in progress so that a stop
+	// can wait for them to back out before draining the job channel.
+	pushWg *sync.WaitGroup
+
 	workerCount     int
 	idleWorkerCount int
+	isRunning       bool
 	stateLocker     sync.Mutex
 
-	walkFunc WalkFunc
+	// maxGoroutines is the limit given to `SetMaxGoroutines()`, and
+	// workerGoroutineLimit is what is left of it for the workers and timed
+	// reads (of which there are readerGoroutineCount) during the current run.
+	maxGoroutines        int
+	workerGoroutineLimit int
+	readerGoroutineCount int
+
+	// runState tracks the completion of the current or last run for
+	// `Wait()`.
+	runState *runState
+
+	// isRunActive is set (atomically) while `Run()` is executing in order to
+	// reject concurrent runs.
+	isRunActive int32
+
+	// warmUpWorkerCount is the number of workers to start before the first
+	// job is queued.
+	warmUpWorkerCount int
+
+	schedulingBias SchedulingBias
+
+	// isAutoTuneBatching indicates that the listings of small directories
+	// are coalesced into shared jobs.
+	isAutoTuneBatching bool
+
+	// coalescedBatches are the held listings of small directories and
+	// coalescedEntryCount is the number of entries across them.
+	coalescedBatches       []jobDirectoryContentsBatch
+	coalescedEntryCount    int
+	coalescedBatchesLocker sync.Mutex
+
+	// intraDirectoryOrder determines whether files or subdirectories are
+	// dispatched first within a batch.
+	intraDirectoryOrder IntraDirectoryOrder
+
+	// directoryStack holds the directory jobs when depth-first. Workers are
+	// woken for them via directoryReadyC.
+	directoryStack       []job
+	directoryStackLocker sync.Mutex
+	directoryReadyC      chan struct{}
+
+	walkFunc      WalkFunc
+	batchWalkFunc BatchWalkFunc
+
+	// visitors are additional callbacks that are called after walkFunc.
+	visitors []WalkFunc
+
+	walkEntryFunc    WalkEntryFunc
+	countingWalkFunc CountingWalkFunc
+
+	// bottomUpWalkFunc replaces walkFunc during `RunBottomUp()`.
+	bottomUpWalkFunc WalkFunc
+
+	// runRoots are the roots and runWalkFunc replaces walkFunc during
+	// `RunN()`.
+	runRoots    []string
+	runWalkFunc WalkFunc
+
+	isPruneEmptyDirectories bool
+
+	// deferredDirectories are the directories whose callbacks are waiting for
+	// their contents during `RunBottomUp()`, keyed by full-path.
+	deferredDirectories       map[string]deferredDirectory
+	deferredDirectoriesLocker sync.Mutex
+
+	contextualDirFunc  ContextualDirFunc
+	contextualFileFunc ContextualFileFunc
+
+	skipNotifyFunc    SkipNotifyFunc
+	filteredVisitFunc FilteredVisitFunc
+	specialFileFunc   SpecialFileFunc
+
+	symlinkDirMode SymlinkDirMode
+
+	callbackErrorPolicy             ErrorPolicy
+	isRecoverCallbackPanics         bool
+	isApplyErrorPolicyToDirectories bool
+
+	isNamesOnly bool
+	nameFunc    NameFunc
+
+	// isCountingMatches indicates that the run was started by
+	// `CountMatches()`.
+	isCountingMatches bool
+
+	isTrackDepthStats     bool
+	isTrackCallbackTiming bool
+
+	// clock is the time source for the idle and deadlock tracking.
+	clock clock
+
+	loadSampler              LoadSampler
+	loadAwareMaxConcurrency  int
+	lastLoadSampleTime       time.Time
+	lastEffectiveConcurrency int
+	loadLocker               sync.Mutex
 
 	jobsInFlight  int
 	counterLocker sync.Mutex
@@ -56,8 +217,149 @@ type Walk struct {
 	// hasStopped indicates that workers should no longer be running.
 	hasStopped bool
 
+	// isJobsClosed indicates that the job channel has already been closed.
+	isJobsClosed bool
+
+	// isTruncated indicates that a configured limit caused some entries to
+	// not be visited.
+	isTruncated bool
+
+	// outcome describes how the last run ended.
+	outcome Outcome
+
 	filter           internalFilter
 	doLogFilterStats bool
+
+	isApplyFilterToRoot bool
+
+	// userFilter is a copy of the filter as it was given.
+	userFilter Filter
+
+	// openFilesC bounds the number of files that we will have open at once
+	// for content filtering.
+	openFilesC chan struct{}
+
+	isCheckpointsEnabled bool
+	tracker              *directoryTracker
+	directoryLeaveFunc   DirectoryLeaveFunc
+
+	// directoryResults are the entries held for each incomplete directory,
+	// if directoryResultFunc is set.
+	directoryResultFunc    DirectoryResultFunc
+	directoryResults       map[string][]WalkEntry
+	directoryResultsLocker sync.Mutex
+
+	// resumeDirectories is the checkpoint state to seed the next run with.
+	resumeDirectories []checkpointDirectory
+
+	// resumeSkipPaths are the directories that were seeded from a checkpoint
+	// and that should therefore not be descended into when encountered.
+	resumeSkipPaths map[string]struct{}
+
+	pathCaseNormalization PathCaseNormalization
+	pathStyle             PathStyle
+
+	// reportedRootPath is what the root is reported as given the path style
+	// and case normalization.
+	reportedRootPath string
+
+	// resolvedRootPath is the absolute root with symlinks resolved. This is
+	// only set if symlinks outside of the root are being excluded.
+	resolvedRootPath string
+
+	// reportPrefixStrip is removed from the front of reported paths after the
+	// path style is applied.
+	reportPrefixStrip string
+
+	maxSkippedEntries int
+
+	isToleratePostEnumerationChanges bool
+
+	isCollapseOverlappingRoots bool
+
+	// isSerialCallback indicates that the callbacks are called from a single
+	// goroutine. serialCallsC feeds it while it's running.
+	isSerialCallback bool
+	serialCallsC     chan serialCall
+
+	// autoTuneDuration is the total time that `AutoTune()` spends sampling.
+	// isAutoTuned indicates that the concurrency and batch-size were chosen
+	// by it.
+	autoTuneDuration time.Duration
+	isAutoTuned      bool
+
+	// failedDirectories are the directories that had errors, up to
+	// maxFailedDirectories.
+	failedDirectories       map[string]struct{}
+	maxFailedDirectories    int
+	failedDirectoriesLocker sync.Mutex
+
+	// excludedPaths are the entries that were excluded by the filters, up to
+	// maxExcludedPaths, if isRecordExcluded is set.
+	excludedPaths       []ExcludedPath
+	isRecordExcluded    bool
+	maxExcludedPaths    int
+	excludedPathsLocker sync.Mutex
+
+	perDirectoryTimeout time.Duration
+
+	// spillThreshold, if not zero, is the number of pending batches of
+	// directory entries above which further batches are spilled to disk.
+	spillThreshold    int
+	spillDirectory    string
+	spillFile         *os.File
+	spillOffset       int64
+	spilledJobs       []spilledJob
+	pendingBatchCount int
+	spillLocker       sync.Mutex
+
+	// runId uniquely identifies the current (or last) run. It's regenerated by
+	// `InitSync()`.
+	runId       string
+	runIdLocker sync.Mutex
+
+	// artificialLatency is slept before every stat, directory read, and file
+	// open. This is only set in testing.
+	artificialLatency time.Duration
+
+	// directoryFileCounts are the number of included files directly in each
+	// directory, if isTrackDirectoryFileCounts is set.
+	directoryFileCounts        map[string]int
+	isTrackDirectoryFileCounts bool
+	directoryFileCountsLocker  sync.Mutex
+
+	// directoryTimings are the slowest directories to list, if
+	// isTrackDirectoryTiming is set.
+	directoryTimings       dirTimingHeap
+	isTrackDirectoryTiming bool
+	directoryTimingsLocker sync.Mutex
+
+	// visitedDirectories are the directories that have been visited, if
+	// isDetectDirectoryCycles is set.
+	visitedDirectories       map[directoryId]struct{}
+	isDetectDirectoryCycles  bool
+	visitedDirectoriesLocker sync.Mutex
+
+	// timedOutDirectories are the directories that have already been
+	// counted as timed-out.
+	timedOutDirectories       map[string]struct{}
+	timedOutDirectoriesLocker sync.Mutex
+
+	sampleEntriesPerDirectory int
+
+	isStayOnFilesystem bool
+	rootDeviceId       uint64
+	hasRootDeviceId    bool
+
+	childLister ChildLister
+
+	// listPaths, if not nil, are the paths to visit instead of listing the
+	// root.
+	listPaths []string
+
+	// maxDepth, if not zero, is the depth of the deepest entries that are
+	// visited. The directories at that depth aren't descended into.
+	maxDepth int
 }
 
 // NewWalk returns a new Walk struct.
@@ -71,48 +373,193 @@ func NewWalk(rootPath string, walkFunc WalkFunc) (walk *Walk) {
 		timeoutDuration: defaultTimeoutDuration,
 
 		walkFunc: walkFunc,
+
+		openFilesC: make(chan struct{}, defaultMaxOpenFiles),
+
+		maxFailedDirectories: defaultMaxFailedDirectories,
+
+		autoTuneDuration: defaultAutoTuneDuration,
+
+		childLister: newFilesystemChildLister(),
+
+		clock: realClock{},
 	}
 
+	walk.reportFdExhaustion()
+
 	// Initialize empty filter state.
 
 	filter := Filter{}
-	walk.SetFilter(filter)
+
+	err := walk.SetFilter(filter)
+	log.PanicIf(err)
 
 	return walk
 }
 
 // SetFilter sets filtering parameters for the next call to Run(). Behavior is
 // undefined if this is changed *during* a call to `Run()`. The filters will be
-// sorted automatically.
-func (walk *Walk) SetFilter(filter Filter) {
+// sorted automatically. The filter is checked with `ValidateFilter()` first and
+// the error is returned (and the previous filter is kept) if it's not valid.
+func (walk *Walk) SetFilter(filter Filter) (err error) {
+	err = ValidateFilter(filter)
+	if err != nil {
+		return err
+	}
+
 	walk.filter = newInternalFilter(filter)
+	walk.userFilter = filter.copy()
+
+	if walk.filter.HasOwnerFilter() == true && isOwnershipSupported == false {
+		walk.logWarningf("file ownership is not supported on this platform; the owner filters will be ignored")
+	}
+
+	if walk.filter.HasCreationTimeFilter() == true && isBirthTimeSupported == false {
+		walk.logWarningf("creation times are not supported on this platform; the creation-time filters will exclude every file")
+	}
 
 	// Only log the stats if we have any filters.
 	walk.doLogFilterStats =
 		len(walk.filter.includePaths) > 0 ||
 			len(walk.filter.excludePaths) > 0 ||
 			len(walk.filter.includeFilenames) > 0 ||
-			len(walk.filter.excludeFilenames) > 0
+			len(walk.filter.excludeFilenames) > 0 ||
+			walk.filter.HasCompoundExtensions() == true ||
+			walk.filter.HasContentMagic() == true ||
+			walk.filter.HasCreationTimeFilter() == true ||
+			walk.filter.maxPathLength > 0 ||
+			walk.filter.maxPathComponents > 0 ||
+			len(walk.filter.allowedTopLevelDirs) > 0
+
+	return nil
 }
 
 // Stats prints statistics about the last walking operation.
 func (walk *Walk) Stats() Stats {
-	return walk.stats
+	walk.statsLocker.Lock()
+	defer walk.statsLocker.Unlock()
+
+	stats := walk.stats.copy()
+	walk.hotStats.addTo(&stats)
+
+	return stats
+}
+
+// ResetStats clears the statistics without touching any other state. This can
+// only be called between runs.
+func (walk *Walk) ResetStats() (err error) {
+	if walk.IsRunning() == true {
+		return ErrWalkRunning
+	}
+
+	walk.statsLocker.Lock()
+	defer walk.statsLocker.Unlock()
+
+	walk.stats = Stats{}
+	walk.hotStats.reset()
+
+	return nil
+}
+
+// IsRunning returns whether `Run()` is currently in progress.
+func (walk *Walk) IsRunning() bool {
+	walk.stateLocker.Lock()
+	defer walk.stateLocker.Unlock()
+
+	return walk.isRunning
 }
 
 // HasFinished returns whether all entries have been visited and processed.
+// This is equivalent to `Outcome()` returning `OutcomeCompleted`.
 func (walk *Walk) HasFinished() bool {
 	return walk.hasFinished
 }
 
+// Outcome returns how the last run ended.
+func (walk *Walk) Outcome() Outcome {
+	walk.counterLocker.Lock()
+	defer walk.counterLocker.Unlock()
+
+	return walk.outcome
+}
+
 // Stop will signal all of the workers to terminate if Run() has not yet
 // returned. This is provided for the user to call as a result of some logic in
-// the callback that calls for immediate return.
+// the callback that calls for immediate return. Any jobs that are still queued
+// are drained and discarded before the job channel is closed.
 func (walk *Walk) Stop() {
-	close(walk.jobsC)
+	walk.stop(OutcomeStopped)
+}
+
+// stop terminates the workers and records the given outcome. If the walk has
+// already stopped, this is a no-op and the original outcome stands.
+func (walk *Walk) stop(outcome Outcome) {
+	walk.counterLocker.Lock()
+
+	if walk.hasStopped == true {
+		walk.counterLocker.Unlock()
+		return
+	}
 
 	// Intentionally does not set `hasFinished`, as we are specifically aborting
 	// the process.
+	walk.hasStopped = true
+	walk.outcome = outcome
+
+	close(walk.stopC)
+
+	walk.counterLocker.Unlock()
+
+	// Any push that was already in progress will now observe the stop and back
+	// out. Wait for them so that nothing lands in the channel after we drain
+	// it.
+	walk.pushWg.Wait()
+
+	walk.drainJobs()
+	walk.drainDirectoryJobs()
+	walk.drainSpilledJobs()
+
+	walk.counterLocker.Lock()
+	walk.closeJobs()
+	walk.counterLocker.Unlock()
+}
+
+// drainJobs reads and discards any jobs that are still buffered in the job
+// channel.
+func (walk *Walk) drainJobs() {
+	for {
+		select {
+		case j, ok := <-walk.jobsC:
+			if ok == false {
+				return
+			}
+
+			releaseJobDirectories(j)
+			walk.jobTickDown()
+		default:
+			return
+		}
+	}
+}
+
+// closeJobs closes the job channel if it hasn't already been closed. The
+// counter locker must be held.
+func (walk *Walk) closeJobs() {
+	if walk.isJobsClosed == true {
+		return
+	}
+
+	close(walk.jobsC)
+	walk.isJobsClosed = true
+}
+
+// isStopped returns whether the walk has stopped, either because it finished
+// or because it was stopped early.
+func (walk *Walk) isStopped() bool {
+	walk.counterLocker.Lock()
+	defer walk.counterLocker.Unlock()
+
+	return walk.hasStopped
 }
 
 // SetConcurrency sets an alternative maximum number of workers.
@@ -149,24 +596,165 @@ func (walk *Walk) InitSync() {
 	// Allows us to wait until jobs have completed before we exit.
 	walk.wg = new(sync.WaitGroup)
 
+	walk.stopC = make(chan struct{})
+	walk.pushWg = new(sync.WaitGroup)
+
+	walk.directoryStack = nil
+	walk.directoryReadyC = make(chan struct{}, 1)
+
 	// To facilitate reuse of the struct for follow-up operations.
 	walk.jobsInFlight = 0
 
 	walk.stats = Stats{}
+	walk.hotStats.reset()
+
+	atomic.StoreInt64(&walk.sequence, 0)
+
+	walk.runIdLocker.Lock()
+	walk.runId = uuid.New().String()
+	walk.runIdLocker.Unlock()
+
+	walk.failedDirectoriesLocker.Lock()
+	walk.failedDirectories = make(map[string]struct{})
+	walk.failedDirectoriesLocker.Unlock()
+
+	walk.excludedPathsLocker.Lock()
+	walk.excludedPaths = nil
+	walk.excludedPathsLocker.Unlock()
+
+	walk.timedOutDirectoriesLocker.Lock()
+	walk.timedOutDirectories = nil
+	walk.timedOutDirectoriesLocker.Unlock()
+
+	walk.directoryFileCountsLocker.Lock()
+	walk.directoryFileCounts = nil
+	walk.directoryFileCountsLocker.Unlock()
+
+	walk.directoryTimingsLocker.Lock()
+	walk.directoryTimings = nil
+	walk.directoryTimingsLocker.Unlock()
+
+	walk.visitedDirectoriesLocker.Lock()
+	walk.visitedDirectories = nil
+	walk.visitedDirectoriesLocker.Unlock()
+
+	walk.directoryResultsLocker.Lock()
+	walk.directoryResults = nil
+	walk.directoryResultsLocker.Unlock()
+
+	walk.coalescedBatchesLocker.Lock()
+	walk.takeCoalescedBatches()
+	walk.coalescedBatchesLocker.Unlock()
+
+	walk.spillLocker.Lock()
+	walk.pendingBatchCount = 0
+	walk.spilledJobs = nil
+	walk.spillLocker.Unlock()
+
 	walk.hasFinished = false
 	walk.hasStopped = false
+	walk.isJobsClosed = false
+	walk.isTruncated = false
+	walk.outcome = OutcomeNone
+
+	if walk.isCheckpointsEnabled == true || walk.directoryLeaveFunc != nil || walk.directoryResultFunc != nil || walk.isDeferringDirectories() == true {
+		walk.tracker = newDirectoryTracker()
+		walk.tracker.doRecordCompletedChildren = walk.isCheckpointsEnabled
+		walk.tracker.areFilesCompletedWithBatches = walk.batchWalkFunc != nil || walk.isNamesOnly == true
+	} else {
+		walk.tracker = nil
+	}
+
+	walk.resumeSkipPaths = nil
+
+	walk.deferredDirectoriesLocker.Lock()
+	walk.deferredDirectories = nil
+	walk.deferredDirectoriesLocker.Unlock()
 }
 
 // Run forks workers to process the tree. All workers will have quit by the time we return.
+// A walk may be run again once it returns, but `ErrAlreadyRunning` is returned
+// if it's run while it's already running. `ErrEmptyRootPath` is returned if
+// there's no root path.
 func (walk *Walk) Run() (err error) {
+	return walk.RunContext(context.Background())
+}
+
+// RunContext is the same as Run() but will stop early if the given context is
+// cancelled, in which case the context's error is returned and the outcome is
+// `OutcomeCancelled`.
+func (walk *Walk) RunContext(ctx context.Context) (err error) {
+	if walk.rootPath == "" {
+		return ErrEmptyRootPath
+	}
+
+	// Two concurrent runs would reinitialize each other's state.
+	if atomic.CompareAndSwapInt32(&walk.isRunActive, 0, 1) == false {
+		return ErrAlreadyRunning
+	}
+
+	return walk.run(ctx, nil, nil)
+}
+
+// run executes one run. The caller must have marked the walk as running. If
+// `bottomUpWalkFunc` is not nil, it replaces the primary callback for this run
+// and the directories are delivered bottom-up. If `it` is not nil, the entries
+// are also delivered to it.
+func (walk *Walk) run(ctx context.Context, bottomUpWalkFunc WalkFunc, it *Iterator) (err error) {
+	rs := walk.beginRun()
+
+	// This must run last so that anyone waiting gets the final error.
+	defer func() {
+		walk.finishRun(rs, err)
+	}()
+
+	walk.bottomUpWalkFunc = bottomUpWalkFunc
+	walk.iterator = it
+
+	defer func() {
+		walk.bottomUpWalkFunc = nil
+		walk.iterator = nil
+		walk.runRoots = nil
+		walk.runWalkFunc = nil
+
+		walk.closeSpillFile()
+	}()
+
 	defer func() {
 		if state := recover(); state != nil {
 			err = log.Wrap(state.(error))
+
+			walk.counterLocker.Lock()
+			if walk.outcome == OutcomeNone {
+				walk.outcome = OutcomeError
+			}
+			walk.counterLocker.Unlock()
 		}
 	}()
 
+	walk.stateLocker.Lock()
+	walk.isRunning = true
+	walk.stateLocker.Unlock()
+
+	defer func() {
+		walk.stateLocker.Lock()
+		walk.isRunning = false
+		walk.stateLocker.Unlock()
+	}()
+
 	walk.InitSync()
 
+	err = walk.prepareGoroutineLimit()
+	log.PanicIf(err)
+
+	// This is stopped after the workers have finished.
+	stopProgressReporter := walk.startProgressReporter()
+	defer stopProgressReporter()
+
+	// This is also stopped after the workers have finished.
+	stopSerialCallback := walk.startSerialCallback()
+	defer stopSerialCallback()
+
 	defer func() {
 		walk.stateLocker.Lock()
 		hasWorkers := walk.workerCount > 0 || walk.idleWorkerCount > 0
@@ -178,9 +766,11 @@ func (walk *Walk) Run() (err error) {
 			isRunning := true
 			var workerError error
 
-			tick := time.NewTicker(frontendIdleCheckInterval)
-			lastState := [2]int{0, 0}
-			lastStateChange := time.Now()
+			clock := walk.getClock()
+
+			tick := clock.NewTicker(frontendIdleCheckInterval)
+			lastState := [3]int64{0, 0, 0}
+			lastStateChange := clock.Now()
 
 			for isRunning == true {
 				select {
@@ -189,8 +779,12 @@ func (walk *Walk) Run() (err error) {
 					isRunning = false
 
 					// Signals workers to close.
-					close(walk.jobsC)
-				case <-tick.C:
+					walk.stop(OutcomeError)
+				case <-ctx.Done():
+					isRunning = false
+
+					walk.stop(OutcomeCancelled)
+				case <-tick.C():
 					// The same locker used to update this field.
 					walk.counterLocker.Lock()
 					isRunning = walk.hasStopped == false
@@ -198,13 +792,16 @@ func (walk *Walk) Run() (err error) {
 
 					// Check for deadlock.
 
-					currentState := [2]int{walk.stats.FilesVisited, walk.stats.DirectoriesVisited}
+					currentState := walk.hotStats.progressState()
 
 					if currentState != lastState {
 						lastState = currentState
-						lastStateChange = time.Now()
-					} else if time.Since(lastStateChange) > walk.timeoutDuration {
-						walk.errorsC <- errors.New("walk appears to be dead-locked; if this is not the case, provide a higher timeout duration")
+						lastStateChange = clock.Now()
+					} else if isRunning == true && clock.Now().Sub(lastStateChange) > walk.timeoutDuration {
+						workerError = ErrDeadlocked
+						isRunning = false
+
+						walk.stop(OutcomeError)
 					}
 				}
 			}
@@ -222,10 +819,77 @@ func (walk *Walk) Run() (err error) {
 			if workerError != nil {
 				log.Panicf("worker terminated under error: %s", workerError.Error())
 			}
+
+			if walk.Outcome() == OutcomeCancelled {
+				err = ctx.Err()
+			}
 		}
 	}()
 
-	info, err := os.Stat(walk.rootPath)
+	err = walk.checkNamesOnly()
+	log.PanicIf(err)
+
+	err = walk.prepareReportedRootPath()
+	log.PanicIf(err)
+
+	err = walk.prepareResolvedRootPath()
+	log.PanicIf(err)
+
+	walk.hasRootDeviceId = false
+	if walk.isStayOnFilesystem == true {
+		rootInfo, err := walk.statNode(walk.rootPath)
+		log.PanicIf(err)
+
+		walk.recordRootDevice(rootInfo)
+	}
+
+	walk.startWarmWorkers()
+
+	if walk.resumeDirectories != nil {
+		directories := walk.resumeDirectories
+		walk.resumeDirectories = nil
+
+		count, err := walk.pushResumeJobs(directories)
+		log.PanicIf(err)
+
+		if count == 0 {
+			// There was nothing left to do.
+
+			walk.counterLocker.Lock()
+			walk.hasStopped = true
+			walk.hasFinished = true
+			walk.outcome = OutcomeCompleted
+			walk.counterLocker.Unlock()
+		}
+
+		return nil
+	}
+
+	if walk.listPaths != nil {
+		count, err := walk.pushListJobs()
+		log.PanicIf(err)
+
+		if count == 0 {
+			// There was nothing to do.
+
+			walk.counterLocker.Lock()
+			walk.hasStopped = true
+			walk.hasFinished = true
+			walk.outcome = OutcomeCompleted
+			walk.counterLocker.Unlock()
+		}
+
+		return nil
+	}
+
+	if walk.runRoots != nil {
+		err := walk.pushRootJobs()
+		log.PanicIf(err)
+
+		return nil
+	}
+
+	info, err := walk.statNode(walk.rootPath)
 	log.PanicIf(err)
 
 	parentPath := path.Dir(walk.rootPath)
@@ -248,8 +912,36 @@ func (walk *Walk) pushJob(job job) (err error) {
 		}
 	}()
 
+	// This is registered even if the job ends up being discarded so that the
+	// work remains outstanding in any checkpoint.
+	if walk.tracker != nil {
+		walk.tracker.JobQueued(job)
+	}
+
+	if walk.beginPush() == false {
+		// We've been stopped. Quietly discard the job.
+		releaseJobDirectories(job)
+		return nil
+	}
+
+	defer walk.pushWg.Done()
+
+	if walk.spillJobIfOverThreshold(job) == true {
+		return nil
+	}
+
+	walk.dispatchJob(job)
+
+	return nil
+}
+
+// dispatchJob starts a worker for the given job if we need one and can, and
+// then queues it. The job must have already been counted as in-flight.
+func (walk *Walk) dispatchJob(job job) {
+	concurrency := walk.effectiveConcurrency()
+
 	walk.stateLocker.Lock()
-	canStart := walk.idleWorkerCount <= 0 && walk.workerCount < walk.concurrency
+	canStart := walk.idleWorkerCount <= 0 && walk.workerCount < concurrency && walk.hasGoroutineRoom() == true
 	walk.stateLocker.Unlock()
 
 	// All workers are occupied but we can start another one.
@@ -261,6 +953,10 @@ func (walk *Walk) pushJob(job job) (err error) {
 
 		walk.stateLocker.Unlock()
 
+		walk.statsLocker.Lock()
+		walk.stats.JobsDispatchedToNewWorker++
+		walk.statsLocker.Unlock()
+
 		go walk.nodeWorker()
 	} else {
 		walk.statsLocker.Lock()
@@ -268,12 +964,20 @@ func (walk *Walk) pushJob(job job) (err error) {
 		walk.statsLocker.Unlock()
 	}
 
-	walk.jobTickUp()
+	if walk.isPriorityJob(job) == true {
+		walk.pushDirectoryJob(job)
+		return
+	}
 
 	// Here, a job gets pushed whether any workers are idle or not.
-	walk.jobsC <- job
-
-	return nil
+	select {
+	case walk.jobsC <- job:
+	case <-walk.stopC:
+		// We were stopped while waiting for room in the channel. The job is
+		// discarded.
+		releaseJobDirectories(job)
+		walk.jobTickDown()
+	}
 }
 
 // idleWorkerTickUp states that one worker has become idle.
@@ -296,21 +1000,34 @@ func (walk *Walk) idleWorkerTickDown() {
 // declare when it's idle (waiting for a job), and it'll eventually shutdown if
 // it doesn't get any jobs.
 func (walk *Walk) nodeWorker() {
+	walk.runNodeWorker(false)
+}
+
+// runNodeWorker is the body of a worker. If `isRegisteredIdle` is true, the
+// worker was already counted as idle when it was started.
+func (walk *Walk) runNodeWorker(isRegisteredIdle bool) {
+	// This must run last so that the frontend doesn't close the error channel
+	// before a panic has been reported.
+	defer walk.wg.Done()
+
 	defer func() {
 		if state := recover(); state != nil {
 			err := log.Wrap(state.(error))
 			log.PrintErrorf(err, "Node worker panicked.")
 
-			walk.errorsC <- err
+			// If another worker already failed and the walk was stopped as a
+			// result, nobody will be reading anymore.
+			select {
+			case walk.errorsC <- err:
+			case <-walk.stopC:
+			}
 		}
 	}()
 
-	isWorking := false
-	tick := time.NewTicker(workerIdleCheckInterval)
+	clock := walk.getClock()
 
-	walk.statsLocker.Lock()
-	walk.stats.JobsDispatchedToNewWorker++
-	walk.statsLocker.Unlock()
+	isWorking := false
+	tick := clock.NewTicker(workerIdleCheckInterval)
 
 	defer func() {
 		tick.Stop()
@@ -328,51 +1045,94 @@ func (walk *Walk) nodeWorker() {
 		walk.idleWorkerCount--
 
 		walk.stateLocker.Unlock()
-
-		walk.wg.Done()
 	}()
 
-	lastActivityTime := time.Now()
+	lastActivityTime := clock.Now()
 
-	walk.idleWorkerTickUp()
+	if isRegisteredIdle == false {
+		walk.idleWorkerTickUp()
+	}
 
-	for {
-		select {
-		case job, ok := <-walk.jobsC:
-			if ok == false {
-				// Channel is closed. The application must be closing. Shutdown.
+	// This is stopped before the worker is accounted as done, including if
+	// there's a panic.
+	workerState, stopWorkerLifecycle := walk.startWorkerLifecycle()
+	defer stopWorkerLifecycle()
 
-				return
-			}
+	// processJob handles one job and returns false if the worker should
+	// shutdown.
+	processJob := func(job job) bool {
+		if walk.isStopped() == true {
+			// We were stopped after this job was queued. Discard it.
 
-			walk.idleWorkerTickDown()
+			releaseJobDirectories(job)
+			walk.jobTickDown()
+			return false
+		}
 
-			walk.statsLocker.Lock()
-			walk.stats.IdleWorkerTime += time.Since(lastActivityTime)
-			walk.statsLocker.Unlock()
+		walk.idleWorkerTickDown()
 
-			// This helps us manage our state if there's a panic.
-			isWorking = true
+		walk.statsLocker.Lock()
+		walk.stats.IdleWorkerTime += clock.Now().Sub(lastActivityTime)
+		walk.statsLocker.Unlock()
 
-			lastActivityTime = time.Now()
+		// This helps us manage our state if there's a panic.
+		isWorking = true
 
-			err := walk.handleJob(job)
-			log.PanicIf(err)
+		lastActivityTime = clock.Now()
+
+		if workerState != nil {
+			job = attachWorkerState(job, workerState)
+		}
 
-			isWorking = false
+		err := walk.handleJob(job)
+		log.PanicIf(err)
 
-			walk.idleWorkerTickUp()
-		case <-tick.C:
-			if isWorking == false && time.Since(lastActivityTime) > maxWorkerIdleDuration {
-				// We haven't had anything to do for a while. Shutdown.
+		isWorking = false
 
-				walk.statsLocker.Lock()
-				walk.stats.IdleWorkerTime += time.Since(lastActivityTime)
-				walk.statsLocker.Unlock()
+		walk.idleWorkerTickUp()
 
-				return
-			}
-		}
+		return true
+	}
+
+	for {
+		// Directory jobs take priority when depth-first.
+		if job, found := walk.popDirectoryJob(); found == true {
+			if processJob(job) == false {
+				return
+			}
+
+			continue
+		}
+
+		select {
+		case job, ok := <-walk.jobsC:
+			if ok == false {
+				// Channel is closed. The application must be closing. Shutdown.
+
+				return
+			}
+
+			if processJob(job) == false {
+				return
+			}
+		case <-walk.directoryReadyC:
+			// A directory job was pushed. We'll pick it up at the top of the
+			// loop.
+		case <-tick.C():
+			if isWorking == false && clock.Now().Sub(lastActivityTime) > maxWorkerIdleDuration {
+				// We haven't had anything to do for a while. Shutdown.
+
+				walk.statsLocker.Lock()
+				walk.stats.IdleWorkerTime += clock.Now().Sub(lastActivityTime)
+				walk.statsLocker.Unlock()
+
+				return
+			}
+		case <-walk.stopC:
+			// We were stopped. Shutdown.
+
+			return
+		}
 	}
 
 	// Execution will reach here before hitting the defer and cleaning up.
@@ -386,10 +1146,14 @@ func (walk *Walk) handleJob(job job) (err error) {
 		}
 	}()
 
+	defer releaseJobDirectories(job)
+
 	switch t := job.(type) {
 	case jobDirectoryContentsBatch:
+		err := walk.batchStarted()
+		log.PanicIf(err)
 
-		err := walk.handleJobDirectoryContentsBatch(t)
+		err = walk.handleJobDirectoryContentsBatch(t)
 		log.PanicIf(err)
 
 	case jobDirectoryNode:
@@ -400,8 +1164,32 @@ func (walk *Walk) handleJob(job job) (err error) {
 		err := walk.handleJobFileNode(t)
 		log.PanicIf(err)
 
+	case jobSpecialNode:
+		err := walk.handleJobSpecialNode(t)
+		log.PanicIf(err)
+
+	case jobCoalescedBatches:
+		err := walk.handleJobCoalescedBatches(t)
+		log.PanicIf(err)
+
 	default:
-		log.Panicf("job not valid: [%v]", reflect.TypeOf(t))
+		err := walk.handleCustomJob(job)
+		log.PanicIf(err)
+	}
+
+	err = walk.trackJobCompleted(job)
+	log.PanicIf(err)
+
+	if walk.isAutoTuneBatching == true {
+		// If this is the last job, the held listings have to be flushed or
+		// the walk would finish without them.
+		batches := walk.jobTickDownOrTakeCoalescedBatches()
+		if batches == nil {
+			return nil
+		}
+
+		err := walk.pushCoalescedBatches(batches)
+		log.PanicIf(err)
 	}
 
 	walk.jobTickDown()
@@ -409,17 +1197,86 @@ func (walk *Walk) handleJob(job job) (err error) {
 	return nil
 }
 
-func (walk *Walk) jobTickUp() {
+// trackJobCompleted updates the directory tracker for a finished job and
+// delivers any directories that were completed as a result. Each directory is
+// only released to its parent once it has been delivered, so a parent is
+// always delivered after its children, deepest first.
+func (walk *Walk) trackJobCompleted(job job) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if walk.tracker == nil {
+		return nil
+	}
+
+	completed := walk.tracker.JobCompleted(job)
+
+	for completed != nil {
+		ds := completed.summary
+
+		if walk.isDeferringDirectories() == true {
+			err := walk.deliverDeferredDirectory(ds)
+			log.PanicIf(err)
+		}
+
+		if walk.directoryLeaveFunc != nil && walk.isCountingMatches == false {
+			err := walk.directoryLeaveFunc(ds)
+			log.PanicIf(err)
+		}
+
+		if walk.directoryResultFunc != nil {
+			err := walk.deliverDirectoryResult(ds)
+			log.PanicIf(err)
+		}
+
+		completed = walk.tracker.DirectoryReleased(*completed)
+	}
+
+	return nil
+}
+
+// beginPush accounts for a new job and registers an in-progress push. It
+// returns false if the walk has already stopped, in which case the job should
+// be discarded.
+func (walk *Walk) beginPush() bool {
 	walk.counterLocker.Lock()
 	defer walk.counterLocker.Unlock()
 
+	if walk.hasStopped == true {
+		return false
+	}
+
 	walk.jobsInFlight++
+	walk.pushWg.Add(1)
+
+	return true
+}
+
+// holdSeeding registers a placeholder in-flight job so that the walk can't be
+// considered complete while the initial jobs are still being pushed (the ones
+// already pushed might otherwise all finish before the next is). The returned
+// function releases it and must be called after the last push.
+func (walk *Walk) holdSeeding() (release func()) {
+	walk.counterLocker.Lock()
+	walk.jobsInFlight++
+	walk.counterLocker.Unlock()
+
+	return walk.jobTickDown
 }
 
 func (walk *Walk) jobTickDown() {
 	walk.counterLocker.Lock()
 	defer walk.counterLocker.Unlock()
 
+	walk.jobTickDownLocked()
+}
+
+// jobTickDownLocked accounts for a finished job. The counter locker must be
+// held.
+func (walk *Walk) jobTickDownLocked() {
 	walk.jobsInFlight--
 
 	// Safety check.
@@ -428,9 +1285,20 @@ func (walk *Walk) jobTickDown() {
 	}
 
 	if walk.jobsInFlight <= 0 {
-		close(walk.jobsC)
-		walk.hasStopped = true
-		walk.hasFinished = true
+		walk.closeJobs()
+
+		// If we were stopped early, the remaining jobs were just discarded and
+		// we didn't actually finish.
+		if walk.hasStopped == false {
+			walk.hasStopped = true
+
+			if walk.isTruncated == true {
+				walk.outcome = OutcomeTruncated
+			} else {
+				walk.hasFinished = true
+				walk.outcome = OutcomeCompleted
+			}
+		}
 	}
 }
 
@@ -443,54 +1311,299 @@ func (walk *Walk) handleJobDirectoryContentsBatch(jdcb jobDirectoryContentsBatch
 		}
 	}()
 
+	if walk.isNamesOnly == true {
+		err := walk.handleJobDirectoryContentsBatchNamesOnly(jdcb)
+		log.PanicIf(err)
+
+		return nil
+	}
+
 	// Produce N leaf jobs from a batch of N items.
 
+	var batchInfos []os.FileInfo
+	filesIncluded := 0
+
+	// heldJobs are dispatched after the rest of the batch because of the
+	// intra-directory order.
+	var heldJobs []job
+
+	deadline := walk.directoryDeadline()
+
 	parentNodePath := jdcb.ParentNodePath()
+	cpb := newChildPathBuilder(parentNodePath)
+
 	for _, childFilename := range jdcb.ChildBatch() {
-		path := path.Join(parentNodePath, childFilename)
+		path := cpb.Join(childFilename)
+
+		if _, found := walk.resumeSkipPaths[path]; found == true {
+			// This entry either already completed in the checkpointed run or
+			// was seeded directly from the checkpoint.
+			continue
+		}
+
+		if walk.isPathTooLong(path) == true || walk.isPathTooDeep(path) == true {
+			continue
+		}
+
+		if walk.isWalkIgnored(path, jdcb.ignoreRules) == true {
+			continue
+		}
+
+		if walk.checkDirectoryDeadline(parentNodePath, deadline) == true {
+			break
+		}
 
-		info, err := os.Stat(path)
+		info, err := walk.statNode(path)
 		if err != nil {
-			walkLogger.Warningf(nil, "can not stat [%s]; it will be skipped: [%s]", path, err.Error())
-			return nil
+			if walk.isVanishedEntry(path, err) == true {
+				continue
+			}
+
+			walk.logWarningf("can not stat [%s]; it will be skipped: [%s]", path, err.Error())
+
+			walk.recordFailedDirectory(parentNodePath)
+
+			err := walk.recordSkippedEntry(path, err)
+			log.PanicIf(err)
+
+			continue
+		}
+
+		if walk.isSymlinkOutsideRoot(path) == true {
+			continue
+		}
+
+		skipChildren := false
+		if info.IsDir() == true {
+			info, skipChildren = walk.applySymlinkDirMode(path, info)
 		}
 
 		if info.IsDir() == true {
+			if jdcb.skipDirectories == true {
+				continue
+			}
+
+			if walk.isTopLevelDirectoryExcluded(path, jdcb.depth+1) == true {
+				continue
+			}
+
 			jdn := newJobDirectoryNode(parentNodePath, info)
+			jdn.dirContext = jdcb.dirContext
+			jdn.skipChildren = jdcb.isListed || skipChildren
+			jdn.depth = jdcb.depth + 1
+			jdn.parentInfo = jdcb.parentInfo
+			jdn.ignoreRules = jdcb.ignoreRules
+			jdn.parentDir = jdcb.dir.acquire()
+
+			if walk.isHeldForIntraDirectoryOrder(true) == true {
+				heldJobs = append(heldJobs, jdn)
+				continue
+			}
 
 			err := walk.pushJob(jdn)
 			log.PanicIf(err)
 		} else if jdcb.DoProcessFiles() == true {
 			// We'll only descend on a non-root path if it passed the path-
 			// filter above.
-			if walk.filter.IsFileIncluded(childFilename) != true {
-				walkLogger.Debugf(nil, "File excluded: [%s]", childFilename)
+			if isIncluded, reason := walk.isFileIncluded(path, info); isIncluded != true {
+				walk.handleFilteredFile(parentNodePath, info, false, reason)
+				continue
+			}
+
+			filesIncluded++
+
+			if walk.isSpecialFileDispatched(info) == true {
+				jsn := newJobSpecialNode(parentNodePath, info)
+				jsn.depth = jdcb.depth + 1
+
+				if walk.isHeldForIntraDirectoryOrder(false) == true {
+					heldJobs = append(heldJobs, jsn)
+					continue
+				}
+
+				err := walk.pushJob(jsn)
+				log.PanicIf(err)
 
-				walk.statsFileFilterExcludeTickUp()
 				continue
 			}
 
-			walk.statsFileFilterIncludeTickUp()
+			if walk.batchWalkFunc != nil {
+				batchInfos = append(batchInfos, info)
+				continue
+			}
 
 			jfn := newJobFileNode(parentNodePath, info)
+			jfn.dirContext = jdcb.dirContext
+			jfn.depth = jdcb.depth + 1
+			jfn.parentInfo = jdcb.parentInfo
+
+			if walk.isHeldForIntraDirectoryOrder(false) == true {
+				heldJobs = append(heldJobs, jfn)
+				continue
+			}
 
 			err := walk.pushJob(jfn)
 			log.PanicIf(err)
+		} else {
+			walk.notifySkip(path, SkipFilterPath)
+			walk.handleFilteredFile(parentNodePath, info, true, SkipFilterPath)
 		}
 	}
 
+	walk.recordDirectoryFileCount(parentNodePath, filesIncluded)
+
+	if len(batchInfos) > 0 {
+		walk.recordDepth(jdcb.depth+1, len(batchInfos))
+
+		batchInfos = walk.skipProcessedBatch(parentNodePath, batchInfos)
+	}
+
+	if len(batchInfos) > 0 {
+		err := walk.callBatchWalkFunc(parentNodePath, batchInfos)
+		log.PanicIf(err)
+	}
+
+	// With the batch callback, the files were just delivered, so any held
+	// directories still follow them.
+	err = walk.pushHeldJobs(heldJobs)
+	log.PanicIf(err)
+
 	return nil
 }
 
+// isFileIncluded applies the filename, owner, and content filters to the given
+// file and updates the filter stats. If excluded, `reason` describes why.
+func (walk *Walk) isFileIncluded(filepath string, info os.FileInfo) (isIncluded bool, reason SkipReason) {
+	filename := info.Name()
+
+	if walk.filter.IsFileIncluded(filename) != true {
+		walk.logDebugf("File excluded: [%s]", filename)
+
+		walk.statsFileFilterExcludeTickUp()
+		walk.notifySkip(filepath, SkipFilterFilename)
+
+		return false, SkipFilterFilename
+	}
+
+	if walk.isCompoundExtensionExcluded(filepath, filename) == true {
+		return false, SkipFilterFilename
+	}
+
+	if walk.filter.HasOwnerFilter() == true && walk.filter.IsOwnerIncluded(info) != true {
+		walk.logDebugf("File excluded by owner: [%s]", filename)
+
+		walk.notifySkip(filepath, SkipFilterOwner)
+
+		walk.statsLocker.Lock()
+		walk.stats.OwnerFilterExcludes++
+		walk.statsLocker.Unlock()
+
+		return false, SkipFilterOwner
+	}
+
+	if walk.filter.HasCreationTimeFilter() == true && walk.isCreationTimeIncluded(filepath, info) != true {
+		walk.logDebugf("File excluded by creation time: [%s]", filename)
+
+		walk.notifySkip(filepath, SkipFilterCreationTime)
+
+		walk.statsLocker.Lock()
+		walk.stats.CreationTimeFilterExcludes++
+		walk.statsLocker.Unlock()
+
+		return false, SkipFilterCreationTime
+	}
+
+	if walk.filter.HasContentMagic() == true {
+		isIncluded, isReadable := walk.isContentIncluded(filepath, info)
+		if isIncluded != true {
+			walk.logDebugf("File excluded by content: [%s]", filename)
+
+			walk.statsFileFilterExcludeTickUp()
+
+			if isReadable == false {
+				return false, SkipUnreadable
+			}
+
+			walk.notifySkip(filepath, SkipFilterContent)
+
+			return false, SkipFilterContent
+		}
+
+		walk.statsLocker.Lock()
+		walk.stats.ContentFilterMatches++
+		walk.statsLocker.Unlock()
+	}
+
+	walk.statsFileFilterIncludeTickUp()
+
+	return true, 0
+}
+
+// isPathTooLong returns true and updates the stats if the given full-path
+// exceeds the maximum length.
+func (walk *Walk) isPathTooLong(fqPath string) bool {
+	if walk.filter.IsPathLengthIncluded(fqPath) == true {
+		return false
+	}
+
+	walk.logDebugf("Path too long: [%s]", fqPath)
+
+	walk.notifySkip(fqPath, SkipPathTooLong)
+
+	walk.statsLocker.Lock()
+	walk.stats.PathsTooLong++
+	walk.statsLocker.Unlock()
+
+	return true
+}
+
+// isPathTooDeep returns true and updates the stats if the given full-path has
+// more components below its root than the maximum.
+func (walk *Walk) isPathTooDeep(fqPath string) bool {
+	if walk.filter.maxPathComponents <= 0 {
+		return false
+	}
+
+	componentCount := relativePathComponentCount(walk.rootPathOf(fqPath), fqPath)
+	if walk.filter.IsPathComponentCountIncluded(componentCount) == true {
+		return false
+	}
+
+	walk.logDebugf("Path too deep: [%s] (%d)", fqPath, componentCount)
+
+	walk.notifySkip(fqPath, SkipPathTooDeep)
+
+	walk.statsLocker.Lock()
+	walk.stats.PathsTooDeep++
+	walk.statsLocker.Unlock()
+
+	return true
+}
+
+// relativePathComponentCount returns the number of components in the given
+// full-path below the given root. The root itself has none.
+func relativePathComponentCount(rootPath, fqPath string) int {
+	rootPath = path.Clean(rootPath)
+
+	relPath := fqPath
+	if rootPath != "." {
+		relPath = strings.TrimPrefix(fqPath, rootPath)
+		relPath = strings.TrimPrefix(relPath, "/")
+	}
+
+	if relPath == "" || relPath == "." {
+		return 0
+	}
+
+	return strings.Count(relPath, "/") + 1
+}
+
 func (walk *Walk) statsPathFilterIncludeTickUp() {
 	if walk.doLogFilterStats == false {
 		return
 	}
 
-	walk.statsLocker.Lock()
-	defer walk.statsLocker.Unlock()
-
-	walk.stats.PathFilterIncludes++
+	atomic.AddInt64(&walk.hotStats.pathFilterIncludes, 1)
 }
 
 func (walk *Walk) statsPathFilterExcludeTickUp() {
@@ -498,10 +1611,7 @@ func (walk *Walk) statsPathFilterExcludeTickUp() {
 		return
 	}
 
-	walk.statsLocker.Lock()
-	defer walk.statsLocker.Unlock()
-
-	walk.stats.PathFilterExcludes++
+	atomic.AddInt64(&walk.hotStats.pathFilterExcludes, 1)
 }
 
 func (walk *Walk) statsFileFilterIncludeTickUp() {
@@ -509,10 +1619,7 @@ func (walk *Walk) statsFileFilterIncludeTickUp() {
 		return
 	}
 
-	walk.statsLocker.Lock()
-	defer walk.statsLocker.Unlock()
-
-	walk.stats.FileFilterIncludes++
+	atomic.AddInt64(&walk.hotStats.fileFilterIncludes, 1)
 }
 
 func (walk *Walk) statsFileFilterExcludeTickUp() {
@@ -520,10 +1627,7 @@ func (walk *Walk) statsFileFilterExcludeTickUp() {
 		return
 	}
 
-	walk.statsLocker.Lock()
-	defer walk.statsLocker.Unlock()
-
-	walk.stats.FileFilterExcludes++
+	atomic.AddInt64(&walk.hotStats.fileFilterExcludes, 1)
 }
 
 // handleJobDirectoryNode handles one directory note. It will read and parcel
@@ -535,15 +1639,31 @@ func (walk *Walk) handleJobDirectoryNode(jdn jobDirectoryNode) (err error) {
 		}
 	}()
 
-	walk.statsLocker.Lock()
-	walk.stats.DirectoriesVisited++
-	walk.statsLocker.Unlock()
-
 	parentNodePath := jdn.ParentNodePath()
 	info := jdn.Info()
 
 	fqPath := path.Join(parentNodePath, info.Name())
-	rootPathPrefixLen := len(walk.rootPath) + 1
+
+	// Children are checked before they're dispatched, so this will only
+	// apply to the root or to a directory seeded from a checkpoint.
+	if walk.isPathTooLong(fqPath) == true || walk.isPathTooDeep(fqPath) == true {
+		return nil
+	}
+
+	if walk.isDirectoryCycle(parentNodePath, info) == true {
+		return nil
+	}
+
+	atomic.AddInt64(&walk.hotStats.directoriesVisited, 1)
+
+	if jdn.depth == 0 && path.Clean(fqPath) == path.Clean(walk.rootPathOf(path.Clean(fqPath))) {
+		walk.statsLocker.Lock()
+		walk.stats.RootVisited = true
+		walk.statsLocker.Unlock()
+	}
+
+	walk.recordDepth(jdn.depth, 1)
+	rootPathPrefixLen := len(walk.rootPathOf(fqPath)) + 1
 	relPath := ""
 	if len(fqPath) > rootPathPrefixLen {
 		relPath = fqPath[rootPathPrefixLen:]
@@ -557,70 +1677,309 @@ func (walk *Walk) handleJobDirectoryNode(jdn jobDirectoryNode) (err error) {
 	// filter).
 
 	isIncluded := true
-	if walk.filter.IsPathIncluded(relPath) != true {
-		walkLogger.Debugf(nil, "Directory excluded: [%s]", relPath)
+	if walk.filter.IsPathIncluded(relPath) != true || walk.isRootFilteredByName(jdn, info) == true {
+		walk.logDebugf("Directory excluded: [%s]", relPath)
 
 		walk.statsPathFilterExcludeTickUp()
+
 		isIncluded = false
 	} else {
 		walk.statsPathFilterIncludeTickUp()
 	}
 
-	if isIncluded {
+	// The root's callback may be called even though its files are still
+	// filtered.
+	isCallbackIncluded := isIncluded == true || walk.isRootAlwaysIncluded(jdn) == true
+
+	if isCallbackIncluded == false {
+		walk.notifySkip(fqPath, SkipFilterPath)
+	}
+
+	if isCallbackIncluded == false && jdn.skipCallback == false {
+		err = walk.callFilteredVisitFunc(parentNodePath, info, false, SkipFilterPath)
+		if err != nil {
+			if err == ErrSkipDirectory {
+				walk.statsLocker.Lock()
+				walk.stats.DirectoriesIgnored++
+				walk.statsLocker.Unlock()
+
+				return nil
+			}
+
+			log.Panic(err)
+		}
+	}
+
+	if isCallbackIncluded == true && jdn.skipCallback == false && walk.isDeferringDirectories() == true {
+		// The callback is called once everything below it has been.
+		walk.deferDirectory(parentNodePath, info, jdn.parentInfo)
+	} else if isCallbackIncluded == true && jdn.skipCallback == false {
 		// Call callback, but only if it didn't get excluded by the filter.
 
 		// We don't concern ourselves with symlinked directories. If they don't want
 		// to descend into them, they can detect them and skip.
 
-		err = walk.walkFunc(parentNodePath, info)
+		err = walk.callWalkFunc(parentNodePath, info, jdn.parentInfo)
 		if err != nil {
-			if err == ErrSkipDirectory {
+			isSkipped := err == ErrSkipDirectory
+			if isSkipped == false {
+				isSkipped, err = walk.applyDirectoryErrorPolicy(parentNodePath, info, err)
+				log.PanicIf(err)
+			}
+
+			if isSkipped == true {
 				walk.statsLocker.Lock()
 				walk.stats.DirectoriesIgnored++
 				walk.statsLocker.Unlock()
 
 				return nil
 			}
+		}
+	}
 
-			log.Panic(err)
+	if jdn.skipChildren == true {
+		return nil
+	}
+
+	if walk.maxDepth > 0 && jdn.depth >= walk.maxDepth {
+		return nil
+	}
+
+	if walk.isOtherFilesystem(info) == true {
+		walk.logDebugf("Not descending into mount point: [%s]", relPath)
+
+		walk.notifySkip(fqPath, SkipOtherFilesystem)
+
+		walk.statsLocker.Lock()
+		walk.stats.MountPointsSkipped++
+		walk.statsLocker.Unlock()
+
+		return nil
+	}
+
+	childCtx, err := walk.callContextualDirFunc(jdn)
+	if err != nil {
+		if err == ErrSkipDirectory {
+			walk.statsLocker.Lock()
+			walk.stats.DirectoriesIgnored++
+			walk.statsLocker.Unlock()
+
+			return nil
 		}
+
+		log.Panic(err)
 	}
 
 	// Now, push jobs for directory children.
 
 	path := path.Join(parentNodePath, info.Name())
 
-	f, err := os.Open(path)
+	// Each batch holds its own reference so that its children can be resolved
+	// relative to the directory.
+	dir := walk.holdDirectory(jdn.parentDir, path)
+	defer dir.release()
+
+	directoryBatchSize, err := walk.directoryBatchSize(path, info)
+	log.PanicIf(err)
+
+	ignoreRules, err := walk.loadWalkIgnoreRules(path, jdn.ignoreRules)
 	log.PanicIf(err)
 
-	defer f.Close()
+	sampleRemaining := walk.sampleEntriesPerDirectory
+	readTimeRemaining := walk.perDirectoryTimeout
+
+	// heldBatch is the first batch of a small directory, which is held until
+	// we know whether it's the only one (and can be coalesced).
+	var heldBatch *jobDirectoryContentsBatch
+	isExhausted := false
+
+	// listDuration and entryCount cover only the reads, not the dispatching.
+	var listDuration time.Duration
+	entryCount := 0
 
 	batchNumber := 0
 	for {
-		names, err := f.Readdirnames(walk.batchSize)
-		if err != nil {
-			if err == io.EOF {
+		batchSize := directoryBatchSize
+		if walk.sampleEntriesPerDirectory > 0 && sampleRemaining < batchSize {
+			batchSize = sampleRemaining
+		}
+
+		listStartedAt := time.Now()
+
+		names, childIsDir, hasMore, err := walk.listChildrenWithTimeout(path, batchSize, &readTimeRemaining)
+
+		listDuration += time.Since(listStartedAt)
+		entryCount += len(names)
+
+		if err == ErrDirectoryTimedOut {
+			walk.recordTimedOutDirectory(path)
+			break
+		} else if err != nil {
+			isHandled, handleErr := walk.handleDirectoryListFailure(jdn, err)
+			log.PanicIf(handleErr)
+
+			if isHandled == true {
 				break
 			}
 
 			log.Panic(err)
 		}
 
-		jdcb := newJobDirectoryContentsBatch(path, batchNumber, names, isIncluded)
+		if len(names) == 0 {
+			if hasMore == false {
+				isExhausted = true
+				break
+			}
 
-		err = walk.pushJob(jdcb)
-		log.PanicIf(err)
+			continue
+		}
+
+		if heldBatch != nil {
+			// There was more after all.
+
+			err = walk.pushJob(*heldBatch)
+			log.PanicIf(err)
+
+			heldBatch = nil
+		}
+
+		jdcb := newJobDirectoryContentsBatch(path, batchNumber, names, isIncluded)
+		jdcb.skipDirectories = jdn.skipSubdirectories
+		jdcb.dirContext = childCtx
+		jdcb.childIsDir = childIsDir
+		jdcb.depth = jdn.depth
+		jdcb.parentInfo = info
+		jdcb.ignoreRules = ignoreRules
+		jdcb.dir = dir.acquire()
+
+		if walk.isCoalescable(batchNumber, len(names), batchSize) == true {
+			heldBatch = &jdcb
+		} else {
+			err = walk.pushJob(jdcb)
+			log.PanicIf(err)
+		}
 
 		batchNumber++
+
+		if hasMore == false {
+			isExhausted = true
+			break
+		}
+
+		if walk.sampleEntriesPerDirectory > 0 {
+			sampleRemaining -= len(names)
+			if sampleRemaining <= 0 {
+				err := walk.finishSample(path)
+				log.PanicIf(err)
+
+				break
+			}
+		}
+	}
+
+	if heldBatch != nil {
+		if isExhausted == true {
+			err = walk.coalesceBatch(*heldBatch)
+			log.PanicIf(err)
+		} else {
+			err = walk.pushJob(*heldBatch)
+			log.PanicIf(err)
+		}
 	}
 
+	walk.recordDirectoryTiming(path, listDuration, entryCount)
+
 	walk.statsLocker.Lock()
 	walk.stats.EntryBatchesProcessed += batchNumber
+
+	if batchNumber > 0 {
+		walk.stats.DirectoriesBatched++
+	}
+
 	walk.statsLocker.Unlock()
 
 	return nil
 }
 
+// handleDirectoryListFailure checks whether a directory that couldn't be
+// listed was changed into something else (or removed) after it was classified.
+// If so, it's re-dispatched as a file (if it's still included) or dropped and
+// `isHandled` will be true. Note that the callback will have already seen it
+// as a directory.
+func (walk *Walk) handleDirectoryListFailure(jdn jobDirectoryNode, listErr error) (isHandled bool, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	parentNodePath := jdn.ParentNodePath()
+	name := jdn.Info().Name()
+	fqPath := path.Join(parentNodePath, name)
+
+	info, err := walk.statNode(fqPath)
+	if err != nil {
+		if walk.isVanishedEntry(fqPath, err) == true {
+			return true, nil
+		}
+
+		walk.logWarningf("directory [%s] could not be listed and can no longer be stat; it will be skipped: [%s]", fqPath, err.Error())
+
+		walk.recordFailedDirectory(fqPath)
+
+		err := walk.recordSkippedEntry(fqPath, err)
+		log.PanicIf(err)
+
+		return true, nil
+	}
+
+	if info.IsDir() == true {
+		// It's still a directory, so this is a legitimate failure.
+
+		walk.recordFailedDirectory(fqPath)
+
+		return false, nil
+	}
+
+	walk.logWarningf("directory [%s] is no longer a directory; it will be processed as a file: [%s]", fqPath, listErr.Error())
+
+	rootPathPrefixLen := len(walk.rootPathOf(parentNodePath)) + 1
+	parentRelPath := ""
+	if len(parentNodePath) > rootPathPrefixLen {
+		parentRelPath = parentNodePath[rootPathPrefixLen:]
+	}
+
+	if walk.filter.IsPathIncluded(parentRelPath) != true {
+		walk.notifySkip(fqPath, SkipFilterPath)
+		walk.handleFilteredFile(parentNodePath, info, true, SkipFilterPath)
+
+		return true, nil
+	} else if isIncluded, reason := walk.isFileIncluded(fqPath, info); isIncluded != true {
+		walk.handleFilteredFile(parentNodePath, info, false, reason)
+
+		return true, nil
+	}
+
+	if walk.batchWalkFunc != nil {
+		walk.recordDepth(jdn.depth, 1)
+
+		err := walk.callBatchWalkFunc(parentNodePath, []os.FileInfo{info})
+		log.PanicIf(err)
+
+		return true, nil
+	}
+
+	jfn := newJobFileNode(parentNodePath, info)
+	jfn.dirContext = jdn.dirContext
+	jfn.depth = jdn.depth
+	jfn.parentInfo = jdn.parentInfo
+
+	err = walk.pushJob(jfn)
+	log.PanicIf(err)
+
+	return true, nil
+}
+
 // handleJobFileNode handles one file node. This is a leaf operation.
 func (walk *Walk) handleJobFileNode(jfn jobFileNode) (err error) {
 	defer func() {
@@ -629,15 +1988,83 @@ func (walk *Walk) handleJobFileNode(jfn jobFileNode) (err error) {
 		}
 	}()
 
-	walk.statsLocker.Lock()
-	walk.stats.FilesVisited++
-	walk.statsLocker.Unlock()
+	// Otherwise, this is counted once the counting callback has decided.
+	if walk.isCountingFiles() == false {
+		atomic.AddInt64(&walk.hotStats.filesVisited, 1)
+	}
+
+	walk.recordDepth(jfn.depth, 1)
 
 	parentNodePath := jfn.ParentNodePath()
 	info := jfn.Info()
 
-	err = walk.walkFunc(parentNodePath, info)
+	if walk.isAlreadyProcessed(parentNodePath, info) == true {
+		// This would've otherwise been counted by the counting callback.
+		if walk.isCountingFiles() == true {
+			atomic.AddInt64(&walk.hotStats.filesVisited, 1)
+		}
+
+		return nil
+	}
+
+	err = walk.callWalkFunc(parentNodePath, info, jfn.parentInfo)
+
+	if walk.isCountingMatches == true {
+		return nil
+	}
+
+	err = walk.applyFileErrorPolicy(parentNodePath, info, err)
+	log.PanicIf(err)
+
+	err = walk.callContextualFileFunc(jfn)
+	log.PanicIf(err)
+
+	err = walk.callWorkerWalkFunc(jfn)
 	log.PanicIf(err)
 
 	return nil
 }
+
+// callWalkFunc delivers one entry to the callback. `parentInfo` is optional.
+func (walk *Walk) callWalkFunc(parentNodePath string, info os.FileInfo, parentInfo os.FileInfo) (err error) {
+	if walk.isPruneEmptyDirectories == true && info.IsDir() == false {
+		walk.markIncludedDescendant(parentNodePath)
+	}
+
+	if walk.isCountingMatches == true {
+		walk.countMatch(info.IsDir(), 1)
+		return nil
+	}
+
+	if walk.isNamesOnly == true {
+		return walk.callNameFunc(path.Join(parentNodePath, info.Name()), info.IsDir())
+	}
+
+	fqParentPath := parentNodePath
+
+	if walk.isReformattingReportedPaths() == true {
+		parentNodePath, info = walk.reportPath(parentNodePath, info)
+	}
+
+	startTime := time.Now()
+
+	defer func() {
+		duration := time.Since(startTime)
+
+		walk.recordCallbackDuration(parentNodePath, info.Name(), duration)
+	}()
+
+	entry := WalkEntry{
+		ParentPath: parentNodePath,
+		Info:       info,
+		ParentInfo: parentInfo,
+		Sequence:   walk.nextSequence(),
+	}
+
+	err = walk.callVisitorsSerially(entry)
+	if err == nil || err == ErrSkipDirectory {
+		walk.bufferDirectoryResult(fqParentPath, entry)
+	}
+
+	return err
+}
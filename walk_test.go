@@ -1,10 +1,13 @@
 package pathwalk
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path"
 	"reflect"
+	"runtime"
 	"sort"
 	"sync"
 	"testing"
@@ -186,6 +189,7 @@ func TestWalk_nodeWorker__processMultipleJob(t *testing.T) {
 	wg.Add(1)
 
 	jobsC := make(chan job, 1)
+	processedC := make(chan struct{})
 
 	handledFilenames := make([]string, 0)
 
@@ -193,14 +197,19 @@ func TestWalk_nodeWorker__processMultipleJob(t *testing.T) {
 		handledFilename := info.Name()
 		handledFilenames = append(handledFilenames, handledFilename)
 
+		processedC <- struct{}{}
+
 		return nil
 	}
 
+	fc := newFakeClock()
+
 	walk := &Walk{
 		workerCount: 1,
 		wg:          wg,
 		jobsC:       jobsC,
 		walkFunc:    walkFunc,
+		clock:       fc,
 	}
 
 	// Give it the right count of jobs so that the last one will automatically
@@ -228,7 +237,10 @@ func TestWalk_nodeWorker__processMultipleJob(t *testing.T) {
 		filename := fmt.Sprintf("test-%d.file", i)
 		oneTestFileInfo := rifs.NewSimpleFileInfoWithFile(filename, 0, 0, time.Time{})
 		jobsC <- newJobFileNode("", oneTestFileInfo)
-		time.Sleep(time.Second * 1)
+
+		<-processedC
+
+		fc.Advance(time.Second * 1)
 	}
 
 	wg.Wait()
@@ -484,6 +496,212 @@ func TestWalk_Run__simple(t *testing.T) {
 	}
 }
 
+func TestWalk_Run__callbackTime(t *testing.T) {
+	fileCount := 5
+	tempPath, _ := pwtesting.FillFlatTempPath(fileCount, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	callbackDelay := time.Millisecond * 10
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		time.Sleep(callbackDelay)
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	stats := walk.Stats()
+
+	// Every file plus the root.
+	minimumCallbackTime := callbackDelay * time.Duration(fileCount+1)
+
+	if stats.CallbackTime < minimumCallbackTime {
+		t.Fatalf("CallbackTime not correct: %s < %s", stats.CallbackTime, minimumCallbackTime)
+	}
+}
+
+func TestWalk_Run__maxPathLength(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	err = os.MkdirAll(path.Join(tempPath, "dir1", "subdirectory2"), 0755)
+	log.PanicIf(err)
+
+	relFilepaths := []string{
+		"file1",
+		"long-filename1",
+		"dir1/file2",
+		"dir1/subdirectory2/file3",
+	}
+
+	for _, relFilepath := range relFilepaths {
+		err := ioutil.WriteFile(path.Join(tempPath, relFilepath), []byte{}, 0644)
+		log.PanicIf(err)
+	}
+
+	m := sync.Mutex{}
+	visited := make([]string, 0)
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		fqPath := path.Join(parentPath, info.Name())
+		if fqPath != tempPath {
+			visited = append(visited, fqPath[len(tempPath)+1:])
+		}
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+
+	// Allow up to "dir1/file2" below the root.
+	filter := Filter{
+		MaxPathLength: len(tempPath) + 1 + len("dir1/file2"),
+	}
+
+	walk.SetFilter(filter)
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	sort.Strings(visited)
+
+	expected := []string{
+		"dir1",
+		"dir1/file2",
+		"file1",
+	}
+
+	if reflect.DeepEqual(visited, expected) != true {
+		t.Fatalf("Visited not correct: %v", visited)
+	} else if walk.Stats().PathsTooLong != 2 {
+		t.Fatalf("PathsTooLong not correct: (%d)", walk.Stats().PathsTooLong)
+	}
+}
+
+func TestWalk_Run__maxPathComponents(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	err = os.MkdirAll(path.Join(tempPath, "dir1", "dir2", "dir3"), 0755)
+	log.PanicIf(err)
+
+	relFilepaths := []string{
+		"file1",
+		"dir1/file2",
+		"dir1/dir2/file3",
+		"dir1/dir2/dir3/file4",
+	}
+
+	for _, relFilepath := range relFilepaths {
+		err := ioutil.WriteFile(path.Join(tempPath, relFilepath), []byte{}, 0644)
+		log.PanicIf(err)
+	}
+
+	for _, isNamesOnly := range []bool{false, true} {
+		m := sync.Mutex{}
+		visited := make([]string, 0)
+
+		walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+			m.Lock()
+			defer m.Unlock()
+
+			fqPath := path.Join(parentPath, info.Name())
+			if fqPath != tempPath {
+				visited = append(visited, fqPath[len(tempPath)+1:])
+			}
+
+			return nil
+		}
+
+		nameFunc := func(fqPath string, isDir bool) (err error) {
+			m.Lock()
+			defer m.Unlock()
+
+			if fqPath != tempPath {
+				visited = append(visited, fqPath[len(tempPath)+1:])
+			}
+
+			return nil
+		}
+
+		walk := NewWalk(tempPath, walkFunc)
+
+		if isNamesOnly == true {
+			walk.SetNamesOnly(true)
+			walk.SetNameFunc(nameFunc)
+		}
+
+		filter := Filter{
+			MaxPathComponents: 2,
+		}
+
+		err = walk.SetFilter(filter)
+		log.PanicIf(err)
+
+		err = walk.Run()
+		log.PanicIf(err)
+
+		sort.Strings(visited)
+
+		// "dir1/dir2/file3" and "dir1/dir2/dir3" are excluded and the latter
+		// isn't descended into.
+		expected := []string{
+			"dir1",
+			"dir1/dir2",
+			"dir1/file2",
+			"file1",
+		}
+
+		if reflect.DeepEqual(visited, expected) != true {
+			t.Fatalf("Visited not correct (names-only=%v): %v", isNamesOnly, visited)
+		} else if walk.Stats().PathsTooDeep != 2 {
+			t.Fatalf("PathsTooDeep not correct (names-only=%v): (%d)", isNamesOnly, walk.Stats().PathsTooDeep)
+		}
+	}
+}
+
+func TestRelativePathComponentCount(t *testing.T) {
+	cases := []struct {
+		rootPath string
+		fqPath   string
+		expected int
+	}{
+		{"/root", "/root", 0},
+		{"/root/", "/root", 0},
+		{"/root", "/root/a", 1},
+		{"/root", "/root/a/b.txt", 2},
+		{"/", "/", 0},
+		{"/", "/a/b", 2},
+		{"root", "root/a/b/c", 3},
+		{".", ".", 0},
+		{".", "a/b", 2},
+	}
+
+	for _, c := range cases {
+		componentCount := relativePathComponentCount(c.rootPath, c.fqPath)
+		if componentCount != c.expected {
+			t.Fatalf("Component count not correct for [%s] under [%s]: (%d) != (%d)", c.fqPath, c.rootPath, componentCount, c.expected)
+		}
+	}
+}
+
 func TestWalk_Run__terminateBecauseOfJobError(t *testing.T) {
 	// This test makes sure that a job panic will terminate the pipeline (and
 	// not just hang or casually exit with empty results).
@@ -826,11 +1044,34 @@ func TestWalk_Stats(t *testing.T) {
 	walk.stats.DirectoriesVisited = 123
 
 	stats := walk.Stats()
-	if stats != walk.stats {
+	if reflect.DeepEqual(stats, walk.stats) != true {
 		t.Fatalf("Stats() does not return the right information.")
 	}
 }
 
+func TestWalk_Stats__rootVisited(t *testing.T) {
+	tempPath := createDepthTestTree()
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walk := NewWalk(tempPath, nil)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	stats := walk.Stats()
+
+	if stats.RootVisited != true {
+		t.Fatalf("Expected root to be visited.")
+	} else if stats.DirectoriesVisited != 3 {
+		t.Fatalf("DirectoriesVisited not correct: (%d)", stats.DirectoriesVisited)
+	} else if stats.DirectoriesVisitedExcludingRoot() != 2 {
+		t.Fatalf("DirectoriesVisitedExcludingRoot not correct: (%d)", stats.DirectoriesVisitedExcludingRoot())
+	}
+}
+
 func TestWalk_Stop(t *testing.T) {
 	defer func() {
 		err := recover().(error)
@@ -850,6 +1091,159 @@ func TestWalk_Stop(t *testing.T) {
 	t.Fatalf("Expected close() call to fail. It should have been redundant.")
 }
 
+func TestWalk_Stop__drainQueuedJobs(t *testing.T) {
+	// Stage a directory with enough entries that there will be plenty of jobs
+	// still queued at the moment that we stop.
+
+	fileCount := 2000
+	tempPath, _ := pwtesting.FillFlatTempPath(fileCount, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	initialGoroutineCount := runtime.NumGoroutine()
+
+	var walk *Walk
+
+	m := sync.Mutex{}
+
+	visitCount := 0
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		visitCount++
+
+		if visitCount == 10 {
+			walk.Stop()
+		}
+
+		return nil
+	}
+
+	walk = NewWalk(tempPath, walkFunc)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	if walk.HasFinished() != false {
+		t.Fatalf("HasFinished() should be false after a stop.")
+	} else if walk.Outcome() != OutcomeStopped {
+		t.Fatalf("Outcome not correct: [%s]", walk.Outcome())
+	} else if walk.Stats().FilesVisited >= fileCount {
+		t.Fatalf("Expected the walk to be cut short: (%d)", walk.Stats().FilesVisited)
+	}
+
+	// Give the runtime a moment to reap the worker goroutines.
+
+	for i := 0; i < 10; i++ {
+		if runtime.NumGoroutine() <= initialGoroutineCount {
+			break
+		}
+
+		time.Sleep(time.Millisecond * 100)
+	}
+
+	if runtime.NumGoroutine() > initialGoroutineCount {
+		t.Fatalf("Goroutines leaked: (%d) > (%d)", runtime.NumGoroutine(), initialGoroutineCount)
+	}
+}
+
+func TestWalk_Outcome__completed(t *testing.T) {
+	tempPath, _ := pwtesting.FillFlatTempPath(20, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+
+	if walk.Outcome() != OutcomeNone {
+		t.Fatalf("Initial outcome not correct: [%s]", walk.Outcome())
+	}
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	if walk.Outcome() != OutcomeCompleted {
+		t.Fatalf("Outcome not correct: [%s]", walk.Outcome())
+	}
+}
+
+func TestWalk_Outcome__cancelled(t *testing.T) {
+	tempPath, _ := pwtesting.FillFlatTempPath(2000, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		cancel()
+
+		// Make sure that the frontend notices the cancellation before we
+		// run out of work.
+		time.Sleep(time.Millisecond * 10)
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+
+	err := walk.RunContext(ctx)
+	if err != context.Canceled {
+		t.Fatalf("Expected cancellation error: %v", err)
+	} else if walk.Outcome() != OutcomeCancelled {
+		t.Fatalf("Outcome not correct: [%s]", walk.Outcome())
+	} else if walk.HasFinished() != false {
+		t.Fatalf("HasFinished() should be false after a cancellation.")
+	}
+}
+
+func TestWalk_Outcome__callbackError(t *testing.T) {
+	tempPath, _ := pwtesting.FillFlatTempPath(20, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		if info.IsDir() == false {
+			return errors.New("callback failed")
+		}
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+
+	err := walk.Run()
+	if err == nil {
+		t.Fatalf("Expected error.")
+	} else if walk.Outcome() != OutcomeError {
+		t.Fatalf("Outcome not correct: [%s]", walk.Outcome())
+	} else if walk.HasFinished() != false {
+		t.Fatalf("HasFinished() should be false after an error.")
+	}
+}
+
+func TestWalk_Outcome__rootError(t *testing.T) {
+	walk := NewWalk("/invalid/path", nil)
+
+	err := walk.Run()
+	if err == nil {
+		t.Fatalf("Expected error.")
+	} else if walk.Outcome() != OutcomeError {
+		t.Fatalf("Outcome not correct: [%s]", walk.Outcome())
+	}
+}
+
 func TestWalk_HasFinished(t *testing.T) {
 	w := Walk{}
 
@@ -868,7 +1262,9 @@ func TestWalk_SetFilter__empty(t *testing.T) {
 	walk := new(Walk)
 
 	f := Filter{}
-	walk.SetFilter(f)
+
+	err := walk.SetFilter(f)
+	log.PanicIf(err)
 
 	expectedFilter := internalFilter{
 		includePaths:     make([]glob.Glob, 0),
@@ -890,7 +1286,8 @@ func TestWalk_SetFilter__nonempty(t *testing.T) {
 		ExcludeFilenames: []string{"filename3", "filename4"},
 	}
 
-	walk.SetFilter(f)
+	err := walk.SetFilter(f)
+	log.PanicIf(err)
 
 	expectedFilter := internalFilter{
 		includePaths:     make([]glob.Glob, 0),
@@ -904,6 +1301,32 @@ func TestWalk_SetFilter__nonempty(t *testing.T) {
 	}
 }
 
+func TestWalk_SetFilter__invalid(t *testing.T) {
+	walk := new(Walk)
+
+	original := Filter{
+		IncludeFilenames: []string{"filename1"},
+	}
+
+	err := walk.SetFilter(original)
+	log.PanicIf(err)
+
+	f := Filter{
+		IncludePaths: []string{"aa/[bb"},
+	}
+
+	err = walk.SetFilter(f)
+	if err == nil {
+		t.Fatalf("Expected error for invalid pattern.")
+	} else if _, ok := err.(*FilterPatternError); ok != true {
+		t.Fatalf("Error not correct: [%v]", err)
+	}
+
+	if reflect.DeepEqual(walk.userFilter, original) != true {
+		t.Fatalf("Previous filter not kept: %v", walk.userFilter)
+	}
+}
+
 func TestWalk_statsPathFilterIncludeTickUp(t *testing.T) {
 	w := Walk{
 		doLogFilterStats: true,
@@ -911,7 +1334,7 @@ func TestWalk_statsPathFilterIncludeTickUp(t *testing.T) {
 
 	w.statsPathFilterIncludeTickUp()
 
-	if w.stats.PathFilterIncludes != 1 {
+	if w.Stats().PathFilterIncludes != 1 {
 		t.Fatalf("PathFilterIncludes not incremented")
 	}
 }
@@ -923,7 +1346,7 @@ func TestWalk_statsPathFilterExcludeTickUp(t *testing.T) {
 
 	w.statsPathFilterExcludeTickUp()
 
-	if w.stats.PathFilterExcludes != 1 {
+	if w.Stats().PathFilterExcludes != 1 {
 		t.Fatalf("PathFilterExcludes not incremented")
 	}
 }
@@ -935,7 +1358,7 @@ func TestWalk_statsFileFilterIncludeTickUp(t *testing.T) {
 
 	w.statsFileFilterIncludeTickUp()
 
-	if w.stats.FileFilterIncludes != 1 {
+	if w.Stats().FileFilterIncludes != 1 {
 		t.Fatalf("FileFilterIncludes not incremented")
 	}
 }
@@ -947,7 +1370,452 @@ func TestWalk_statsFileFilterExcludeTickUp(t *testing.T) {
 
 	w.statsFileFilterExcludeTickUp()
 
-	if w.stats.FileFilterExcludes != 1 {
+	if w.Stats().FileFilterExcludes != 1 {
 		t.Fatalf("FileFilterExcludes not incremented")
 	}
 }
+
+// testFlippingChildLister simulates a directory that is replaced by a file
+// after it was classified but before it was listed.
+type testFlippingChildLister struct {
+	*testMapChildLister
+
+	flippedPath string
+	isFlipped   bool
+}
+
+func (tfcl *testFlippingChildLister) ListChildren(path string, batchSize int) (names []string, hasMore bool, err error) {
+	if path == tfcl.flippedPath {
+		tfcl.locker.Lock()
+		tfcl.isFlipped = true
+		tfcl.locker.Unlock()
+
+		return nil, false, errors.New("not a directory")
+	}
+
+	return tfcl.testMapChildLister.ListChildren(path, batchSize)
+}
+
+func (tfcl *testFlippingChildLister) StatChild(nodePath string) (info os.FileInfo, err error) {
+	tfcl.locker.Lock()
+	isFlipped := tfcl.isFlipped
+	tfcl.locker.Unlock()
+
+	if nodePath == tfcl.flippedPath && isFlipped == true {
+		return rifs.NewSimpleFileInfoWithFile(path.Base(nodePath), 0, 0, time.Time{}), nil
+	}
+
+	return tfcl.testMapChildLister.StatChild(nodePath)
+}
+
+func TestWalk_handleJobDirectoryNode__directoryBecameFile(t *testing.T) {
+	tfcl := &testFlippingChildLister{
+		testMapChildLister: &testMapChildLister{
+			children: map[string][]string{
+				"/root":         {"file", "flipped"},
+				"/root/flipped": {"never_seen"},
+			},
+			offsets: make(map[string]int),
+		},
+		flippedPath: "/root/flipped",
+	}
+
+	m := sync.Mutex{}
+	visited := make([]string, 0)
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		entry := fmt.Sprintf("%s (%v)", path.Join(parentPath, info.Name()), info.IsDir())
+		visited = append(visited, entry)
+
+		return nil
+	}
+
+	walk := NewWalk("/root", walkFunc)
+	walk.SetChildLister(tfcl)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	sort.Strings(visited)
+
+	expected := []string{
+		"/root (true)",
+		"/root/file (false)",
+		"/root/flipped (false)",
+		"/root/flipped (true)",
+	}
+
+	if reflect.DeepEqual(visited, expected) != true {
+		t.Fatalf("Visited not correct: %v", visited)
+	}
+}
+
+func TestWalk_handleJobDirectoryNode__listFailure(t *testing.T) {
+	tfcl := &testFlippingChildLister{
+		testMapChildLister: &testMapChildLister{
+			children: map[string][]string{
+				"/root": {},
+			},
+			offsets: make(map[string]int),
+		},
+
+		// The root will fail to be listed but will still be a directory.
+		flippedPath: "/root",
+	}
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		return nil
+	}
+
+	walk := NewWalk("/root", walkFunc)
+	walk.SetChildLister(tfcl)
+
+	sfi := rifs.NewSimpleFileInfoWithDirectory("root", time.Time{})
+
+	isHandled, err := walk.handleDirectoryListFailure(newJobDirectoryNode("/", sfi), errors.New("list failed"))
+	log.PanicIf(err)
+
+	if isHandled != false {
+		t.Fatalf("Expected a failure on a directory to not be handled.")
+	}
+}
+
+func TestWalk_ResetStats(t *testing.T) {
+	fileCount := 10
+	tempPath, _ := pwtesting.FillFlatTempPath(fileCount, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	var walk *Walk
+	var runningErr error
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		if info.IsDir() == true {
+			runningErr = walk.ResetStats()
+		}
+
+		return nil
+	}
+
+	walk = NewWalk(tempPath, walkFunc)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	if runningErr != ErrWalkRunning {
+		t.Fatalf("Expected ResetStats() to fail while running: %v", runningErr)
+	} else if walk.IsRunning() != false {
+		t.Fatalf("Expected IsRunning() to be false after the run.")
+	} else if walk.Stats().FilesVisited != fileCount {
+		t.Fatalf("Stats not correct before reset: (%d)", walk.Stats().FilesVisited)
+	}
+
+	err = walk.ResetStats()
+	log.PanicIf(err)
+
+	if reflect.DeepEqual(walk.Stats(), Stats{}) != true {
+		t.Fatalf("Stats not reset: %v", walk.Stats())
+	}
+}
+
+func TestWalk_Run__syscallStats(t *testing.T) {
+	fileCount := 10
+	tempPath, _ := pwtesting.FillFlatTempPath(fileCount, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walk := NewWalk(tempPath, nil)
+	walk.SetBatchSize(4)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	stats := walk.Stats()
+
+	// Three full or partial batches and then the end of the directory.
+	if stats.DirectoryReadCalls != 4 {
+		t.Fatalf("DirectoryReadCalls not correct: (%d)", stats.DirectoryReadCalls)
+	}
+
+	// The root and then each file.
+	if stats.StatCalls != fileCount+1 {
+		t.Fatalf("StatCalls not correct: (%d)", stats.StatCalls)
+	}
+}
+
+func TestWalk_SetApplyFilterToRoot(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	rootPath := path.Join(tempPath, "build")
+	subPath := path.Join(rootPath, "subdirectory")
+
+	err = os.MkdirAll(subPath, 0755)
+	log.PanicIf(err)
+
+	err = ioutil.WriteFile(path.Join(rootPath, "root-file"), []byte{}, 0644)
+	log.PanicIf(err)
+
+	err = ioutil.WriteFile(path.Join(subPath, "sub-file"), []byte{}, 0644)
+	log.PanicIf(err)
+
+	filter := Filter{
+		ExcludePaths: []string{"build"},
+	}
+
+	getVisited := func(isApplyFilterToRoot bool) []string {
+		m := sync.Mutex{}
+		visited := make([]string, 0)
+
+		walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+			m.Lock()
+			defer m.Unlock()
+
+			visited = append(visited, info.Name())
+			return nil
+		}
+
+		walk := NewWalk(rootPath, walkFunc)
+		walk.SetApplyFilterToRoot(isApplyFilterToRoot)
+
+		err := walk.SetFilter(filter)
+		log.PanicIf(err)
+
+		err = walk.Run()
+		log.PanicIf(err)
+
+		sort.Strings(visited)
+
+		return visited
+	}
+
+	// By default, the root's own name isn't matched.
+
+	visited := getVisited(false)
+
+	expected := []string{"build", "root-file", "sub-file", "subdirectory"}
+	if reflect.DeepEqual(visited, expected) != true {
+		t.Fatalf("Visited entries not correct by default: %v", visited)
+	}
+
+	// The root and its files are excluded but we still descend.
+
+	visited = getVisited(true)
+
+	expected = []string{"sub-file", "subdirectory"}
+	if reflect.DeepEqual(visited, expected) != true {
+		t.Fatalf("Visited entries not correct when applied to the root: %v", visited)
+	}
+}
+
+func TestWalk_Run__suppressRoot(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	srcPath := path.Join(tempPath, "src")
+
+	err = os.Mkdir(srcPath, 0755)
+	log.PanicIf(err)
+
+	err = ioutil.WriteFile(path.Join(tempPath, "root-file"), []byte{}, 0644)
+	log.PanicIf(err)
+
+	err = ioutil.WriteFile(path.Join(srcPath, "src-file"), []byte{}, 0644)
+	log.PanicIf(err)
+
+	getVisited := func(suppressRoot bool) []string {
+		m := sync.Mutex{}
+		visited := make([]string, 0)
+
+		walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+			m.Lock()
+			defer m.Unlock()
+
+			relPath := path.Join(parentPath, info.Name())[len(tempPath):]
+			if relPath == "" {
+				relPath = "."
+			}
+
+			visited = append(visited, relPath)
+			return nil
+		}
+
+		walk := NewWalk(tempPath, walkFunc)
+
+		filter := Filter{
+			IncludePaths: []string{"src", "src/**"},
+			SuppressRoot: suppressRoot,
+		}
+
+		err := walk.SetFilter(filter)
+		log.PanicIf(err)
+
+		err = walk.Run()
+		log.PanicIf(err)
+
+		sort.Strings(visited)
+
+		return visited
+	}
+
+	// By default, the root is delivered even though it doesn't match the
+	// include, but its files are still filtered.
+
+	visited := getVisited(false)
+
+	expected := []string{".", "/src", "/src/src-file"}
+	if reflect.DeepEqual(visited, expected) != true {
+		t.Fatalf("Visited entries not correct by default: %v", visited)
+	}
+
+	// The root is filtered like any other directory.
+
+	visited = getVisited(true)
+
+	expected = []string{"/src", "/src/src-file"}
+	if reflect.DeepEqual(visited, expected) != true {
+		t.Fatalf("Visited entries not correct when suppressing the root: %v", visited)
+	}
+}
+
+func TestWalk_Run__concurrentRuns(t *testing.T) {
+	tempPath, tempFilenames := pwtesting.FillFlatTempPath(10, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	startedC := make(chan struct{})
+	releaseC := make(chan struct{})
+	once := sync.Once{}
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		once.Do(func() {
+			close(startedC)
+		})
+
+		<-releaseC
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+
+	errorsC := make(chan error, 2)
+
+	for i := 0; i < 2; i++ {
+		go func() {
+			errorsC <- walk.Run()
+		}()
+	}
+
+	// Whichever run was rejected returns without having to wait for the other
+	// one.
+
+	<-startedC
+
+	err := <-errorsC
+	if err != ErrAlreadyRunning {
+		t.Fatalf("Expected ErrAlreadyRunning: [%v]", err)
+	}
+
+	close(releaseC)
+
+	err = <-errorsC
+	log.PanicIf(err)
+
+	if walk.Stats().FilesVisited != len(tempFilenames) {
+		t.Fatalf("FilesVisited not correct: (%d)", walk.Stats().FilesVisited)
+	}
+
+	// The walk can be run again once the previous run is finished.
+
+	err = walk.Run()
+	log.PanicIf(err)
+
+	if walk.Stats().FilesVisited != len(tempFilenames) {
+		t.Fatalf("FilesVisited not correct on rerun: (%d)", walk.Stats().FilesVisited)
+	}
+}
+
+func TestWalk_Run__runAfterWait(t *testing.T) {
+	tempPath, _ := pwtesting.FillFlatTempPath(10, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	startedC := make(chan struct{}, 1)
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		select {
+		case startedC <- struct{}{}:
+		default:
+		}
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+
+	for i := 0; i < 3; i++ {
+		go walk.Run()
+
+		<-startedC
+
+		err := walk.Wait()
+		log.PanicIf(err)
+
+		// Anyone that's released by `Wait()` must be able to run it again
+		// immediately.
+
+		err = walk.Run()
+		log.PanicIf(err)
+
+		// Drain the signal from the synchronous run.
+		select {
+		case <-startedC:
+		default:
+		}
+	}
+}
+
+func TestWalk_Run__emptyRootPath(t *testing.T) {
+	walk := NewWalk("", nil)
+
+	err := walk.Run()
+	if err != ErrEmptyRootPath {
+		t.Fatalf("Expected ErrEmptyRootPath: [%v]", err)
+	}
+
+	err = walk.RunBottomUp(nil)
+	if err != ErrEmptyRootPath {
+		t.Fatalf("Expected ErrEmptyRootPath for bottom-up run: [%v]", err)
+	}
+
+	// A missing root is still reported as such.
+
+	walk = NewWalk("/does/not/exist", nil)
+
+	err = walk.Run()
+	if err == nil {
+		t.Fatalf("Expected error for missing root.")
+	} else if err == ErrEmptyRootPath {
+		t.Fatalf("Missing root should not be reported as empty.")
+	}
+}
@@ -0,0 +1,133 @@
+package pathwalk
+
+import (
+	"bufio"
+	"os"
+	"strings"
+
+	"github.com/dsoprea/go-logging"
+	"github.com/gobwas/glob"
+)
+
+// SetWalkIgnoreFilename enables reading ignore files with the given name (e.g.
+// ".walkignore") from every directory. An ignore file has one glob pattern per
+// line. Blank lines and lines starting with "#" are skipped. Each pattern is
+// matched against the paths of the entries below the directory that has the
+// ignore file, relative to that directory, and any entry that matches is
+// neither visited nor descended into. As with the path filters, "*" doesn't
+// match across directories, so "*.log" only matches the immediate children
+// while "**.log" matches at any depth. The ignore files of all of the
+// ancestors apply. There's no negation. Invalid patterns are logged and
+// skipped. Ignore files above a directory that is seeded from a checkpoint
+// or a list aren't consulted. An empty name (the default) disables this.
+func (walk *Walk) SetWalkIgnoreFilename(filename string) {
+	walk.walkIgnoreFilename = filename
+}
+
+// walkIgnoreRules are the patterns from the ignore file of one directory,
+// chained to those of its ancestors.
+type walkIgnoreRules struct {
+	directoryPath string
+	patterns      []glob.Glob
+	parent        *walkIgnoreRules
+}
+
+// isIgnored returns whether the given full-path matches the patterns of the
+// directory or any of its ancestors.
+func (wir *walkIgnoreRules) isIgnored(fqPath string) bool {
+	for rules := wir; rules != nil; rules = rules.parent {
+		prefix := rules.directoryPath + "/"
+		if strings.HasPrefix(fqPath, prefix) == false {
+			continue
+		}
+
+		relPath := fqPath[len(prefix):]
+
+		for _, pattern := range rules.patterns {
+			if pattern.Match(relPath) == true {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// loadWalkIgnoreRules reads the ignore file of the given directory, if any,
+// and returns the rules that apply to its children. If there's no ignore file,
+// the parent's rules are returned.
+func (walk *Walk) loadWalkIgnoreRules(directoryPath string, parent *walkIgnoreRules) (rules *walkIgnoreRules, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if walk.walkIgnoreFilename == "" {
+		return parent, nil
+	}
+
+	filepath := newChildPathBuilder(directoryPath).Join(walk.walkIgnoreFilename)
+
+	f, err := walk.openChild(filepath)
+	if err != nil {
+		if os.IsNotExist(err) == false {
+			walk.logWarningf("can not read ignore file [%s]; it will be ignored: [%s]", filepath, err.Error())
+		}
+
+		return parent, nil
+	}
+
+	defer f.Close()
+
+	patterns := make([]glob.Glob, 0)
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		pattern, err := glob.Compile(line, '/')
+		if err != nil {
+			walk.logWarningf("pattern [%s] in ignore file [%s] is not valid; it will be skipped: [%s]", line, filepath, err.Error())
+			continue
+		}
+
+		patterns = append(patterns, pattern)
+	}
+
+	err = s.Err()
+	log.PanicIf(err)
+
+	if len(patterns) == 0 {
+		return parent, nil
+	}
+
+	rules = &walkIgnoreRules{
+		directoryPath: directoryPath,
+		patterns:      patterns,
+		parent:        parent,
+	}
+
+	return rules, nil
+}
+
+// isWalkIgnored returns true and updates the stats if the given full-path is
+// excluded by an ignore file.
+func (walk *Walk) isWalkIgnored(fqPath string, rules *walkIgnoreRules) bool {
+	if rules == nil || rules.isIgnored(fqPath) == false {
+		return false
+	}
+
+	walk.logDebugf("Entry excluded by ignore file: [%s]", fqPath)
+
+	walk.notifySkip(fqPath, SkipWalkIgnore)
+
+	walk.statsLocker.Lock()
+	walk.stats.WalkIgnoreExcludes++
+	walk.statsLocker.Unlock()
+
+	return true
+}
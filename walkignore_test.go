@@ -0,0 +1,206 @@
+package pathwalk
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+	"github.com/gobwas/glob"
+)
+
+func createWalkIgnoreTestTree() (tempPath string) {
+	tempPath, err := ioutil.TempDir("", "")
+	log.PanicIf(err)
+
+	files := map[string]string{
+		".walkignore":         "# Logs at the top only.\n*.log\n\nbuild\n[\n",
+		"a.txt":               "",
+		"a.log":               "",
+		"build/x.txt":         "",
+		"sub/.walkignore":     "cache\n**.tmp\n",
+		"sub/b.log":           "",
+		"sub/keep.txt":        "",
+		"sub/cache/y.txt":     "",
+		"sub/deep/c.tmp":      "",
+		"sub/deep/d.txt":      "",
+		"sub/deep/e.log.keep": "",
+	}
+
+	for relFilepath, content := range files {
+		filepath := path.Join(tempPath, relFilepath)
+
+		err := os.MkdirAll(path.Dir(filepath), 0755)
+		log.PanicIf(err)
+
+		err = ioutil.WriteFile(filepath, []byte(content), 0644)
+		log.PanicIf(err)
+	}
+
+	return tempPath
+}
+
+func TestWalk_SetWalkIgnoreFilename(t *testing.T) {
+	tempPath := createWalkIgnoreTestTree()
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	m := sync.Mutex{}
+	visited := make([]string, 0)
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		visited = append(visited, path.Join(parentPath, info.Name()))
+
+		return nil
+	}
+
+	skipped := make(map[string]SkipReason)
+
+	skipNotifyFunc := func(path string, reason SkipReason) {
+		m.Lock()
+		defer m.Unlock()
+
+		skipped[path] = reason
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetWalkIgnoreFilename(".walkignore")
+	walk.SetPathStyle(PathStyleRelative)
+	walk.SetSkipNotifyFunc(skipNotifyFunc)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	sort.Strings(visited)
+
+	expected := []string{
+		".",
+		".walkignore",
+		"a.txt",
+		"sub",
+		"sub/.walkignore",
+		"sub/b.log",
+		"sub/deep",
+		"sub/deep/d.txt",
+		"sub/deep/e.log.keep",
+		"sub/keep.txt",
+	}
+
+	if reflect.DeepEqual(visited, expected) != true {
+		t.Fatalf("Visited entries not correct: %v", visited)
+	}
+
+	expectedSkipped := map[string]SkipReason{
+		"a.log":          SkipWalkIgnore,
+		"build":          SkipWalkIgnore,
+		"sub/cache":      SkipWalkIgnore,
+		"sub/deep/c.tmp": SkipWalkIgnore,
+	}
+
+	if reflect.DeepEqual(skipped, expectedSkipped) != true {
+		t.Fatalf("Skipped entries not correct: %v", skipped)
+	} else if walk.Stats().WalkIgnoreExcludes != 4 {
+		t.Fatalf("WalkIgnoreExcludes not correct: (%d)", walk.Stats().WalkIgnoreExcludes)
+	}
+}
+
+func TestWalk_SetWalkIgnoreFilename__namesOnly(t *testing.T) {
+	tempPath := createWalkIgnoreTestTree()
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	m := sync.Mutex{}
+	visited := make([]string, 0)
+
+	nameFunc := func(fqPath string, isDir bool) (err error) {
+		m.Lock()
+		defer m.Unlock()
+
+		if isDir == false {
+			visited = append(visited, fqPath)
+		}
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, nil)
+	walk.SetNamesOnly(true)
+	walk.SetNameFunc(nameFunc)
+	walk.SetWalkIgnoreFilename(".walkignore")
+	walk.SetPathStyle(PathStyleRelative)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	sort.Strings(visited)
+
+	expected := []string{
+		".walkignore",
+		"a.txt",
+		"sub/.walkignore",
+		"sub/b.log",
+		"sub/deep/d.txt",
+		"sub/deep/e.log.keep",
+		"sub/keep.txt",
+	}
+
+	if reflect.DeepEqual(visited, expected) != true {
+		t.Fatalf("Visited files not correct: %v", visited)
+	}
+}
+
+func TestWalk_SetWalkIgnoreFilename__disabled(t *testing.T) {
+	tempPath := createWalkIgnoreTestTree()
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walk := NewWalk(tempPath, nil)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	stats := walk.Stats()
+
+	if stats.FilesVisited != 11 {
+		t.Fatalf("FilesVisited not correct: (%d)", stats.FilesVisited)
+	} else if stats.WalkIgnoreExcludes != 0 {
+		t.Fatalf("WalkIgnoreExcludes not correct: (%d)", stats.WalkIgnoreExcludes)
+	}
+}
+
+func TestWalkIgnoreRules_isIgnored(t *testing.T) {
+	parent := &walkIgnoreRules{
+		directoryPath: "/root",
+		patterns:      []glob.Glob{glob.MustCompile("*.log", '/')},
+	}
+
+	rules := &walkIgnoreRules{
+		directoryPath: "/root/sub",
+		patterns:      []glob.Glob{glob.MustCompile("cache", '/')},
+		parent:        parent,
+	}
+
+	if rules.isIgnored("/root/a.log") != true {
+		t.Fatalf("Expected ancestor pattern to match.")
+	} else if rules.isIgnored("/root/sub/cache") != true {
+		t.Fatalf("Expected own pattern to match.")
+	} else if rules.isIgnored("/root/sub/b.log") != false {
+		t.Fatalf("Expected ancestor pattern to be relative to its directory.")
+	} else if rules.isIgnored("/root/cache") != false {
+		t.Fatalf("Expected own pattern not to apply above its directory.")
+	}
+}
@@ -0,0 +1,51 @@
+package pathwalk
+
+// WarmUp starts the given number of workers at the beginning of every run,
+// before the first job is queued, rather than starting them as the jobs
+// arrive. This trades idle goroutines for a lower latency for the first jobs
+// of repeated walks. Warmed-up workers are otherwise the same as any other:
+// they quit if they don't get any work within the idle timeout. This is capped
+// at the concurrency and by `SetMaxGoroutines()`. Zero (the default) disables
+// it.
+func (walk *Walk) WarmUp(workerCount int) {
+	walk.warmUpWorkerCount = workerCount
+}
+
+// startWarmWorkers starts the warmed-up workers. They are registered as idle
+// before they're started so that the first jobs go to them rather than to new
+// workers.
+func (walk *Walk) startWarmWorkers() {
+	workerCount := walk.warmUpWorkerCount
+
+	concurrency := walk.effectiveConcurrency()
+	if workerCount > concurrency {
+		workerCount = concurrency
+	}
+
+	if workerCount <= 0 {
+		return
+	}
+
+	for i := 0; i < workerCount; i++ {
+		walk.stateLocker.Lock()
+
+		if walk.hasGoroutineRoom() == false {
+			walk.stateLocker.Unlock()
+
+			workerCount = i
+			break
+		}
+
+		walk.workerCount++
+		walk.idleWorkerCount++
+		walk.wg.Add(1)
+
+		walk.stateLocker.Unlock()
+
+		go walk.runNodeWorker(true)
+	}
+
+	walk.statsLocker.Lock()
+	walk.stats.WorkersWarmedUp += workerCount
+	walk.statsLocker.Unlock()
+}
@@ -0,0 +1,93 @@
+package pathwalk
+
+import (
+	"os"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-parallel-walker/internal/testing"
+)
+
+func TestWalk_WarmUp(t *testing.T) {
+	tempPath, tempFiles := pwtesting.FillHeirarchicalTempPath(100, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walk := NewWalk(tempPath, nil)
+	walk.WarmUp(4)
+
+	if walk.Config().WarmUpWorkerCount != 4 {
+		t.Fatalf("Config not correct: (%d)", walk.Config().WarmUpWorkerCount)
+	}
+
+	for run := 0; run < 2; run++ {
+		err := walk.Run()
+		log.PanicIf(err)
+
+		stats := walk.Stats()
+
+		if stats.WorkersWarmedUp != 4 {
+			t.Fatalf("WorkersWarmedUp not correct (run %d): (%d)", run, stats.WorkersWarmedUp)
+		} else if stats.FilesVisited != len(tempFiles) {
+			t.Fatalf("FilesVisited not correct (run %d): (%d)", run, stats.FilesVisited)
+		}
+
+		// The unused workers must have quit too.
+
+		walk.stateLocker.Lock()
+		workerCount := walk.workerCount
+		idleWorkerCount := walk.idleWorkerCount
+		walk.stateLocker.Unlock()
+
+		if workerCount != 0 || idleWorkerCount != 0 {
+			t.Fatalf("Workers still registered (run %d): (%d) (%d)", run, workerCount, idleWorkerCount)
+		}
+	}
+}
+
+func TestWalk_WarmUp__firstJobGoesToWarmWorker(t *testing.T) {
+	tempPath, tempFilenames := pwtesting.FillFlatTempPath(10, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walk := NewWalk(tempPath, nil)
+	walk.SetConcurrency(2)
+	walk.WarmUp(2)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	stats := walk.Stats()
+
+	if stats.WorkersWarmedUp != 2 {
+		t.Fatalf("WorkersWarmedUp not correct: (%d)", stats.WorkersWarmedUp)
+	} else if stats.JobsDispatchedToNewWorker != 0 {
+		t.Fatalf("Expected no workers to be started on demand: (%d)", stats.JobsDispatchedToNewWorker)
+	} else if stats.FilesVisited != len(tempFilenames) {
+		t.Fatalf("FilesVisited not correct: (%d)", stats.FilesVisited)
+	}
+}
+
+func TestWalk_WarmUp__cappedAtConcurrency(t *testing.T) {
+	tempPath, _ := pwtesting.FillFlatTempPath(1, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	walk := NewWalk(tempPath, nil)
+	walk.SetConcurrency(2)
+	walk.WarmUp(10)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	if walk.Stats().WorkersWarmedUp != 2 {
+		t.Fatalf("WorkersWarmedUp not correct: (%d)", walk.Stats().WorkersWarmedUp)
+	}
+}
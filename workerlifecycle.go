@@ -0,0 +1,149 @@
+package pathwalk
+
+import (
+	"os"
+	"time"
+)
+
+// WorkerStartFunc is called when a worker starts and returns the state to
+// associate with that worker (e.g. a database connection).
+type WorkerStartFunc func(workerId int) (workerState interface{})
+
+// WorkerStopFunc is called when a worker stops with the state that was
+// returned when it started.
+type WorkerStopFunc func(workerId int, workerState interface{})
+
+// WorkerWalkFunc is called for every file with the state of the worker that
+// is processing it. Returning an error will terminate the walk.
+type WorkerWalkFunc func(workerState interface{}, parentPath string, info os.FileInfo) (err error)
+
+// SetWorkerLifecycleFuncs sets callbacks that are called when each worker
+// starts and stops, for per-worker resources. The value returned by `onStart`
+// is passed to `onStop` and to the worker callback (see
+// `SetWorkerWalkFunc()`). Either may be nil.
+//
+// Workers are started and stopped as needed during a run, so these may be
+// called many times. Worker IDs are reused: a new worker gets the lowest ID
+// that isn't held by a running worker, so they're always less than the
+// concurrency. `onStop` is called for every worker that `onStart` returned
+// for, even if the worker fails or the walk is stopped, and it's called before
+// `Run()` returns. Since workers quit after being idle, `onStart` may be called
+// again for the same ID during a run.
+func (walk *Walk) SetWorkerLifecycleFuncs(onStart WorkerStartFunc, onStop WorkerStopFunc) {
+	walk.workerStartFunc = onStart
+	walk.workerStopFunc = onStop
+}
+
+// SetWorkerWalkFunc sets a callback that receives every file along with the
+// state of the worker that is processing it. It's called after the regular
+// callbacks. If a batch callback was set, files are only delivered to it and
+// not to this callback.
+func (walk *Walk) SetWorkerWalkFunc(workerWalkFunc WorkerWalkFunc) {
+	walk.workerWalkFunc = workerWalkFunc
+}
+
+// hasWorkerLifecycle indicates that the workers have to be tracked.
+func (walk *Walk) hasWorkerLifecycle() bool {
+	return walk.workerStartFunc != nil || walk.workerStopFunc != nil || walk.workerWalkFunc != nil
+}
+
+// acquireWorkerId returns the lowest ID that isn't in use.
+func (walk *Walk) acquireWorkerId() int {
+	walk.stateLocker.Lock()
+	defer walk.stateLocker.Unlock()
+
+	for i, isUsed := range walk.workerIdsUsed {
+		if isUsed == false {
+			walk.workerIdsUsed[i] = true
+			return i
+		}
+	}
+
+	walk.workerIdsUsed = append(walk.workerIdsUsed, true)
+
+	return len(walk.workerIdsUsed) - 1
+}
+
+// releaseWorkerId makes the given ID available to the next worker.
+func (walk *Walk) releaseWorkerId(workerId int) {
+	walk.stateLocker.Lock()
+	defer walk.stateLocker.Unlock()
+
+	walk.workerIdsUsed[workerId] = false
+}
+
+// startWorkerLifecycle is called by each worker when it starts. The returned
+// function must be called when it stops.
+func (walk *Walk) startWorkerLifecycle() (workerState interface{}, stop func()) {
+	if walk.hasWorkerLifecycle() == false {
+		return nil, func() {}
+	}
+
+	workerId := walk.acquireWorkerId()
+
+	if walk.workerStartFunc != nil {
+		// The ID must be released if this fails.
+		isStarted := false
+
+		defer func() {
+			if isStarted == false {
+				walk.releaseWorkerId(workerId)
+			}
+		}()
+
+		workerState = walk.workerStartFunc(workerId)
+
+		isStarted = true
+	}
+
+	walk.statsLocker.Lock()
+	walk.stats.WorkerLifecycleStarts++
+	walk.statsLocker.Unlock()
+
+	stop = func() {
+		defer walk.releaseWorkerId(workerId)
+
+		if walk.workerStopFunc != nil {
+			walk.workerStopFunc(workerId, workerState)
+		}
+
+		walk.statsLocker.Lock()
+		walk.stats.WorkerLifecycleStops++
+		walk.statsLocker.Unlock()
+	}
+
+	return workerState, stop
+}
+
+// attachWorkerState returns the given job with the state of the worker that
+// is about to process it.
+func attachWorkerState(j job, workerState interface{}) job {
+	if jfn, ok := j.(jobFileNode); ok == true {
+		jfn.workerState = workerState
+		return jfn
+	}
+
+	return j
+}
+
+// callWorkerWalkFunc delivers one file to the worker callback.
+func (walk *Walk) callWorkerWalkFunc(jfn jobFileNode) (err error) {
+	if walk.workerWalkFunc == nil {
+		return nil
+	}
+
+	parentNodePath, info := jfn.ParentNodePath(), jfn.Info()
+	if walk.isReformattingReportedPaths() == true {
+		parentNodePath, info = walk.reportPath(parentNodePath, info)
+	}
+
+	startTime := time.Now()
+
+	defer func() {
+		duration := time.Since(startTime)
+
+		walk.hotStats.addCallbackTime(duration)
+	}()
+
+	return walk.workerWalkFunc(jfn.workerState, parentNodePath, info)
+}
@@ -0,0 +1,199 @@
+package pathwalk
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-parallel-walker/internal/testing"
+)
+
+type testWorkerResource struct {
+	workerId int
+	isClosed bool
+}
+
+type testWorkerLifecycle struct {
+	m sync.Mutex
+
+	starts int
+	stops  int
+
+	// active are the resources of the workers that are currently running,
+	// by ID.
+	active map[int]*testWorkerResource
+
+	maxWorkerId int
+	problems    []string
+}
+
+func newTestWorkerLifecycle() *testWorkerLifecycle {
+	return &testWorkerLifecycle{
+		active:   make(map[int]*testWorkerResource),
+		problems: make([]string, 0),
+	}
+}
+
+func (twl *testWorkerLifecycle) onStart(workerId int) interface{} {
+	twl.m.Lock()
+	defer twl.m.Unlock()
+
+	if _, found := twl.active[workerId]; found == true {
+		twl.problems = append(twl.problems, "worker ID started twice")
+	}
+
+	if workerId > twl.maxWorkerId {
+		twl.maxWorkerId = workerId
+	}
+
+	twr := &testWorkerResource{
+		workerId: workerId,
+	}
+
+	twl.active[workerId] = twr
+	twl.starts++
+
+	return twr
+}
+
+func (twl *testWorkerLifecycle) onStop(workerId int, workerState interface{}) {
+	twl.m.Lock()
+	defer twl.m.Unlock()
+
+	twr := workerState.(*testWorkerResource)
+	if twr.workerId != workerId || twl.active[workerId] != twr {
+		twl.problems = append(twl.problems, "worker stopped with the wrong state")
+	}
+
+	twr.isClosed = true
+
+	delete(twl.active, workerId)
+	twl.stops++
+}
+
+func TestWalk_SetWorkerLifecycleFuncs(t *testing.T) {
+	tempPath, _ := pwtesting.FillHeirarchicalTempPath(200, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	twl := newTestWorkerLifecycle()
+
+	filesVisited := 0
+	workerWalkFunc := func(workerState interface{}, parentPath string, info os.FileInfo) (err error) {
+		twl.m.Lock()
+		defer twl.m.Unlock()
+
+		twr := workerState.(*testWorkerResource)
+		if twr.isClosed == true || twl.active[twr.workerId] != twr {
+			twl.problems = append(twl.problems, "callback received the state of a stopped worker")
+		}
+
+		filesVisited++
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, nil)
+	walk.SetWorkerLifecycleFuncs(twl.onStart, twl.onStop)
+	walk.SetWorkerWalkFunc(workerWalkFunc)
+
+	err := walk.Run()
+	log.PanicIf(err)
+
+	twl.m.Lock()
+	defer twl.m.Unlock()
+
+	if len(twl.problems) > 0 {
+		t.Fatalf("Lifecycle problems: %v", twl.problems)
+	} else if twl.starts == 0 {
+		t.Fatalf("No workers were started.")
+	} else if twl.starts != twl.stops {
+		t.Fatalf("Starts and stops not balanced: (%d) != (%d)", twl.starts, twl.stops)
+	} else if len(twl.active) != 0 {
+		t.Fatalf("Workers still active: %v", twl.active)
+	} else if twl.maxWorkerId >= defaultConcurrency {
+		t.Fatalf("Worker ID exceeds the concurrency: (%d)", twl.maxWorkerId)
+	}
+
+	stats := walk.Stats()
+	if stats.WorkerLifecycleStarts != twl.starts || stats.WorkerLifecycleStops != twl.stops {
+		t.Fatalf("Stats not correct: (%d) (%d)", stats.WorkerLifecycleStarts, stats.WorkerLifecycleStops)
+	}
+
+	if filesVisited != stats.FilesVisited {
+		t.Fatalf("Worker callback not called for every file: (%d) != (%d)", filesVisited, stats.FilesVisited)
+	}
+
+	if walk.Config().HasWorkerLifecycle != true {
+		t.Fatalf("Config does not reflect the worker lifecycle.")
+	}
+}
+
+func TestWalk_SetWorkerLifecycleFuncs__failure(t *testing.T) {
+	tempPath, _ := pwtesting.FillFlatTempPath(50, nil)
+
+	defer func() {
+		os.RemoveAll(tempPath)
+	}()
+
+	twl := newTestWorkerLifecycle()
+
+	errTest := errors.New("test failure")
+
+	walkFunc := func(parentPath string, info os.FileInfo) (err error) {
+		if info.IsDir() == false {
+			return errTest
+		}
+
+		return nil
+	}
+
+	walk := NewWalk(tempPath, walkFunc)
+	walk.SetConcurrency(10)
+	walk.SetWorkerLifecycleFuncs(twl.onStart, twl.onStop)
+
+	err := walk.Run()
+	if err == nil {
+		t.Fatalf("Expected failure.")
+	} else if strings.Contains(err.Error(), errTest.Error()) == false {
+		t.Fatalf("Error not correct: [%v]", err)
+	}
+
+	twl.m.Lock()
+	defer twl.m.Unlock()
+
+	if twl.starts == 0 {
+		t.Fatalf("No workers were started.")
+	} else if twl.starts != twl.stops {
+		t.Fatalf("Starts and stops not balanced after failure: (%d) != (%d)", twl.starts, twl.stops)
+	}
+}
+
+func TestWalk_acquireWorkerId(t *testing.T) {
+	walk := NewWalk("root", nil)
+
+	for i := 0; i < 3; i++ {
+		workerId := walk.acquireWorkerId()
+		if workerId != i {
+			t.Fatalf("Worker ID not correct: (%d) != (%d)", workerId, i)
+		}
+	}
+
+	// The lowest free ID is reused.
+
+	walk.releaseWorkerId(1)
+
+	if workerId := walk.acquireWorkerId(); workerId != 1 {
+		t.Fatalf("Worker ID not reused: (%d)", workerId)
+	}
+
+	if workerId := walk.acquireWorkerId(); workerId != 3 {
+		t.Fatalf("Next worker ID not correct: (%d)", workerId)
+	}
+}